@@ -0,0 +1,99 @@
+// Command audit-verify walks the hash-chained audit log of every tenant, or
+// of one with -tenant, and reports the first broken link of each chain. It
+// exits with status 1 when a chain is broken.
+//
+// Checkpoint signatures are checked against AUDIT_SIGNING_KEY, or against
+// -public-key for auditors who only hold the public key.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	tenantID := flag.String("tenant", "", "verify only this tenant's chain")
+	publicKey := flag.String("public-key", "", "base64 Ed25519 public key of the checkpoints (default: from AUDIT_SIGNING_KEY)")
+	asJSON := flag.Bool("json", false, "print the reports as JSON")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	var key ed25519.PublicKey
+	if *publicKey != "" {
+		raw, err := base64.StdEncoding.DecodeString(*publicKey)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			log.Fatalf("-public-key must be a base64 %d byte Ed25519 public key", ed25519.PublicKeySize)
+		}
+		key = raw
+	} else {
+		signer, err := audit.NewSigner(cfg.AuditSigningKey, cfg.JWT.SecretKey)
+		if err != nil {
+			log.Fatalf("Invalid audit signing key: %v", err)
+		}
+		key = signer.PublicKey()
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.DBName, cfg.Database.SSLMode)
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	ctx := context.Background()
+	var reports []*audit.Report
+	if *tenantID != "" {
+		report, err := audit.Verify(ctx, db, *tenantID, key)
+		if err != nil {
+			log.Fatalf("Failed to verify tenant %s: %v", *tenantID, err)
+		}
+		reports = append(reports, report)
+	} else {
+		if reports, err = audit.VerifyAll(ctx, db, key); err != nil {
+			log.Fatalf("Failed to verify audit log: %v", err)
+		}
+	}
+
+	broken := false
+	for _, report := range reports {
+		broken = broken || !report.Valid
+		if *asJSON {
+			line, _ := json.Marshal(report)
+			fmt.Println(string(line))
+			continue
+		}
+		tenant := report.TenantID
+		if tenant == "" {
+			tenant = "(no tenant)"
+		}
+		if report.Valid {
+			fmt.Printf("%s: ok, %d entries to sequence %d (%s), %d checkpoints, %d unchained\n",
+				tenant, report.Entries, report.HeadSequence, report.HeadHash, report.Checkpoints, report.Unchained)
+			continue
+		}
+		fmt.Printf("%s: BROKEN at sequence %d", tenant, report.Break.Sequence)
+		if report.Break.EntryID != "" {
+			fmt.Printf(" (entry %s)", report.Break.EntryID)
+		}
+		fmt.Printf(": %s\n", report.Break.Reason)
+	}
+	if broken {
+		os.Exit(1)
+	}
+}
@@ -1,18 +1,45 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/cyber/backend/internal/api"
+	"github.com/cyber/backend/internal/audit"
 	"github.com/cyber/backend/internal/cache"
 	"github.com/cyber/backend/internal/config"
 	"github.com/cyber/backend/internal/crypto"
 	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/metrics"
 	"github.com/cyber/backend/internal/middleware"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/notify"
+	"github.com/cyber/backend/internal/openapi"
+	"github.com/cyber/backend/internal/ratelimit"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/cyber/backend/internal/reminders"
+	"github.com/cyber/backend/internal/reqlog"
+	"github.com/cyber/backend/internal/retention"
+	"github.com/cyber/backend/internal/tracing"
+	"github.com/cyber/backend/internal/versions"
+	"github.com/cyber/backend/internal/webhook"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -22,14 +49,43 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
 	// Initialize encryption key from environment
-	encryptionKey := os.Getenv("ENCRYPTION_KEY")
-	if encryptionKey != "" {
-		crypto.SetEncryptionKey(encryptionKey)
+	if cfg.EncryptionKey != "" {
+		crypto.SetEncryptionKey(cfg.EncryptionKey)
 		log.Println("Encryption key initialized from environment")
 	} else {
 		log.Println("Warning: ENCRYPTION_KEY not set, using default key")
 	}
+	if cfg.Server.WebhookAllowPrivate {
+		egress.AllowPrivate(true)
+		log.Println("Warning: WEBHOOK_ALLOW_PRIVATE set, webhooks may reach localhost and private addresses")
+	}
+
+	// Tracing, set up first so the Redis and database clients are traced
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		Exporter:   cfg.Server.TracingExporter,
+		Endpoint:   cfg.Server.TracingEndpoint,
+		SampleRate: cfg.Server.TracingSampleRate,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Background workers run until shutdown, after the HTTP server drained
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
 
 	// Initialize Redis cache
 	redisAddr := os.Getenv("REDIS_ADDR")
@@ -60,15 +116,44 @@ func main() {
 		log.Fatalf("Failed to get DB: %v", err)
 	}
 	defer sqlDB.Close()
+	if err := tracing.RegisterGORM(dbConn.DB); err != nil {
+		log.Fatalf("Failed to register tracing callbacks: %v", err)
+	}
+	// Every change to a tenant model is written to AuditLog with who made it,
+	// hash-chained per tenant, with the chain heads signed periodically
+	if err := audit.RegisterCallbacks(dbConn.DB); err != nil {
+		log.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+	// Policies, DPIAs, controls, continuity plans and documents keep every
+	// version
+	versions.Register()
+	auditSigner, err := audit.NewSigner(cfg.AuditSigningKey, cfg.JWT.SecretKey)
+	if err != nil {
+		log.Fatalf("Invalid audit signing key: %v", err)
+	}
+	runWorker(audit.NewCheckpointer(dbConn.DB, auditSigner, time.Duration(cfg.Server.AuditCheckpointInterval)*time.Minute).Run)
 
 	// Initialize API handlers
 	api.InitHandlers(dbConn)
+	api.SetIfMatchRequired(cfg.Server.RequireIfMatch)
+
+	// Background jobs, run here unless JOB_WORKER is off and cmd/worker
+	// runs them. Data past its retention period is purged on a schedule,
+	// unless under legal hold.
+	jobQueue := jobs.NewQueue(dbConn.DB)
+	jobQueue.Concurrency = cfg.Server.JobConcurrency
+	api.InitJobs(jobQueue, dbConn)
+	purger := retention.NewPurger(dbConn, api.GetDocumentHandler().Storage())
+	if err := purger.Schedule(jobQueue, cfg.Server.RetentionPurgeSchedule); err != nil {
+		log.Fatalf("Invalid retention purge schedule: %v", err)
+	}
 
 	// Initialize new sub-module handlers
 	regopsGapAnalysisHandler := api.NewRegOpsGapAnalysisHandler(dbConn.DB)
 	regopsObligationMappingHandler := api.NewRegOpsObligationMappingHandler(dbConn.DB)
 	regopsPoliciesHandler := api.NewRegOpsPoliciesHandler(dbConn.DB)
 	regopsControlsHandler := api.NewRegOpsControlsHandler(dbConn.DB)
+	regulationClauseHandler := api.NewRegulationClauseHandler(dbConn)
 	privacyopsDataInventoryHandler := api.NewPrivacyOpsDataInventoryHandler(dbConn.DB)
 	privacyopsRoPAHandler := api.NewPrivacyOpsRoPAHandler(dbConn.DB)
 	privacyopsDSRHandler := api.NewPrivacyOpsDSRHandler(dbConn.DB)
@@ -86,6 +171,56 @@ func main() {
 	auditopsReportingHandler := api.NewAuditOpsReportingHandler(dbConn.DB)
 	aiDocumentHandler := api.NewAIDocumentHandler(dbConn.DB)
 	platformHandler := api.NewPlatformHandler(dbConn)
+	searchHandler := api.NewSearchHandler(dbConn)
+	importHandler := api.NewImportHandler(dbConn.DB)
+	exportHandler := api.NewExportHandler(dbConn)
+
+	// Real-time change notifications, fanned out to other replicas through
+	// Redis when it is configured
+	realtimeHub := realtime.NewHub(redisClient)
+	if err := realtime.RegisterCallbacks(dbConn.DB, realtimeHub); err != nil {
+		log.Fatalf("Failed to register realtime callbacks: %v", err)
+	}
+	api.InitRealtime(realtimeHub)
+	streamHandler := api.NewStreamHandler(dbConn.DB, realtimeHub)
+	runWorker(realtimeHub.Run)
+
+	// Notices to users' inboxes, email and chat, raised by domain events
+	notifier := notify.NewService(dbConn.DB, cfg.SMTP, realtimeHub)
+	if err := notifier.Register(jobQueue, cfg.Server.NotificationDigestSchedule); err != nil {
+		log.Fatalf("Invalid notification digest schedule: %v", err)
+	}
+	notificationHandler := api.NewNotificationHandler(dbConn.DB, notifier)
+
+	// Outbound webhooks: deliveries are sent and retried in the background
+	webhookDispatcher := webhook.NewDispatcher(dbConn.DB)
+	webhookDispatcher.MaxAttempts = cfg.Server.WebhookMaxAttempts
+	webhookHandler := api.NewWebhookHandler(dbConn.DB, webhookDispatcher)
+	runWorker(webhookDispatcher.Run)
+
+	// Domain events are written to the outbox with the change that raised
+	// them and delivered to these subscribers in the background
+	eventBus := events.NewBus(dbConn.DB)
+	eventBus.Subscribe("webhooks", webhookDispatcher.HandleEvent)
+	eventBus.Subscribe("audit_log", api.AuditEvents(dbConn.DB))
+	eventBus.Subscribe("notifications", notifier.HandleEvent)
+	api.InitEvents(eventBus)
+	runWorker(eventBus.Run)
+	runWorker(func(ctx context.Context) { api.WatchOverdueDSRs(ctx, dbConn.DB, 15*time.Minute) })
+
+	// Owners are reminded of their deadlines, and missed ones escalated, by
+	// a scheduled job. The queue starts once every schedule is registered.
+	if err := reminders.NewEngine(dbConn.DB, realtimeHub).Schedule(jobQueue, cfg.Server.ReminderSchedule); err != nil {
+		log.Fatalf("Invalid reminder schedule: %v", err)
+	}
+	// KPI snapshots for the trend charts, taken hourly by a scheduled job
+	kpis := metrics.NewKPICollector(dbConn.DB)
+	if err := kpis.Schedule(jobQueue); err != nil {
+		log.Fatalf("Invalid KPI snapshot schedule: %v", err)
+	}
+	if cfg.Server.JobWorker {
+		runWorker(jobQueue.Run)
+	}
 
 	// Initialize Redis cache
 	if redisClient != nil {
@@ -93,36 +228,147 @@ func main() {
 		defer redisClient.Close()
 	}
 
+	// Per-plan rate limits, shared across replicas through Redis when it is
+	// configured
+	var limiter *ratelimit.Limiter
+	if cfg.Server.RateLimiting {
+		limiter = ratelimit.NewLimiter(redisClient)
+	}
+	policies := ratelimit.NewPolicies(dbConn.DB)
+
+	// Request logs go to stdout as JSON and, in batches, to SystemLog and
+	// APIUsage
+	requestLog := reqlog.NewRecorder(dbConn.DB)
+	requestLog.SampleRate = cfg.Server.RequestLogSampleRate
+	runWorker(requestLog.Run)
+
+	// Prometheus metrics: statement timings, connection pool stats and the
+	// business KPIs, which the scheduled job above snapshots to SystemMetric
+	if err := metrics.RegisterGORM(dbConn.DB); err != nil {
+		log.Fatalf("Failed to register metrics callbacks: %v", err)
+	}
+	prometheus.MustRegister(collectors.NewDBStatsCollector(sqlDB, "komplai"))
+	runWorker(kpis.Run)
+	metricsHandler := api.NewMetricsHandler(dbConn.DB)
+	auditHandler := api.NewAuditHandler(dbConn.DB, auditSigner)
+	versionHandler := api.NewVersionHandler(dbConn)
+	trashHandler := api.NewTrashHandler(dbConn)
+	retentionHandler := api.NewRetentionHandler(dbConn.DB)
+	jobHandler := api.NewJobHandler(dbConn.DB)
+	reminderHandler := api.NewReminderHandler(dbConn.DB)
+
 	// Create Gin router
-	r := gin.Default()
+	r := gin.New()
+
+	// Probes are registered before the middleware, so they are not logged,
+	// measured or traced
+	healthHandler := api.NewHealthHandler(dbConn, redisClient)
+	r.GET("/healthz", healthHandler.Healthz)
+	r.GET("/readyz", healthHandler.Readyz)
 
 	// Apply middleware
-	r.Use(middleware.Logger())
+	r.Use(middleware.Logger(slog.New(slog.NewJSONHandler(os.Stdout, nil)), requestLog))
+	r.Use(gin.Recovery())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Tracing())
 	r.Use(middleware.Cors())
 	r.Use(middleware.TenantMiddleware())
 
+	// Request validation against the OpenAPI document. The document is built
+	// from the route table, so it is loaded once the routes are registered.
+	validator := openapi.NewValidator()
+	if cfg.Server.OpenAPIValidation {
+		r.Use(validator.Middleware())
+	}
+
 	// Setup routes
-	setupRoutes(r, regopsGapAnalysisHandler, regopsObligationMappingHandler, regopsPoliciesHandler, regopsControlsHandler, privacyopsDataInventoryHandler, privacyopsRoPAHandler, privacyopsDSRHandler, privacyopsDPIAHandler, privacyopsControlsHandler, privacyopsIncidentHandler, riskopsERMHandler, riskopsSecurityHandler, riskopsVendorHandler, riskopsContinuityHandler, auditopsInternalAuditHandler, auditopsGovernanceHandler, auditopsContinuousAuditHandler, auditopsEvidenceHandler, auditopsReportingHandler, aiDocumentHandler, platformHandler)
+	setupRoutes(r, regopsGapAnalysisHandler, regopsObligationMappingHandler, regopsPoliciesHandler, regopsControlsHandler, privacyopsDataInventoryHandler, privacyopsRoPAHandler, privacyopsDSRHandler, privacyopsDPIAHandler, privacyopsControlsHandler, privacyopsIncidentHandler, riskopsERMHandler, riskopsSecurityHandler, riskopsVendorHandler, riskopsContinuityHandler, auditopsInternalAuditHandler, auditopsGovernanceHandler, auditopsContinuousAuditHandler, auditopsEvidenceHandler, auditopsReportingHandler, aiDocumentHandler, platformHandler, searchHandler, importHandler, exportHandler, webhookHandler, streamHandler, metricsHandler, auditHandler, versionHandler, trashHandler, retentionHandler, jobHandler, reminderHandler, notificationHandler, regulationClauseHandler,
+		middleware.Idempotency(dbConn.DB, time.Duration(cfg.Server.IdempotencyKeyTTL)*time.Hour, int64(cfg.Server.IdempotencyMaxBody)<<20), limiter, policies)
+
+	// OpenAPI document
+	doc := openapi.Build(r.Routes(), api.OpenAPIEndpoints(), openapi.Info{
+		Title:   "Komplai API",
+		Version: "1.0.0",
+	})
+	validator.Load(doc)
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err)
+	}
+	r.GET("/api/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", middleware.MetricsAuth(cfg.Server.MetricsToken), gin.WrapH(promhttp.Handler()))
 
 	// Start server
-	port := fmt.Sprintf(":%s", cfg.Server.Port)
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler: r,
+	}
+	// Event streams never finish on their own
+	srv.RegisterOnShutdown(streamHandler.Close)
+	go func() {
+		log.Printf("Server starting on port %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Graceful shutdown: fail readiness so load balancers stop sending
+	// requests, finish in-flight ones, then stop the workers, which write
+	// what they have queued
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down")
+	healthHandler.Drain()
+	time.Sleep(time.Duration(cfg.Server.ShutdownDrainDelay) * time.Second)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server did not drain: %v", err)
+	}
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		log.Println("Server stopped")
+	case <-shutdownCtx.Done():
+		log.Println("Background workers did not stop in time")
 	}
 }
 
-func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisHandler, regopsObligationMappingHandler *api.RegOpsObligationMappingHandler, regopsPoliciesHandler *api.RegOpsPoliciesHandler, regopsControlsHandler *api.RegOpsControlsHandler, privacyopsDataInventoryHandler *api.PrivacyOpsDataInventoryHandler, privacyopsRoPAHandler *api.PrivacyOpsRoPAHandler, privacyopsDSRHandler *api.PrivacyOpsDSRHandler, privacyopsDPIAHandler *api.PrivacyOpsDPIAHandler, privacyopsControlsHandler *api.PrivacyOpsControlsHandler, privacyopsIncidentHandler *api.PrivacyOpsIncidentHandler, riskopsERMHandler *api.RiskOpsERMHandler, riskopsSecurityHandler *api.RiskOpsSecurityHandler, riskopsVendorHandler *api.RiskOpsVendorHandler, riskopsContinuityHandler *api.RiskOpsContinuityHandler, auditopsInternalAuditHandler *api.AuditOpsInternalAuditHandler, auditopsGovernanceHandler *api.AuditOpsGovernanceHandler, auditopsContinuousAuditHandler *api.AuditOpsContinuousAuditHandler, auditopsEvidenceHandler *api.AuditOpsEvidenceHandler, auditopsReportingHandler *api.AuditOpsReportingHandler, aiDocumentHandler *api.AIDocumentHandler, platformHandler *api.PlatformHandler) {
+func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisHandler, regopsObligationMappingHandler *api.RegOpsObligationMappingHandler, regopsPoliciesHandler *api.RegOpsPoliciesHandler, regopsControlsHandler *api.RegOpsControlsHandler, privacyopsDataInventoryHandler *api.PrivacyOpsDataInventoryHandler, privacyopsRoPAHandler *api.PrivacyOpsRoPAHandler, privacyopsDSRHandler *api.PrivacyOpsDSRHandler, privacyopsDPIAHandler *api.PrivacyOpsDPIAHandler, privacyopsControlsHandler *api.PrivacyOpsControlsHandler, privacyopsIncidentHandler *api.PrivacyOpsIncidentHandler, riskopsERMHandler *api.RiskOpsERMHandler, riskopsSecurityHandler *api.RiskOpsSecurityHandler, riskopsVendorHandler *api.RiskOpsVendorHandler, riskopsContinuityHandler *api.RiskOpsContinuityHandler, auditopsInternalAuditHandler *api.AuditOpsInternalAuditHandler, auditopsGovernanceHandler *api.AuditOpsGovernanceHandler, auditopsContinuousAuditHandler *api.AuditOpsContinuousAuditHandler, auditopsEvidenceHandler *api.AuditOpsEvidenceHandler, auditopsReportingHandler *api.AuditOpsReportingHandler, aiDocumentHandler *api.AIDocumentHandler, platformHandler *api.PlatformHandler, searchHandler *api.SearchHandler, importHandler *api.ImportHandler, exportHandler *api.ExportHandler, webhookHandler *api.WebhookHandler, streamHandler *api.StreamHandler, metricsHandler *api.MetricsHandler, auditHandler *api.AuditHandler, versionHandler *api.VersionHandler, trashHandler *api.TrashHandler, retentionHandler *api.RetentionHandler, jobHandler *api.JobHandler, reminderHandler *api.ReminderHandler, notificationHandler *api.NotificationHandler, regulationClauseHandler *api.RegulationClauseHandler, idempotency gin.HandlerFunc, limiter *ratelimit.Limiter, policies *ratelimit.Policies) {
 	// Public routes
 	public := r.Group("/api")
+	public.Use(middleware.Audit())
 	{
 		public.POST("/auth/login", api.GetAuthHandler().Login)
 		public.POST("/auth/register", api.GetAuthHandler().Register)
+		// Server-Sent Events stream of change notifications (filtered by RBAC
+		// in the hub), opened with a ticket from /stream/tickets since
+		// EventSource cannot send the Authorization header
+		public.GET("/stream", streamHandler.Stream)
 	}
 
 	// Protected routes
+	// Tenant-wide budgets of the expensive AI routes
+	aiBudget := middleware.RateBudget(limiter, policies, ratelimit.BudgetAI)
+	analysisBudget := middleware.RateBudget(limiter, policies, ratelimit.BudgetDocumentAnalysis)
+
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.Audit())
+	protected.Use(middleware.RateLimit(limiter, policies))
+	// POSTs with an Idempotency-Key replay their first response on retry
+	protected.Use(idempotency)
 	{
 		// Tenant management
 		tenants := protected.Group("/tenants")
@@ -134,45 +380,180 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			tenants.DELETE("/:id", api.GetTenantHandler().Delete) // Soft delete
 		}
 
+		// Tenant-wide full-text search (results filtered by RBAC in the handler)
+		protected.GET("/search", searchHandler.Search)
+
+		// Single-use tickets opening the event stream
+		protected.POST("/stream/tickets", streamHandler.CreateStreamTicket)
+
+		// Hourly KPI snapshots for the dashboard trend charts
+		protected.GET("/metrics/kpis", metricsHandler.GetKPITrends)
+
+		// Record history of the tenant's changes
+		protected.GET("/audit-log", middleware.RequireTenantAdmin(), auditHandler.GetAuditLog)
+		protected.GET("/audit-log/verify", middleware.RequireTenantAdmin(), auditHandler.VerifyAuditLog)
+
+		// Version history of versioned records (permissions checked per
+		// resource in the handler)
+		protected.GET("/versions/:resource/:id", versionHandler.GetVersions)
+		protected.GET("/versions/:resource/:id/diff", versionHandler.DiffVersions)
+		protected.GET("/versions/:resource/:id/:version", versionHandler.GetVersion)
+		protected.POST("/versions/:resource/:id/:version/revert", versionHandler.RevertVersion)
+
+		// Recycle bin of every tenant resource (permissions checked per
+		// resource in the handler)
+		protected.GET("/trash/:resource", trashHandler.GetTrash)
+		protected.POST("/trash/:resource/:id/restore", trashHandler.RestoreFromTrash)
+		protected.DELETE("/trash/:resource/:id/permanent", trashHandler.PermanentDelete)
+
+		// Retention periods of the tenant's data and legal holds suspending
+		// their purge
+		retentionRoutes := protected.Group("/retention")
+		retentionRoutes.Use(middleware.RequireTenantAdmin())
+		{
+			retentionRoutes.GET("/policies", retentionHandler.GetRetention)
+			retentionRoutes.PUT("/policies/:class", retentionHandler.SetRetention)
+			retentionRoutes.DELETE("/policies/:class", retentionHandler.DeleteRetention)
+			retentionRoutes.GET("/holds", retentionHandler.GetLegalHolds)
+			retentionRoutes.POST("/holds", retentionHandler.CreateLegalHold)
+			retentionRoutes.POST("/holds/:id/release", retentionHandler.ReleaseLegalHold)
+		}
+
+		// Background jobs of the tenant
+		jobRoutes := protected.Group("/jobs")
+		jobRoutes.Use(middleware.RequireTenantAdmin())
+		{
+			jobRoutes.GET("", jobHandler.GetJobs)
+			jobRoutes.GET("/:id", jobHandler.GetJob)
+			jobRoutes.POST("/:id/retry", jobHandler.RetryJob)
+			jobRoutes.POST("/:id/cancel", jobHandler.CancelJob)
+		}
+
+		// Deadline reminders sent to the caller, and the tenant's reminder
+		// rules and quiet hours
+		protected.GET("/reminders", reminderHandler.GetReminders)
+		reminderRoutes := protected.Group("/reminders")
+		reminderRoutes.Use(middleware.RequireTenantAdmin())
+		{
+			reminderRoutes.GET("/rules", reminderHandler.GetReminderRules)
+			reminderRoutes.PUT("/rules/:source", reminderHandler.SetReminderRule)
+			reminderRoutes.DELETE("/rules/:source", reminderHandler.DeleteReminderRule)
+			reminderRoutes.GET("/settings", reminderHandler.GetReminderSettings)
+			reminderRoutes.PUT("/settings", reminderHandler.SetReminderSettings)
+		}
+
+		// The caller's notification inbox and preferences, and the tenant's
+		// notification templates and chat channels
+		protected.GET("/notifications", notificationHandler.GetNotifications)
+		protected.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
+		protected.POST("/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+		protected.GET("/notifications/preferences", notificationHandler.GetNotificationPreferences)
+		protected.PUT("/notifications/preferences", notificationHandler.SetNotificationPreferences)
+		notificationRoutes := protected.Group("/notifications")
+		notificationRoutes.Use(middleware.RequireTenantAdmin())
+		{
+			notificationRoutes.GET("/templates", notificationHandler.GetNotificationTemplates)
+			notificationRoutes.PUT("/templates/:key/:language", notificationHandler.SetNotificationTemplate)
+			notificationRoutes.DELETE("/templates/:key/:language", notificationHandler.DeleteNotificationTemplate)
+			notificationRoutes.GET("/chat-channels", notificationHandler.GetChatChannels)
+			notificationRoutes.POST("/chat-channels", notificationHandler.CreateChatChannel)
+			notificationRoutes.PUT("/chat-channels/:id", notificationHandler.UpdateChatChannel)
+			notificationRoutes.DELETE("/chat-channels/:id", notificationHandler.DeleteChatChannel)
+			notificationRoutes.POST("/chat-channels/:id/test", notificationHandler.TestChatChannel)
+		}
+
+		// Bulk import (permissions checked per resource in the handler)
+		protected.GET("/imports/:resource/template", importHandler.GetTemplate)
+		protected.POST("/imports/:resource", importHandler.Import)
+		protected.GET("/import-jobs", importHandler.GetImportJobs)
+		protected.GET("/import-jobs/:id", importHandler.GetImportJob)
+		protected.POST("/import-jobs/:id/rollback", importHandler.RollbackImportJob)
+
+		// Bulk export (permissions checked per resource in the handler)
+		protected.GET("/exports/:resource", exportHandler.Export)
+		protected.GET("/exports/:resource/columns", exportHandler.GetExportColumns)
+		protected.PUT("/exports/:resource/columns", exportHandler.UpdateExportColumns)
+
+		// Outbound webhooks, managed by tenant admins
+		webhooks := protected.Group("/webhooks")
+		webhooks.Use(middleware.RequireTenantAdmin())
+		{
+			webhooks.GET("", webhookHandler.GetWebhooks)
+			webhooks.GET("/events", webhookHandler.GetEventTypes)
+			webhooks.POST("", webhookHandler.CreateWebhook)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.POST("/:id/ping", webhookHandler.PingWebhook)
+			webhooks.POST("/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+		}
+		webhookDeliveries := protected.Group("/webhook-deliveries")
+		webhookDeliveries.Use(middleware.RequireTenantAdmin())
+		{
+			webhookDeliveries.GET("", webhookHandler.GetWebhookDeliveries)
+			webhookDeliveries.GET("/dead-letters", webhookHandler.GetDeadLetters)
+			webhookDeliveries.GET("/:id", webhookHandler.GetWebhookDelivery)
+			webhookDeliveries.POST("/:id/redeliver", webhookHandler.RedeliverWebhook)
+		}
+
 		// Domain-specific routes - RegOps with RBAC
 		regops := protected.Group("/regops")
 		{
 			// Regulations
 			regops.GET("/regulations", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetRegulations)
 			regops.POST("/regulations", middleware.RBACMiddleware(models.PermissionRegOpsCreate), api.GetRegOpsHandler().CreateRegulation)
+			regops.GET("/regulations/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetRegulation)
 			regops.PUT("/regulations/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().UpdateRegulation)
+			regops.PATCH("/regulations/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().PatchRegulation)
 			regops.DELETE("/regulations/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), api.GetRegOpsHandler().DeleteRegulation)
 			// Recovery endpoints
 			regops.GET("/regulations/deleted", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetDeletedRegulations)
 			regops.POST("/regulations/:id/restore", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().RestoreRegulation)
 			regops.DELETE("/regulations/:id/permanent", middleware.RBACMiddleware(models.PermissionRegOpsDelete), api.GetRegOpsHandler().PermanentDeleteRegulation)
+			// Clauses of regulations
+			regops.GET("/regulations/:id/clauses", middleware.RBACMiddleware(models.PermissionRegOpsView), regulationClauseHandler.GetRegulationClauses)
+			regops.POST("/regulations/:id/clauses/import", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regulationClauseHandler.ImportRegulationClauses)
+			regops.GET("/clauses/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), regulationClauseHandler.GetRegulationClause)
 			// Compliance Assessments
 			regops.GET("/compliance-assessments", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetComplianceAssessments)
 			regops.POST("/compliance-assessments", middleware.RBACMiddleware(models.PermissionRegOpsCreate), api.GetRegOpsHandler().CreateComplianceAssessment)
+			regops.GET("/compliance-assessments/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetComplianceAssessment)
 			regops.PUT("/compliance-assessments/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().UpdateComplianceAssessment)
+			regops.PATCH("/compliance-assessments/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().PatchComplianceAssessment)
 			regops.DELETE("/compliance-assessments/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), api.GetRegOpsHandler().DeleteComplianceAssessment)
 			// Compliance Gap Analysis
 			regops.GET("/compliance-gaps", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsGapAnalysisHandler.GetComplianceGaps)
 			regops.POST("/compliance-gaps", middleware.RBACMiddleware(models.PermissionRegOpsCreate), regopsGapAnalysisHandler.CreateComplianceGap)
+			regops.GET("/compliance-gaps/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsGapAnalysisHandler.GetComplianceGap)
 			regops.PUT("/compliance-gaps/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsGapAnalysisHandler.UpdateComplianceGap)
+			regops.PATCH("/compliance-gaps/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsGapAnalysisHandler.PatchComplianceGap)
 			regops.DELETE("/compliance-gaps/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), regopsGapAnalysisHandler.DeleteComplianceGap)
 			regops.GET("/compliance-gaps/stats", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsGapAnalysisHandler.GetGapStats)
 			// Obligation Mapping
 			regops.GET("/obligations", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsObligationMappingHandler.GetObligations)
 			regops.POST("/obligations", middleware.RBACMiddleware(models.PermissionRegOpsCreate), regopsObligationMappingHandler.CreateObligation)
+			regops.GET("/obligations/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsObligationMappingHandler.GetObligation)
 			regops.PUT("/obligations/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsObligationMappingHandler.UpdateObligation)
+			regops.PATCH("/obligations/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsObligationMappingHandler.PatchObligation)
 			regops.DELETE("/obligations/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), regopsObligationMappingHandler.DeleteObligation)
+			regops.PUT("/obligations/:id/clause", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsObligationMappingHandler.LinkObligationClause)
 			regops.GET("/obligations/stats", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsObligationMappingHandler.GetObligationStats)
 			// Policies
 			regops.GET("/policies", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetPolicies)
 			regops.POST("/policies", middleware.RBACMiddleware(models.PermissionRegOpsCreate), api.GetRegOpsHandler().CreatePolicy)
+			regops.GET("/policies/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetPolicy)
 			regops.PUT("/policies/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().UpdatePolicy)
+			regops.PATCH("/policies/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().PatchPolicy)
 			regops.DELETE("/policies/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), api.GetRegOpsHandler().DeletePolicy)
+			regops.POST("/policies/:id/publish", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), api.GetRegOpsHandler().PublishPolicy)
 			// Controls
 			regops.GET("/controls", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsControlsHandler.GetControls)
 			regops.POST("/controls", middleware.RBACMiddleware(models.PermissionRegOpsCreate), regopsControlsHandler.CreateControl)
+			regops.GET("/controls/:id", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsControlsHandler.GetControl)
 			regops.PUT("/controls/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsControlsHandler.UpdateControl)
+			regops.PATCH("/controls/:id", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsControlsHandler.PatchControl)
 			regops.DELETE("/controls/:id", middleware.RBACMiddleware(models.PermissionRegOpsDelete), regopsControlsHandler.DeleteControl)
+			regops.PUT("/controls/:id/clause", middleware.RBACMiddleware(models.PermissionRegOpsUpdate), regopsControlsHandler.LinkControlClause)
 			regops.GET("/controls/stats", middleware.RBACMiddleware(models.PermissionRegOpsView), regopsControlsHandler.GetControlStats)
 			// Recovery endpoints for all RegOps entities
 			regops.GET("/compliance-assessments/deleted", middleware.RBACMiddleware(models.PermissionRegOpsView), api.GetRegOpsHandler().GetDeletedComplianceAssessments)
@@ -189,19 +570,25 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Data Inventory
 			privacyops.GET("/data-inventory", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDataInventoryHandler.GetDataInventory)
 			privacyops.POST("/data-inventory", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsDataInventoryHandler.CreateDataItem)
+			privacyops.GET("/data-inventory/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDataInventoryHandler.GetDataItem)
 			privacyops.PUT("/data-inventory/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDataInventoryHandler.UpdateDataItem)
+			privacyops.PATCH("/data-inventory/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDataInventoryHandler.PatchDataItem)
 			privacyops.DELETE("/data-inventory/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsDataInventoryHandler.DeleteDataItem)
 			privacyops.GET("/data-inventory/stats", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDataInventoryHandler.GetDataInventoryStats)
 			// RoPA
 			privacyops.GET("/ropa", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsRoPAHandler.GetProcessingActivities)
 			privacyops.POST("/ropa", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsRoPAHandler.CreateProcessingActivity)
+			privacyops.GET("/ropa/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsRoPAHandler.GetProcessingActivity)
 			privacyops.PUT("/ropa/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsRoPAHandler.UpdateProcessingActivity)
+			privacyops.PATCH("/ropa/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsRoPAHandler.PatchProcessingActivity)
 			privacyops.DELETE("/ropa/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsRoPAHandler.DeleteProcessingActivity)
 			privacyops.GET("/ropa/stats", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsRoPAHandler.GetRoPAStats)
 			// DSR Requests
 			privacyops.GET("/dsr", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDSRHandler.GetDSRs)
 			privacyops.POST("/dsr", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsDSRHandler.CreateDSR)
+			privacyops.GET("/dsr/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDSRHandler.GetDSR)
 			privacyops.PUT("/dsr/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDSRHandler.UpdateDSR)
+			privacyops.PATCH("/dsr/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDSRHandler.PatchDSR)
 			privacyops.DELETE("/dsr/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsDSRHandler.DeleteDSR)
 			privacyops.POST("/dsr/:id/approve", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDSRHandler.ApproveDSR)
 			privacyops.POST("/dsr/:id/reject", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDSRHandler.RejectDSR)
@@ -209,20 +596,26 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// DPIAs
 			privacyops.GET("/dpias", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDPIAHandler.GetDPIAs)
 			privacyops.POST("/dpias", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsDPIAHandler.CreateDPIA)
+			privacyops.GET("/dpias/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDPIAHandler.GetDPIA)
 			privacyops.PUT("/dpias/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDPIAHandler.UpdateDPIA)
+			privacyops.PATCH("/dpias/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDPIAHandler.PatchDPIA)
 			privacyops.DELETE("/dpias/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsDPIAHandler.DeleteDPIA)
 			privacyops.POST("/dpias/:id/approve", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsDPIAHandler.ApproveDPIA)
 			privacyops.GET("/dpias/stats", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsDPIAHandler.GetDPIAStats)
 			// Privacy Controls
 			privacyops.GET("/privacy-controls", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsControlsHandler.GetPrivacyControls)
 			privacyops.POST("/privacy-controls", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsControlsHandler.CreatePrivacyControl)
+			privacyops.GET("/privacy-controls/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsControlsHandler.GetPrivacyControl)
 			privacyops.PUT("/privacy-controls/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsControlsHandler.UpdatePrivacyControl)
+			privacyops.PATCH("/privacy-controls/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsControlsHandler.PatchPrivacyControl)
 			privacyops.DELETE("/privacy-controls/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsControlsHandler.DeletePrivacyControl)
 			privacyops.GET("/privacy-controls/stats", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsControlsHandler.GetPrivacyControlsStats)
 			// Incident & Breach Response
 			privacyops.GET("/incidents", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsIncidentHandler.GetIncidents)
 			privacyops.POST("/incidents", middleware.RBACMiddleware(models.PermissionPrivacyOpsCreate), privacyopsIncidentHandler.CreateIncident)
+			privacyops.GET("/incidents/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsIncidentHandler.GetIncident)
 			privacyops.PUT("/incidents/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsIncidentHandler.UpdateIncident)
+			privacyops.PATCH("/incidents/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsIncidentHandler.PatchIncident)
 			privacyops.DELETE("/incidents/:id", middleware.RBACMiddleware(models.PermissionPrivacyOpsDelete), privacyopsIncidentHandler.DeleteIncident)
 			privacyops.POST("/incidents/:id/resolve", middleware.RBACMiddleware(models.PermissionPrivacyOpsUpdate), privacyopsIncidentHandler.ResolveIncident)
 			privacyops.GET("/incidents/stats", middleware.RBACMiddleware(models.PermissionPrivacyOpsView), privacyopsIncidentHandler.GetIncidentStats)
@@ -234,27 +627,35 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Risk Register (ERM)
 			riskops.GET("/risk-register", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsERMHandler.GetRiskRegister)
 			riskops.POST("/risk-register", middleware.RBACMiddleware(models.PermissionRiskOpsCreate), riskopsERMHandler.CreateRisk)
+			riskops.GET("/risk-register/:id", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsERMHandler.GetRisk)
 			riskops.PUT("/risk-register/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsERMHandler.UpdateRisk)
+			riskops.PATCH("/risk-register/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsERMHandler.PatchRisk)
 			riskops.DELETE("/risk-register/:id", middleware.RBACMiddleware(models.PermissionRiskOpsDelete), riskopsERMHandler.CloseRisk)
 			riskops.POST("/risk-register/:id/close", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsERMHandler.CloseRisk)
 			riskops.GET("/risk-register/stats", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsERMHandler.GetRiskStats)
 			// Security Risk & Vulnerability Management
 			riskops.GET("/vulnerabilities", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsSecurityHandler.GetVulnerabilities)
 			riskops.POST("/vulnerabilities", middleware.RBACMiddleware(models.PermissionRiskOpsCreate), riskopsSecurityHandler.CreateVulnerability)
+			riskops.GET("/vulnerabilities/:id", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsSecurityHandler.GetVulnerability)
 			riskops.PUT("/vulnerabilities/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsSecurityHandler.UpdateVulnerability)
+			riskops.PATCH("/vulnerabilities/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsSecurityHandler.PatchVulnerability)
 			riskops.DELETE("/vulnerabilities/:id", middleware.RBACMiddleware(models.PermissionRiskOpsDelete), riskopsSecurityHandler.DeleteVulnerability)
 			riskops.POST("/vulnerabilities/:id/resolve", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsSecurityHandler.ResolveVulnerability)
 			riskops.GET("/vulnerabilities/stats", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsSecurityHandler.GetVulnerabilityStats)
 			// Vendor & Third-Party Risk
 			riskops.GET("/vendors", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsVendorHandler.GetVendors)
 			riskops.POST("/vendors", middleware.RBACMiddleware(models.PermissionRiskOpsCreate), riskopsVendorHandler.CreateVendor)
+			riskops.GET("/vendors/:id", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsVendorHandler.GetVendor)
 			riskops.PUT("/vendors/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsVendorHandler.UpdateVendor)
+			riskops.PATCH("/vendors/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsVendorHandler.PatchVendor)
 			riskops.DELETE("/vendors/:id", middleware.RBACMiddleware(models.PermissionRiskOpsDelete), riskopsVendorHandler.DeleteVendor)
 			riskops.GET("/vendors/stats", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsVendorHandler.GetVendorStats)
 			// Business Continuity & Resilience
 			riskops.GET("/continuity", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsContinuityHandler.GetContinuityPlans)
 			riskops.POST("/continuity", middleware.RBACMiddleware(models.PermissionRiskOpsCreate), riskopsContinuityHandler.CreateContinuityPlan)
+			riskops.GET("/continuity/:id", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsContinuityHandler.GetContinuityPlan)
 			riskops.PUT("/continuity/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsContinuityHandler.UpdateContinuityPlan)
+			riskops.PATCH("/continuity/:id", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsContinuityHandler.PatchContinuityPlan)
 			riskops.DELETE("/continuity/:id", middleware.RBACMiddleware(models.PermissionRiskOpsDelete), riskopsContinuityHandler.DeleteContinuityPlan)
 			riskops.POST("/continuity/:id/test", middleware.RBACMiddleware(models.PermissionRiskOpsUpdate), riskopsContinuityHandler.TestContinuityPlan)
 			riskops.GET("/continuity/stats", middleware.RBACMiddleware(models.PermissionRiskOpsView), riskopsContinuityHandler.GetContinuityStats)
@@ -266,26 +667,34 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Internal Audit Management
 			auditops.GET("/internal-audits", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsInternalAuditHandler.GetInternalAudits)
 			auditops.POST("/internal-audits", middleware.RBACMiddleware(models.PermissionAuditOpsCreate), auditopsInternalAuditHandler.CreateInternalAudit)
+			auditops.GET("/internal-audits/:id", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsInternalAuditHandler.GetInternalAudit)
 			auditops.PUT("/internal-audits/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsInternalAuditHandler.UpdateInternalAudit)
+			auditops.PATCH("/internal-audits/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsInternalAuditHandler.PatchInternalAudit)
 			auditops.DELETE("/internal-audits/:id", middleware.RBACMiddleware(models.PermissionAuditOpsDelete), auditopsInternalAuditHandler.DeleteInternalAudit)
 			auditops.GET("/internal-audits/stats", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsInternalAuditHandler.GetInternalAuditStats)
 			// Governance & Accountability (KRI)
 			auditops.GET("/kris", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsGovernanceHandler.GetKRIs)
 			auditops.POST("/kris", middleware.RBACMiddleware(models.PermissionAuditOpsCreate), auditopsGovernanceHandler.CreateKRI)
+			auditops.GET("/kris/:id", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsGovernanceHandler.GetKRI)
 			auditops.PUT("/kris/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsGovernanceHandler.UpdateKRI)
+			auditops.PATCH("/kris/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsGovernanceHandler.PatchKRI)
 			auditops.DELETE("/kris/:id", middleware.RBACMiddleware(models.PermissionAuditOpsDelete), auditopsGovernanceHandler.DeleteKRI)
 			auditops.GET("/kris/stats", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsGovernanceHandler.GetKRIStats)
 			// Continuous Audit & Control Testing
 			auditops.GET("/control-tests", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsContinuousAuditHandler.GetControlTests)
 			auditops.POST("/control-tests", middleware.RBACMiddleware(models.PermissionAuditOpsCreate), auditopsContinuousAuditHandler.CreateControlTest)
+			auditops.GET("/control-tests/:id", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsContinuousAuditHandler.GetControlTest)
 			auditops.PUT("/control-tests/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsContinuousAuditHandler.UpdateControlTest)
+			auditops.PATCH("/control-tests/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsContinuousAuditHandler.PatchControlTest)
 			auditops.DELETE("/control-tests/:id", middleware.RBACMiddleware(models.PermissionAuditOpsDelete), auditopsContinuousAuditHandler.DeleteControlTest)
 			auditops.POST("/control-tests/:id/run", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsContinuousAuditHandler.RunControlTest)
 			auditops.GET("/control-tests/stats", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsContinuousAuditHandler.GetControlTestStats)
 			// Audit Evidence
 			auditops.GET("/evidence", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsEvidenceHandler.GetEvidence)
 			auditops.POST("/evidence", middleware.RBACMiddleware(models.PermissionAuditOpsCreate), auditopsEvidenceHandler.CreateEvidence)
+			auditops.GET("/evidence/:id", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsEvidenceHandler.GetEvidenceByID)
 			auditops.PUT("/evidence/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsEvidenceHandler.UpdateEvidence)
+			auditops.PATCH("/evidence/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsEvidenceHandler.PatchEvidence)
 			auditops.DELETE("/evidence/:id", middleware.RBACMiddleware(models.PermissionAuditOpsDelete), auditopsEvidenceHandler.DeleteEvidence)
 			auditops.POST("/evidence/:id/approve", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsEvidenceHandler.ApproveEvidence)
 			auditops.POST("/evidence/:id/reject", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsEvidenceHandler.RejectEvidence)
@@ -293,7 +702,9 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Reporting & Assurance
 			auditops.GET("/reports", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsReportingHandler.GetReports)
 			auditops.POST("/reports", middleware.RBACMiddleware(models.PermissionAuditOpsCreate), auditopsReportingHandler.CreateReport)
+			auditops.GET("/reports/:id", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsReportingHandler.GetReport)
 			auditops.PUT("/reports/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsReportingHandler.UpdateReport)
+			auditops.PATCH("/reports/:id", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsReportingHandler.PatchReport)
 			auditops.DELETE("/reports/:id", middleware.RBACMiddleware(models.PermissionAuditOpsDelete), auditopsReportingHandler.DeleteReport)
 			auditops.POST("/reports/:id/generate", middleware.RBACMiddleware(models.PermissionAuditOpsUpdate), auditopsReportingHandler.GenerateReport)
 			auditops.GET("/reports/stats", middleware.RBACMiddleware(models.PermissionAuditOpsView), auditopsReportingHandler.GetReportStats)
@@ -305,8 +716,8 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			ai.GET("/settings", middleware.RBACMiddleware(models.PermissionAIView), api.GetAIHandler().GetAISettings)
 			ai.PUT("/settings", middleware.RBACMiddleware(models.PermissionAIUpdate), api.GetAIHandler().UpdateAISettings)
 			ai.GET("/models", middleware.RBACMiddleware(models.PermissionAIView), api.GetAIHandler().GetAvailableModels)
-			ai.POST("/test", middleware.RBACMiddleware(models.PermissionAIUpdate), api.GetAIHandler().TestAIConnection)
-			ai.POST("/chat", middleware.RBACMiddleware(models.PermissionAIUse), api.GetAIHandler().AIChat)
+			ai.POST("/test", middleware.RBACMiddleware(models.PermissionAIUpdate), aiBudget, api.GetAIHandler().TestAIConnection)
+			ai.POST("/chat", middleware.RBACMiddleware(models.PermissionAIUse), aiBudget, api.GetAIHandler().AIChat)
 		}
 
 		// RBAC routes - with RBAC
@@ -323,15 +734,15 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 		// Document routes - with RBAC
 		documents := protected.Group("/documents")
 		{
-			documents.POST("/analyze", middleware.RBACMiddleware(models.PermissionDocumentAnalyze), api.GetDocumentHandler().AnalyzeDocument)
-			documents.POST("/generate", middleware.RBACMiddleware(models.PermissionDocumentCreate), api.GetDocumentHandler().GenerateDocument)
+			documents.POST("/analyze", middleware.RBACMiddleware(models.PermissionDocumentAnalyze), analysisBudget, api.GetDocumentHandler().AnalyzeDocument)
+			documents.POST("/generate", middleware.RBACMiddleware(models.PermissionDocumentCreate), aiBudget, api.GetDocumentHandler().GenerateDocument)
 			documents.POST("/save", middleware.RBACMiddleware(models.PermissionDocumentCreate), api.GetDocumentHandler().SaveDocument)
 			documents.GET("/templates", middleware.RBACMiddleware(models.PermissionDocumentView), api.GetDocumentHandler().GetDocumentTemplates)
 			documents.GET("/", middleware.RBACMiddleware(models.PermissionDocumentView), api.GetDocumentHandler().GetDocuments)
 			documents.GET("/:id", middleware.RBACMiddleware(models.PermissionDocumentView), api.GetDocumentHandler().GetDocumentByID)
 			documents.GET("/:id/analyses", middleware.RBACMiddleware(models.PermissionDocumentView), api.GetDocumentHandler().GetDocumentAnalyses)
 			documents.GET("/analyses/:id/infographic", middleware.RBACMiddleware(models.PermissionDocumentView), api.GetDocumentHandler().GetInfographicHTML)
-			documents.POST("/autofill", middleware.RBACMiddleware(models.PermissionDocumentUpdate), api.GetDocumentHandler().AutoFillDocument)
+			documents.POST("/autofill", middleware.RBACMiddleware(models.PermissionDocumentUpdate), aiBudget, api.GetDocumentHandler().AutoFillDocument)
 		}
 
 		// AI Document Generator & Analyzer routes - with RBAC
@@ -346,13 +757,13 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Generated Documents
 			aiDocuments.GET("/generated", middleware.RBACMiddleware(models.PermissionDocumentView), aiDocumentHandler.GetGeneratedDocuments)
 			aiDocuments.GET("/generated/:id", middleware.RBACMiddleware(models.PermissionDocumentView), aiDocumentHandler.GetGeneratedDocument)
-			aiDocuments.POST("/generated", middleware.RBACMiddleware(models.PermissionDocumentCreate), aiDocumentHandler.GenerateDocument)
+			aiDocuments.POST("/generated", middleware.RBACMiddleware(models.PermissionDocumentCreate), aiBudget, aiDocumentHandler.GenerateDocument)
 			aiDocuments.PUT("/generated/:id", middleware.RBACMiddleware(models.PermissionDocumentUpdate), aiDocumentHandler.UpdateGeneratedDocument)
 			aiDocuments.DELETE("/generated/:id", middleware.RBACMiddleware(models.PermissionDocumentDelete), aiDocumentHandler.DeleteGeneratedDocument)
 			// Document Analysis
 			aiDocuments.GET("/analyses", middleware.RBACMiddleware(models.PermissionDocumentView), aiDocumentHandler.GetDocumentAnalyses)
 			aiDocuments.GET("/analyses/:id", middleware.RBACMiddleware(models.PermissionDocumentView), aiDocumentHandler.GetDocumentAnalysis)
-			aiDocuments.POST("/analyses", middleware.RBACMiddleware(models.PermissionDocumentAnalyze), aiDocumentHandler.AnalyzeDocument)
+			aiDocuments.POST("/analyses", middleware.RBACMiddleware(models.PermissionDocumentAnalyze), analysisBudget, aiDocumentHandler.AnalyzeDocument)
 			aiDocuments.DELETE("/analyses/:id", middleware.RBACMiddleware(models.PermissionDocumentDelete), aiDocumentHandler.DeleteDocumentAnalysis)
 		}
 
@@ -398,6 +809,19 @@ func setupRoutes(r *gin.Engine, regopsGapAnalysisHandler *api.RegOpsGapAnalysisH
 			// Logs
 			platform.GET("/logs", platformHandler.GetSystemLogs)
 			platform.GET("/logs/stats", platformHandler.GetLogStats)
+
+			// Default retention periods and on-demand purge
+			platform.GET("/retention/policies", retentionHandler.GetDefaultRetention)
+			platform.PUT("/retention/policies/:class", retentionHandler.SetDefaultRetention)
+			platform.DELETE("/retention/policies/:class", retentionHandler.DeleteDefaultRetention)
+			platform.POST("/retention/purge", retentionHandler.PurgeNow)
+
+			// Background jobs of every tenant and recurring schedules
+			platform.GET("/jobs", jobHandler.GetPlatformJobs)
+			platform.GET("/jobs/:id", jobHandler.GetPlatformJob)
+			platform.POST("/jobs/:id/retry", jobHandler.RetryPlatformJob)
+			platform.POST("/jobs/:id/cancel", jobHandler.CancelPlatformJob)
+			platform.GET("/job-schedules", jobHandler.GetJobSchedules)
 		}
 	}
 }
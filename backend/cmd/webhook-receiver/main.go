@@ -0,0 +1,63 @@
+// Command webhook-receiver is a local endpoint for testing webhook
+// subscriptions. It verifies the signature of each delivery and prints it.
+//
+//	go run ./cmd/webhook-receiver -secret whsec_... -addr :9000
+//
+// Webhook URLs may not point at localhost or private addresses unless the
+// server, and cmd/worker if it delivers the webhooks, run with
+// WEBHOOK_ALLOW_PRIVATE=true. Production refuses to start with it. Then
+// register http://localhost:9000/ as the subscription URL. Use -fail to
+// answer with an error status and watch the retries and dead letters.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	secret := flag.String("secret", "", "subscription signing secret; signatures are not checked when empty")
+	fail := flag.Int("fail", 0, "respond with this status code instead of 200")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		event := r.Header.Get(webhook.HeaderEvent)
+		delivery := r.Header.Get(webhook.HeaderDelivery)
+		if *secret != "" {
+			if err := webhook.Verify(*secret, r.Header.Get(webhook.HeaderSignature), body, 5*time.Minute); err != nil {
+				log.Printf("REJECTED %s delivery %s: %v", event, delivery, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") != nil {
+			pretty.Write(body)
+		}
+		log.Printf("%s delivery %s\n%s", event, delivery, pretty.String())
+
+		if *fail != 0 {
+			http.Error(w, "simulated failure", *fail)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,104 @@
+// Command worker runs the background job queue without serving HTTP, so
+// jobs can be scaled apart from the API. Run the servers with
+// JOB_WORKER=false when workers run the jobs. Any number of workers, and
+// servers with the embedded worker, can run at once.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cyber/backend/internal/api"
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/cache"
+	"github.com/cyber/backend/internal/config"
+	"github.com/cyber/backend/internal/crypto"
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/metrics"
+	"github.com/cyber/backend/internal/notify"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/cyber/backend/internal/reminders"
+	"github.com/cyber/backend/internal/retention"
+	"github.com/cyber/backend/internal/versions"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+	if cfg.EncryptionKey != "" {
+		crypto.SetEncryptionKey(cfg.EncryptionKey)
+	}
+	if cfg.Server.WebhookAllowPrivate {
+		egress.AllowPrivate(true)
+		log.Println("Warning: WEBHOOK_ALLOW_PRIVATE set, webhooks may reach localhost and private addresses")
+	}
+
+	dbConn, err := db.Init(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer dbConn.Close()
+
+	// Jobs change records like requests do: they are audited, versioned and
+	// announced to the servers' streams through Redis when it is configured
+	if err := audit.RegisterCallbacks(dbConn.DB); err != nil {
+		log.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+	versions.Register()
+	var hub *realtime.Hub
+	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		redisClient, err := cache.NewRedisClient(addr, password)
+		if err != nil {
+			log.Printf("Warning: Failed to connect to Redis, changes made by jobs are not announced: %v", err)
+		} else {
+			defer redisClient.Close()
+			hub = realtime.NewHub(redisClient)
+			if err := realtime.RegisterCallbacks(dbConn.DB, hub); err != nil {
+				log.Fatalf("Failed to register realtime callbacks: %v", err)
+			}
+		}
+	}
+
+	// The same jobs and schedules as the servers' embedded worker
+	api.InitHandlers(dbConn)
+	if hub != nil {
+		api.InitRealtime(hub)
+	}
+	queue := jobs.NewQueue(dbConn.DB)
+	queue.Concurrency = cfg.Server.JobConcurrency
+	api.InitJobs(queue, dbConn)
+	purger := retention.NewPurger(dbConn, api.GetDocumentHandler().Storage())
+	if err := purger.Schedule(queue, cfg.Server.RetentionPurgeSchedule); err != nil {
+		log.Fatalf("Invalid retention purge schedule: %v", err)
+	}
+	if err := metrics.NewKPICollector(dbConn.DB).Schedule(queue); err != nil {
+		log.Fatalf("Invalid KPI snapshot schedule: %v", err)
+	}
+	if err := reminders.NewEngine(dbConn.DB, hub).Schedule(queue, cfg.Server.ReminderSchedule); err != nil {
+		log.Fatalf("Invalid reminder schedule: %v", err)
+	}
+	if err := notify.NewService(dbConn.DB, cfg.SMTP, hub).Register(queue, cfg.Server.NotificationDigestSchedule); err != nil {
+		log.Fatalf("Invalid notification digest schedule: %v", err)
+	}
+
+	// Jobs running at shutdown are released for another worker
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	log.Printf("Worker running %d jobs at a time", queue.Concurrency)
+	queue.Run(ctx)
+	log.Println("Worker stopped")
+}
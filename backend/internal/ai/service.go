@@ -8,6 +8,10 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/cyber/backend/internal/metrics"
+	"github.com/cyber/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // AIService handles AI API calls
@@ -17,7 +21,7 @@ type AIService struct {
 
 func NewAIService() *AIService {
 	return &AIService{
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: &http.Client{Timeout: 60 * time.Second, Transport: tracing.Transport(nil)},
 	}
 }
 
@@ -45,14 +49,34 @@ type ChatResponse struct {
 
 // Chat sends a message to AI and returns response
 func (s *AIService) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var chat func(context.Context, ChatRequest) (*ChatResponse, error)
 	switch req.Provider {
 	case "gemini":
-		return s.chatGemini(ctx, req)
+		chat = s.chatGemini
 	case "openrouter":
-		return s.chatOpenRouter(ctx, req)
+		chat = s.chatOpenRouter
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", req.Provider)
 	}
+
+	ctx, span := tracing.Start(ctx, "ai.chat",
+		attribute.String("gen_ai.system", req.Provider),
+		attribute.String("gen_ai.request.model", req.Model),
+		attribute.String("ai.feature", req.Feature),
+	)
+	start := time.Now()
+	resp, err := chat(ctx, req)
+	var promptTokens, outputTokens int
+	if resp != nil {
+		promptTokens, outputTokens = resp.PromptTokens, resp.OutputTokens
+	}
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", promptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", outputTokens),
+	)
+	tracing.End(span, err)
+	metrics.ObserveAIRequest(req.Provider, req.Model, time.Since(start), promptTokens, outputTokens, err)
+	return resp, err
 }
 
 // Gemini API integration
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -35,14 +36,17 @@ func (h *AIDocumentHandler) GetDocumentTemplates(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var templates []models.Document
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ? AND is_generated = ?", tenantID, false, false).Find(&templates).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+	base := requestDB(c, h.db).Model(&models.Document{}).Where("tenant_id = ? AND is_deleted = ? AND is_generated = ?", tenantID, false, false)
+	page, err := query.List(c, base, documentQuery, &templates)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch templates")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    templates,
+		"success":    true,
+		"data":       templates,
+		"pagination": page,
 	})
 }
 
@@ -52,26 +56,30 @@ func (h *AIDocumentHandler) GetDocumentTemplate(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var template models.Document
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&template).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&template).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
 		return
 	}
 
+	setETag(c, &template)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    template,
 	})
 }
 
+// createDocumentTemplateRequest is the body accepted by CreateDocumentTemplate
+type createDocumentTemplateRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Description     string `json:"description"`
+	DocumentType    string `json:"documentType" binding:"required"`
+	TemplateType    string `json:"templateType" binding:"required"`
+	TemplateContent string `json:"templateContent" binding:"required"`
+}
+
 // CreateDocumentTemplate creates a new document template
 func (h *AIDocumentHandler) CreateDocumentTemplate(c *gin.Context) {
-	var req struct {
-		Name            string `json:"name" binding:"required"`
-		Description     string `json:"description"`
-		DocumentType    string `json:"documentType" binding:"required"`
-		TemplateType    string `json:"templateType" binding:"required"`
-		TemplateContent string `json:"templateContent" binding:"required"`
-	}
+	var req createDocumentTemplateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -93,7 +101,7 @@ func (h *AIDocumentHandler) CreateDocumentTemplate(c *gin.Context) {
 		CreatedBy:    createdBy,
 	}
 
-	if err := h.db.Create(&template).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&template).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
 		return
 	}
@@ -105,17 +113,20 @@ func (h *AIDocumentHandler) CreateDocumentTemplate(c *gin.Context) {
 	})
 }
 
+// updateDocumentTemplateRequest is the body accepted by UpdateDocumentTemplate
+type updateDocumentTemplateRequest struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	DocumentType    string `json:"documentType"`
+	TemplateType    string `json:"templateType"`
+	TemplateContent string `json:"templateContent"`
+}
+
 // UpdateDocumentTemplate updates an existing document template
 func (h *AIDocumentHandler) UpdateDocumentTemplate(c *gin.Context) {
 	id := c.Param("id")
 
-	var req struct {
-		Name            string `json:"name"`
-		Description     string `json:"description"`
-		DocumentType    string `json:"documentType"`
-		TemplateType    string `json:"templateType"`
-		TemplateContent string `json:"templateContent"`
-	}
+	var req updateDocumentTemplateRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -125,10 +136,13 @@ func (h *AIDocumentHandler) UpdateDocumentTemplate(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var template models.Document
 
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&template).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&template).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
 		return
 	}
+	if !checkIfMatch(c, &template) {
+		return
+	}
 
 	updates := map[string]interface{}{}
 	if req.Name != "" {
@@ -147,14 +161,19 @@ func (h *AIDocumentHandler) UpdateDocumentTemplate(c *gin.Context) {
 		updates["content"] = req.TemplateContent
 	}
 
-	if err := h.db.Model(&template).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&template).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &template, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
 		return
 	}
 
+	setETag(c, &template)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Document template updated successfully",
+		"data":    template,
 	})
 }
 
@@ -163,11 +182,19 @@ func (h *AIDocumentHandler) DeleteDocumentTemplate(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Document{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-		}).Error; err != nil {
+	var template models.Document
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if !checkIfMatch(c, &template) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "document", id, &template); err != nil {
+		if staleWrite(c, h.db, &template, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
 		return
 	}
@@ -185,14 +212,17 @@ func (h *AIDocumentHandler) GetGeneratedDocuments(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var documents []models.Document
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ? AND is_generated = ?", tenantID, false, true).Find(&documents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+	base := requestDB(c, h.db).Model(&models.Document{}).Where("tenant_id = ? AND is_deleted = ? AND is_generated = ?", tenantID, false, true)
+	page, err := query.List(c, base, documentQuery, &documents)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch documents")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    documents,
+		"success":    true,
+		"data":       documents,
+		"pagination": page,
 	})
 }
 
@@ -202,28 +232,32 @@ func (h *AIDocumentHandler) GetGeneratedDocument(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var document models.Document
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&document).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
+	setETag(c, &document)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    document,
 	})
 }
 
+// generateDocumentRequest is the body accepted by GenerateDocument
+type generateDocumentRequest struct {
+	DocumentType     string                 `json:"documentType" binding:"required"`
+	TemplateType     string                 `json:"templateType" binding:"required"`
+	Name             string                 `json:"name" binding:"required"`
+	RequirementsData map[string]interface{} `json:"requirementsData" binding:"required"`
+}
+
 // GenerateDocument generates a document using AI
 func (h *AIDocumentHandler) GenerateDocument(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	createdBy := c.GetString("user_id")
 
-	var req struct {
-		DocumentType     string                 `json:"documentType" binding:"required"`
-		TemplateType     string                 `json:"templateType" binding:"required"`
-		Name             string                 `json:"name" binding:"required"`
-		RequirementsData map[string]interface{} `json:"requirementsData" binding:"required"`
-	}
+	var req generateDocumentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -253,7 +287,7 @@ func (h *AIDocumentHandler) GenerateDocument(c *gin.Context) {
 		CreatedBy:        createdBy,
 	}
 
-	if err := h.db.Create(&document).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&document).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate document"})
 		return
 	}
@@ -265,16 +299,19 @@ func (h *AIDocumentHandler) GenerateDocument(c *gin.Context) {
 	})
 }
 
+// updateGeneratedDocumentRequest is the body accepted by UpdateGeneratedDocument
+type updateGeneratedDocumentRequest struct {
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	StyledHTML string `json:"styledHtml"`
+	Status     string `json:"status"`
+}
+
 // UpdateGeneratedDocument updates an existing generated document
 func (h *AIDocumentHandler) UpdateGeneratedDocument(c *gin.Context) {
 	id := c.Param("id")
 
-	var req struct {
-		Name       string `json:"name"`
-		Content    string `json:"content"`
-		StyledHTML string `json:"styledHtml"`
-		Status     string `json:"status"`
-	}
+	var req updateGeneratedDocumentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -284,10 +321,13 @@ func (h *AIDocumentHandler) UpdateGeneratedDocument(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var document models.Document
 
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&document).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
+	if !checkIfMatch(c, &document) {
+		return
+	}
 
 	updates := map[string]interface{}{}
 	if req.Name != "" {
@@ -303,14 +343,19 @@ func (h *AIDocumentHandler) UpdateGeneratedDocument(c *gin.Context) {
 		updates["status"] = req.Status
 	}
 
-	if err := h.db.Model(&document).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&document).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &document, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update document"})
 		return
 	}
 
+	setETag(c, &document)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Document updated successfully",
+		"data":    document,
 	})
 }
 
@@ -319,11 +364,19 @@ func (h *AIDocumentHandler) DeleteGeneratedDocument(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Document{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-		}).Error; err != nil {
+	var document models.Document
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if !checkIfMatch(c, &document) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "document", id, &document); err != nil {
+		if staleWrite(c, h.db, &document, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete document"})
 		return
 	}
@@ -341,14 +394,17 @@ func (h *AIDocumentHandler) GetDocumentAnalyses(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var analyses []models.DocumentAnalysis
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&analyses).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analyses"})
+	base := requestDB(c, h.db).Model(&models.DocumentAnalysis{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, documentAnalysisQuery, &analyses)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch analyses")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    analyses,
+		"success":    true,
+		"data":       analyses,
+		"pagination": page,
 	})
 }
 
@@ -358,31 +414,35 @@ func (h *AIDocumentHandler) GetDocumentAnalysis(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var analysis models.DocumentAnalysis
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&analysis).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&analysis).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis not found"})
 		return
 	}
 
+	setETag(c, &analysis)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    analysis,
 	})
 }
 
+// analyzeDocumentRequest is the body accepted by AnalyzeDocument
+type analyzeDocumentRequest struct {
+	DocumentID   string `json:"documentId"` // Optional
+	AnalysisType string `json:"analysisType"`
+	DocumentName string `json:"documentName" binding:"required"`
+	FilePath     string `json:"filePath" binding:"required"`
+	FileType     string `json:"fileType" binding:"required"`
+	FileSize     int64  `json:"fileSize" binding:"required"`
+}
+
 // AnalyzeDocument analyzes a document using AI
 // AnalyzeDocument analyzes a document using AI
 func (h *AIDocumentHandler) AnalyzeDocument(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	userID := c.GetString("user_id")
 
-	var req struct {
-		DocumentID   string `json:"documentId"` // Optional
-		AnalysisType string `json:"analysisType"`
-		DocumentName string `json:"documentName" binding:"required"`
-		FilePath     string `json:"filePath" binding:"required"`
-		FileType     string `json:"fileType" binding:"required"`
-		FileSize     int64  `json:"fileSize" binding:"required"`
-	}
+	var req analyzeDocumentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -403,7 +463,7 @@ func (h *AIDocumentHandler) AnalyzeDocument(c *gin.Context) {
 			Status:       "uploaded",
 			CreatedBy:    userID,
 		}
-		if err := h.db.Create(&newDoc).Error; err != nil {
+		if err := requestDB(c, h.db).Create(&newDoc).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register document for analysis"})
 			return
 		}
@@ -454,14 +514,14 @@ func (h *AIDocumentHandler) AnalyzeDocument(c *gin.Context) {
 		AIModel:         "gemini-2.5-flash",
 	}
 
-	if err := h.db.Create(&analysis).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&analysis).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save analysis results"})
 		return
 	}
 
 	// Fetch the full analysis with ID to return
 	var fullAnalysis models.DocumentAnalysis
-	h.db.First(&fullAnalysis, "id = ?", analysis.ID)
+	requestDB(c, h.db).First(&fullAnalysis, "id = ?", analysis.ID)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -475,11 +535,19 @@ func (h *AIDocumentHandler) DeleteDocumentAnalysis(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.DocumentAnalysis{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-		}).Error; err != nil {
+	var analysis models.DocumentAnalysis
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&analysis).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis not found"})
+		return
+	}
+	if !checkIfMatch(c, &analysis) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "document_analysis", id, &analysis); err != nil {
+		if staleWrite(c, h.db, &analysis, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete analysis"})
 		return
 	}
@@ -28,7 +28,7 @@ func (h *AIHandler) GetAISettings(c *gin.Context) {
 	}
 
 	var settings models.AISettings
-	result := h.db.Where("tenant_id = ?", tenantID).First(&settings)
+	result := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings)
 
 	if result.Error != nil {
 		// Return default settings if not found
@@ -77,7 +77,7 @@ func (h *AIHandler) UpdateAISettings(c *gin.Context) {
 	}
 
 	var settings models.AISettings
-	result := h.db.Where("tenant_id = ?", tenantID).First(&settings)
+	result := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings)
 
 	if result.Error != nil {
 		// Create new settings
@@ -114,9 +114,9 @@ func (h *AIHandler) UpdateAISettings(c *gin.Context) {
 	}
 
 	if result.Error != nil {
-		h.db.Create(&settings)
+		requestDB(c, h.db.DB).Create(&settings)
 	} else {
-		h.db.Save(&settings)
+		requestDB(c, h.db.DB).Save(&settings)
 	}
 
 	// Hide API keys in response
@@ -159,7 +159,7 @@ func (h *AIHandler) TestAIConnection(c *gin.Context) {
 	}
 
 	var settings models.AISettings
-	result := h.db.Where("tenant_id = ?", tenantID).First(&settings)
+	result := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings)
 
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "AI settings not configured"})
@@ -230,7 +230,7 @@ func (h *AIHandler) AIChat(c *gin.Context) {
 
 	// Get AI settings
 	var settings models.AISettings
-	if err := h.db.Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "AI not configured. Please go to Settings > AI Configuration"})
 		return
 	}
@@ -290,7 +290,7 @@ func (h *AIHandler) AIChat(c *gin.Context) {
 
 	if err != nil {
 		usage.ErrorMessage = err.Error()
-		h.db.Create(&usage)
+		requestDB(c, h.db.DB).Create(&usage)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI request failed: " + err.Error()})
 		return
 	}
@@ -298,7 +298,7 @@ func (h *AIHandler) AIChat(c *gin.Context) {
 	usage.PromptTokens = aiResp.PromptTokens
 	usage.OutputTokens = aiResp.OutputTokens
 	usage.TotalTokens = aiResp.TotalTokens
-	h.db.Create(&usage)
+	requestDB(c, h.db.DB).Create(&usage)
 
 	response := gin.H{
 		"success":  true,
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var auditLogQuery = query.Spec{
+	Filters:     []string{"resource_type", "resource_id", "user_id", "action", "request_id", "created_at"},
+	Sorts:       []string{"created_at", "action", "resource_type"},
+	DefaultSort: "-created_at",
+}
+
+type AuditHandler struct {
+	db     *gorm.DB
+	signer *audit.Signer
+}
+
+func NewAuditHandler(db *gorm.DB, signer *audit.Signer) *AuditHandler {
+	return &AuditHandler{db: db, signer: signer}
+}
+
+// GetAuditLog lists the tenant's audit entries, newest first. Filter by
+// resource_type and resource_id for the history of a record, or by user_id
+// for what a user did.
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var entries []models.AuditLog
+
+	base := requestDB(c, h.db).Model(&models.AuditLog{}).Where("tenant_id = ?", c.GetString("tenant_id"))
+	page, err := query.List(c, base, auditLogQuery, &entries)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       entries,
+		"pagination": page,
+	})
+}
+
+// VerifyAuditLog walks the tenant's audit chain and reports the first broken
+// link. A broken chain is reported with 200 and valid false.
+func (h *AuditHandler) VerifyAuditLog(c *gin.Context) {
+	report, err := audit.Verify(c.Request.Context(), h.db, c.GetString("tenant_id"), h.signer.PublicKey())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+
+	message := "Audit log verified"
+	if !report.Valid {
+		message = "Audit log chain is broken"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    report,
+	})
+}
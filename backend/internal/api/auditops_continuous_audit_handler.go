@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,36 +22,35 @@ func (h *AuditOpsContinuousAuditHandler) GetControlTests(c *gin.Context) {
 	var tests []models.ControlTest
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&tests).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch control tests"})
+	base := requestDB(c, h.db).Model(&models.ControlTest{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, controlTestQuery, &tests)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch control tests")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    tests,
+		"success":    true,
+		"data":       tests,
+		"pagination": page,
 	})
 }
 
-func (h *AuditOpsContinuousAuditHandler) CreateControlTest(c *gin.Context) {
-	var req struct {
-		Name          string `json:"name" binding:"required"`
-		Description   string `json:"description" binding:"required"`
-		ControlID     string `json:"controlId"`
-		ControlName   string `json:"controlName" binding:"required"`
-		TestType      string `json:"testType" binding:"required"`
-		TestProcedure string `json:"testProcedure"`
-		Tester        string `json:"tester" binding:"required"`
-		TestDate      string `json:"testDate"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createControlTestRequest is the body accepted by CreateControlTest. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createControlTestRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Description   string `json:"description" binding:"required"`
+	ControlID     string `json:"controlId"`
+	ControlName   string `json:"controlName" binding:"required"`
+	TestType      string `json:"testType" binding:"required"`
+	TestProcedure string `json:"testProcedure"`
+	Tester        string `json:"tester" binding:"required"`
+	TestDate      string `json:"testDate"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateControlTest stores it
+func (req *createControlTestRequest) toModel(tenantID, userID string) models.ControlTest {
 	test := models.ControlTest{
 		TenantID:     tenantID,
 		ControlID:     req.ControlID,
@@ -68,7 +68,25 @@ func (h *AuditOpsContinuousAuditHandler) CreateControlTest(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&test).Error; err != nil {
+	return test
+}
+
+func (req *createControlTestRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *AuditOpsContinuousAuditHandler) CreateControlTest(c *gin.Context) {
+	var req createControlTestRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	test := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&test).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create control test"})
 		return
 	}
@@ -80,25 +98,45 @@ func (h *AuditOpsContinuousAuditHandler) CreateControlTest(c *gin.Context) {
 	})
 }
 
+// updateControlTestRequest is the body accepted by UpdateControlTest
+type updateControlTestRequest struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	ControlID     string `json:"controlId"`
+	ControlName   string `json:"controlName"`
+	TestType      string `json:"testType"`
+	TestProcedure string `json:"testProcedure"`
+	TestResult    string `json:"testResult"`
+	Tester        string `json:"tester"`
+	TestDate      string `json:"testDate"`
+	Findings      string `json:"findings"`
+	Recommendations string `json:"recommendations"`
+	Evidence      string `json:"evidence"`
+	FollowUpRequired bool   `json:"followUpRequired"`
+	FollowUpDate    string `json:"followUpDate"`
+}
+
+func (h *AuditOpsContinuousAuditHandler) GetControlTest(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var test models.ControlTest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&test).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Control test not found"})
+		return
+	}
+
+	setETag(c, &test)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    test,
+	})
+}
+
 func (h *AuditOpsContinuousAuditHandler) UpdateControlTest(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name          string `json:"name"`
-		Description   string `json:"description"`
-		ControlID     string `json:"controlId"`
-		ControlName   string `json:"controlName"`
-		TestType      string `json:"testType"`
-		TestProcedure string `json:"testProcedure"`
-		TestResult    string `json:"testResult"`
-		Tester        string `json:"tester"`
-		TestDate      string `json:"testDate"`
-		Findings      string `json:"findings"`
-		Recommendations string `json:"recommendations"`
-		Evidence      string `json:"evidence"`
-		FollowUpRequired bool   `json:"followUpRequired"`
-		FollowUpDate    string `json:"followUpDate"`
-	}
+	var req updateControlTestRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -108,10 +146,13 @@ func (h *AuditOpsContinuousAuditHandler) UpdateControlTest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var test models.ControlTest
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&test).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&test).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Control test not found"})
 		return
 	}
+	if !checkIfMatch(c, &test) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -162,27 +203,44 @@ func (h *AuditOpsContinuousAuditHandler) UpdateControlTest(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&test).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&test).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &test, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update control test"})
 		return
 	}
 
+	setETag(c, &test)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Control test updated successfully",
+		"data":    test,
 	})
 }
 
+func (h *AuditOpsContinuousAuditHandler) PatchControlTest(c *gin.Context) {
+	var test models.ControlTest
+	patchRecord(c, h.db, patchTarget{resource: "control_test"}, &test)
+}
+
 func (h *AuditOpsContinuousAuditHandler) DeleteControlTest(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.ControlTest{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var test models.ControlTest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&test).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Control test not found"})
+		return
+	}
+	if !checkIfMatch(c, &test) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "control_test", id, &test); err != nil {
+		if staleWrite(c, h.db, &test, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete control test"})
 		return
 	}
@@ -197,19 +255,38 @@ func (h *AuditOpsContinuousAuditHandler) RunControlTest(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.ControlTest{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
+	var test models.ControlTest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&test).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Control test not found"})
+		return
+	}
+	if !checkIfMatch(c, &test) {
+		return
+	}
+
+	var job *models.Job
+	if err := withJobs(requestDB(c, h.db), func(tx *gorm.DB) error {
+		if err := tx.Model(&test).Updates(map[string]interface{}{
 			"test_result": "in_progress",
 			"updated_at":  time.Now(),
 		}).Error; err != nil {
+			return err
+		}
+		var err error
+		job, err = runControlTestJob.Enqueue(tx, tenantID, recordPayload{ID: id})
+		return err
+	}); err != nil {
+		if staleWrite(c, h.db, &test, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to initiate control test"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"message": "Control test initiated successfully",
+		"data":    job,
 	})
 }
 
@@ -222,23 +299,23 @@ func (h *AuditOpsContinuousAuditHandler) GetControlTestStats(c *gin.Context) {
 	var failing int64
 	var pending int64
 
-	h.db.Model(&models.ControlTest{}).
+	requestDB(c, h.db).Model(&models.ControlTest{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.ControlTest{}).
+	requestDB(c, h.db).Model(&models.ControlTest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND test_result = ?", tenantID, false, "pass").
 		Count(&passing)
 
-	h.db.Model(&models.ControlTest{}).
+	requestDB(c, h.db).Model(&models.ControlTest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND test_result = ?", tenantID, false, "warning").
 		Count(&warning)
 
-	h.db.Model(&models.ControlTest{}).
+	requestDB(c, h.db).Model(&models.ControlTest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND test_result = ?", tenantID, false, "fail").
 		Count(&failing)
 
-	h.db.Model(&models.ControlTest{}).
+	requestDB(c, h.db).Model(&models.ControlTest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND test_result = ?", tenantID, false, "pending").
 		Count(&pending)
 
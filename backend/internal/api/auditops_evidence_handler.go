@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,37 +23,35 @@ func (h *AuditOpsEvidenceHandler) GetEvidence(c *gin.Context) {
 	var evidence []models.AuditEvidence
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&evidence).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch evidence"})
+	base := requestDB(c, h.db).Model(&models.AuditEvidence{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, evidenceQuery, &evidence)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch evidence")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    evidence,
+		"success":    true,
+		"data":       evidence,
+		"pagination": page,
 	})
 }
 
-func (h *AuditOpsEvidenceHandler) CreateEvidence(c *gin.Context) {
-	var req struct {
-		AuditID      string `json:"auditId" binding:"required"`
-		ControlID    string `json:"controlId" binding:"required"`
-		EvidenceType string `json:"evidenceType" binding:"required"`
-		Name         string `json:"name" binding:"required"`
-		Description  string `json:"description" binding:"required"`
-		FilePath     string `json:"filePath"`
-		FileSize     int64  `json:"fileSize"`
-		FileType     string `json:"fileType"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	tenantID := c.GetString("tenant_id")
-	collectedBy := c.GetString("user_id")
+// createEvidenceRequest is the body accepted by CreateEvidence. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createEvidenceRequest struct {
+	AuditID      string `json:"auditId" binding:"required"`
+	ControlID    string `json:"controlId" binding:"required"`
+	EvidenceType string `json:"evidenceType" binding:"required"`
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description" binding:"required"`
+	FilePath     string `json:"filePath"`
+	FileSize     int64  `json:"fileSize"`
+	FileType     string `json:"fileType"`
+}
 
+// toModel builds the record exactly as CreateEvidence stores it
+func (req *createEvidenceRequest) toModel(tenantID, userID string) models.AuditEvidence {
 	evidence := models.AuditEvidence{
 		TenantID:     tenantID,
 		AuditID:      req.AuditID,
@@ -63,11 +63,29 @@ func (h *AuditOpsEvidenceHandler) CreateEvidence(c *gin.Context) {
 		FileSize:     req.FileSize,
 		FileType:     req.FileType,
 		UploadDate:   time.Now(),
-		CollectedBy:  collectedBy,
+		CollectedBy:  userID,
 		Status:       "pending_review",
 	}
 
-	if err := h.db.Create(&evidence).Error; err != nil {
+	return evidence
+}
+
+func (req *createEvidenceRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *AuditOpsEvidenceHandler) CreateEvidence(c *gin.Context) {
+	var req createEvidenceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	evidence := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&evidence).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create evidence"})
 		return
 	}
@@ -79,21 +97,41 @@ func (h *AuditOpsEvidenceHandler) CreateEvidence(c *gin.Context) {
 	})
 }
 
+// updateEvidenceRequest is the body accepted by UpdateEvidence
+type updateEvidenceRequest struct {
+	AuditID      string `json:"auditId"`
+	ControlID    string `json:"controlId"`
+	EvidenceType string `json:"evidenceType"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	FilePath     string `json:"filePath"`
+	FileSize     int64  `json:"fileSize"`
+	FileType     string `json:"fileType"`
+	Status       string `json:"status"`
+	ReviewNotes  string `json:"reviewNotes"`
+}
+
+func (h *AuditOpsEvidenceHandler) GetEvidenceByID(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var evidence models.AuditEvidence
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evidence not found"})
+		return
+	}
+
+	setETag(c, &evidence)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    evidence,
+	})
+}
+
 func (h *AuditOpsEvidenceHandler) UpdateEvidence(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		AuditID      string `json:"auditId"`
-		ControlID    string `json:"controlId"`
-		EvidenceType string `json:"evidenceType"`
-		Name         string `json:"name"`
-		Description  string `json:"description"`
-		FilePath     string `json:"filePath"`
-		FileSize     int64  `json:"fileSize"`
-		FileType     string `json:"fileType"`
-		Status       string `json:"status"`
-		ReviewNotes  string `json:"reviewNotes"`
-	}
+	var req updateEvidenceRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -103,10 +141,13 @@ func (h *AuditOpsEvidenceHandler) UpdateEvidence(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var evidence models.AuditEvidence
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Evidence not found"})
 		return
 	}
+	if !checkIfMatch(c, &evidence) {
+		return
+	}
 
 	updates := map[string]interface{}{}
 	if req.AuditID != "" {
@@ -140,26 +181,46 @@ func (h *AuditOpsEvidenceHandler) UpdateEvidence(c *gin.Context) {
 		updates["review_notes"] = req.ReviewNotes
 	}
 
-	if err := h.db.Model(&evidence).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&evidence).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &evidence, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update evidence"})
 		return
 	}
 
+	setETag(c, &evidence)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Evidence updated successfully",
+		"data":    evidence,
 	})
 }
 
+func (h *AuditOpsEvidenceHandler) PatchEvidence(c *gin.Context) {
+	var evidence models.AuditEvidence
+	patchRecord(c, h.db, patchTarget{resource: "evidence"}, &evidence)
+}
+
 func (h *AuditOpsEvidenceHandler) ApproveEvidence(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.AuditEvidence{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status": "approved",
-		}).Error; err != nil {
+	var evidence models.AuditEvidence
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evidence not found"})
+		return
+	}
+	if !checkIfMatch(c, &evidence) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &evidence, map[string]interface{}{
+		"status": "approved",
+	}, events.EvidenceApproved, "evidence", id); err != nil {
+		if staleWrite(c, h.db, &evidence, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve evidence"})
 		return
 	}
@@ -174,11 +235,21 @@ func (h *AuditOpsEvidenceHandler) RejectEvidence(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.AuditEvidence{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status": "rejected",
-		}).Error; err != nil {
+	var evidence models.AuditEvidence
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evidence not found"})
+		return
+	}
+	if !checkIfMatch(c, &evidence) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &evidence, map[string]interface{}{
+		"status": "rejected",
+	}, events.EvidenceRejected, "evidence", id); err != nil {
+		if staleWrite(c, h.db, &evidence, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject evidence"})
 		return
 	}
@@ -193,11 +264,19 @@ func (h *AuditOpsEvidenceHandler) DeleteEvidence(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.AuditEvidence{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-		}).Error; err != nil {
+	var evidence models.AuditEvidence
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&evidence).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Evidence not found"})
+		return
+	}
+	if !checkIfMatch(c, &evidence) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "evidence", id, &evidence); err != nil {
+		if staleWrite(c, h.db, &evidence, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete evidence"})
 		return
 	}
@@ -216,19 +295,19 @@ func (h *AuditOpsEvidenceHandler) GetEvidenceStats(c *gin.Context) {
 	var pending int64
 	var rejected int64
 
-	h.db.Model(&models.AuditEvidence{}).
+	requestDB(c, h.db).Model(&models.AuditEvidence{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.AuditEvidence{}).
+	requestDB(c, h.db).Model(&models.AuditEvidence{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "approved").
 		Count(&approved)
 
-	h.db.Model(&models.AuditEvidence{}).
+	requestDB(c, h.db).Model(&models.AuditEvidence{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "pending_review").
 		Count(&pending)
 
-	h.db.Model(&models.AuditEvidence{}).
+	requestDB(c, h.db).Model(&models.AuditEvidence{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "rejected").
 		Count(&rejected)
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,40 +22,39 @@ func (h *AuditOpsGovernanceHandler) GetKRIs(c *gin.Context) {
 	var governance []models.Governance
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&governance).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch governance records"})
+	base := requestDB(c, h.db).Model(&models.Governance{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, governanceQuery, &governance)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch governance records")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    governance,
+		"success":    true,
+		"data":       governance,
+		"pagination": page,
 	})
 }
 
-func (h *AuditOpsGovernanceHandler) CreateKRI(c *gin.Context) {
-	var req struct {
-		Name                   string `json:"name" binding:"required"`
-		Description            string `json:"description" binding:"required"`
-		GovernanceType        string `json:"governanceType" binding:"required"`
-		Framework             string `json:"framework"`
-		CommitteeName         string `json:"committeeName"`
-		MeetingFrequency      string `json:"meetingFrequency"`
-		Charter               string `json:"charter"`
-		RolesResponsibilities  string `json:"rolesResponsibilities"`
-		OversightAreas        string `json:"oversightAreas"`
-		ComplianceRequirements string `json:"complianceRequirements"`
-		LastMeetingDate       string `json:"lastMeetingDate"`
-		NextMeetingDate       string `json:"nextMeetingDate"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createKRIRequest is the body accepted by CreateKRI. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createKRIRequest struct {
+	Name                   string `json:"name" binding:"required"`
+	Description            string `json:"description" binding:"required"`
+	GovernanceType        string `json:"governanceType" binding:"required"`
+	Framework             string `json:"framework"`
+	CommitteeName         string `json:"committeeName"`
+	MeetingFrequency      string `json:"meetingFrequency"`
+	Charter               string `json:"charter"`
+	RolesResponsibilities  string `json:"rolesResponsibilities"`
+	OversightAreas        string `json:"oversightAreas"`
+	ComplianceRequirements string `json:"complianceRequirements"`
+	LastMeetingDate       string `json:"lastMeetingDate"`
+	NextMeetingDate       string `json:"nextMeetingDate"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateKRI stores it
+func (req *createKRIRequest) toModel(tenantID, userID string) models.Governance {
 	governance := models.Governance{
 		TenantID:              tenantID,
 		Name:                  req.Name,
@@ -82,7 +82,25 @@ func (h *AuditOpsGovernanceHandler) CreateKRI(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&governance).Error; err != nil {
+	return governance
+}
+
+func (req *createKRIRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *AuditOpsGovernanceHandler) CreateKRI(c *gin.Context) {
+	var req createKRIRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	governance := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&governance).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create governance record"})
 		return
 	}
@@ -94,24 +112,44 @@ func (h *AuditOpsGovernanceHandler) CreateKRI(c *gin.Context) {
 	})
 }
 
+// updateKRIRequest is the body accepted by UpdateKRI
+type updateKRIRequest struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	GovernanceType        string `json:"governanceType"`
+	Framework             string `json:"framework"`
+	CommitteeName         string `json:"committeeName"`
+	MeetingFrequency      string `json:"meetingFrequency"`
+	Charter               string `json:"charter"`
+	RolesResponsibilities  string `json:"rolesResponsibilities"`
+	OversightAreas        string `json:"oversightAreas"`
+	ComplianceRequirements string `json:"complianceRequirements"`
+	Status                string `json:"status"`
+	LastMeetingDate       string `json:"lastMeetingDate"`
+	NextMeetingDate       string `json:"nextMeetingDate"`
+}
+
+func (h *AuditOpsGovernanceHandler) GetKRI(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var governance models.Governance
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&governance).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Governance record not found"})
+		return
+	}
+
+	setETag(c, &governance)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    governance,
+	})
+}
+
 func (h *AuditOpsGovernanceHandler) UpdateKRI(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name                   string `json:"name"`
-		Description            string `json:"description"`
-		GovernanceType        string `json:"governanceType"`
-		Framework             string `json:"framework"`
-		CommitteeName         string `json:"committeeName"`
-		MeetingFrequency      string `json:"meetingFrequency"`
-		Charter               string `json:"charter"`
-		RolesResponsibilities  string `json:"rolesResponsibilities"`
-		OversightAreas        string `json:"oversightAreas"`
-		ComplianceRequirements string `json:"complianceRequirements"`
-		Status                string `json:"status"`
-		LastMeetingDate       string `json:"lastMeetingDate"`
-		NextMeetingDate       string `json:"nextMeetingDate"`
-	}
+	var req updateKRIRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -121,10 +159,13 @@ func (h *AuditOpsGovernanceHandler) UpdateKRI(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var governance models.Governance
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&governance).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&governance).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Governance record not found"})
 		return
 	}
+	if !checkIfMatch(c, &governance) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -174,27 +215,44 @@ func (h *AuditOpsGovernanceHandler) UpdateKRI(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&governance).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&governance).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &governance, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update governance record"})
 		return
 	}
 
+	setETag(c, &governance)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Governance record updated successfully",
+		"data":    governance,
 	})
 }
 
+func (h *AuditOpsGovernanceHandler) PatchKRI(c *gin.Context) {
+	var governance models.Governance
+	patchRecord(c, h.db, patchTarget{resource: "governance"}, &governance)
+}
+
 func (h *AuditOpsGovernanceHandler) DeleteKRI(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Governance{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var governance models.Governance
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&governance).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Governance record not found"})
+		return
+	}
+	if !checkIfMatch(c, &governance) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "governance", id, &governance); err != nil {
+		if staleWrite(c, h.db, &governance, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete governance record"})
 		return
 	}
@@ -211,11 +269,11 @@ func (h *AuditOpsGovernanceHandler) GetKRIStats(c *gin.Context) {
 	var total int64
 	var active int64
 
-	h.db.Model(&models.Governance{}).
+	requestDB(c, h.db).Model(&models.Governance{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.Governance{}).
+	requestDB(c, h.db).Model(&models.Governance{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "active").
 		Count(&active)
 
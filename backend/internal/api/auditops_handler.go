@@ -18,7 +18,7 @@ func NewAuditOpsHandler(db *db.Database) *AuditOpsHandler {
 
 func (h *AuditOpsHandler) GetAuditPlans(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var auditPlans []models.AuditPlan
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&auditPlans).Error; err != nil {
@@ -31,7 +31,7 @@ func (h *AuditOpsHandler) GetAuditPlans(c *gin.Context) {
 
 func (h *AuditOpsHandler) CreateAuditPlan(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var auditPlan models.AuditPlan
 	if err := c.ShouldBindJSON(&auditPlan); err != nil {
@@ -51,7 +51,7 @@ func (h *AuditOpsHandler) CreateAuditPlan(c *gin.Context) {
 
 func (h *AuditOpsHandler) UpdateAuditPlan(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var auditPlan models.AuditPlan
@@ -76,7 +76,7 @@ func (h *AuditOpsHandler) UpdateAuditPlan(c *gin.Context) {
 
 func (h *AuditOpsHandler) DeleteAuditPlan(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var auditPlan models.AuditPlan
@@ -85,10 +85,7 @@ func (h *AuditOpsHandler) DeleteAuditPlan(c *gin.Context) {
 		return
 	}
 
-	// Soft delete
-	auditPlan.IsDeleted = true
-	auditPlan.Status = "deleted"
-	if err := tenantDB.Save(&auditPlan).Error; err != nil {
+	if err := softDelete(tenantDB, c, "audit_plan", id, &auditPlan); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete audit plan"})
 		return
 	}
@@ -99,7 +96,7 @@ func (h *AuditOpsHandler) DeleteAuditPlan(c *gin.Context) {
 // Audit Evidence CRUD
 func (h *AuditOpsHandler) GetAuditEvidence(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var evidence []models.AuditEvidence
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&evidence).Error; err != nil {
@@ -112,7 +109,7 @@ func (h *AuditOpsHandler) GetAuditEvidence(c *gin.Context) {
 
 func (h *AuditOpsHandler) CreateAuditEvidence(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var evidence models.AuditEvidence
 	if err := c.ShouldBindJSON(&evidence); err != nil {
@@ -132,7 +129,7 @@ func (h *AuditOpsHandler) CreateAuditEvidence(c *gin.Context) {
 
 func (h *AuditOpsHandler) UpdateAuditEvidence(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var evidence models.AuditEvidence
@@ -157,7 +154,7 @@ func (h *AuditOpsHandler) UpdateAuditEvidence(c *gin.Context) {
 
 func (h *AuditOpsHandler) DeleteAuditEvidence(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var evidence models.AuditEvidence
@@ -166,8 +163,7 @@ func (h *AuditOpsHandler) DeleteAuditEvidence(c *gin.Context) {
 		return
 	}
 
-	evidence.IsDeleted = true
-	if err := tenantDB.Save(&evidence).Error; err != nil {
+	if err := softDelete(tenantDB, c, "evidence", id, &evidence); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete audit evidence"})
 		return
 	}
@@ -178,7 +174,7 @@ func (h *AuditOpsHandler) DeleteAuditEvidence(c *gin.Context) {
 // Control Test CRUD
 func (h *AuditOpsHandler) GetControlTests(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var tests []models.ControlTest
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&tests).Error; err != nil {
@@ -191,7 +187,7 @@ func (h *AuditOpsHandler) GetControlTests(c *gin.Context) {
 
 func (h *AuditOpsHandler) CreateControlTest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var test models.ControlTest
 	if err := c.ShouldBindJSON(&test); err != nil {
@@ -211,7 +207,7 @@ func (h *AuditOpsHandler) CreateControlTest(c *gin.Context) {
 
 func (h *AuditOpsHandler) UpdateControlTest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var test models.ControlTest
@@ -236,7 +232,7 @@ func (h *AuditOpsHandler) UpdateControlTest(c *gin.Context) {
 
 func (h *AuditOpsHandler) DeleteControlTest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var test models.ControlTest
@@ -245,8 +241,7 @@ func (h *AuditOpsHandler) DeleteControlTest(c *gin.Context) {
 		return
 	}
 
-	test.IsDeleted = true
-	if err := tenantDB.Save(&test).Error; err != nil {
+	if err := softDelete(tenantDB, c, "control_test", id, &test); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete control test"})
 		return
 	}
@@ -257,7 +252,7 @@ func (h *AuditOpsHandler) DeleteControlTest(c *gin.Context) {
 // Audit Report CRUD
 func (h *AuditOpsHandler) GetAuditReports(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var reports []models.AuditReport
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&reports).Error; err != nil {
@@ -270,7 +265,7 @@ func (h *AuditOpsHandler) GetAuditReports(c *gin.Context) {
 
 func (h *AuditOpsHandler) CreateAuditReport(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var report models.AuditReport
 	if err := c.ShouldBindJSON(&report); err != nil {
@@ -290,7 +285,7 @@ func (h *AuditOpsHandler) CreateAuditReport(c *gin.Context) {
 
 func (h *AuditOpsHandler) UpdateAuditReport(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var report models.AuditReport
@@ -315,7 +310,7 @@ func (h *AuditOpsHandler) UpdateAuditReport(c *gin.Context) {
 
 func (h *AuditOpsHandler) DeleteAuditReport(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var report models.AuditReport
@@ -324,8 +319,7 @@ func (h *AuditOpsHandler) DeleteAuditReport(c *gin.Context) {
 		return
 	}
 
-	report.IsDeleted = true
-	if err := tenantDB.Save(&report).Error; err != nil {
+	if err := softDelete(tenantDB, c, "audit_report", id, &report); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete audit report"})
 		return
 	}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,40 +22,39 @@ func (h *AuditOpsInternalAuditHandler) GetInternalAudits(c *gin.Context) {
 	var audits []models.AuditPlan
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&audits).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit plans"})
+	base := requestDB(c, h.db).Model(&models.AuditPlan{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, auditPlanQuery, &audits)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch audit plans")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    audits,
+		"success":    true,
+		"data":       audits,
+		"pagination": page,
 	})
 }
 
-func (h *AuditOpsInternalAuditHandler) CreateInternalAudit(c *gin.Context) {
-	var req struct {
-		Name        string  `json:"name" binding:"required"`
-		Description string  `json:"description" binding:"required"`
-		AuditType   string  `json:"auditType" binding:"required"`
-		Framework   string  `json:"framework"`
-		Scope       string  `json:"scope"`
-		Objectives  string  `json:"objectives"`
-		Auditor     string  `json:"auditor" binding:"required"`
-		StartDate   string  `json:"startDate"`
-		EndDate     string  `json:"endDate"`
-		Budget      float64 `json:"budget"`
-		Resources   string  `json:"resources"`
-		RiskLevel   string  `json:"riskLevel"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createInternalAuditRequest is the body accepted by CreateInternalAudit. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createInternalAuditRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description" binding:"required"`
+	AuditType   string  `json:"auditType" binding:"required"`
+	Framework   string  `json:"framework"`
+	Scope       string  `json:"scope"`
+	Objectives  string  `json:"objectives"`
+	Auditor     string  `json:"auditor" binding:"required"`
+	StartDate   string  `json:"startDate"`
+	EndDate     string  `json:"endDate"`
+	Budget      float64 `json:"budget"`
+	Resources   string  `json:"resources"`
+	RiskLevel   string  `json:"riskLevel"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateInternalAudit stores it
+func (req *createInternalAuditRequest) toModel(tenantID, userID string) models.AuditPlan {
 	audit := models.AuditPlan{
 		TenantID:   tenantID,
 		Name:       req.Name,
@@ -82,7 +82,25 @@ func (h *AuditOpsInternalAuditHandler) CreateInternalAudit(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&audit).Error; err != nil {
+	return audit
+}
+
+func (req *createInternalAuditRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *AuditOpsInternalAuditHandler) CreateInternalAudit(c *gin.Context) {
+	var req createInternalAuditRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	audit := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&audit).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create audit plan"})
 		return
 	}
@@ -94,24 +112,44 @@ func (h *AuditOpsInternalAuditHandler) CreateInternalAudit(c *gin.Context) {
 	})
 }
 
+// updateInternalAuditRequest is the body accepted by UpdateInternalAudit
+type updateInternalAuditRequest struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	AuditType   string  `json:"auditType"`
+	Framework   string  `json:"framework"`
+	Scope       string  `json:"scope"`
+	Objectives  string  `json:"objectives"`
+	Status      string  `json:"status"`
+	Auditor     string  `json:"auditor"`
+	StartDate   string  `json:"startDate"`
+	EndDate     string  `json:"endDate"`
+	Budget      float64 `json:"budget"`
+	Resources   string  `json:"resources"`
+	RiskLevel   string  `json:"riskLevel"`
+}
+
+func (h *AuditOpsInternalAuditHandler) GetInternalAudit(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var audit models.AuditPlan
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&audit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit plan not found"})
+		return
+	}
+
+	setETag(c, &audit)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    audit,
+	})
+}
+
 func (h *AuditOpsInternalAuditHandler) UpdateInternalAudit(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name        string  `json:"name"`
-		Description string  `json:"description"`
-		AuditType   string  `json:"auditType"`
-		Framework   string  `json:"framework"`
-		Scope       string  `json:"scope"`
-		Objectives  string  `json:"objectives"`
-		Status      string  `json:"status"`
-		Auditor     string  `json:"auditor"`
-		StartDate   string  `json:"startDate"`
-		EndDate     string  `json:"endDate"`
-		Budget      float64 `json:"budget"`
-		Resources   string  `json:"resources"`
-		RiskLevel   string  `json:"riskLevel"`
-	}
+	var req updateInternalAuditRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -121,10 +159,13 @@ func (h *AuditOpsInternalAuditHandler) UpdateInternalAudit(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var audit models.AuditPlan
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&audit).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&audit).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Audit plan not found"})
 		return
 	}
+	if !checkIfMatch(c, &audit) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -174,27 +215,44 @@ func (h *AuditOpsInternalAuditHandler) UpdateInternalAudit(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&audit).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&audit).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &audit, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update audit plan"})
 		return
 	}
 
+	setETag(c, &audit)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Internal audit updated successfully",
+		"data":    audit,
 	})
 }
 
+func (h *AuditOpsInternalAuditHandler) PatchInternalAudit(c *gin.Context) {
+	var audit models.AuditPlan
+	patchRecord(c, h.db, patchTarget{resource: "audit_plan"}, &audit)
+}
+
 func (h *AuditOpsInternalAuditHandler) DeleteInternalAudit(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.AuditPlan{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var audit models.AuditPlan
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&audit).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit plan not found"})
+		return
+	}
+	if !checkIfMatch(c, &audit) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "audit_plan", id, &audit); err != nil {
+		if staleWrite(c, h.db, &audit, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete audit plan"})
 		return
 	}
@@ -213,19 +271,19 @@ func (h *AuditOpsInternalAuditHandler) GetInternalAuditStats(c *gin.Context) {
 	var inProgress int64
 	var completed int64
 
-	h.db.Model(&models.AuditPlan{}).
+	requestDB(c, h.db).Model(&models.AuditPlan{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.AuditPlan{}).
+	requestDB(c, h.db).Model(&models.AuditPlan{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "planned").
 		Count(&scheduled)
 
-	h.db.Model(&models.AuditPlan{}).
+	requestDB(c, h.db).Model(&models.AuditPlan{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_progress").
 		Count(&inProgress)
 
-	h.db.Model(&models.AuditPlan{}).
+	requestDB(c, h.db).Model(&models.AuditPlan{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "completed").
 		Count(&completed)
 
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,42 +22,41 @@ func (h *AuditOpsReportingHandler) GetReports(c *gin.Context) {
 	var reports []models.AuditReport
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&reports).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit reports"})
+	base := requestDB(c, h.db).Model(&models.AuditReport{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, auditReportQuery, &reports)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch audit reports")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    reports,
+		"success":    true,
+		"data":       reports,
+		"pagination": page,
 	})
 }
 
-func (h *AuditOpsReportingHandler) CreateReport(c *gin.Context) {
-	var req struct {
-		Name           string  `json:"name" binding:"required"`
-		Description    string  `json:"description" binding:"required"`
-		ReportType     string  `json:"reportType" binding:"required"`
-		Framework      string  `json:"framework"`
-		PeriodStart    string  `json:"periodStart"`
-		PeriodEnd      string  `json:"periodEnd"`
-		ExecutiveSummary string `json:"executiveSummary"`
-		Findings       string  `json:"findings"`
-		Recommendations string `json:"recommendations"`
-		OverallRating  string  `json:"overallRating"`
-		PreparedBy     string  `json:"preparedBy" binding:"required"`
-		ReviewedBy      string  `json:"reviewedBy"`
-		ApprovedBy      string  `json:"approvedBy"`
-		DistributionList string `json:"distributionList"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createReportRequest is the body accepted by CreateReport. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createReportRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Description    string  `json:"description" binding:"required"`
+	ReportType     string  `json:"reportType" binding:"required"`
+	Framework      string  `json:"framework"`
+	PeriodStart    string  `json:"periodStart"`
+	PeriodEnd      string  `json:"periodEnd"`
+	ExecutiveSummary string `json:"executiveSummary"`
+	Findings       string  `json:"findings"`
+	Recommendations string `json:"recommendations"`
+	OverallRating  string  `json:"overallRating"`
+	PreparedBy     string  `json:"preparedBy" binding:"required"`
+	ReviewedBy      string  `json:"reviewedBy"`
+	ApprovedBy      string  `json:"approvedBy"`
+	DistributionList string `json:"distributionList"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateReport stores it
+func (req *createReportRequest) toModel(tenantID, userID string) models.AuditReport {
 	report := models.AuditReport{
 		TenantID:        tenantID,
 		ReportName:       req.Name,
@@ -86,7 +86,25 @@ func (h *AuditOpsReportingHandler) CreateReport(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&report).Error; err != nil {
+	return report
+}
+
+func (req *createReportRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *AuditOpsReportingHandler) CreateReport(c *gin.Context) {
+	var req createReportRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&report).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create audit report"})
 		return
 	}
@@ -98,27 +116,47 @@ func (h *AuditOpsReportingHandler) CreateReport(c *gin.Context) {
 	})
 }
 
+// updateReportRequest is the body accepted by UpdateReport
+type updateReportRequest struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	ReportType     string  `json:"reportType"`
+	Framework      string  `json:"framework"`
+	PeriodStart    string  `json:"periodStart"`
+	PeriodEnd      string  `json:"periodEnd"`
+	ExecutiveSummary string `json:"executiveSummary"`
+	Findings       string  `json:"findings"`
+	Recommendations string `json:"recommendations"`
+	OverallRating  string  `json:"overallRating"`
+	Status         string  `json:"status"`
+	PreparedBy     string  `json:"preparedBy"`
+	ReviewedBy      string  `json:"reviewedBy"`
+	ApprovedBy      string  `json:"approvedBy"`
+	ReportDate     string  `json:"reportDate"`
+	DistributionList string `json:"distributionList"`
+}
+
+func (h *AuditOpsReportingHandler) GetReport(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var report models.AuditReport
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&report).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit report not found"})
+		return
+	}
+
+	setETag(c, &report)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
 func (h *AuditOpsReportingHandler) UpdateReport(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name           string  `json:"name"`
-		Description    string  `json:"description"`
-		ReportType     string  `json:"reportType"`
-		Framework      string  `json:"framework"`
-		PeriodStart    string  `json:"periodStart"`
-		PeriodEnd      string  `json:"periodEnd"`
-		ExecutiveSummary string `json:"executiveSummary"`
-		Findings       string  `json:"findings"`
-		Recommendations string `json:"recommendations"`
-		OverallRating  string  `json:"overallRating"`
-		Status         string  `json:"status"`
-		PreparedBy     string  `json:"preparedBy"`
-		ReviewedBy      string  `json:"reviewedBy"`
-		ApprovedBy      string  `json:"approvedBy"`
-		ReportDate     string  `json:"reportDate"`
-		DistributionList string `json:"distributionList"`
-	}
+	var req updateReportRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -128,10 +166,13 @@ func (h *AuditOpsReportingHandler) UpdateReport(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var report models.AuditReport
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&report).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&report).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Audit report not found"})
 		return
 	}
+	if !checkIfMatch(c, &report) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -192,27 +233,44 @@ func (h *AuditOpsReportingHandler) UpdateReport(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&report).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&report).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &report, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update audit report"})
 		return
 	}
 
+	setETag(c, &report)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Audit report updated successfully",
+		"data":    report,
 	})
 }
 
+func (h *AuditOpsReportingHandler) PatchReport(c *gin.Context) {
+	var report models.AuditReport
+	patchRecord(c, h.db, patchTarget{resource: "audit_report"}, &report)
+}
+
 func (h *AuditOpsReportingHandler) DeleteReport(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.AuditReport{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var report models.AuditReport
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&report).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit report not found"})
+		return
+	}
+	if !checkIfMatch(c, &report) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "audit_report", id, &report); err != nil {
+		if staleWrite(c, h.db, &report, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete audit report"})
 		return
 	}
@@ -227,22 +285,38 @@ func (h *AuditOpsReportingHandler) GenerateReport(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	reportDate := time.Now()
+	var report models.AuditReport
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&report).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit report not found"})
+		return
+	}
+	if !checkIfMatch(c, &report) {
+		return
+	}
 
-	if err := h.db.Model(&models.AuditReport{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":      "completed",
-			"report_date": &reportDate,
-			"updated_at":  time.Now(),
+	var job *models.Job
+	if err := withJobs(requestDB(c, h.db), func(tx *gorm.DB) error {
+		if err := tx.Model(&report).Updates(map[string]interface{}{
+			"status":     "in_progress",
+			"updated_at": time.Now(),
 		}).Error; err != nil {
+			return err
+		}
+		var err error
+		job, err = generateReportJob.Enqueue(tx, tenantID, recordPayload{ID: id})
+		return err
+	}); err != nil {
+		if staleWrite(c, h.db, &report, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusAccepted, gin.H{
 		"success": true,
 		"message": "Report generation initiated successfully",
+		"data":    job,
 	})
 }
 
@@ -254,19 +328,19 @@ func (h *AuditOpsReportingHandler) GetReportStats(c *gin.Context) {
 	var inProgress int64
 	var draft int64
 
-	h.db.Model(&models.AuditReport{}).
+	requestDB(c, h.db).Model(&models.AuditReport{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.AuditReport{}).
+	requestDB(c, h.db).Model(&models.AuditReport{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "completed").
 		Count(&completed)
 
-	h.db.Model(&models.AuditReport{}).
+	requestDB(c, h.db).Model(&models.AuditReport{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_progress").
 		Count(&inProgress)
 
-	h.db.Model(&models.AuditReport{}).
+	requestDB(c, h.db).Model(&models.AuditReport{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "draft").
 		Count(&draft)
 
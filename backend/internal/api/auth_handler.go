@@ -32,11 +32,14 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+// loginRequest is the body accepted by Login
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
 func (h *AuthHandler) Login(c *gin.Context) {
-	var loginRequest struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
-	}
+	var loginRequest loginRequest
 
 	if err := c.ShouldBindJSON(&loginRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -45,7 +48,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// Find user by email
 	var user models.User
-	if err := h.db.Where("email = ?", loginRequest.Email).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("email = ?", loginRequest.Email).First(&user).Error; err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
@@ -72,7 +75,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	// Check if tenant is active (skip for super admin)
 	if !user.IsSuperAdmin && tenantIDForToken != "" {
 		var tenant models.Tenant
-		if err := h.db.Where("id = ?", tenantIDForToken).First(&tenant).Error; err != nil {
+		if err := requestDB(c, h.db.DB).Where("id = ?", tenantIDForToken).First(&tenant).Error; err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Tenant not found"})
 			return
 		}
@@ -93,7 +96,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 		// Check subscription expiry
 		var subscription models.Subscription
-		if err := h.db.Where("tenant_id = ? AND deleted_at IS NULL", tenantIDForToken).First(&subscription).Error; err == nil {
+		if err := requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", tenantIDForToken).First(&subscription).Error; err == nil {
 			// Check if subscription has expired
 			if subscription.EndDate != nil && subscription.EndDate.Before(time.Now()) {
 				c.JSON(http.StatusUnauthorized, gin.H{"error": "Your subscription has expired. Please contact platform administrator to renew."})
@@ -107,7 +110,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Update last login using raw SQL to avoid JSONB issues
-	h.db.Exec("UPDATE users SET last_login = NOW() WHERE id = ?", user.ID)
+	requestDB(c, h.db.DB).Exec("UPDATE users SET last_login = NOW() WHERE id = ?", user.ID)
 
 	// Get config for JWT secret
 	cfg, err := config.Load()
@@ -148,15 +151,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// registerRequest is the body accepted by Register
+type registerRequest struct {
+	Email         string `json:"email" binding:"required,email"`
+	Password      string `json:"password" binding:"required,min=6"`
+	FirstName     string `json:"firstName" binding:"required"`
+	LastName      string `json:"lastName" binding:"required"`
+	CompanyName   string `json:"companyName" binding:"required"` // Company/Organization name
+	CompanyDomain string `json:"companyDomain"`                  // Optional domain
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
-	var registerRequest struct {
-		Email         string `json:"email" binding:"required,email"`
-		Password      string `json:"password" binding:"required,min=6"`
-		FirstName     string `json:"firstName" binding:"required"`
-		LastName      string `json:"lastName" binding:"required"`
-		CompanyName   string `json:"companyName" binding:"required"` // Company/Organization name
-		CompanyDomain string `json:"companyDomain"`                  // Optional domain
-	}
+	var registerRequest registerRequest
 
 	if err := c.ShouldBindJSON(&registerRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -165,7 +171,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// Check if user already exists
 	var existingUser models.User
-	if err := h.db.First(&existingUser, "email = ?", registerRequest.Email).Error; err == nil {
+	if err := requestDB(c, h.db.DB).First(&existingUser, "email = ?", registerRequest.Email).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already registered"})
 		return
 	}
@@ -178,7 +184,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	var existingTenant models.Tenant
-	if err := h.db.First(&existingTenant, "domain = ? AND deleted_at IS NULL", domain).Error; err == nil {
+	if err := requestDB(c, h.db.DB).First(&existingTenant, "domain = ? AND deleted_at IS NULL", domain).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "An organization with this domain already exists"})
 		return
 	}
@@ -198,7 +204,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Status:      "pending", // PENDING - requires Super Admin activation
 	}
 
-	if err := h.db.Create(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&tenant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create organization"})
 		return
 	}
@@ -214,13 +220,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Price:        0, // Will be set by Super Admin
 		Currency:     "IDR",
 	}
-	h.db.Create(&subscription)
+	requestDB(c, h.db.DB).Create(&subscription)
 
 	// Create tenant schema with all GRC tables
 	if err := h.db.CreateTenantSchema(tenant.ID); err != nil {
 		// Rollback - delete tenant and subscription
-		h.db.Delete(&subscription)
-		h.db.Delete(&tenant)
+		requestDB(c, h.db.DB).Delete(&subscription)
+		requestDB(c, h.db.DB).Delete(&tenant)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to setup organization: " + err.Error()})
 		return
 	}
@@ -237,7 +243,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		IsSuperAdmin: false,
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user account"})
 		return
 	}
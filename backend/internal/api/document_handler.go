@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -13,8 +14,10 @@ import (
 	"github.com/cyber/backend/internal/ai"
 	"github.com/cyber/backend/internal/db"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/cyber/backend/internal/storage"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type DocumentHandler struct {
@@ -36,6 +39,11 @@ func NewDocumentHandler(database *db.Database) *DocumentHandler {
 	}
 }
 
+// Storage returns the storage documents are written to
+func (h *DocumentHandler) Storage() *storage.StorageService {
+	return h.storage
+}
+
 // AnalyzeDocument analyzes uploaded document with AI and saves analysis to database
 func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
@@ -57,7 +65,7 @@ func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 	}
 
 	// Store original document in storage
-	storageURL, storagePath, err := h.storage.StoreFile(header, tenantID, "documents")
+	storageURL, storagePath, err := h.storage.StoreFile(c.Request.Context(), header, tenantID, "documents")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document"})
 		return
@@ -78,7 +86,7 @@ func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 		CreatedBy:     userID,
 	}
 
-	if err := h.db.Create(&document).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&document).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
 		return
 	}
@@ -91,14 +99,64 @@ func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 
 	// Get AI settings
 	var settings models.AISettings
-	if err := h.db.Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "AI not configured"})
 		return
 	}
 
+	// Large documents are analyzed in the background on request
+	if c.PostForm("async") == "true" {
+		var job *models.Job
+		if err := withJobs(requestDB(c, h.db.DB), func(tx *gorm.DB) error {
+			var err error
+			job, err = analyzeDocumentJob.Enqueue(tx, tenantID, analyzeDocumentPayload{
+				DocumentID:   document.ID,
+				AnalysisType: analysisType,
+			})
+			return err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue analysis"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"success":      true,
+			"filename":     header.Filename,
+			"document_id":  document.ID,
+			"job_id":       job.ID,
+			"storage_url":  storageURL,
+			"analysisType": analysisType,
+		})
+		return
+	}
+
+	documentAnalysis, err := h.analyze(c.Request.Context(), &settings, &document, analysisType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI analysis failed: " + err.Error()})
+		return
+	}
+
+	if err := requestDB(c, h.db.DB).Create(documentAnalysis).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save analysis"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"filename":     header.Filename,
+		"document_id":  document.ID,
+		"analysis_id":  documentAnalysis.ID,
+		"storage_url":  storageURL,
+		"analysis":     documentAnalysis,
+		"analysisType": analysisType,
+	})
+}
+
+// analyze has the AI analyze a stored document. The analysis is returned
+// unsaved.
+func (h *DocumentHandler) analyze(ctx context.Context, settings *models.AISettings, document *models.Document, analysisType string) (*models.DocumentAnalysis, error) {
 	// Build prompt based on file type and analysis type
-	ext := strings.ToLower(filepath.Ext(header.Filename))
-	prompt := buildAnalysisPrompt(analysisType, header.Filename, ext, content)
+	ext := strings.ToLower(filepath.Ext(document.Title))
+	prompt := buildAnalysisPrompt(analysisType, document.Title, ext, []byte(document.Content))
 
 	// Get API key
 	var apiKey string
@@ -119,18 +177,16 @@ func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 		Feature:     "analyze",
 	}
 
-	resp, err := h.aiService.Chat(c.Request.Context(), req)
+	resp, err := h.aiService.Chat(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI analysis failed: " + err.Error()})
-		return
+		return nil, err
 	}
 
 	// Parse AI response to extract structured data
 	analysisData := extractAnalysisData(resp.Message)
 
-	// Save analysis to database
-	documentAnalysis := models.DocumentAnalysis{
-		TenantID:         tenantID,
+	return &models.DocumentAnalysis{
+		TenantID:         document.TenantID,
 		DocumentID:       document.ID,
 		AnalysisType:     analysisType,
 		Summary:          analysisData["summary"].(string),
@@ -140,22 +196,7 @@ func (h *DocumentHandler) AnalyzeDocument(c *gin.Context) {
 		Recommendations:  toJSONString(analysisData["recommendations"]),
 		AnalysisMetadata: toJSONString(analysisData),
 		AIModel:          settings.ModelName,
-	}
-
-	if err := h.db.Create(&documentAnalysis).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save analysis"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success":      true,
-		"filename":     header.Filename,
-		"document_id":  document.ID,
-		"analysis_id":  documentAnalysis.ID,
-		"storage_url":  storageURL,
-		"analysis":     documentAnalysis,
-		"analysisType": analysisType,
-	})
+	}, nil
 }
 
 // GenerateDocument generates a styled document based on template type and saves to storage
@@ -182,7 +223,7 @@ func (h *DocumentHandler) GenerateDocument(c *gin.Context) {
 
 	// Get AI settings
 	var settings models.AISettings
-	if err := h.db.Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "AI not configured"})
 		return
 	}
@@ -220,7 +261,7 @@ func (h *DocumentHandler) GenerateDocument(c *gin.Context) {
 
 	// Store document in storage
 	htmlContent := []byte(styledHTML)
-	storageURL, storagePath, err := h.storage.StoreContent(htmlContent, tenantID, "documents", fmt.Sprintf("%s_%d.html", input.DocumentType, time.Now().Unix()))
+	storageURL, storagePath, err := h.storage.StoreContent(c.Request.Context(), htmlContent, tenantID, "documents", fmt.Sprintf("%s_%d.html", input.DocumentType, time.Now().Unix()))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document"})
 		return
@@ -245,7 +286,7 @@ func (h *DocumentHandler) GenerateDocument(c *gin.Context) {
 		CreatedBy:        userID,
 	}
 
-	if err := h.db.Create(&document).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&document).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
 		return
 	}
@@ -486,7 +527,7 @@ func (h *DocumentHandler) SaveDocument(c *gin.Context) {
 
 	// Store document in storage
 	htmlContent := []byte(styledHTML)
-	storageURL, storagePath, err := h.storage.StoreContent(htmlContent, tenantID, "documents", fmt.Sprintf("%s_%d.html", input.DocumentType, time.Now().Unix()))
+	storageURL, storagePath, err := h.storage.StoreContent(c.Request.Context(), htmlContent, tenantID, "documents", fmt.Sprintf("%s_%d.html", input.DocumentType, time.Now().Unix()))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store document"})
 		return
@@ -509,7 +550,7 @@ func (h *DocumentHandler) SaveDocument(c *gin.Context) {
 		CreatedBy:     userID,
 	}
 
-	if err := h.db.Create(&document).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&document).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
 		return
 	}
@@ -527,8 +568,9 @@ func (h *DocumentHandler) GetDocuments(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 
 	var documents []models.Document
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&documents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch documents"})
+	base := requestDB(c, h.db.DB).Model(&models.Document{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	if _, err := query.List(c, base, documentQuery, &documents); err != nil {
+		respondListError(c, err, "Failed to fetch documents")
 		return
 	}
 
@@ -541,11 +583,12 @@ func (h *DocumentHandler) GetDocumentByID(c *gin.Context) {
 	documentID := c.Param("id")
 
 	var document models.Document
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", documentID, tenantID, false).First(&document).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND tenant_id = ? AND is_deleted = ?", documentID, tenantID, false).First(&document).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
 		return
 	}
 
+	setETag(c, &document)
 	c.JSON(http.StatusOK, document)
 }
 
@@ -555,8 +598,9 @@ func (h *DocumentHandler) GetDocumentAnalyses(c *gin.Context) {
 	documentID := c.Param("id")
 
 	var analyses []models.DocumentAnalysis
-	if err := h.db.Where("document_id = ? AND tenant_id = ?", documentID, tenantID).Find(&analyses).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch analyses"})
+	base := requestDB(c, h.db.DB).Model(&models.DocumentAnalysis{}).Where("document_id = ? AND tenant_id = ?", documentID, tenantID)
+	if _, err := query.List(c, base, documentAnalysisQuery, &analyses); err != nil {
+		respondListError(c, err, "Failed to fetch analyses")
 		return
 	}
 
@@ -569,7 +613,7 @@ func (h *DocumentHandler) GetInfographicHTML(c *gin.Context) {
 	analysisID := c.Param("id")
 
 	var analysis models.DocumentAnalysis
-	if err := h.db.Where("id = ? AND tenant_id = ?", analysisID, tenantID).First(&analysis).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND tenant_id = ?", analysisID, tenantID).First(&analysis).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Analysis not found"})
 		return
 	}
@@ -597,7 +641,7 @@ func (h *DocumentHandler) AutoFillDocument(c *gin.Context) {
 
 	// Get AI settings
 	var settings models.AISettings
-	if err := h.db.Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ?", tenantID).First(&settings).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "AI not configured"})
 		return
 	}
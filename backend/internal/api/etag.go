@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Domain records carry their revision as an ETag. PUT and DELETE must send
+// the ETag the client last read in If-Match, so two people editing the same
+// record get a 412 instead of silently overwriting each other.
+
+// ifMatchRequired rejects writes without If-Match. It is off by default
+// while clients, the bundled frontend among them, are migrated to send the
+// header.
+var ifMatchRequired = false
+
+func SetIfMatchRequired(required bool) {
+	ifMatchRequired = required
+}
+
+// setETag sets the ETag header to the record's revision
+func setETag(c *gin.Context, record models.Revisioned) {
+	c.Header("ETag", record.GetRevision().ETag())
+}
+
+// checkIfMatch compares If-Match with the current record. It writes 428 when
+// the header is missing and 412 with the current record when it does not
+// match, and returns false in both cases.
+func checkIfMatch(c *gin.Context, current models.Revisioned) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		// Actions invoked with POST honour If-Match but do not require it
+		if !ifMatchRequired || c.Request.Method == http.MethodPost {
+			return true
+		}
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required; send the ETag of the record being changed"})
+		return false
+	}
+
+	etag := current.GetRevision().ETag()
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	preconditionFailed(c, current)
+	return false
+}
+
+// preconditionFailed responds 412 with the current state of the record so the
+// client can merge its changes
+func preconditionFailed(c *gin.Context, current models.Revisioned) {
+	setETag(c, current)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error": "Record was modified by another request",
+		"data":  current,
+	})
+}
+
+// staleWrite handles a write that lost a race after If-Match was checked. It
+// reloads the record and responds 412 when err is models.ErrStaleRevision,
+// and returns false for any other error.
+func staleWrite(c *gin.Context, db *gorm.DB, record models.Revisioned, err error) bool {
+	if !errors.Is(err, models.ErrStaleRevision) {
+		return false
+	}
+	if err := db.First(record).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record no longer exists"})
+		return true
+	}
+	preconditionFailed(c, record)
+	return true
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordEvent writes a domain event about a record to the outbox with tx.
+// The tenant and actor are taken from the request.
+func recordEvent(tx *gorm.DB, c *gin.Context, eventType, resourceType, resourceID string, data interface{}) error {
+	e, err := events.New(eventType, c.GetString("tenant_id"), resourceType, resourceID, c.GetString("user_id"), data)
+	if err != nil {
+		return err
+	}
+	return events.Record(tx, e)
+}
+
+// withEvents runs fn in a transaction and wakes the event bus once it has
+// committed, so recorded events go out without waiting for the next poll
+func withEvents(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if err := db.Transaction(fn); err != nil {
+		return err
+	}
+	notifyEvents()
+	return nil
+}
+
+func notifyEvents() {
+	if eventBus != nil {
+		eventBus.Notify()
+	}
+}
+
+// Incident severities from least to most severe
+var severityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// recordSeverityRaised records incident.severity_raised when incident is now
+// more severe than previous
+func recordSeverityRaised(tx *gorm.DB, c *gin.Context, incident *models.Incident, previous string) error {
+	if severityRank[incident.Severity] <= severityRank[previous] {
+		return nil
+	}
+	return recordEvent(tx, c, events.IncidentSeverityRaised, "incident", incident.ID, map[string]interface{}{
+		"incident":          incident,
+		"previous_severity": previous,
+	})
+}
+
+// WatchOverdueDSRs records dsr.overdue for open DSRs past their due date,
+// once per DSR and due date, checking every interval until ctx is cancelled
+func WatchOverdueDSRs(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var overdue []models.DSRRequest
+		if err := db.Where("is_deleted = ? AND due_date < ? AND status NOT IN ?", false, time.Now(), models.ClosedDSRStatuses).
+			Find(&overdue).Error; err != nil {
+			log.Printf("Failed to check overdue DSRs: %v", err)
+		}
+		for i := range overdue {
+			dsr := &overdue[i]
+			e, err := events.New(events.DSROverdue, dsr.TenantID, "dsr", dsr.ID, "", dsr)
+			if err != nil {
+				log.Printf("Failed to build dsr.overdue event: %v", err)
+				continue
+			}
+			e.Key = events.DSROverdue + ":" + dsr.ID + ":" + dsr.DueDate.Format("2006-01-02")
+			if err := events.Record(db, e); err != nil {
+				log.Printf("Failed to record dsr.overdue event: %v", err)
+			}
+		}
+		if len(overdue) > 0 {
+			notifyEvents()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// eventsWithoutChange are the events raised without a change of a record the
+// audit callbacks log: the system raises them on its own, or the record they
+// come with is bookkeeping the callbacks skip. Every other event is recorded
+// with the change it describes, which the callbacks already log.
+var eventsWithoutChange = map[string]bool{
+	events.DSROverdue:        true,
+	events.ReminderDue:       true,
+	events.ReminderEscalated: true,
+}
+
+// AuditEvents is the event subscriber that writes the events in
+// eventsWithoutChange to AuditLog with the event type as the action. The
+// entry carries the event ID, so a redelivered event is not logged twice.
+func AuditEvents(db *gorm.DB) events.Handler {
+	return func(ctx context.Context, e events.Event) error {
+		if !eventsWithoutChange[e.Type] {
+			return nil
+		}
+		values, err := json.Marshal(map[string]interface{}{"event_id": e.ID, "data": e.Data})
+		if err != nil {
+			return err
+		}
+		userID := e.ActorID
+		if userID == "" {
+			userID = "system"
+		}
+		eventID := e.ID
+		return db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}},
+			DoNothing: true,
+		}).Create(&models.AuditLog{
+			TenantID:     e.TenantID,
+			UserID:       userID,
+			Action:       e.Type,
+			ResourceType: e.ResourceType,
+			ResourceID:   e.ResourceID,
+			OldValues:    "{}",
+			NewValues:    string(values),
+			EventID:      &eventID,
+		}).Error
+	}
+}
+
+// updateWithEvent applies updates to the loaded record and records eventType
+// with the updated record as its payload, in one transaction
+func updateWithEvent(c *gin.Context, db *gorm.DB, record interface{}, updates map[string]interface{}, eventType, resourceType, resourceID string) error {
+	return withEvents(requestDB(c, db), func(tx *gorm.DB) error {
+		if err := tx.Model(record).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, c, eventType, resourceType, resourceID, record)
+	})
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/export"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Query parameters consumed by the export endpoint; everything else is
+// treated as a list filter
+var exportParams = []string{"format", "lang", "columns"}
+
+// exportSettings is stored under "export" in Tenant.Config
+type exportSettings struct {
+	Language string                       `json:"language,omitempty"`
+	Columns  map[string][]string          `json:"columns,omitempty"` // resource -> column names
+	Headers  map[string]map[string]string `json:"headers,omitempty"` // resource -> column -> header
+}
+
+// updateExportColumnsRequest is the body accepted by UpdateExportColumns
+type updateExportColumnsRequest struct {
+	Language string            `json:"language" binding:"omitempty,oneof=en id"`
+	Columns  []string          `json:"columns"`
+	Headers  map[string]string `json:"headers"`
+}
+
+type ExportHandler struct {
+	db *db.Database
+}
+
+func NewExportHandler(db *db.Database) *ExportHandler {
+	return &ExportHandler{db: db}
+}
+
+// exportResource resolves the :resource param and checks the caller may view
+// it. It writes the error response and returns false on failure.
+func exportResource(c *gin.Context) (models.Resource, bool) {
+	resource, ok := models.LookupResource(c.Param("resource"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown resource"})
+		return resource, false
+	}
+	if !models.HasPermission(c.GetString("user_role"), resource.Permission("view")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return resource, false
+	}
+	return resource, true
+}
+
+// loadTenantConfig returns the tenant's config document and its export settings
+func (h *ExportHandler) loadTenantConfig(tenantID string) (*models.Tenant, map[string]json.RawMessage, exportSettings) {
+	var tenant models.Tenant
+	config := map[string]json.RawMessage{}
+	var settings exportSettings
+
+	if err := h.db.DB.Where("id = ?", tenantID).First(&tenant).Error; err != nil {
+		return nil, config, settings
+	}
+	if tenant.Config != "" {
+		json.Unmarshal([]byte(tenant.Config), &config)
+	}
+	if raw, ok := config["export"]; ok {
+		json.Unmarshal(raw, &settings)
+	}
+	return &tenant, config, settings
+}
+
+// exportLanguage picks the header language: ?lang, then the tenant default,
+// then the Accept-Language header. English is the fallback.
+func exportLanguage(c *gin.Context, settings exportSettings) string {
+	for _, lang := range []string{c.Query("lang"), settings.Language} {
+		if lang == export.LangEnglish || lang == export.LangIndonesian {
+			return lang
+		}
+	}
+	if strings.HasPrefix(strings.ToLower(c.GetHeader("Accept-Language")), "id") {
+		return export.LangIndonesian
+	}
+	return export.LangEnglish
+}
+
+// Export streams every record of a resource matching the list filters.
+// Query params: format (csv, xlsx, ndjson; default csv), lang (en, id),
+// columns (comma separated), plus the filters and sort of the list endpoint.
+// Pagination params are ignored; the full result set is exported.
+func (h *ExportHandler) Export(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	resource, ok := exportResource(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatCSV)
+	contentType := export.ContentType(format)
+	if contentType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, expected csv, xlsx or ndjson"})
+		return
+	}
+
+	_, _, settings := h.loadTenantConfig(tenantID)
+	lang := exportLanguage(c, settings)
+
+	var names []string
+	if v := c.Query("columns"); v != "" {
+		names = strings.Split(v, ",")
+	} else {
+		names = settings.Columns[resource.Name]
+	}
+
+	values := c.Request.URL.Query()
+	for _, key := range exportParams {
+		values.Del(key)
+	}
+	values.Del("limit")
+	values.Del("offset")
+	values.Del("cursor")
+
+	model := resource.New()
+	var rows int64
+	var streamErr error
+	started := time.Now()
+
+	err := h.db.TenantTx(resourceSchema(resource.Name, tenantID), func(tx *gorm.DB) error {
+		params, err := query.Parse(tx, model, resourceQueries[resource.Name], values)
+		if err != nil {
+			return err
+		}
+		sch, err := query.Schema(tx, model)
+		if err != nil {
+			return err
+		}
+		columns, err := export.Columns(sch, names, lang, settings.Headers[resource.Name])
+		if err != nil {
+			return &query.Error{Message: err.Error()}
+		}
+
+		base := tx.Model(model).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+		base = params.Order(params.Scope(base))
+
+		filename := fmt.Sprintf("%s_%s.%s", resource.Name, started.Format("20060102_150405"), format)
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Header("X-Content-Type-Options", "nosniff")
+
+		w, err := export.NewWriter(format, c.Writer)
+		if err != nil {
+			return err
+		}
+		rows, streamErr = export.Stream(base, columns, w, c.Writer.Flush)
+		return streamErr
+	})
+
+	h.recordExport(c, resource, format, lang, names, values.Encode(), rows, err)
+
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// Headers are already sent; the client sees a truncated file
+		log.Printf("Export of %s for tenant %s failed after %d rows: %v", resource.Name, tenantID, rows, err)
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	if query.IsInvalid(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + resource.Label})
+}
+
+// recordExport writes the export to AuditLog. Exports are a data exfiltration
+// vector, so failed attempts are recorded too.
+func (h *ExportHandler) recordExport(c *gin.Context, resource models.Resource, format, lang string, columns []string, filters string, rows int64, err error) {
+	details := map[string]interface{}{
+		"tenant_id": c.GetString("tenant_id"),
+		"format":    format,
+		"language":  lang,
+		"columns":   columns,
+		"filters":   filters,
+		"rows":      rows,
+		"success":   err == nil,
+	}
+	if err != nil {
+		details["error"] = err.Error()
+	}
+	detailsJSON, _ := json.Marshal(details)
+
+	entry := models.AuditLog{
+		TenantID:     c.GetString("tenant_id"),
+		UserID:       c.GetString("user_id"),
+		Action:       "export",
+		ResourceType: resource.Name,
+		OldValues:    "{}",
+		NewValues:    string(detailsJSON),
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		RequestID:    c.GetString("request_id"),
+	}
+	if err := h.db.DB.Create(&entry).Error; err != nil {
+		log.Printf("Failed to record export audit log: %v", err)
+	}
+}
+
+// GetExportColumns lists the exportable columns of a resource with their
+// headers, and the tenant's configured default selection
+func (h *ExportHandler) GetExportColumns(c *gin.Context) {
+	resource, ok := exportResource(c)
+	if !ok {
+		return
+	}
+	_, _, settings := h.loadTenantConfig(c.GetString("tenant_id"))
+	lang := exportLanguage(c, settings)
+
+	sch, err := query.Schema(h.db.DB, resource.New())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read resource schema"})
+		return
+	}
+	columns, _ := export.Columns(sch, nil, lang, settings.Headers[resource.Name])
+
+	selected := settings.Columns[resource.Name]
+	if selected == nil {
+		selected = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"resource": resource.Name,
+			"language": lang,
+			"columns":  columns,
+			"selected": selected,
+		},
+	})
+}
+
+// UpdateExportColumns sets the tenant's default columns and header overrides
+// for a resource. Requires tenant.update.
+func (h *ExportHandler) UpdateExportColumns(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	resource, ok := exportResource(c)
+	if !ok {
+		return
+	}
+	if !models.HasPermission(c.GetString("user_role"), "tenant.update") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return
+	}
+
+	var req updateExportColumnsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sch, err := query.Schema(h.db.DB, resource.New())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read resource schema"})
+		return
+	}
+	if _, err := export.Columns(sch, req.Columns, export.LangEnglish, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant, config, settings := h.loadTenantConfig(tenantID)
+	if tenant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
+		return
+	}
+	if settings.Columns == nil {
+		settings.Columns = map[string][]string{}
+	}
+	if settings.Headers == nil {
+		settings.Headers = map[string]map[string]string{}
+	}
+	if req.Language != "" {
+		settings.Language = req.Language
+	}
+	settings.Columns[resource.Name] = req.Columns
+	settings.Headers[resource.Name] = req.Headers
+
+	raw, _ := json.Marshal(settings)
+	config["export"] = raw
+	configJSON, _ := json.Marshal(config)
+
+	if err := requestDB(c, h.db.DB).Model(tenant).Update("config", string(configJSON)).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update export settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Export settings updated successfully",
+		"data":    settings,
+	})
+}
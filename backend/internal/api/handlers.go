@@ -3,6 +3,11 @@ package api
 import (
 	"github.com/cyber/backend/internal/cache"
 	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var (
@@ -16,6 +21,9 @@ var (
 	documentHandler   *DocumentHandler
 	rbacHandler       *RBACHandler
 	cacheHandler      *cache.RedisClient
+	eventBus          *events.Bus
+	realtimeHub       *realtime.Hub
+	jobQueue          *jobs.Queue
 )
 
 func InitHandlers(db *db.Database) {
@@ -34,6 +42,16 @@ func InitCache(redisClient *cache.RedisClient) {
 	cacheHandler = redisClient
 }
 
+// InitEvents sets the bus woken after handlers commit domain events
+func InitEvents(bus *events.Bus) {
+	eventBus = bus
+}
+
+// InitRealtime sets the hub handlers push progress notifications to
+func InitRealtime(hub *realtime.Hub) {
+	realtimeHub = hub
+}
+
 func GetCacheHandler() *cache.RedisClient {
 	return cacheHandler
 }
@@ -73,3 +91,28 @@ func GetDocumentHandler() *DocumentHandler {
 func GetRBACHandler() *RBACHandler {
 	return rbacHandler
 }
+
+// requestDB binds db to the request context, so its statements are traced
+// as part of the request
+func requestDB(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return db.WithContext(c.Request.Context())
+}
+
+// legacyResources are the resources the legacy RegOps handler keeps in the
+// tenant schema. The routed handlers keep every other resource in public,
+// scoped by its tenant_id column; the tenant schema holds empty copies of
+// their tables.
+var legacyResources = map[string]bool{
+	"regulation":            true,
+	"compliance_assessment": true,
+	"policy":                true,
+}
+
+// resourceSchema returns the tenant to pass to TenantTx for a resource: the
+// tenant for legacy resources, and "" to stay in public for the rest
+func resourceSchema(resource, tenantID string) string {
+	if legacyResources[resource] {
+		return tenantID
+	}
+	return ""
+}
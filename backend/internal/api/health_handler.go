@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyber/backend/internal/cache"
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// How long each readiness check may take
+const readinessTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db       *db.Database
+	redis    *cache.RedisClient
+	storage  *storage.StorageService
+	draining atomic.Bool
+}
+
+// NewHealthHandler checks database, redis when configured, and the storage
+// the document handlers write to. It runs after InitHandlers.
+func NewHealthHandler(database *db.Database, redis *cache.RedisClient) *HealthHandler {
+	return &HealthHandler{db: database, redis: redis, storage: GetDocumentHandler().storage}
+}
+
+// Drain makes readiness fail, so load balancers stop routing new requests
+// here while in-flight ones finish
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz reports that the process is up. It checks no dependencies, so an
+// outage of one does not get every replica restarted.
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server can take traffic: the database answers
+// and is migrated, Redis answers when configured, and storage is writable.
+// It responds 503 with the failing checks otherwise.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	checks := map[string]string{}
+	ready := true
+	check := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			checks[name] = err.Error()
+			ready = false
+			return
+		}
+		checks[name] = "ok"
+	}
+
+	if h.draining.Load() {
+		checks["server"] = "shutting down"
+		ready = false
+	}
+	check("postgres", func(ctx context.Context) error {
+		sqlDB, err := h.db.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	check("migrations", func(ctx context.Context) error {
+		version, err := h.db.CurrentSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version < db.SchemaVersion {
+			return fmt.Errorf("schema version %q, expected %s", version, db.SchemaVersion)
+		}
+		return nil
+	})
+	if h.redis != nil {
+		check("redis", h.redis.Ping)
+	}
+	check("storage", func(context.Context) error {
+		return h.storage.CheckWritable()
+	})
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"status":         map[bool]string{true: "ready", false: "not ready"}[ready],
+		"schema_version": db.SchemaVersion,
+		"checks":         checks,
+	})
+}
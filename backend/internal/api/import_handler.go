@@ -0,0 +1,522 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/importer"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// Uploads larger than this are rejected before parsing
+	importMaxFileSize = 20 << 20
+	// Files with more rows than this are imported by the import.run job
+	importAsyncThreshold = 500
+	// Rows inserted per INSERT statement
+	importBatchSize = 200
+)
+
+// importable is implemented by the create request types. record returns a
+// pointer to the model the matching create handler would store.
+type importable interface {
+	record(tenantID, userID string) interface{}
+}
+
+// importers maps resource names (see models.TenantResources) to the create
+// request type used to validate each spreadsheet row.
+var importers = map[string]func() importable{
+	"regulation":            func() importable { return &createRegulationRequest{} },
+	"compliance_assessment": func() importable { return &createComplianceAssessmentRequest{} },
+	"gap_analysis":          func() importable { return &createComplianceGapRequest{} },
+	"obligation":            func() importable { return &createObligationRequest{} },
+	"policy":                func() importable { return &createPolicyRequest{} },
+	"control":               func() importable { return &createControlRequest{} },
+	"data_inventory":        func() importable { return &createDataItemRequest{} },
+	"dsr":                   func() importable { return &createDSRRequest{} },
+	"dpia":                  func() importable { return &createDPIARequest{} },
+	"privacy_control":       func() importable { return &createPrivacyControlRequest{} },
+	"incident":              func() importable { return &createIncidentRequest{} },
+	"risk":                  func() importable { return &createRiskRequest{} },
+	"vulnerability":         func() importable { return &createVulnerabilityRequest{} },
+	"vendor":                func() importable { return &createVendorRequest{} },
+	"continuity_plan":       func() importable { return &createContinuityPlanRequest{} },
+	"audit_plan":            func() importable { return &createInternalAuditRequest{} },
+	"governance":            func() importable { return &createKRIRequest{} },
+	"control_test":          func() importable { return &createControlTestRequest{} },
+	"evidence":              func() importable { return &createEvidenceRequest{} },
+	"audit_report":          func() importable { return &createReportRequest{} },
+}
+
+var importJobQuery = query.Spec{
+	Filters: []string{"resource", "status", "file_format", "created_by", "created_at"},
+	Sorts:   []string{"resource", "status", "total_rows", "created_at", "completed_at"},
+}
+
+type ImportHandler struct {
+	db *gorm.DB
+}
+
+func NewImportHandler(db *gorm.DB) *ImportHandler {
+	return &ImportHandler{db: db}
+}
+
+// importResource resolves the :resource param and checks the caller may
+// perform action on it. It writes the error response and returns false on failure.
+func importResource(c *gin.Context, name, action string) (models.Resource, bool) {
+	resource, ok := models.LookupResource(name)
+	if _, supported := importers[name]; !ok || !supported {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource does not support import"})
+		return resource, false
+	}
+	if !models.HasPermission(c.GetString("user_role"), resource.Permission(action)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return resource, false
+	}
+	return resource, true
+}
+
+// GetTemplate returns a CSV file containing only the header row for a resource
+func (h *ImportHandler) GetTemplate(c *gin.Context) {
+	name := c.Param("resource")
+	if _, ok := importResource(c, name, "create"); !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(importer.Fields(importers[name]()))
+	w.Flush()
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_import_template.csv"`, name))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// Import uploads a CSV or XLSX file for a resource.
+// Form fields: file (required), mapping (JSON object of header -> field),
+// dry_run (true to validate only).
+// Imports are all-or-nothing: any invalid row fails the whole file.
+func (h *ImportHandler) Import(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	name := c.Param("resource")
+	if _, ok := importResource(c, name, "create"); !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, importMaxFileSize)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded or file too large"})
+		return
+	}
+	defer file.Close()
+
+	mapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping, expected a JSON object of column -> field"})
+			return
+		}
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+	sheet, err := importer.Read(file, header.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(sheet.Rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File contains no data rows"})
+		return
+	}
+
+	if dryRun {
+		records, rowErrors := bindRows(name, sheet, mapping, tenantID, userID)
+		c.JSON(http.StatusOK, gin.H{
+			"success": len(rowErrors) == 0,
+			"data": gin.H{
+				"resource":   name,
+				"total_rows": len(sheet.Rows),
+				"valid_rows": len(records),
+				"error_rows": countRows(rowErrors),
+				"errors":     rowErrors,
+				"columns":    columnReport(name, sheet.Headers, mapping),
+			},
+			"message": "Dry run completed, no records were written",
+		})
+		return
+	}
+
+	mappingJSON, _ := json.Marshal(mapping)
+	job := models.ImportJob{
+		TenantID:   tenantID,
+		Resource:   name,
+		FileName:   header.Filename,
+		FileFormat: sheet.Format,
+		Status:     "pending",
+		Mapping:    string(mappingJSON),
+		TotalRows:  len(sheet.Rows),
+		Errors:     "[]",
+		RecordIDs:  "[]",
+		CreatedBy:  userID,
+	}
+
+	if len(sheet.Rows) > importAsyncThreshold {
+		// The rows are kept with the import job until the job queued with it
+		// has run, so the import survives restarts and deploys
+		sheetJSON, err := json.Marshal(sheet)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+			return
+		}
+		rows := string(sheetJSON)
+		job.Sheet = &rows
+		if err := withJobs(requestDB(c, h.db), func(tx *gorm.DB) error {
+			if err := tx.Create(&job).Error; err != nil {
+				return err
+			}
+			_, err := runImportJob.Enqueue(tx, tenantID, recordPayload{ID: job.ID})
+			return err
+		}); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Import started, poll the job for progress",
+			"data":    job,
+		})
+		return
+	}
+
+	if err := requestDB(c, h.db).Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create import job"})
+		return
+	}
+	if err := h.run(c.Request.Context(), &job, sheet, mapping); err != nil {
+		h.finish(h.db, &job, "failed", "Import was interrupted")
+		publishProgress(&job)
+	}
+	switch job.Status {
+	case "completed":
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Import completed successfully", "data": job})
+	case "failed":
+		if job.ErrorRows > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Import contains invalid rows", "data": job})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed", "data": job})
+	}
+}
+
+// runQueued runs the import job with the given ID, queued by Import. An
+// import that already finished is left alone. One that was running when its
+// worker stopped is run again: its records are written in one transaction
+// with its completion, so none were kept.
+func (h *ImportHandler) runQueued(ctx context.Context, queued *models.Job, id string) error {
+	var job models.ImportJob
+	if err := loadImportJob(h.db.WithContext(ctx), queued, id, &job); err != nil {
+		return err
+	}
+	if job.Status != "pending" && job.Status != "running" {
+		return nil
+	}
+	if job.Sheet == nil {
+		return jobs.Permanent(errors.New("import job has no rows"))
+	}
+
+	var sheet importer.Sheet
+	mapping := map[string]string{}
+	if err := json.Unmarshal([]byte(*job.Sheet), &sheet); err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := json.Unmarshal([]byte(job.Mapping), &mapping); err != nil {
+		return jobs.Permanent(err)
+	}
+	return h.run(ctx, &job, &sheet, mapping)
+}
+
+// loadImportJob loads the import job of the queued job's tenant. A job that
+// is gone fails the queued job for good.
+func loadImportJob(tx *gorm.DB, queued *models.Job, id string, job *models.ImportJob) error {
+	err := tx.Where("id = ? AND tenant_id = ?", id, queued.TenantID).First(job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// run validates every row and inserts them in one transaction, recording the
+// outcome on job. The records are audited as created by the actor of ctx.
+// An error means ctx ended before the outcome was recorded.
+func (h *ImportHandler) run(ctx context.Context, job *models.ImportJob, sheet *importer.Sheet, mapping map[string]string) error {
+	started := time.Now()
+	job.Status = "running"
+	job.StartedAt = &started
+	h.db.Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": started})
+	publishProgress(job)
+
+	records, rowErrors := bindRows(job.Resource, sheet, mapping, job.TenantID, job.CreatedBy)
+	if len(rowErrors) > 0 {
+		errorsJSON, _ := json.Marshal(rowErrors)
+		job.Errors = string(errorsJSON)
+		job.ErrorRows = countRows(rowErrors)
+		h.finish(h.db, job, "failed", "")
+		publishProgress(job)
+		return nil
+	}
+
+	if err := h.insert(ctx, job, records); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Import job %s failed: %v", job.ID, err)
+		h.finish(h.db, job, "failed", "Failed to save records")
+	}
+	publishProgress(job)
+	return nil
+}
+
+// finish records the outcome of job with tx and drops its rows
+func (h *ImportHandler) finish(tx *gorm.DB, job *models.ImportJob, status, message string) error {
+	now := time.Now()
+	job.Status = status
+	job.ErrorMessage = message
+	job.CompletedAt = &now
+	job.Sheet = nil
+	return tx.Model(job).Updates(map[string]interface{}{
+		"status":        job.Status,
+		"error_message": job.ErrorMessage,
+		"errors":        job.Errors,
+		"error_rows":    job.ErrorRows,
+		"record_ids":    job.RecordIDs,
+		"imported_rows": job.ImportedRows,
+		"completed_at":  now,
+		"sheet":         nil,
+	}).Error
+}
+
+// publishProgress pushes the job's state to the stream of the user who
+// started it
+func publishProgress(job *models.ImportJob) {
+	if realtimeHub == nil {
+		return
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return
+	}
+	realtimeHub.Publish(realtime.Message{
+		Type:       realtime.JobProgress,
+		TenantID:   job.TenantID,
+		Resource:   "import_job",
+		ResourceID: job.ID,
+		UserID:     job.CreatedBy,
+		Data:       data,
+	})
+}
+
+// insert writes records in batches and completes job, all in one
+// transaction. Records of legacy resources go to the tenant schema.
+func (h *ImportHandler) insert(ctx context.Context, job *models.ImportJob, records []interface{}) error {
+	elemType := reflect.TypeOf(records[0]).Elem()
+	var ids []string
+
+	return (&db.Database{DB: h.db.WithContext(ctx)}).TenantTx(resourceSchema(job.Resource, job.TenantID), func(tx *gorm.DB) error {
+		for start := 0; start < len(records); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(records) {
+				end = len(records)
+			}
+			batch := reflect.MakeSlice(reflect.SliceOf(elemType), 0, end-start)
+			for _, rec := range records[start:end] {
+				batch = reflect.Append(batch, reflect.ValueOf(rec).Elem())
+			}
+			ptr := reflect.New(batch.Type())
+			ptr.Elem().Set(batch)
+			if err := tx.Create(ptr.Interface()).Error; err != nil {
+				return err
+			}
+			for i := 0; i < ptr.Elem().Len(); i++ {
+				ids = append(ids, ptr.Elem().Index(i).FieldByName("ID").String())
+			}
+			// Progress is written outside the transaction so pollers can see it
+			job.ImportedRows = len(ids)
+			h.db.Model(job).Update("imported_rows", job.ImportedRows)
+			publishProgress(job)
+		}
+
+		idsJSON, _ := json.Marshal(ids)
+		job.RecordIDs = string(idsJSON)
+		return h.finish(tx, job, "completed", "")
+	})
+}
+
+// bindRows binds and validates every row. Records are only meaningful when
+// no errors are returned.
+func bindRows(name string, sheet *importer.Sheet, mapping map[string]string, tenantID, userID string) ([]interface{}, []importer.RowError) {
+	newRequest := importers[name]
+	columns := importer.Columns(sheet.Headers, mapping, newRequest())
+
+	var records []interface{}
+	var rowErrors []importer.RowError
+	for i, row := range sheet.Rows {
+		req := newRequest()
+		if errs := importer.Bind(sheet.RowNumbers[i], columns, row, req); len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		records = append(records, req.record(tenantID, userID))
+	}
+	if rowErrors == nil {
+		rowErrors = []importer.RowError{}
+	}
+	return records, rowErrors
+}
+
+// columnReport shows how each header was mapped so the caller can fix the mapping
+func columnReport(name string, headers []string, mapping map[string]string) []gin.H {
+	columns := importer.Columns(headers, mapping, importers[name]())
+	report := make([]gin.H, len(headers))
+	for i, h := range headers {
+		report[i] = gin.H{"header": h, "field": columns[i], "ignored": columns[i] == ""}
+	}
+	return report
+}
+
+func countRows(errs []importer.RowError) int {
+	rows := map[int]bool{}
+	for _, e := range errs {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+// GetImportJobs lists the tenant's import history
+func (h *ImportHandler) GetImportJobs(c *gin.Context) {
+	var jobs []models.ImportJob
+
+	base := requestDB(c, h.db).Model(&models.ImportJob{}).Where("tenant_id = ? AND is_deleted = ?", c.GetString("tenant_id"), false)
+	page, err := query.List(c, base, importJobQuery, &jobs)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch import jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       jobs,
+		"pagination": page,
+	})
+}
+
+// GetImportJob returns a single job, including its progress and row errors
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	var job models.ImportJob
+
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ?", c.Param("id"), c.GetString("tenant_id")).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    job,
+	})
+}
+
+// RollbackImportJob soft deletes every record created by a completed import
+func (h *ImportHandler) RollbackImportJob(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	userID := c.GetString("user_id")
+	var job models.ImportJob
+
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ?", c.Param("id"), tenantID).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import job not found"})
+		return
+	}
+	resource, ok := importResource(c, job.Resource, "delete")
+	if !ok {
+		return
+	}
+	if job.Status != "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only completed imports can be rolled back"})
+		return
+	}
+
+	var ids []string
+	if err := json.Unmarshal([]byte(job.RecordIDs), &ids); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import job has invalid record list"})
+		return
+	}
+
+	// Records of legacy resources were imported into the tenant schema
+	schemaTenant := resourceSchema(job.Resource, tenantID)
+	now := time.Now()
+	var removed int64
+	err := (&db.Database{DB: requestDB(c, h.db)}).TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		if len(ids) > 0 {
+			result := tx.Model(resource.New()).
+				Where("id IN ? AND tenant_id = ? AND is_deleted = ?", ids, tenantID, false).
+				Updates(models.TrashUpdates(userID, now))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(ids)) {
+				return errImportRecordsChanged
+			}
+			removed = result.RowsAffected
+		}
+		res := tx.Model(&job).Where("status = ?", "completed").
+			Updates(map[string]interface{}{"status": "rolled_back", "rolled_back_at": now, "rolled_back_by": userID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errImportAlreadyRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, errImportAlreadyRolledBack) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Import has already been rolled back"})
+		return
+	}
+	if errors.Is(err, errImportRecordsChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Some imported records no longer exist or are in the trash; restore them before rolling back"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back import"})
+		return
+	}
+
+	job.Status = "rolled_back"
+	job.RolledBackAt = &now
+	job.RolledBackBy = userID
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Import rolled back, %d records removed", removed),
+		"data":    job,
+	})
+}
+
+var (
+	errImportAlreadyRolledBack = errors.New("import already rolled back")
+	errImportRecordsChanged    = errors.New("imported records were deleted since")
+)
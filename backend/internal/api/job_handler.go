@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var jobQuery = query.Spec{
+	Filters:     []string{"tenant_id", "type", "status", "schedule", "created_by", "run_at", "created_at", "finished_at"},
+	Sorts:       []string{"created_at", "run_at", "finished_at"},
+	DefaultSort: "-created_at",
+}
+
+type JobHandler struct {
+	db *gorm.DB
+}
+
+func NewJobHandler(db *gorm.DB) *JobHandler {
+	return &JobHandler{db: db}
+}
+
+// scope returns the jobs the caller may see: the tenant's, or every job for
+// the platform endpoints
+func (h *JobHandler) scope(c *gin.Context, platform bool) *gorm.DB {
+	base := requestDB(c, h.db).Model(&models.Job{})
+	if !platform {
+		base = base.Where("tenant_id = ?", c.GetString("tenant_id"))
+	}
+	return base
+}
+
+func (h *JobHandler) list(c *gin.Context, platform bool) {
+	var list []models.Job
+	page, err := query.List(c, h.scope(c, platform), jobQuery, &list)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch jobs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       list,
+		"pagination": page,
+	})
+}
+
+// load loads the :id job within the caller's scope. It writes the error
+// response and returns false when there is no such job.
+func (h *JobHandler) load(c *gin.Context, platform bool, job *models.Job) bool {
+	if err := h.scope(c, platform).Where("id = ?", c.Param("id")).First(job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return false
+	}
+	return true
+}
+
+func (h *JobHandler) get(c *gin.Context, platform bool) {
+	var job models.Job
+	if !h.load(c, platform, &job) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": job})
+}
+
+// change applies jobs.Retry or jobs.Cancel to the :id job
+func (h *JobHandler) change(c *gin.Context, platform bool, apply func(*gorm.DB, *models.Job) error, message string) {
+	var job models.Job
+	if !h.load(c, platform, &job) {
+		return
+	}
+	if err := apply(requestDB(c, h.db), &job); err != nil {
+		if errors.Is(err, jobs.ErrNotRetryable) || errors.Is(err, jobs.ErrNotCancellable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update job"})
+		return
+	}
+	notifyJobs()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    job,
+	})
+}
+
+// GetJobs lists the tenant's background jobs
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	h.list(c, false)
+}
+
+// GetJob returns one of the tenant's background jobs
+func (h *JobHandler) GetJob(c *gin.Context) {
+	h.get(c, false)
+}
+
+// RetryJob runs a failed or cancelled job of the tenant again
+func (h *JobHandler) RetryJob(c *gin.Context) {
+	h.change(c, false, jobs.Retry, "Job queued to run again")
+}
+
+// CancelJob cancels a pending or running job of the tenant
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	h.change(c, false, jobs.Cancel, "Job cancelled successfully")
+}
+
+// GetPlatformJobs lists the background jobs of every tenant and of the
+// platform
+func (h *JobHandler) GetPlatformJobs(c *gin.Context) {
+	h.list(c, true)
+}
+
+// GetPlatformJob returns any background job
+func (h *JobHandler) GetPlatformJob(c *gin.Context) {
+	h.get(c, true)
+}
+
+// RetryPlatformJob runs any failed or cancelled job again
+func (h *JobHandler) RetryPlatformJob(c *gin.Context) {
+	h.change(c, true, jobs.Retry, "Job queued to run again")
+}
+
+// CancelPlatformJob cancels any pending or running job
+func (h *JobHandler) CancelPlatformJob(c *gin.Context) {
+	h.change(c, true, jobs.Cancel, "Job cancelled successfully")
+}
+
+// GetJobSchedules lists the recurring jobs with their last and next runs
+func (h *JobHandler) GetJobSchedules(c *gin.Context) {
+	var schedules []models.JobSchedule
+	if err := requestDB(c, h.db).Order("name").Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job schedules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": schedules})
+}
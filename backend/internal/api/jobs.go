@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Jobs handlers queue for work that does not fit in a request
+var (
+	generateReportJob  = jobs.Kind[recordPayload]{Name: "audit_report.generate"}
+	runControlTestJob  = jobs.Kind[recordPayload]{Name: "control_test.run"}
+	analyzeDocumentJob = jobs.Kind[analyzeDocumentPayload]{Name: "document.analyze", MaxAttempts: 3}
+	runImportJob       = jobs.Kind[recordPayload]{Name: "import.run", MaxAttempts: 3}
+)
+
+// recordPayload names the record a job works on; the job's tenant owns it
+type recordPayload struct {
+	ID string `json:"id"`
+}
+
+type analyzeDocumentPayload struct {
+	DocumentID   string `json:"document_id"`
+	AnalysisType string `json:"analysis_type"`
+}
+
+// InitJobs registers the handlers of the jobs handlers queue with q, and
+// sets q as the queue woken after handlers commit jobs. Call it after
+// InitHandlers, in every process running q.
+func InitJobs(q *jobs.Queue, database *db.Database) {
+	jobQueue = q
+	generateReportJob.Handle(q, func(ctx context.Context, job *models.Job, p recordPayload) error {
+		return generateReport(database.DB.WithContext(ctx), job, p.ID)
+	})
+	runControlTestJob.Handle(q, func(ctx context.Context, job *models.Job, p recordPayload) error {
+		return runControlTest(database.DB.WithContext(ctx), job, p.ID)
+	})
+	analyzeDocumentJob.Handle(q, func(ctx context.Context, job *models.Job, p analyzeDocumentPayload) error {
+		return documentHandler.analyzeStored(ctx, database.DB.WithContext(ctx), job, p)
+	})
+	imports := NewImportHandler(database.DB)
+	runImportJob.Handle(q, func(ctx context.Context, job *models.Job, p recordPayload) error {
+		return imports.runQueued(ctx, job, p.ID)
+	})
+}
+
+// withJobs runs fn in a transaction and wakes the job queue once it has
+// committed, so queued jobs start without waiting for the next poll
+func withJobs(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if err := db.Transaction(fn); err != nil {
+		return err
+	}
+	notifyJobs()
+	return nil
+}
+
+func notifyJobs() {
+	if jobQueue != nil {
+		jobQueue.Notify()
+	}
+}
+
+// recordJobEvent writes a domain event raised by a job to the outbox with
+// tx, on behalf of whoever queued the job. The job keys the event, so a
+// job that runs again does not raise it twice.
+func recordJobEvent(tx *gorm.DB, job *models.Job, eventType, resourceType, resourceID string, data interface{}) error {
+	e, err := events.New(eventType, job.TenantID, resourceType, resourceID, job.CreatedBy, data)
+	if err != nil {
+		return err
+	}
+	e.Key = eventType + ":" + job.ID
+	return events.Record(tx, e)
+}
+
+// loadJobRecord loads the live record of the job's tenant a job works on.
+// A record that is gone fails the job for good.
+func loadJobRecord(tx *gorm.DB, job *models.Job, id string, record interface{}) error {
+	err := tx.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, job.TenantID, false).First(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// generateReport completes an audit report queued by GenerateReport
+func generateReport(tx *gorm.DB, job *models.Job, id string) error {
+	var report models.AuditReport
+	if err := loadJobRecord(tx, job, id, &report); err != nil {
+		return err
+	}
+	reportDate := time.Now()
+	return withEvents(tx, func(tx *gorm.DB) error {
+		if err := tx.Model(&report).Updates(map[string]interface{}{
+			"status":      "completed",
+			"report_date": &reportDate,
+		}).Error; err != nil {
+			return err
+		}
+		return recordJobEvent(tx, job, events.AuditReportGenerated, "audit_report", id, &report)
+	})
+}
+
+// runControlTest records the run of a control test queued by
+// RunControlTest. The test then awaits the tester's result.
+func runControlTest(tx *gorm.DB, job *models.Job, id string) error {
+	var test models.ControlTest
+	if err := loadJobRecord(tx, job, id, &test); err != nil {
+		return err
+	}
+	return withEvents(tx, func(tx *gorm.DB) error {
+		if err := tx.Model(&test).Updates(map[string]interface{}{
+			"test_date":   time.Now(),
+			"test_result": "pending",
+		}).Error; err != nil {
+			return err
+		}
+		return recordJobEvent(tx, job, events.ControlTestRun, "control_test", id, &test)
+	})
+}
+
+// analyzeStored analyzes a document stored by AnalyzeDocument with async set
+func (h *DocumentHandler) analyzeStored(ctx context.Context, tx *gorm.DB, job *models.Job, p analyzeDocumentPayload) error {
+	var document models.Document
+	if err := loadJobRecord(tx, job, p.DocumentID, &document); err != nil {
+		return err
+	}
+	var settings models.AISettings
+	if err := tx.Where("tenant_id = ?", job.TenantID).First(&settings).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(errors.New("AI not configured"))
+		}
+		return err
+	}
+
+	analysis, err := h.analyze(ctx, &settings, &document, p.AnalysisType)
+	if err != nil {
+		return err
+	}
+	return tx.Create(analysis).Error
+}
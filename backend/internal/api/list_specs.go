@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// Filter and sort whitelists for the domain list endpoints.
+// See package query for the query string syntax.
+
+var regulationQuery = query.Spec{
+	Filters: []string{"name", "jurisdiction", "type", "status", "version", "created_at", "updated_at"},
+	Sorts:   []string{"name", "jurisdiction", "type", "status", "created_at", "updated_at"},
+}
+
+var complianceAssessmentQuery = query.Spec{
+	Filters:     []string{"regulation_id", "status", "score", "assessment_date", "created_by", "created_at"},
+	Sorts:       []string{"assessment_date", "score", "status", "created_at", "updated_at"},
+	DefaultSort: "-assessment_date",
+}
+
+var gapAnalysisQuery = query.Spec{
+	Filters: []string{"regulation_id", "name", "framework", "status", "gap_score", "owner", "due_date", "created_at"},
+	Sorts:   []string{"name", "framework", "status", "gap_score", "due_date", "created_at", "updated_at"},
+}
+
+var obligationQuery = query.Spec{
+	Filters: []string{"regulation_id", "clause_id", "name", "obligation_type", "control_id", "mapping_status", "compliance_status", "last_reviewed", "next_review", "created_at"},
+	Sorts:   []string{"name", "obligation_type", "mapping_status", "compliance_status", "next_review", "created_at", "updated_at"},
+}
+
+var policyQuery = query.Spec{
+	Filters: []string{"regulation_id", "name", "policy_type", "version", "status", "owner", "approval_date", "review_date", "created_at"},
+	Sorts:   []string{"name", "policy_type", "status", "approval_date", "review_date", "created_at", "updated_at"},
+}
+
+var regOpsControlQuery = query.Spec{
+	Filters: []string{"regulation_id", "clause_id", "name", "control_type", "control_family", "framework", "implementation_status", "effectiveness", "owner", "last_tested", "next_test", "created_at"},
+	Sorts:   []string{"name", "control_family", "framework", "implementation_status", "last_tested", "next_test", "created_at", "updated_at"},
+}
+
+var dataInventoryQuery = query.Spec{
+	Filters: []string{"data_type", "data_source", "data_category", "sensitivity_level", "storage_location", "retention_period", "created_by", "created_at"},
+	Sorts:   []string{"data_type", "data_category", "sensitivity_level", "retention_period", "created_at", "updated_at"},
+}
+
+var dsrQuery = query.Spec{
+	Filters: []string{"request_type", "data_subject_name", "data_subject_email", "status", "priority", "handler", "request_date", "due_date", "completed_date", "created_at"},
+	Sorts:   []string{"request_type", "status", "priority", "request_date", "due_date", "completed_date", "created_at", "updated_at"},
+}
+
+var dpiaQuery = query.Spec{
+	Filters: []string{"name", "processing_activity", "risk_level", "status", "reviewer", "approval_date", "created_at"},
+	Sorts:   []string{"name", "risk_level", "status", "approval_date", "created_at", "updated_at"},
+}
+
+var privacyControlQuery = query.Spec{
+	Filters: []string{"name", "control_type", "control_domain", "framework", "implementation_status", "effectiveness", "owner", "last_tested", "next_test", "created_at"},
+	Sorts:   []string{"name", "control_domain", "framework", "implementation_status", "last_tested", "next_test", "created_at", "updated_at"},
+}
+
+var incidentQuery = query.Spec{
+	Filters: []string{"name", "incident_type", "severity", "status", "handler", "notification_required", "affected_individuals", "detection_date", "reported_date", "resolution_date", "created_at"},
+	Sorts:   []string{"name", "severity", "status", "affected_individuals", "detection_date", "reported_date", "resolution_date", "created_at", "updated_at"},
+}
+
+var riskRegisterQuery = query.Spec{
+	Filters: []string{"name", "risk_category", "risk_type", "likelihood", "impact", "risk_score", "risk_level", "owner", "status", "residual_risk_score", "residual_risk_level", "review_date", "created_at"},
+	Sorts:   []string{"name", "risk_category", "risk_score", "risk_level", "status", "residual_risk_score", "review_date", "created_at", "updated_at"},
+}
+
+var vulnerabilityQuery = query.Spec{
+	Filters: []string{"name", "cve_id", "cvss_score", "severity", "status", "patch_available", "assigned_to", "discovery_date", "remediation_date", "created_at"},
+	Sorts:   []string{"name", "cve_id", "cvss_score", "severity", "status", "discovery_date", "remediation_date", "created_at", "updated_at"},
+}
+
+var vendorQuery = query.Spec{
+	Filters: []string{"vendor_name", "vendor_type", "risk_level", "compliance_status", "sla_compliance", "owner", "assessment_date", "next_assessment_date", "created_at"},
+	Sorts:   []string{"vendor_name", "vendor_type", "risk_level", "compliance_status", "assessment_date", "next_assessment_date", "created_at", "updated_at"},
+}
+
+var continuityQuery = query.Spec{
+	Filters: []string{"name", "business_function", "criticality", "rto_hours", "rpo_hours", "test_result", "owner", "status", "test_date", "created_at"},
+	Sorts:   []string{"name", "business_function", "criticality", "rto_hours", "rpo_hours", "status", "test_date", "created_at", "updated_at"},
+}
+
+var auditPlanQuery = query.Spec{
+	Filters: []string{"name", "audit_type", "framework", "status", "auditor", "risk_level", "start_date", "end_date", "created_at"},
+	Sorts:   []string{"name", "audit_type", "framework", "status", "start_date", "end_date", "budget", "created_at", "updated_at"},
+}
+
+var governanceQuery = query.Spec{
+	Filters: []string{"name", "governance_type", "framework", "committee_name", "meeting_frequency", "status", "last_meeting_date", "next_meeting_date", "created_at"},
+	Sorts:   []string{"name", "governance_type", "framework", "status", "last_meeting_date", "next_meeting_date", "created_at", "updated_at"},
+}
+
+var controlTestQuery = query.Spec{
+	Filters: []string{"control_id", "control_name", "test_type", "tester", "test_result", "follow_up_required", "test_date", "follow_up_date", "created_at"},
+	Sorts:   []string{"control_name", "test_type", "test_result", "test_date", "follow_up_date", "created_at", "updated_at"},
+}
+
+var evidenceQuery = query.Spec{
+	Filters: []string{"audit_id", "control_id", "evidence_type", "name", "file_type", "collected_by", "status", "upload_date", "created_at"},
+	Sorts:   []string{"name", "evidence_type", "status", "file_size", "upload_date", "created_at", "updated_at"},
+}
+
+var auditReportQuery = query.Spec{
+	Filters: []string{"audit_id", "report_name", "report_type", "framework", "overall_rating", "status", "prepared_by", "report_date", "period_start", "period_end", "created_at"},
+	Sorts:   []string{"report_name", "report_type", "framework", "overall_rating", "status", "report_date", "created_at", "updated_at"},
+}
+
+var documentQuery = query.Spec{
+	Filters: []string{"title", "document_type", "template_type", "file_format", "status", "is_generated", "ai_model", "created_by", "created_at"},
+	Sorts:   []string{"title", "document_type", "template_type", "status", "file_size", "created_at", "updated_at"},
+}
+
+var documentAnalysisQuery = query.Spec{
+	Filters: []string{"document_id", "analysis_type", "confidence_score", "ai_model", "created_at"},
+	Sorts:   []string{"analysis_type", "confidence_score", "created_at", "updated_at"},
+}
+
+// resourceQueries maps the names in models.TenantResources to their list
+// spec, for endpoints that work on any resource such as export.
+var resourceQueries = map[string]query.Spec{
+	"regulation":            regulationQuery,
+	"compliance_assessment": complianceAssessmentQuery,
+	"gap_analysis":          gapAnalysisQuery,
+	"obligation":            obligationQuery,
+	"policy":                policyQuery,
+	"control":               regOpsControlQuery,
+	"data_inventory":        dataInventoryQuery,
+	"dsr":                   dsrQuery,
+	"dpia":                  dpiaQuery,
+	"privacy_control":       privacyControlQuery,
+	"incident":              incidentQuery,
+	"risk":                  riskRegisterQuery,
+	"vulnerability":         vulnerabilityQuery,
+	"vendor":                vendorQuery,
+	"continuity_plan":       continuityQuery,
+	"audit_plan":            auditPlanQuery,
+	"governance":            governanceQuery,
+	"evidence":              evidenceQuery,
+	"control_test":          controlTestQuery,
+	"audit_report":          auditReportQuery,
+	"document":              documentQuery,
+	"document_analysis":     documentAnalysisQuery,
+}
+
+// respondListError maps errors from the query layer to a response.
+// Invalid filters, sorts or cursors are the caller's fault and return 400.
+func respondListError(c *gin.Context, err error, message string) {
+	if query.IsInvalid(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
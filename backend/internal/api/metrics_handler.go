@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/metrics"
+	"github.com/cyber/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Period returned when the trend request has no 'from'
+const defaultTrendPeriod = 30 * 24 * time.Hour
+
+type MetricsHandler struct {
+	db *gorm.DB
+}
+
+func NewMetricsHandler(db *gorm.DB) *MetricsHandler {
+	return &MetricsHandler{db: db}
+}
+
+// GetKPITrends returns the tenant's hourly KPI snapshots, oldest first.
+// Query params: metric (one of the KPI names; all when empty), from/to
+// (YYYY-MM-DD, default the last 30 days).
+func (h *MetricsHandler) GetKPITrends(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+
+	names := metrics.KPINames
+	if metric := c.Query("metric"); metric != "" {
+		valid := false
+		for _, name := range metrics.KPINames {
+			valid = valid || name == metric
+		}
+		if !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown metric", "metrics": metrics.KPINames})
+			return
+		}
+		names = []string{metric}
+	}
+
+	to := time.Now()
+	from := to.Add(-defaultTrendPeriod)
+	for param, dest := range map[string]*time.Time{"from": &from, "to": &to} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid '" + param + "' date, expected YYYY-MM-DD"})
+				return
+			}
+			*dest = t
+		}
+	}
+	if c.Query("to") != "" {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	var snapshots []models.SystemMetric
+	if err := requestDB(c, h.db).Where("tenant_id = ? AND metric_name IN ? AND timestamp >= ? AND timestamp < ?", tenantID, names, from, to).
+		Order("timestamp").Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch KPI trends"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "KPI trends retrieved successfully",
+		"data":    snapshots,
+	})
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/crypto"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/notify"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var notificationQuery = query.Spec{
+	Filters:     []string{"template", "priority", "resource_type", "resource_id", "created_at"},
+	Sorts:       []string{"created_at"},
+	DefaultSort: "-created_at",
+}
+
+type notificationTemplateRequest struct {
+	Subject string `json:"subject" binding:"required"`
+	Body    string `json:"body" binding:"required"`
+}
+
+// notificationTemplateView is the template in effect for a key and language
+type notificationTemplateView struct {
+	Key      string          `json:"key"`
+	Language string          `json:"language"`
+	Template notify.Template `json:"template"`
+	Custom   bool            `json:"custom"` // false when the built-in template applies
+}
+
+type chatChannelRequest struct {
+	Name   string `json:"name" binding:"required"`
+	URL    string `json:"url" binding:"required"`
+	Active *bool  `json:"active"`
+}
+
+type NotificationHandler struct {
+	db      *gorm.DB
+	service *notify.Service
+}
+
+func NewNotificationHandler(db *gorm.DB, service *notify.Service) *NotificationHandler {
+	return &NotificationHandler{db: db, service: service}
+}
+
+// inbox returns the caller's notifications
+func (h *NotificationHandler) inbox(c *gin.Context) *gorm.DB {
+	return requestDB(c, h.db).Model(&models.Notification{}).
+		Where("tenant_id = ? AND user_id = ?", c.GetString("tenant_id"), c.GetString("user_id"))
+}
+
+// GetNotifications lists the caller's inbox, newest first, with the count of
+// unread notices. unread=true lists the unread ones only.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	base := h.inbox(c)
+	if c.Query("unread") == "true" {
+		base = base.Where("read_at IS NULL")
+	}
+	var list []models.Notification
+	page, err := query.List(c, base, notificationQuery, &list)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch notifications")
+		return
+	}
+	var unread int64
+	if err := h.inbox(c).Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       list,
+		"unread":     unread,
+		"pagination": page,
+	})
+}
+
+// MarkNotificationRead marks one of the caller's notifications read
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	var n models.Notification
+	if err := h.inbox(c).Where("id = ?", c.Param("id")).First(&n).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if n.ReadAt == nil {
+		if err := requestDB(c, h.db).Model(&n).Update("read_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notification marked as read",
+		"data":    n,
+	})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the caller
+// read
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	result := h.inbox(c).Where("read_at IS NULL").Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notifications marked as read",
+		"data":    gin.H{"updated": result.RowsAffected},
+	})
+}
+
+// currentUser loads the caller. It writes the error response and returns
+// false when the user is gone.
+func (h *NotificationHandler) currentUser(c *gin.Context, user *models.User) bool {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ?", c.GetString("user_id"), c.GetString("tenant_id")).
+		First(user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return false
+	}
+	return true
+}
+
+// GetNotificationPreferences returns the caller's notification settings
+func (h *NotificationHandler) GetNotificationPreferences(c *gin.Context) {
+	var user models.User
+	if !h.currentUser(c, &user) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": notify.LoadPreferences(&user)})
+}
+
+// SetNotificationPreferences sets the caller's notification settings.
+// Omitted fields keep their values.
+func (h *NotificationHandler) SetNotificationPreferences(c *gin.Context) {
+	var user models.User
+	if !h.currentUser(c, &user) {
+		return
+	}
+	prefs := notify.LoadPreferences(&user)
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := notify.ValidatePreferences(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	gdb := requestDB(c, h.db)
+	if prefs.Channels[notify.ChannelChat] {
+		var count int64
+		gdb.Model(&models.ChatChannel{}).Where("id = ? AND tenant_id = ? AND is_deleted = ? AND active = ?",
+			prefs.ChatChannelID, user.TenantID, false, true).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Chat channel not found or inactive"})
+			return
+		}
+	}
+
+	if err := notify.StorePreferences(&user, prefs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification preferences"})
+		return
+	}
+	if err := gdb.Model(&user).Update("preferences", user.Preferences).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notification preferences saved successfully",
+		"data":    prefs,
+	})
+}
+
+// notificationTemplateParams resolves the :key and :language params. It
+// writes the error response and returns false for an unknown template.
+func notificationTemplateParams(c *gin.Context) (key, language string, ok bool) {
+	key, language = c.Param("key"), c.Param("language")
+	if _, ok = notify.BuiltinTemplate(key, language); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown notification template or language"})
+	}
+	return key, language, ok
+}
+
+// GetNotificationTemplates lists the template in effect for every notice
+// and language
+func (h *NotificationHandler) GetNotificationTemplates(c *gin.Context) {
+	gdb := requestDB(c, h.db)
+	tenantID := c.GetString("tenant_id")
+	var views []notificationTemplateView
+	for _, key := range notify.TemplateKeys() {
+		for _, language := range notify.Languages {
+			t, custom, err := notify.LookupTemplate(gdb, tenantID, key, language)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification templates"})
+				return
+			}
+			views = append(views, notificationTemplateView{Key: key, Language: language, Template: t, Custom: custom})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": views})
+}
+
+// SetNotificationTemplate overrides a notice's template in one language for
+// the tenant
+func (h *NotificationHandler) SetNotificationTemplate(c *gin.Context) {
+	key, language, ok := notificationTemplateParams(c)
+	if !ok {
+		return
+	}
+	var req notificationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := notify.ValidateTemplate(notify.Template{Subject: req.Subject, Body: req.Body}); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gdb := requestDB(c, h.db)
+	tenantID := c.GetString("tenant_id")
+	var tmpl models.NotificationTemplate
+	err := gdb.Where("tenant_id = ? AND key = ? AND language = ?", tenantID, key, language).First(&tmpl).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		tmpl = models.NotificationTemplate{
+			TenantID:  tenantID,
+			Key:       key,
+			Language:  language,
+			Subject:   req.Subject,
+			Body:      req.Body,
+			UpdatedBy: c.GetString("user_id"),
+		}
+		err = gdb.Create(&tmpl).Error
+	case err == nil:
+		err = gdb.Model(&tmpl).Updates(map[string]interface{}{
+			"subject":    req.Subject,
+			"body":       req.Body,
+			"updated_by": c.GetString("user_id"),
+		}).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notification template saved successfully",
+		"data":    tmpl,
+	})
+}
+
+// DeleteNotificationTemplate removes the tenant's override of a template,
+// so the built-in one applies again
+func (h *NotificationHandler) DeleteNotificationTemplate(c *gin.Context) {
+	key, language, ok := notificationTemplateParams(c)
+	if !ok {
+		return
+	}
+	result := requestDB(c, h.db).Where("tenant_id = ? AND key = ? AND language = ?", c.GetString("tenant_id"), key, language).
+		Delete(&models.NotificationTemplate{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification template"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notification template deleted successfully",
+	})
+}
+
+// validate checks the channel's webhook URL, which must not point into the
+// server's own network. It writes the error response and returns false on
+// failure.
+func (req *chatChannelRequest) validate(c *gin.Context) bool {
+	if err := egress.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *NotificationHandler) findChatChannel(c *gin.Context) (*models.ChatChannel, bool) {
+	var chat models.ChatChannel
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), c.GetString("tenant_id"), false).
+		First(&chat).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chat channel not found"})
+		return nil, false
+	}
+	return &chat, true
+}
+
+// GetChatChannels lists the tenant's chat channels. Their URLs, which are
+// credentials, are not returned.
+func (h *NotificationHandler) GetChatChannels(c *gin.Context) {
+	var channels []models.ChatChannel
+	if err := requestDB(c, h.db).Where("tenant_id = ? AND is_deleted = ?", c.GetString("tenant_id"), false).
+		Order("name").Find(&channels).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch chat channels"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": channels})
+}
+
+// CreateChatChannel adds a chat incoming webhook users can receive notices
+// on
+func (h *NotificationHandler) CreateChatChannel(c *gin.Context) {
+	var req chatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.validate(c) {
+		return
+	}
+	encrypted, err := crypto.Encrypt(req.URL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt URL"})
+		return
+	}
+
+	chat := models.ChatChannel{
+		TenantID:  c.GetString("tenant_id"),
+		Name:      req.Name,
+		URL:       encrypted,
+		Active:    req.Active == nil || *req.Active,
+		CreatedBy: c.GetString("user_id"),
+	}
+	if err := requestDB(c, h.db).Create(&chat).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create chat channel"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Chat channel created successfully",
+		"data":    chat,
+	})
+}
+
+// UpdateChatChannel renames a chat channel, replaces its URL or turns it on
+// or off
+func (h *NotificationHandler) UpdateChatChannel(c *gin.Context) {
+	chat, ok := h.findChatChannel(c)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(c, chat) {
+		return
+	}
+	var req chatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.validate(c) {
+		return
+	}
+	encrypted, err := crypto.Encrypt(req.URL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt URL"})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name": req.Name,
+		"url":  encrypted,
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if err := requestDB(c, h.db).Model(chat).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, chat, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update chat channel"})
+		return
+	}
+
+	setETag(c, chat)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Chat channel updated successfully",
+		"data":    chat,
+	})
+}
+
+// DeleteChatChannel removes a chat channel. Users who chose it stop
+// receiving notices by chat.
+func (h *NotificationHandler) DeleteChatChannel(c *gin.Context) {
+	chat, ok := h.findChatChannel(c)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(c, chat) {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if err := requestDB(c, h.db).Model(chat).Updates(map[string]interface{}{
+		"is_deleted": true,
+		"deleted_by": &userID,
+		"active":     false,
+	}).Error; err != nil {
+		if staleWrite(c, h.db, chat, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete chat channel"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Chat channel deleted successfully",
+	})
+}
+
+// TestChatChannel posts a test message to a chat channel and reports
+// whether it was accepted
+func (h *NotificationHandler) TestChatChannel(c *gin.Context) {
+	chat, ok := h.findChatChannel(c)
+	if !ok {
+		return
+	}
+	if err := h.service.TestChat(c.Request.Context(), chat); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Test message posted successfully",
+	})
+}
@@ -0,0 +1,387 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/export"
+	"github.com/cyber/backend/internal/mergepatch"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/notify"
+	"github.com/cyber/backend/internal/openapi"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/cyber/backend/internal/regulations"
+	"github.com/cyber/backend/internal/retention"
+	"github.com/cyber/backend/internal/search"
+)
+
+// Helpers for the common shapes of the sub-module handlers, which wrap their
+// payload in {"success", "message", "data"}
+
+func listEndpoint(handler, items interface{}, spec query.Spec) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Response: items, Envelope: true, Query: &spec}
+}
+
+func createEndpoint(handler, request, record interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Request: request, Response: record, Envelope: true}
+}
+
+func updateEndpoint(handler, request, record interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Request: request, Response: record, Envelope: true}
+}
+
+// patchEndpoint accepts any subset of the record's fields as a merge patch
+func patchEndpoint(handler, record interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Request: map[string]interface{}{}, Response: record, Envelope: true,
+		Consumes: mergepatch.ContentType}
+}
+
+func actionEndpoint(handler, record interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Response: record, Envelope: true, Status: http.StatusOK}
+}
+
+// jobEndpoint queues a background job and returns it
+func jobEndpoint(handler interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Response: models.Job{}, Envelope: true, Status: http.StatusAccepted}
+}
+
+func deleteEndpoint(handler interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Envelope: true}
+}
+
+func statsEndpoint(handler interface{}) openapi.Endpoint {
+	return openapi.Endpoint{Handler: handler, Response: map[string]interface{}{}, Envelope: true}
+}
+
+// OpenAPIEndpoints describes the request and response types of the routed
+// handlers. Routes missing from this list are still documented, with
+// untyped bodies.
+func OpenAPIEndpoints() []openapi.Endpoint {
+	return []openapi.Endpoint{
+		// Auth
+		{Handler: (*AuthHandler).Login, Request: loginRequest{}, Response: map[string]interface{}{}, Public: true, Status: http.StatusOK},
+		{Handler: (*AuthHandler).Register, Request: registerRequest{}, Response: map[string]interface{}{}, Public: true},
+
+		// Probes
+		{Handler: (*HealthHandler).Healthz, Response: map[string]interface{}{}, Public: true},
+		{Handler: (*HealthHandler).Readyz, Response: map[string]interface{}{}, Public: true,
+			Description: "503 with the failing checks when the server should not take traffic"},
+
+		// Tenants
+		{Handler: (*TenantHandler).GetAll, Response: []models.Tenant{}},
+		{Handler: (*TenantHandler).GetByID, Response: models.Tenant{}},
+		{Handler: (*TenantHandler).Create, Request: models.Tenant{}, Response: models.Tenant{}},
+		{Handler: (*TenantHandler).Update, Request: models.Tenant{}, Response: models.Tenant{}},
+		{Handler: (*TenantHandler).Delete},
+
+		// Search, import and export
+		{Handler: (*SearchHandler).Search, Response: []search.Hit{}, Envelope: true},
+		{Handler: (*MetricsHandler).GetKPITrends, Response: []models.SystemMetric{}, Envelope: true,
+			Description: "metric is open_critical_risks, overdue_dsrs or failing_control_tests (all when empty); from/to are YYYY-MM-DD, default the last 30 days"},
+		listEndpoint((*AuditHandler).GetAuditLog, []models.AuditLog{}, auditLogQuery),
+		{Handler: (*AuditHandler).VerifyAuditLog, Response: audit.Report{}, Envelope: true,
+			Description: "Walks the tenant's hash-chained audit log and its signed checkpoints. data.break is the first broken link when data.valid is false."},
+		listEndpoint((*VersionHandler).GetVersions, []models.RecordVersion{}, recordVersionQuery),
+		actionEndpoint((*VersionHandler).GetVersion, models.RecordVersion{}),
+		{Handler: (*VersionHandler).DiffVersions, Response: versionDiff{}, Envelope: true,
+			Description: "from and to are version numbers; to defaults to the latest version and from to the one before to"},
+		{Handler: (*VersionHandler).RevertVersion, Response: map[string]interface{}{}, Envelope: true, Status: http.StatusOK,
+			Description: "Writes the content of the version to the record as a new change. Honours If-Match."},
+		{Handler: (*TrashHandler).GetTrash, Response: []map[string]interface{}{}, Envelope: true, Query: &trashQuery,
+			Description: "Records of the resource in the trash, most recently deleted first. Requires the view permission of the resource's domain."},
+		{Handler: (*TrashHandler).RestoreFromTrash, Response: map[string]interface{}{}, Envelope: true, Status: http.StatusOK,
+			Description: "Restores the record with the children deleted along with it. Fails with 409 while its parent is in the trash. Honours If-Match."},
+		{Handler: (*TrashHandler).PermanentDelete, Envelope: true,
+			Description: "Permanently deletes the record and its children in the trash. Fails with 409 while a legal hold covers the record's trash class or its children's. Requires the delete permission of the resource's domain. Honours If-Match."},
+		{Handler: (*RetentionHandler).GetRetention, Response: []retention.Setting{}, Envelope: true,
+			Description: "Retention of every data class: the tenant's own period, the platform default, the period applied (kept forever when null) and whether a legal hold covers it"},
+		updateEndpoint((*RetentionHandler).SetRetention, setRetentionRequest{}, models.RetentionPolicy{}),
+		deleteEndpoint((*RetentionHandler).DeleteRetention),
+		listEndpoint((*RetentionHandler).GetLegalHolds, []models.LegalHold{}, legalHoldQuery),
+		{Handler: (*RetentionHandler).CreateLegalHold, Request: legalHoldRequest{}, Response: models.LegalHold{}, Envelope: true,
+			Description: "Suspends purging of the data class until the hold is released; an empty data_class holds every class"},
+		actionEndpoint((*RetentionHandler).ReleaseLegalHold, models.LegalHold{}),
+		{Handler: (*RetentionHandler).GetDefaultRetention, Response: []retention.Setting{}, Envelope: true},
+		updateEndpoint((*RetentionHandler).SetDefaultRetention, setRetentionRequest{}, models.RetentionPolicy{}),
+		deleteEndpoint((*RetentionHandler).DeleteDefaultRetention),
+		{Handler: (*RetentionHandler).PurgeNow, Response: models.Job{}, Envelope: true, Status: http.StatusAccepted,
+			Description: "Queues the scheduled purge to run now. Its reports are written to each tenant's audit log."},
+		listEndpoint((*JobHandler).GetJobs, []models.Job{}, jobQuery),
+		actionEndpoint((*JobHandler).GetJob, models.Job{}),
+		{Handler: (*JobHandler).RetryJob, Response: models.Job{}, Envelope: true, Status: http.StatusOK,
+			Description: "Runs a failed or cancelled job again with its attempts reset; 409 for jobs in another status"},
+		{Handler: (*JobHandler).CancelJob, Response: models.Job{}, Envelope: true, Status: http.StatusOK,
+			Description: "Cancels a pending or running job; a running job is stopped within a minute. 409 for finished jobs."},
+		listEndpoint((*JobHandler).GetPlatformJobs, []models.Job{}, jobQuery),
+		actionEndpoint((*JobHandler).GetPlatformJob, models.Job{}),
+		actionEndpoint((*JobHandler).RetryPlatformJob, models.Job{}),
+		actionEndpoint((*JobHandler).CancelPlatformJob, models.Job{}),
+		{Handler: (*JobHandler).GetJobSchedules, Response: []models.JobSchedule{}, Envelope: true},
+		listEndpoint((*ReminderHandler).GetReminders, []models.Reminder{}, reminderQuery),
+		{Handler: (*ReminderHandler).GetReminderRules, Response: []reminderRuleView{}, Envelope: true,
+			Description: "The rule in effect for every source of deadlines; custom is false where the default rule applies"},
+		{Handler: (*ReminderHandler).SetReminderRule, Request: reminderRuleRequest{}, Response: models.ReminderRule{}, Envelope: true,
+			Description: "Owners are reminded days_before days ahead of the date and escalated to escalate_to (manager or tenant_admins) escalate_after_days after it. Omitted fields take the default rule's."},
+		deleteEndpoint((*ReminderHandler).DeleteReminderRule),
+		actionEndpoint((*ReminderHandler).GetReminderSettings, models.ReminderSettings{}),
+		{Handler: (*ReminderHandler).SetReminderSettings, Request: reminderSettingsRequest{}, Response: models.ReminderSettings{}, Envelope: true,
+			Description: "timezone is an IANA zone, Asia/Jakarta by default; quiet_start and quiet_end are HH:MM and may span midnight. Empty quiet hours turn them off."},
+		{Handler: (*NotificationHandler).GetNotifications, Response: []models.Notification{}, Envelope: true, Query: &notificationQuery,
+			Description: "The caller's inbox, newest first; unread=true lists unread notices only. The response's unread is the count of unread notices."},
+		actionEndpoint((*NotificationHandler).MarkNotificationRead, models.Notification{}),
+		actionEndpoint((*NotificationHandler).MarkAllNotificationsRead, map[string]interface{}{}),
+		{Handler: (*NotificationHandler).GetNotificationPreferences, Response: notify.Preferences{}, Envelope: true},
+		{Handler: (*NotificationHandler).SetNotificationPreferences, Request: notify.Preferences{}, Response: notify.Preferences{}, Envelope: true,
+			Description: "language is id or en; channels turns email and chat on or off, chat needing chat_channel_id; digest sends low-priority notices in one daily digest. Omitted fields keep their values."},
+		{Handler: (*NotificationHandler).GetNotificationTemplates, Response: []notificationTemplateView{}, Envelope: true,
+			Description: "The template in effect for every notice and language; custom is false where the built-in template applies"},
+		{Handler: (*NotificationHandler).SetNotificationTemplate, Request: notificationTemplateRequest{}, Response: models.NotificationTemplate{}, Envelope: true,
+			Description: "subject and body are Go text/template templates over the payload of the event raising the notice and recipient, the user's first name. date formats a timestamp as YYYY-MM-DD."},
+		deleteEndpoint((*NotificationHandler).DeleteNotificationTemplate),
+		{Handler: (*NotificationHandler).GetChatChannels, Response: []models.ChatChannel{}, Envelope: true},
+		{Handler: (*NotificationHandler).CreateChatChannel, Request: chatChannelRequest{}, Response: models.ChatChannel{}, Envelope: true,
+			Description: "url is the channel's incoming webhook, posted {\"text\": ...}; it is stored encrypted and never returned"},
+		updateEndpoint((*NotificationHandler).UpdateChatChannel, chatChannelRequest{}, models.ChatChannel{}),
+		deleteEndpoint((*NotificationHandler).DeleteChatChannel),
+		{Handler: (*NotificationHandler).TestChatChannel, Envelope: true, Status: http.StatusOK,
+			Description: "Posts a test message to the channel; 502 with the channel's answer when it is refused"},
+		{Handler: (*StreamHandler).CreateStreamTicket, Response: streamTicketResponse{}, Envelope: true,
+			Description: "Issues a ticket for the ticket query parameter of the event stream. It works once and expires after 30 seconds."},
+		{Handler: (*StreamHandler).Stream, Response: realtime.Message{}, Produces: "text/event-stream", Public: true,
+			Description: "Server-Sent Events stream of change notifications the ticket's holder may see. The event name is the message type. " +
+				"Open it with a new ticket from /stream/tickets each time; 401 when the ticket is missing, used or expired."},
+		{Handler: (*ImportHandler).GetTemplate, Produces: "text/csv"},
+		{Handler: (*ImportHandler).Import, Response: models.ImportJob{}, Envelope: true,
+			Description: "multipart/form-data with file (CSV or XLSX), mapping (JSON object of column to field) and dry_run"},
+		listEndpoint((*ImportHandler).GetImportJobs, []models.ImportJob{}, importJobQuery),
+		actionEndpoint((*ImportHandler).GetImportJob, models.ImportJob{}),
+		actionEndpoint((*ImportHandler).RollbackImportJob, models.ImportJob{}),
+		{Handler: (*ExportHandler).Export, Produces: "application/octet-stream",
+			Description: "format is csv, xlsx or ndjson. Accepts the filters and sort of the resource's list endpoint."},
+		{Handler: (*ExportHandler).GetExportColumns, Response: []export.Column{}, Envelope: true},
+		{Handler: (*ExportHandler).UpdateExportColumns, Request: updateExportColumnsRequest{}, Response: exportSettings{}, Envelope: true},
+
+		// Webhooks
+		{Handler: (*WebhookHandler).GetEventTypes, Response: []string{}, Envelope: true},
+		{Handler: (*WebhookHandler).GetWebhooks, Response: []models.WebhookSubscription{}, Envelope: true},
+		actionEndpoint((*WebhookHandler).GetWebhook, models.WebhookSubscription{}),
+		{Handler: (*WebhookHandler).CreateWebhook, Request: webhookRequest{}, Response: models.WebhookSubscription{}, Envelope: true,
+			Description: "The response's top-level secret is the HMAC-SHA256 signing key and is only returned once."},
+		updateEndpoint((*WebhookHandler).UpdateWebhook, webhookRequest{}, models.WebhookSubscription{}),
+		deleteEndpoint((*WebhookHandler).DeleteWebhook),
+		actionEndpoint((*WebhookHandler).PingWebhook, models.WebhookDelivery{}),
+		{Handler: (*WebhookHandler).RotateWebhookSecret, Response: models.WebhookSubscription{}, Envelope: true, Status: http.StatusOK,
+			Description: "The response's top-level secret is the new signing key and is only returned once."},
+		listEndpoint((*WebhookHandler).GetWebhookDeliveries, []models.WebhookDelivery{}, webhookDeliveryQuery),
+		listEndpoint((*WebhookHandler).GetDeadLetters, []models.WebhookDelivery{}, webhookDeliveryQuery),
+		actionEndpoint((*WebhookHandler).GetWebhookDelivery, models.WebhookDelivery{}),
+		actionEndpoint((*WebhookHandler).RedeliverWebhook, models.WebhookDelivery{}),
+
+		// RegOps (legacy handler, bare bodies)
+		{Handler: (*RegOpsHandler).GetRegulations, Response: []models.Regulation{}, Query: &regulationQuery},
+		{Handler: (*RegOpsHandler).GetRegulation, Response: models.Regulation{}},
+		{Handler: (*RegOpsHandler).CreateRegulation, Request: createRegulationRequest{}, Response: models.Regulation{}},
+		{Handler: (*RegOpsHandler).UpdateRegulation, Request: models.Regulation{}, Response: models.Regulation{}},
+		{Handler: (*RegOpsHandler).PatchRegulation, Request: map[string]interface{}{}, Response: models.Regulation{}, Consumes: mergepatch.ContentType},
+		{Handler: (*RegOpsHandler).DeleteRegulation},
+		{Handler: (*RegOpsHandler).GetDeletedRegulations, Response: []models.Regulation{}, Query: &regulationQuery},
+		{Handler: (*RegOpsHandler).RestoreRegulation, Status: http.StatusOK},
+		{Handler: (*RegOpsHandler).PermanentDeleteRegulation},
+		{Handler: (*RegulationClauseHandler).GetRegulationClauses, Response: []regulations.ClauseNode{}, Envelope: true,
+			Description: "The regulation's chapters, sections, articles, clauses, points and requirements as a tree; flat=true lists them in order instead. include_removed=true adds the clauses a later import no longer had."},
+		{Handler: (*RegulationClauseHandler).ImportRegulationClauses, Request: importClausesRequest{}, Response: regulations.Result{}, Envelope: true, Status: http.StatusOK,
+			Description: "format is text, markdown or json. Clauses are matched to earlier imports by ref (ch-I, art-5, art-5.2.a), keeping their IDs and links; clauses no longer present are marked removed. dry_run returns the counts and the parsed tree as preview without saving."},
+		actionEndpoint((*RegulationClauseHandler).GetRegulationClause, clauseDetail{}),
+		{Handler: (*RegOpsHandler).GetComplianceAssessments, Response: []models.ComplianceAssessment{}, Query: &complianceAssessmentQuery},
+		{Handler: (*RegOpsHandler).GetComplianceAssessment, Response: models.ComplianceAssessment{}},
+		{Handler: (*RegOpsHandler).CreateComplianceAssessment, Request: createComplianceAssessmentRequest{}, Response: models.ComplianceAssessment{}},
+		{Handler: (*RegOpsHandler).UpdateComplianceAssessment, Request: models.ComplianceAssessment{}, Response: models.ComplianceAssessment{}},
+		{Handler: (*RegOpsHandler).PatchComplianceAssessment, Request: map[string]interface{}{}, Response: models.ComplianceAssessment{}, Consumes: mergepatch.ContentType},
+		{Handler: (*RegOpsHandler).DeleteComplianceAssessment},
+		{Handler: (*RegOpsHandler).GetDeletedComplianceAssessments, Response: []models.ComplianceAssessment{}, Query: &complianceAssessmentQuery},
+		{Handler: (*RegOpsHandler).RestoreComplianceAssessment, Status: http.StatusOK},
+		{Handler: (*RegOpsHandler).PermanentDeleteComplianceAssessment},
+		{Handler: (*RegOpsHandler).GetPolicies, Response: []models.Policy{}, Query: &policyQuery},
+		{Handler: (*RegOpsHandler).GetPolicy, Response: models.Policy{}},
+		{Handler: (*RegOpsHandler).CreatePolicy, Request: models.Policy{}, Response: models.Policy{}},
+		{Handler: (*RegOpsHandler).UpdatePolicy, Request: models.Policy{}, Response: models.Policy{}},
+		{Handler: (*RegOpsHandler).PatchPolicy, Request: map[string]interface{}{}, Response: models.Policy{}, Consumes: mergepatch.ContentType},
+		{Handler: (*RegOpsHandler).PublishPolicy, Request: publishPolicyRequest{}, Response: models.Policy{}, Status: http.StatusOK,
+			Description: "Sets status active and a semantic version: the first publish keeps a semantic version, later ones bump it (bump: major, minor or patch; default minor) unless version is given."},
+		{Handler: (*RegOpsHandler).DeletePolicy},
+		{Handler: (*RegOpsHandler).GetDeletedPolicies, Response: []models.Policy{}, Query: &policyQuery},
+		{Handler: (*RegOpsHandler).RestorePolicy, Status: http.StatusOK},
+		{Handler: (*RegOpsHandler).PermanentDeletePolicy},
+
+		// RegOps sub-modules
+		listEndpoint((*RegOpsGapAnalysisHandler).GetComplianceGaps, []models.GapAnalysis{}, gapAnalysisQuery),
+		actionEndpoint((*RegOpsGapAnalysisHandler).GetComplianceGap, models.GapAnalysis{}),
+		createEndpoint((*RegOpsGapAnalysisHandler).CreateComplianceGap, createComplianceGapRequest{}, models.GapAnalysis{}),
+		updateEndpoint((*RegOpsGapAnalysisHandler).UpdateComplianceGap, updateComplianceGapRequest{}, models.GapAnalysis{}),
+		patchEndpoint((*RegOpsGapAnalysisHandler).PatchComplianceGap, models.GapAnalysis{}),
+		deleteEndpoint((*RegOpsGapAnalysisHandler).DeleteComplianceGap),
+		statsEndpoint((*RegOpsGapAnalysisHandler).GetGapStats),
+		listEndpoint((*RegOpsObligationMappingHandler).GetObligations, []models.ObligationMapping{}, obligationQuery),
+		actionEndpoint((*RegOpsObligationMappingHandler).GetObligation, models.ObligationMapping{}),
+		createEndpoint((*RegOpsObligationMappingHandler).CreateObligation, createObligationRequest{}, models.ObligationMapping{}),
+		updateEndpoint((*RegOpsObligationMappingHandler).UpdateObligation, updateObligationRequest{}, models.ObligationMapping{}),
+		patchEndpoint((*RegOpsObligationMappingHandler).PatchObligation, models.ObligationMapping{}),
+		deleteEndpoint((*RegOpsObligationMappingHandler).DeleteObligation),
+		{Handler: (*RegOpsObligationMappingHandler).LinkObligationClause, Request: linkClauseRequest{}, Response: models.ObligationMapping{}, Envelope: true,
+			Description: "Links the obligation to the regulation clause it derives from, and to the clause's regulation; an empty clause_id unlinks it."},
+		statsEndpoint((*RegOpsObligationMappingHandler).GetObligationStats),
+		listEndpoint((*RegOpsControlsHandler).GetControls, []models.RegOpsControl{}, regOpsControlQuery),
+		actionEndpoint((*RegOpsControlsHandler).GetControl, models.RegOpsControl{}),
+		createEndpoint((*RegOpsControlsHandler).CreateControl, createControlRequest{}, models.RegOpsControl{}),
+		updateEndpoint((*RegOpsControlsHandler).UpdateControl, updateControlRequest{}, models.RegOpsControl{}),
+		patchEndpoint((*RegOpsControlsHandler).PatchControl, models.RegOpsControl{}),
+		deleteEndpoint((*RegOpsControlsHandler).DeleteControl),
+		{Handler: (*RegOpsControlsHandler).LinkControlClause, Request: linkClauseRequest{}, Response: models.RegOpsControl{}, Envelope: true,
+			Description: "Links the control to a regulation clause and its regulation; an empty clause_id unlinks it."},
+		statsEndpoint((*RegOpsControlsHandler).GetControlStats),
+
+		// PrivacyOps
+		listEndpoint((*PrivacyOpsDataInventoryHandler).GetDataInventory, []models.DataInventory{}, dataInventoryQuery),
+		actionEndpoint((*PrivacyOpsDataInventoryHandler).GetDataItem, models.DataInventory{}),
+		createEndpoint((*PrivacyOpsDataInventoryHandler).CreateDataItem, createDataItemRequest{}, models.DataInventory{}),
+		updateEndpoint((*PrivacyOpsDataInventoryHandler).UpdateDataItem, updateDataItemRequest{}, models.DataInventory{}),
+		patchEndpoint((*PrivacyOpsDataInventoryHandler).PatchDataItem, models.DataInventory{}),
+		deleteEndpoint((*PrivacyOpsDataInventoryHandler).DeleteDataItem),
+		statsEndpoint((*PrivacyOpsDataInventoryHandler).GetDataInventoryStats),
+		listEndpoint((*PrivacyOpsRoPAHandler).GetProcessingActivities, []models.DataInventory{}, dataInventoryQuery),
+		actionEndpoint((*PrivacyOpsRoPAHandler).GetProcessingActivity, models.DataInventory{}),
+		createEndpoint((*PrivacyOpsRoPAHandler).CreateProcessingActivity, createProcessingActivityRequest{}, models.DataInventory{}),
+		updateEndpoint((*PrivacyOpsRoPAHandler).UpdateProcessingActivity, updateProcessingActivityRequest{}, models.DataInventory{}),
+		patchEndpoint((*PrivacyOpsRoPAHandler).PatchProcessingActivity, models.DataInventory{}),
+		deleteEndpoint((*PrivacyOpsRoPAHandler).DeleteProcessingActivity),
+		statsEndpoint((*PrivacyOpsRoPAHandler).GetRoPAStats),
+		listEndpoint((*PrivacyOpsDSRHandler).GetDSRs, []models.DSRRequest{}, dsrQuery),
+		actionEndpoint((*PrivacyOpsDSRHandler).GetDSR, models.DSRRequest{}),
+		createEndpoint((*PrivacyOpsDSRHandler).CreateDSR, createDSRRequest{}, models.DSRRequest{}),
+		updateEndpoint((*PrivacyOpsDSRHandler).UpdateDSR, updateDSRRequest{}, models.DSRRequest{}),
+		patchEndpoint((*PrivacyOpsDSRHandler).PatchDSR, models.DSRRequest{}),
+		deleteEndpoint((*PrivacyOpsDSRHandler).DeleteDSR),
+		actionEndpoint((*PrivacyOpsDSRHandler).ApproveDSR, models.DSRRequest{}),
+		actionEndpoint((*PrivacyOpsDSRHandler).RejectDSR, models.DSRRequest{}),
+		statsEndpoint((*PrivacyOpsDSRHandler).GetDSRStats),
+		listEndpoint((*PrivacyOpsDPIAHandler).GetDPIAs, []models.DPIA{}, dpiaQuery),
+		actionEndpoint((*PrivacyOpsDPIAHandler).GetDPIA, models.DPIA{}),
+		createEndpoint((*PrivacyOpsDPIAHandler).CreateDPIA, createDPIARequest{}, models.DPIA{}),
+		updateEndpoint((*PrivacyOpsDPIAHandler).UpdateDPIA, updateDPIARequest{}, models.DPIA{}),
+		patchEndpoint((*PrivacyOpsDPIAHandler).PatchDPIA, models.DPIA{}),
+		deleteEndpoint((*PrivacyOpsDPIAHandler).DeleteDPIA),
+		actionEndpoint((*PrivacyOpsDPIAHandler).ApproveDPIA, models.DPIA{}),
+		statsEndpoint((*PrivacyOpsDPIAHandler).GetDPIAStats),
+		listEndpoint((*PrivacyOpsControlsHandler).GetPrivacyControls, []models.PrivacyControl{}, privacyControlQuery),
+		actionEndpoint((*PrivacyOpsControlsHandler).GetPrivacyControl, models.PrivacyControl{}),
+		createEndpoint((*PrivacyOpsControlsHandler).CreatePrivacyControl, createPrivacyControlRequest{}, models.PrivacyControl{}),
+		updateEndpoint((*PrivacyOpsControlsHandler).UpdatePrivacyControl, updatePrivacyControlRequest{}, models.PrivacyControl{}),
+		patchEndpoint((*PrivacyOpsControlsHandler).PatchPrivacyControl, models.PrivacyControl{}),
+		deleteEndpoint((*PrivacyOpsControlsHandler).DeletePrivacyControl),
+		statsEndpoint((*PrivacyOpsControlsHandler).GetPrivacyControlsStats),
+		listEndpoint((*PrivacyOpsIncidentHandler).GetIncidents, []models.Incident{}, incidentQuery),
+		actionEndpoint((*PrivacyOpsIncidentHandler).GetIncident, models.Incident{}),
+		createEndpoint((*PrivacyOpsIncidentHandler).CreateIncident, createIncidentRequest{}, models.Incident{}),
+		updateEndpoint((*PrivacyOpsIncidentHandler).UpdateIncident, updateIncidentRequest{}, models.Incident{}),
+		patchEndpoint((*PrivacyOpsIncidentHandler).PatchIncident, models.Incident{}),
+		deleteEndpoint((*PrivacyOpsIncidentHandler).DeleteIncident),
+		actionEndpoint((*PrivacyOpsIncidentHandler).ResolveIncident, models.Incident{}),
+		statsEndpoint((*PrivacyOpsIncidentHandler).GetIncidentStats),
+
+		// RiskOps
+		listEndpoint((*RiskOpsERMHandler).GetRiskRegister, []models.RiskRegister{}, riskRegisterQuery),
+		actionEndpoint((*RiskOpsERMHandler).GetRisk, models.RiskRegister{}),
+		createEndpoint((*RiskOpsERMHandler).CreateRisk, createRiskRequest{}, models.RiskRegister{}),
+		updateEndpoint((*RiskOpsERMHandler).UpdateRisk, updateRiskRequest{}, models.RiskRegister{}),
+		patchEndpoint((*RiskOpsERMHandler).PatchRisk, models.RiskRegister{}),
+		actionEndpoint((*RiskOpsERMHandler).CloseRisk, models.RiskRegister{}),
+		statsEndpoint((*RiskOpsERMHandler).GetRiskStats),
+		listEndpoint((*RiskOpsSecurityHandler).GetVulnerabilities, []models.Vulnerability{}, vulnerabilityQuery),
+		actionEndpoint((*RiskOpsSecurityHandler).GetVulnerability, models.Vulnerability{}),
+		createEndpoint((*RiskOpsSecurityHandler).CreateVulnerability, createVulnerabilityRequest{}, models.Vulnerability{}),
+		updateEndpoint((*RiskOpsSecurityHandler).UpdateVulnerability, updateVulnerabilityRequest{}, models.Vulnerability{}),
+		patchEndpoint((*RiskOpsSecurityHandler).PatchVulnerability, models.Vulnerability{}),
+		deleteEndpoint((*RiskOpsSecurityHandler).DeleteVulnerability),
+		actionEndpoint((*RiskOpsSecurityHandler).ResolveVulnerability, models.Vulnerability{}),
+		statsEndpoint((*RiskOpsSecurityHandler).GetVulnerabilityStats),
+		listEndpoint((*RiskOpsVendorHandler).GetVendors, []models.VendorAssessment{}, vendorQuery),
+		actionEndpoint((*RiskOpsVendorHandler).GetVendor, models.VendorAssessment{}),
+		createEndpoint((*RiskOpsVendorHandler).CreateVendor, createVendorRequest{}, models.VendorAssessment{}),
+		updateEndpoint((*RiskOpsVendorHandler).UpdateVendor, updateVendorRequest{}, models.VendorAssessment{}),
+		patchEndpoint((*RiskOpsVendorHandler).PatchVendor, models.VendorAssessment{}),
+		deleteEndpoint((*RiskOpsVendorHandler).DeleteVendor),
+		statsEndpoint((*RiskOpsVendorHandler).GetVendorStats),
+		listEndpoint((*RiskOpsContinuityHandler).GetContinuityPlans, []models.BusinessContinuity{}, continuityQuery),
+		actionEndpoint((*RiskOpsContinuityHandler).GetContinuityPlan, models.BusinessContinuity{}),
+		createEndpoint((*RiskOpsContinuityHandler).CreateContinuityPlan, createContinuityPlanRequest{}, models.BusinessContinuity{}),
+		updateEndpoint((*RiskOpsContinuityHandler).UpdateContinuityPlan, updateContinuityPlanRequest{}, models.BusinessContinuity{}),
+		patchEndpoint((*RiskOpsContinuityHandler).PatchContinuityPlan, models.BusinessContinuity{}),
+		deleteEndpoint((*RiskOpsContinuityHandler).DeleteContinuityPlan),
+		actionEndpoint((*RiskOpsContinuityHandler).TestContinuityPlan, models.BusinessContinuity{}),
+		statsEndpoint((*RiskOpsContinuityHandler).GetContinuityStats),
+
+		// AuditOps
+		listEndpoint((*AuditOpsInternalAuditHandler).GetInternalAudits, []models.AuditPlan{}, auditPlanQuery),
+		actionEndpoint((*AuditOpsInternalAuditHandler).GetInternalAudit, models.AuditPlan{}),
+		createEndpoint((*AuditOpsInternalAuditHandler).CreateInternalAudit, createInternalAuditRequest{}, models.AuditPlan{}),
+		updateEndpoint((*AuditOpsInternalAuditHandler).UpdateInternalAudit, updateInternalAuditRequest{}, models.AuditPlan{}),
+		patchEndpoint((*AuditOpsInternalAuditHandler).PatchInternalAudit, models.AuditPlan{}),
+		deleteEndpoint((*AuditOpsInternalAuditHandler).DeleteInternalAudit),
+		statsEndpoint((*AuditOpsInternalAuditHandler).GetInternalAuditStats),
+		listEndpoint((*AuditOpsGovernanceHandler).GetKRIs, []models.Governance{}, governanceQuery),
+		actionEndpoint((*AuditOpsGovernanceHandler).GetKRI, models.Governance{}),
+		createEndpoint((*AuditOpsGovernanceHandler).CreateKRI, createKRIRequest{}, models.Governance{}),
+		updateEndpoint((*AuditOpsGovernanceHandler).UpdateKRI, updateKRIRequest{}, models.Governance{}),
+		patchEndpoint((*AuditOpsGovernanceHandler).PatchKRI, models.Governance{}),
+		deleteEndpoint((*AuditOpsGovernanceHandler).DeleteKRI),
+		statsEndpoint((*AuditOpsGovernanceHandler).GetKRIStats),
+		listEndpoint((*AuditOpsContinuousAuditHandler).GetControlTests, []models.ControlTest{}, controlTestQuery),
+		actionEndpoint((*AuditOpsContinuousAuditHandler).GetControlTest, models.ControlTest{}),
+		createEndpoint((*AuditOpsContinuousAuditHandler).CreateControlTest, createControlTestRequest{}, models.ControlTest{}),
+		updateEndpoint((*AuditOpsContinuousAuditHandler).UpdateControlTest, updateControlTestRequest{}, models.ControlTest{}),
+		patchEndpoint((*AuditOpsContinuousAuditHandler).PatchControlTest, models.ControlTest{}),
+		deleteEndpoint((*AuditOpsContinuousAuditHandler).DeleteControlTest),
+		jobEndpoint((*AuditOpsContinuousAuditHandler).RunControlTest),
+		statsEndpoint((*AuditOpsContinuousAuditHandler).GetControlTestStats),
+		listEndpoint((*AuditOpsEvidenceHandler).GetEvidence, []models.AuditEvidence{}, evidenceQuery),
+		actionEndpoint((*AuditOpsEvidenceHandler).GetEvidenceByID, models.AuditEvidence{}),
+		createEndpoint((*AuditOpsEvidenceHandler).CreateEvidence, createEvidenceRequest{}, models.AuditEvidence{}),
+		updateEndpoint((*AuditOpsEvidenceHandler).UpdateEvidence, updateEvidenceRequest{}, models.AuditEvidence{}),
+		patchEndpoint((*AuditOpsEvidenceHandler).PatchEvidence, models.AuditEvidence{}),
+		deleteEndpoint((*AuditOpsEvidenceHandler).DeleteEvidence),
+		actionEndpoint((*AuditOpsEvidenceHandler).ApproveEvidence, models.AuditEvidence{}),
+		actionEndpoint((*AuditOpsEvidenceHandler).RejectEvidence, models.AuditEvidence{}),
+		statsEndpoint((*AuditOpsEvidenceHandler).GetEvidenceStats),
+		listEndpoint((*AuditOpsReportingHandler).GetReports, []models.AuditReport{}, auditReportQuery),
+		actionEndpoint((*AuditOpsReportingHandler).GetReport, models.AuditReport{}),
+		createEndpoint((*AuditOpsReportingHandler).CreateReport, createReportRequest{}, models.AuditReport{}),
+		updateEndpoint((*AuditOpsReportingHandler).UpdateReport, updateReportRequest{}, models.AuditReport{}),
+		patchEndpoint((*AuditOpsReportingHandler).PatchReport, models.AuditReport{}),
+		deleteEndpoint((*AuditOpsReportingHandler).DeleteReport),
+		jobEndpoint((*AuditOpsReportingHandler).GenerateReport),
+		statsEndpoint((*AuditOpsReportingHandler).GetReportStats),
+
+		// Documents (legacy handler, bare bodies)
+		{Handler: (*DocumentHandler).GetDocuments, Response: []models.Document{}, Query: &documentQuery},
+		{Handler: (*DocumentHandler).GetDocumentByID, Response: models.Document{}},
+		{Handler: (*DocumentHandler).GetDocumentAnalyses, Response: []models.DocumentAnalysis{}, Query: &documentAnalysisQuery},
+		{Handler: (*DocumentHandler).GetInfographicHTML, Produces: "text/html"},
+
+		// AI documents
+		listEndpoint((*AIDocumentHandler).GetDocumentTemplates, []models.Document{}, documentQuery),
+		actionEndpoint((*AIDocumentHandler).GetDocumentTemplate, models.Document{}),
+		createEndpoint((*AIDocumentHandler).CreateDocumentTemplate, createDocumentTemplateRequest{}, models.Document{}),
+		updateEndpoint((*AIDocumentHandler).UpdateDocumentTemplate, updateDocumentTemplateRequest{}, models.Document{}),
+		deleteEndpoint((*AIDocumentHandler).DeleteDocumentTemplate),
+		listEndpoint((*AIDocumentHandler).GetGeneratedDocuments, []models.Document{}, documentQuery),
+		actionEndpoint((*AIDocumentHandler).GetGeneratedDocument, models.Document{}),
+		createEndpoint((*AIDocumentHandler).GenerateDocument, generateDocumentRequest{}, models.Document{}),
+		updateEndpoint((*AIDocumentHandler).UpdateGeneratedDocument, updateGeneratedDocumentRequest{}, models.Document{}),
+		deleteEndpoint((*AIDocumentHandler).DeleteGeneratedDocument),
+		listEndpoint((*AIDocumentHandler).GetDocumentAnalyses, []models.DocumentAnalysis{}, documentAnalysisQuery),
+		actionEndpoint((*AIDocumentHandler).GetDocumentAnalysis, models.DocumentAnalysis{}),
+		createEndpoint((*AIDocumentHandler).AnalyzeDocument, analyzeDocumentRequest{}, models.DocumentAnalysis{}),
+		deleteEndpoint((*AIDocumentHandler).DeleteDocumentAnalysis),
+	}
+}
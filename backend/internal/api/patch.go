@@ -0,0 +1,337 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/importer"
+	"github.com/cyber/backend/internal/mergepatch"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// PATCH endpoints apply an RFC 7396 merge patch to the JSON representation
+// of a record, as returned by GET. Unlike PUT, explicit nulls clear a field,
+// so empty strings, zero numbers and missing dates can be stored.
+
+// Maximum size of a merge patch document
+const maxPatchBody = 1 << 20
+
+// Fields managed by the server. A patch may repeat their current value but
+// not change them.
+var immutableFields = map[string]bool{
+	"id":         true,
+	"tenant_id":  true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"deleted_by": true,
+	"is_deleted": true,
+	"revision":   true,
+	"clause_id":  true, // set through the clause link endpoints, which check the clause
+}
+
+// patchHooks recompute derived fields of the merged record
+var patchHooks = map[string]func(record interface{}){
+	"risk": func(record interface{}) {
+		risk := record.(*models.RiskRegister)
+		risk.RiskScore = calculateRiskScore(risk.Likelihood, risk.Impact)
+		risk.RiskLevel = calculateRiskLevel(risk.RiskScore)
+	},
+}
+
+// patchEvents record domain events for a patch in its transaction. oldValues
+// holds the previous values of the changed fields, keyed by JSON name, and
+// record has been updated.
+var patchEvents = map[string]func(tx *gorm.DB, c *gin.Context, oldValues map[string]interface{}, record interface{}) error{
+	"incident": func(tx *gorm.DB, c *gin.Context, oldValues map[string]interface{}, record interface{}) error {
+		if previous, ok := oldValues["severity"].(string); ok {
+			return recordSeverityRaised(tx, c, record.(*models.Incident), previous)
+		}
+		return nil
+	},
+	"evidence": func(tx *gorm.DB, c *gin.Context, oldValues map[string]interface{}, record interface{}) error {
+		evidence := record.(*models.AuditEvidence)
+		if _, changed := oldValues["status"]; !changed {
+			return nil
+		}
+		switch evidence.Status {
+		case "approved":
+			return recordEvent(tx, c, events.EvidenceApproved, "evidence", evidence.ID, evidence)
+		case "rejected":
+			return recordEvent(tx, c, events.EvidenceRejected, "evidence", evidence.ID, evidence)
+		}
+		return nil
+	},
+}
+
+// patchTarget describes the record a PATCH endpoint changes
+type patchTarget struct {
+	resource string
+	// Legacy handlers keep their records in the tenant schema and respond
+	// with the bare record instead of the success envelope
+	legacy bool
+}
+
+// patchFailure is a client error found while applying a patch
+type patchFailure struct {
+	status int
+	body   gin.H
+}
+
+func (f *patchFailure) Error() string {
+	return f.body["error"].(string)
+}
+
+func patchFailed(status int, message string) *patchFailure {
+	return &patchFailure{status: status, body: gin.H{"error": message}}
+}
+
+// patchRecord applies the request's merge patch to the record with the :id
+// param. record is a pointer to a zero value of the resource's model. The
+// merged record is validated with the rules of the resource's create request.
+// The audit callbacks record the change.
+func patchRecord(c *gin.Context, gdb *gorm.DB, target patchTarget, record models.Revisioned) {
+	tenantID := c.GetString("tenant_id")
+	gdb = requestDB(c, gdb)
+	resource, _ := models.LookupResource(target.resource)
+
+	if ct := c.ContentType(); ct != mergepatch.ContentType && ct != "application/json" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + mergepatch.ContentType})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPatchBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Patch must be a JSON object"})
+		return
+	}
+
+	schemaTenant := ""
+	if target.legacy {
+		schemaTenant = tenantID
+	}
+	var changed map[string]interface{}
+	err = (&db.Database{DB: gdb}).TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), tenantID, false).
+			First(record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return patchFailed(http.StatusNotFound, resource.Label+" not found")
+			}
+			return err
+		}
+		if !checkIfMatch(c, record) {
+			return errPatchResponded
+		}
+
+		updates, oldValues, newValues, err := mergeRecord(tx, target.resource, record, patch)
+		if err != nil || len(updates) == 0 {
+			return err
+		}
+		changed = newValues
+
+		if err := tx.Model(record).Updates(updates).Error; err != nil {
+			return err
+		}
+		if recordEvents := patchEvents[target.resource]; recordEvents != nil {
+			return recordEvents(tx, c, oldValues, record)
+		}
+		return nil
+	})
+
+	var failure *patchFailure
+	switch {
+	case errors.Is(err, errPatchResponded):
+		return
+	case errors.As(err, &failure):
+		c.JSON(failure.status, failure.body)
+		return
+	case errors.Is(err, models.ErrStaleRevision):
+		// Lost a race with another write after If-Match was checked
+		if err := (&db.Database{DB: gdb}).TenantTx(schemaTenant, func(tx *gorm.DB) error {
+			return tx.First(record).Error
+		}); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": resource.Label + " not found"})
+			return
+		}
+		preconditionFailed(c, record)
+		return
+	case err != nil:
+		log.Printf("Failed to patch %s %s: %v", resource.Name, c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update " + strings.ToLower(resource.Label)})
+		return
+	}
+
+	if len(changed) > 0 {
+		notifyEvents()
+	}
+
+	setETag(c, record)
+	if target.legacy {
+		c.JSON(http.StatusOK, record)
+		return
+	}
+	message := resource.Label + " updated successfully"
+	if len(changed) == 0 {
+		message = "No changes"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    record,
+	})
+}
+
+// errPatchResponded aborts the transaction after a response was written
+var errPatchResponded = errors.New("response already written")
+
+// mergeRecord merges patch into record and returns the column updates and
+// the field-level diff keyed by JSON name. record is left unchanged.
+func mergeRecord(tx *gorm.DB, resourceName string, record interface{}, patch map[string]interface{}) (map[string]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	sch, err := query.Schema(tx, record)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fields := map[string]*schema.Field{}
+	for _, f := range sch.Fields {
+		if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" && f.DBName != "" {
+			fields[name] = f
+		}
+	}
+
+	before, err := toJSONMap(record)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for name, value := range patch {
+		if _, ok := fields[name]; !ok {
+			return nil, nil, nil, patchFailed(http.StatusBadRequest, "Unknown field: "+name)
+		}
+		if immutableFields[name] && !reflect.DeepEqual(mergepatch.Merge(before[name], value), before[name]) {
+			return nil, nil, nil, patchFailed(http.StatusBadRequest, "Field cannot be changed: "+name)
+		}
+	}
+
+	mergedJSON, err := json.Marshal(mergepatch.Merge(before, patch))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	merged := reflect.New(reflect.TypeOf(record).Elem())
+	if err := json.Unmarshal(mergedJSON, merged.Interface()); err != nil {
+		return nil, nil, nil, patchFailed(http.StatusBadRequest, "Invalid patch: "+err.Error())
+	}
+	if hook := patchHooks[resourceName]; hook != nil {
+		hook(merged.Interface())
+	}
+	errs, err := validateMerged(resourceName, merged.Interface())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(errs) > 0 {
+		return nil, nil, nil, &patchFailure{status: http.StatusBadRequest, body: gin.H{
+			"error":   "Patched record is invalid",
+			"details": errs,
+		}}
+	}
+
+	after, err := toJSONMap(merged.Interface())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	oldValues, newValues := mergepatch.Diff(before, after)
+	updates := map[string]interface{}{}
+	for name := range newValues {
+		f := fields[name]
+		if f == nil || immutableFields[name] {
+			continue
+		}
+		updates[f.DBName], _ = f.ValueOf(tx.Statement.Context, merged.Elem())
+	}
+	return updates, oldValues, newValues, nil
+}
+
+// fieldError is a failed binding rule on a field of the merged record
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// validateMerged checks the merged record against the binding rules of the
+// resource's create request. Request fields are matched to model fields by
+// name; rules that do not apply to the model field's type are skipped. A
+// resource without a create request in importers cannot be patched.
+func validateMerged(resourceName string, record interface{}) ([]fieldError, error) {
+	newRequest, ok := importers[resourceName]
+	if !ok {
+		return nil, fmt.Errorf("no validation rules for %s", resourceName)
+	}
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil, errors.New("binding validator is not go-playground/validator")
+	}
+
+	var errs []fieldError
+	reqType := reflect.TypeOf(newRequest()).Elem()
+	value := reflect.ValueOf(record).Elem()
+	for i := 0; i < reqType.NumField(); i++ {
+		rf := reqType.Field(i)
+		rules := rf.Tag.Get("binding")
+		if rules == "" {
+			continue
+		}
+		mf, ok := value.Type().FieldByName(rf.Name)
+		if !ok {
+			continue
+		}
+		fv := value.FieldByIndex(mf.Index)
+		if rules != "required" && indirectKind(mf.Type) != indirectKind(rf.Type) {
+			continue
+		}
+		if err := engine.Var(fv.Interface(), rules); err != nil {
+			var verrs validator.ValidationErrors
+			if errors.As(err, &verrs) {
+				for _, fe := range verrs {
+					errs = append(errs, fieldError{
+						Field:   strings.Split(mf.Tag.Get("json"), ",")[0],
+						Message: importer.ValidationMessage(fe),
+					})
+				}
+			}
+		}
+	}
+	return errs, nil
+}
+
+func indirectKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// toJSONMap returns the JSON representation of v as a map
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	return out, json.Unmarshal(raw, &out)
+}
@@ -42,43 +42,43 @@ func (h *PlatformHandler) GetPlatformStats(c *gin.Context) {
 	var stats PlatformStats
 
 	// Count tenants
-	h.db.Model(&models.Tenant{}).Where("deleted_at IS NULL").Count(&stats.TotalTenants)
-	h.db.Model(&models.Tenant{}).Where("deleted_at IS NULL AND status = ?", "active").Count(&stats.ActiveTenants)
-	h.db.Model(&models.Tenant{}).Where("deleted_at IS NULL AND status = ?", "suspended").Count(&stats.SuspendedTenants)
+	requestDB(c, h.db.DB).Model(&models.Tenant{}).Where("deleted_at IS NULL").Count(&stats.TotalTenants)
+	requestDB(c, h.db.DB).Model(&models.Tenant{}).Where("deleted_at IS NULL AND status = ?", "active").Count(&stats.ActiveTenants)
+	requestDB(c, h.db.DB).Model(&models.Tenant{}).Where("deleted_at IS NULL AND status = ?", "suspended").Count(&stats.SuspendedTenants)
 
 	// Count users
-	h.db.Model(&models.User{}).Where("deleted_at IS NULL").Count(&stats.TotalUsers)
-	h.db.Model(&models.User{}).Where("deleted_at IS NULL AND status = ?", "active").Count(&stats.ActiveUsers)
+	requestDB(c, h.db.DB).Model(&models.User{}).Where("deleted_at IS NULL").Count(&stats.TotalUsers)
+	requestDB(c, h.db.DB).Model(&models.User{}).Where("deleted_at IS NULL AND status = ?", "active").Count(&stats.ActiveUsers)
 
 	// Count documents
-	h.db.Model(&models.Document{}).Where("deleted_at IS NULL").Count(&stats.TotalDocuments)
+	requestDB(c, h.db.DB).Model(&models.Document{}).Where("deleted_at IS NULL").Count(&stats.TotalDocuments)
 
 	// Count risks
-	h.db.Model(&models.RiskRegister{}).Where("deleted_at IS NULL").Count(&stats.TotalRisks)
+	requestDB(c, h.db.DB).Model(&models.RiskRegister{}).Where("deleted_at IS NULL").Count(&stats.TotalRisks)
 
 	// Count open vulnerabilities
-	h.db.Model(&models.Vulnerability{}).Where("deleted_at IS NULL AND status = ?", "open").Count(&stats.OpenVulnerabilities)
+	requestDB(c, h.db.DB).Model(&models.Vulnerability{}).Where("deleted_at IS NULL AND status = ?", "open").Count(&stats.OpenVulnerabilities)
 
 	// API Usage - today
 	today := time.Now().Truncate(24 * time.Hour)
-	h.db.Model(&models.APIUsage{}).Where("created_at >= ?", today).Count(&stats.APIUsageToday)
+	requestDB(c, h.db.DB).Model(&models.APIUsage{}).Where("created_at >= ?", today).Count(&stats.APIUsageToday)
 
 	// API Usage - this month
 	startOfMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
-	h.db.Model(&models.APIUsage{}).Where("created_at >= ?", startOfMonth).Count(&stats.APIUsageMonth)
+	requestDB(c, h.db.DB).Model(&models.APIUsage{}).Where("created_at >= ?", startOfMonth).Count(&stats.APIUsageMonth)
 
 	// Revenue this month - sum of paid invoices
 	var revenue struct {
 		Total float64
 	}
-	h.db.Model(&models.Invoice{}).
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).
 		Select("COALESCE(SUM(total_amount), 0) as total").
 		Where("status = ? AND paid_date >= ?", "paid", startOfMonth).
 		Scan(&revenue)
 	stats.RevenueMonth = revenue.Total
 
 	// Pending invoices count
-	h.db.Model(&models.Invoice{}).Where("status = ?", "pending").Count(&stats.PendingInvoices)
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).Where("status = ?", "pending").Count(&stats.PendingInvoices)
 
 	// System health check
 	stats.SystemHealth = "healthy"
@@ -102,19 +102,19 @@ type TenantSummary struct {
 // GetTopTenants returns top tenants by usage
 func (h *PlatformHandler) GetTopTenants(c *gin.Context) {
 	var tenants []models.Tenant
-	h.db.Where("deleted_at IS NULL").Order("created_at DESC").Limit(10).Find(&tenants)
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").Order("created_at DESC").Limit(10).Find(&tenants)
 
 	var summaries []TenantSummary
 	for _, t := range tenants {
 		var userCount, docCount, riskCount int64
-		h.db.Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&userCount)
-		h.db.Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&docCount)
-		h.db.Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&riskCount)
+		requestDB(c, h.db.DB).Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&userCount)
+		requestDB(c, h.db.DB).Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&docCount)
+		requestDB(c, h.db.DB).Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&riskCount)
 
 		// Get subscription plan
 		var sub models.Subscription
 		planType := "basic"
-		if err := h.db.Where("tenant_id = ? AND deleted_at IS NULL", t.ID).First(&sub).Error; err == nil {
+		if err := requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).First(&sub).Error; err == nil {
 			planType = sub.PlanType
 		}
 
@@ -136,7 +136,7 @@ func (h *PlatformHandler) GetTopTenants(c *gin.Context) {
 // GetRecentActivity returns recent system activity
 func (h *PlatformHandler) GetRecentActivity(c *gin.Context) {
 	var logs []models.SystemLog
-	h.db.Where("deleted_at IS NULL").
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").
 		Order("created_at DESC").
 		Limit(20).
 		Find(&logs)
@@ -147,7 +147,7 @@ func (h *PlatformHandler) GetRecentActivity(c *gin.Context) {
 // GetSystemAlerts returns active system alerts
 func (h *PlatformHandler) GetSystemAlerts(c *gin.Context) {
 	var alerts []models.SystemLog
-	h.db.Where("deleted_at IS NULL AND level IN ?", []string{"warning", "error", "critical"}).
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL AND level IN ?", []string{"warning", "error", "critical"}).
 		Order("created_at DESC").
 		Limit(10).
 		Find(&alerts)
@@ -160,18 +160,18 @@ func (h *PlatformHandler) GetSystemAlerts(c *gin.Context) {
 // GetAllTenants returns all tenants with pagination
 func (h *PlatformHandler) GetAllTenants(c *gin.Context) {
 	var tenants []models.Tenant
-	h.db.Where("deleted_at IS NULL").Order("created_at DESC").Find(&tenants)
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").Order("created_at DESC").Find(&tenants)
 
 	var result []TenantSummary
 	for _, t := range tenants {
 		var userCount, docCount, riskCount int64
-		h.db.Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&userCount)
-		h.db.Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&docCount)
-		h.db.Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&riskCount)
+		requestDB(c, h.db.DB).Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&userCount)
+		requestDB(c, h.db.DB).Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&docCount)
+		requestDB(c, h.db.DB).Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).Count(&riskCount)
 
 		var sub models.Subscription
 		planType := "basic"
-		if err := h.db.Where("tenant_id = ? AND deleted_at IS NULL", t.ID).First(&sub).Error; err == nil {
+		if err := requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", t.ID).First(&sub).Error; err == nil {
 			planType = sub.PlanType
 		}
 
@@ -226,7 +226,7 @@ func (h *PlatformHandler) GetTenantByID(c *gin.Context) {
 	id := c.Param("id")
 
 	var tenant models.Tenant
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -242,20 +242,20 @@ func (h *PlatformHandler) GetTenantByID(c *gin.Context) {
 	}
 
 	// Get stats
-	h.db.Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.UserCount)
-	h.db.Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.DocCount)
-	h.db.Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.RiskCount)
-	h.db.Model(&models.Vulnerability{}).Where("tenant_id = ? AND deleted_at IS NULL AND status = ?", id, "open").Count(&detail.VulnCount)
+	requestDB(c, h.db.DB).Model(&models.User{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.UserCount)
+	requestDB(c, h.db.DB).Model(&models.Document{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.DocCount)
+	requestDB(c, h.db.DB).Model(&models.RiskRegister{}).Where("tenant_id = ? AND deleted_at IS NULL", id).Count(&detail.RiskCount)
+	requestDB(c, h.db.DB).Model(&models.Vulnerability{}).Where("tenant_id = ? AND deleted_at IS NULL AND status = ?", id, "open").Count(&detail.VulnCount)
 
 	// Get subscription
 	var sub models.Subscription
-	if err := h.db.Where("tenant_id = ? AND deleted_at IS NULL", id).First(&sub).Error; err == nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", id).First(&sub).Error; err == nil {
 		detail.Subscription = &sub
 	}
 
 	// Get users
 	var users []models.User
-	h.db.Where("tenant_id = ? AND deleted_at IS NULL", id).Order("created_at DESC").Limit(50).Find(&users)
+	requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", id).Order("created_at DESC").Limit(50).Find(&users)
 	for _, u := range users {
 		var lastLogin *string
 		if u.LastLogin != nil {
@@ -274,7 +274,7 @@ func (h *PlatformHandler) GetTenantByID(c *gin.Context) {
 	}
 
 	// Get invoices
-	h.db.Where("tenant_id = ? AND deleted_at IS NULL", id).Order("created_at DESC").Limit(20).Find(&detail.Invoices)
+	requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", id).Order("created_at DESC").Limit(20).Find(&detail.Invoices)
 
 	c.JSON(http.StatusOK, detail)
 }
@@ -305,7 +305,7 @@ func (h *PlatformHandler) CreateTenant(c *gin.Context) {
 		tenant.Status = input.Status
 	}
 
-	if err := h.db.Create(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&tenant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
 		return
 	}
@@ -320,7 +320,7 @@ func (h *PlatformHandler) CreateTenant(c *gin.Context) {
 		Price:        1500000,
 		Currency:     "IDR",
 	}
-	h.db.Create(&sub)
+	requestDB(c, h.db.DB).Create(&sub)
 
 	// Create admin user for tenant
 	adminEmail := input.AdminEmail
@@ -351,9 +351,9 @@ func (h *PlatformHandler) CreateTenant(c *gin.Context) {
 		IsSuperAdmin: false,
 	}
 
-	if err := h.db.Create(&adminUser).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&adminUser).Error; err != nil {
 		// If user creation fails, delete the tenant
-		h.db.Delete(&tenant)
+		requestDB(c, h.db.DB).Delete(&tenant)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create admin user. Email may already exist."})
 		return
 	}
@@ -366,7 +366,7 @@ func (h *PlatformHandler) CreateTenant(c *gin.Context) {
 		Action:   "tenant_created",
 		Message:  fmt.Sprintf("New tenant created: %s with admin %s", tenant.Name, adminEmail),
 	}
-	h.db.Create(&log)
+	requestDB(c, h.db.DB).Create(&log)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"tenant": tenant,
@@ -383,7 +383,7 @@ func (h *PlatformHandler) UpdateTenant(c *gin.Context) {
 	id := c.Param("id")
 
 	var tenant models.Tenant
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -413,7 +413,7 @@ func (h *PlatformHandler) UpdateTenant(c *gin.Context) {
 		tenant.Status = input.Status
 	}
 
-	h.db.Save(&tenant)
+	requestDB(c, h.db.DB).Save(&tenant)
 	c.JSON(http.StatusOK, tenant)
 }
 
@@ -422,7 +422,7 @@ func (h *PlatformHandler) ActivateTenant(c *gin.Context) {
 	id := c.Param("id")
 
 	var tenant models.Tenant
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -451,7 +451,7 @@ func (h *PlatformHandler) ActivateTenant(c *gin.Context) {
 
 	// Update or create subscription
 	var subscription models.Subscription
-	if err := h.db.Where("tenant_id = ? AND deleted_at IS NULL", id).First(&subscription).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", id).First(&subscription).Error; err != nil {
 		// Create new subscription
 		subscription = models.Subscription{
 			TenantID: id,
@@ -472,11 +472,11 @@ func (h *PlatformHandler) ActivateTenant(c *gin.Context) {
 	subscription.Price = input.Price
 	subscription.Currency = "IDR"
 
-	h.db.Save(&subscription)
+	requestDB(c, h.db.DB).Save(&subscription)
 
 	// Activate tenant
 	tenant.Status = "active"
-	h.db.Save(&tenant)
+	requestDB(c, h.db.DB).Save(&tenant)
 
 	// Log activation
 	log := models.SystemLog{
@@ -485,7 +485,7 @@ func (h *PlatformHandler) ActivateTenant(c *gin.Context) {
 		Message:  fmt.Sprintf("Tenant '%s' activated until %s", tenant.Name, endDate.Format("2006-01-02")),
 		Details:  fmt.Sprintf(`{"tenant_id":"%s","plan":"%s","duration_months":%d}`, id, subscription.PlanType, durationMonths),
 	}
-	h.db.Create(&log)
+	requestDB(c, h.db.DB).Create(&log)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -511,15 +511,15 @@ func (h *PlatformHandler) DeleteTenant(c *gin.Context) {
 	id := c.Param("id")
 
 	var tenant models.Tenant
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", id).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
 
 	// Append timestamp to domain to free up unique constraint
 	deletedDomain := fmt.Sprintf("%s_deleted_%d", tenant.Domain, time.Now().Unix())
-	h.db.Model(&tenant).Update("domain", deletedDomain)
-	h.db.Delete(&tenant)
+	requestDB(c, h.db.DB).Model(&tenant).Update("domain", deletedDomain)
+	requestDB(c, h.db.DB).Delete(&tenant)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Tenant deleted successfully"})
 }
@@ -531,7 +531,7 @@ func (h *PlatformHandler) GetTenantUsers(c *gin.Context) {
 	tenantID := c.Param("tenantId")
 
 	var users []models.User
-	h.db.Where("tenant_id = ? AND deleted_at IS NULL", tenantID).Order("created_at DESC").Find(&users)
+	requestDB(c, h.db.DB).Where("tenant_id = ? AND deleted_at IS NULL", tenantID).Order("created_at DESC").Find(&users)
 
 	// Map to response without password hash
 	var result []UserSummary
@@ -560,7 +560,7 @@ func (h *PlatformHandler) UpdateUser(c *gin.Context) {
 	userID := c.Param("userId")
 
 	var user models.User
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -571,6 +571,8 @@ func (h *PlatformHandler) UpdateUser(c *gin.Context) {
 		LastName  string `json:"last_name"`
 		Role      string `json:"role"`
 		Status    string `json:"status"`
+		// ManagerID receives the user's escalated reminders; empty clears it
+		ManagerID *string `json:"manager_id"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -578,6 +580,16 @@ func (h *PlatformHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if input.ManagerID != nil && *input.ManagerID != "" {
+		var count int64
+		requestDB(c, h.db.DB).Model(&models.User{}).
+			Where("id = ? AND tenant_id = ? AND id <> ?", *input.ManagerID, user.TenantID, user.ID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Manager must be another user of the same tenant"})
+			return
+		}
+	}
+
 	if input.Email != "" {
 		user.Email = input.Email
 	}
@@ -593,8 +605,11 @@ func (h *PlatformHandler) UpdateUser(c *gin.Context) {
 	if input.Status != "" {
 		user.Status = input.Status
 	}
+	if input.ManagerID != nil {
+		user.ManagerID = *input.ManagerID
+	}
 
-	h.db.Save(&user)
+	requestDB(c, h.db.DB).Save(&user)
 	c.JSON(http.StatusOK, gin.H{
 		"id":         user.ID,
 		"email":      user.Email,
@@ -602,6 +617,7 @@ func (h *PlatformHandler) UpdateUser(c *gin.Context) {
 		"last_name":  user.LastName,
 		"role":       user.Role,
 		"status":     user.Status,
+		"manager_id": user.ManagerID,
 	})
 }
 
@@ -610,7 +626,7 @@ func (h *PlatformHandler) ResetUserPassword(c *gin.Context) {
 	userID := c.Param("userId")
 
 	var user models.User
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -631,7 +647,7 @@ func (h *PlatformHandler) ResetUserPassword(c *gin.Context) {
 	}
 
 	user.PasswordHash = string(hashedPassword)
-	h.db.Save(&user)
+	requestDB(c, h.db.DB).Save(&user)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
@@ -642,7 +658,7 @@ func (h *PlatformHandler) CreateUser(c *gin.Context) {
 
 	// Verify tenant exists
 	var tenant models.Tenant
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", tenantID).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", tenantID).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -661,7 +677,7 @@ func (h *PlatformHandler) CreateUser(c *gin.Context) {
 
 	// Check if email already exists
 	var existingUser models.User
-	if err := h.db.Where("email = ? AND deleted_at IS NULL", input.Email).First(&existingUser).Error; err == nil {
+	if err := requestDB(c, h.db.DB).Where("email = ? AND deleted_at IS NULL", input.Email).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
 		return
 	}
@@ -690,7 +706,7 @@ func (h *PlatformHandler) CreateUser(c *gin.Context) {
 		IsSuperAdmin: false,
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -714,15 +730,15 @@ func (h *PlatformHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("userId")
 
 	var user models.User
-	if err := h.db.Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Where("id = ? AND deleted_at IS NULL", userID).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	// Append timestamp to email to free up unique constraint
 	deletedEmail := fmt.Sprintf("%s_deleted_%d", user.Email, time.Now().Unix())
-	h.db.Model(&user).Update("email", deletedEmail)
-	h.db.Delete(&user)
+	requestDB(c, h.db.DB).Model(&user).Update("email", deletedEmail)
+	requestDB(c, h.db.DB).Delete(&user)
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
@@ -732,7 +748,7 @@ func (h *PlatformHandler) RestoreTenant(c *gin.Context) {
 	id := c.Param("id")
 
 	var tenant models.Tenant
-	if err := h.db.Unscoped().Where("id = ?", id).First(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Unscoped().Where("id = ?", id).First(&tenant).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -744,7 +760,7 @@ func (h *PlatformHandler) RestoreTenant(c *gin.Context) {
 
 	// Check if domain conflicts with existing tenant
 	var existingTenant models.Tenant
-	if err := h.db.Where("domain = ? AND deleted_at IS NULL AND id != ?", tenant.Domain, id).First(&existingTenant).Error; err == nil {
+	if err := requestDB(c, h.db.DB).Where("domain = ? AND deleted_at IS NULL AND id != ?", tenant.Domain, id).First(&existingTenant).Error; err == nil {
 		// Conflict exists - domain is taken by another tenant
 		c.JSON(http.StatusConflict, gin.H{
 			"error":   "Cannot restore: domain is already in use by another tenant",
@@ -760,7 +776,7 @@ func (h *PlatformHandler) RestoreTenant(c *gin.Context) {
 	}
 
 	// Restore by setting deleted_at to nil
-	h.db.Unscoped().Model(&tenant).Updates(map[string]interface{}{
+	requestDB(c, h.db.DB).Unscoped().Model(&tenant).Updates(map[string]interface{}{
 		"deleted_at": nil,
 		"domain":     tenant.Domain,
 		"status":     "active",
@@ -774,7 +790,7 @@ func (h *PlatformHandler) RestoreUser(c *gin.Context) {
 	userID := c.Param("userId")
 
 	var user models.User
-	if err := h.db.Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Unscoped().Where("id = ?", userID).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
@@ -793,7 +809,7 @@ func (h *PlatformHandler) RestoreUser(c *gin.Context) {
 
 	// Check if email conflicts with existing user
 	var existingUser models.User
-	if err := h.db.Where("email = ? AND deleted_at IS NULL AND id != ?", originalEmail, userID).First(&existingUser).Error; err == nil {
+	if err := requestDB(c, h.db.DB).Where("email = ? AND deleted_at IS NULL AND id != ?", originalEmail, userID).First(&existingUser).Error; err == nil {
 		c.JSON(http.StatusConflict, gin.H{
 			"error":   "Cannot restore: email is already in use by another user",
 			"details": fmt.Sprintf("Email '%s' is used by user '%s %s'", originalEmail, existingUser.FirstName, existingUser.LastName),
@@ -802,7 +818,7 @@ func (h *PlatformHandler) RestoreUser(c *gin.Context) {
 	}
 
 	// Restore by setting deleted_at to nil
-	h.db.Unscoped().Model(&user).Updates(map[string]interface{}{
+	requestDB(c, h.db.DB).Unscoped().Model(&user).Updates(map[string]interface{}{
 		"deleted_at": nil,
 		"email":      originalEmail,
 		"status":     "active",
@@ -814,7 +830,7 @@ func (h *PlatformHandler) RestoreUser(c *gin.Context) {
 // GetDeletedTenants returns all soft-deleted tenants
 func (h *PlatformHandler) GetDeletedTenants(c *gin.Context) {
 	var tenants []models.Tenant
-	h.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&tenants)
+	requestDB(c, h.db.DB).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&tenants)
 
 	var result []map[string]interface{}
 	for _, t := range tenants {
@@ -834,7 +850,7 @@ func (h *PlatformHandler) GetDeletedUsers(c *gin.Context) {
 	tenantID := c.Param("tenantId")
 
 	var users []models.User
-	h.db.Unscoped().Where("tenant_id = ? AND deleted_at IS NOT NULL", tenantID).Order("deleted_at DESC").Find(&users)
+	requestDB(c, h.db.DB).Unscoped().Where("tenant_id = ? AND deleted_at IS NOT NULL", tenantID).Order("deleted_at DESC").Find(&users)
 
 	var result []map[string]interface{}
 	for _, u := range users {
@@ -891,7 +907,7 @@ func (h *PlatformHandler) GetAnalytics(c *gin.Context) {
 		nextDate := date.Add(24 * time.Hour)
 
 		var count int64
-		h.db.Model(&models.APIUsage{}).
+		requestDB(c, h.db.DB).Model(&models.APIUsage{}).
 			Where("created_at >= ? AND created_at < ?", date, nextDate).
 			Count(&count)
 
@@ -908,7 +924,7 @@ func (h *PlatformHandler) GetAnalytics(c *gin.Context) {
 		endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
 		var count int64
-		h.db.Model(&models.Tenant{}).
+		requestDB(c, h.db.DB).Model(&models.Tenant{}).
 			Where("created_at < ? AND deleted_at IS NULL", endOfMonth).
 			Count(&count)
 
@@ -925,7 +941,7 @@ func (h *PlatformHandler) GetAnalytics(c *gin.Context) {
 		endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
 		var count int64
-		h.db.Model(&models.User{}).
+		requestDB(c, h.db.DB).Model(&models.User{}).
 			Where("created_at < ? AND deleted_at IS NULL", endOfMonth).
 			Count(&count)
 
@@ -941,7 +957,7 @@ func (h *PlatformHandler) GetAnalytics(c *gin.Context) {
 		Count    int64
 	}
 	var endpoints []endpointResult
-	h.db.Model(&models.APIUsage{}).
+	requestDB(c, h.db.DB).Model(&models.APIUsage{}).
 		Select("endpoint, COUNT(*) as count").
 		Group("endpoint").
 		Order("count DESC").
@@ -957,11 +973,11 @@ func (h *PlatformHandler) GetAnalytics(c *gin.Context) {
 
 	// Usage by tenant
 	var tenants []models.Tenant
-	h.db.Where("deleted_at IS NULL").Limit(10).Find(&tenants)
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").Limit(10).Find(&tenants)
 
 	for _, t := range tenants {
 		var count int64
-		h.db.Model(&models.APIUsage{}).Where("tenant_id = ?", t.ID).Count(&count)
+		requestDB(c, h.db.DB).Model(&models.APIUsage{}).Where("tenant_id = ?", t.ID).Count(&count)
 		analytics.UsageByTenant = append(analytics.UsageByTenant, TenantUsage{
 			TenantID:   t.ID,
 			TenantName: t.Name,
@@ -998,7 +1014,7 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 
 	// Total revenue (all time)
 	var totalRev struct{ Total float64 }
-	h.db.Model(&models.Invoice{}).
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).
 		Select("COALESCE(SUM(total_amount), 0) as total").
 		Where("status = ?", "paid").
 		Scan(&totalRev)
@@ -1007,7 +1023,7 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 	// Monthly revenue
 	startOfMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
 	var monthlyRev struct{ Total float64 }
-	h.db.Model(&models.Invoice{}).
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).
 		Select("COALESCE(SUM(total_amount), 0) as total").
 		Where("status = ? AND paid_date >= ?", "paid", startOfMonth).
 		Scan(&monthlyRev)
@@ -1015,24 +1031,24 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 
 	// Pending and overdue amounts
 	var pending struct{ Total float64 }
-	h.db.Model(&models.Invoice{}).
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).
 		Select("COALESCE(SUM(total_amount), 0) as total").
 		Where("status = ?", "pending").
 		Scan(&pending)
 	billing.PendingAmount = pending.Total
 
 	var overdue struct{ Total float64 }
-	h.db.Model(&models.Invoice{}).
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).
 		Select("COALESCE(SUM(total_amount), 0) as total").
 		Where("status = ?", "overdue").
 		Scan(&overdue)
 	billing.OverdueAmount = overdue.Total
 
 	// Invoice counts
-	h.db.Model(&models.Invoice{}).Where("deleted_at IS NULL").Count(&billing.TotalInvoices)
-	h.db.Model(&models.Invoice{}).Where("status = ?", "paid").Count(&billing.PaidInvoices)
-	h.db.Model(&models.Invoice{}).Where("status = ?", "pending").Count(&billing.PendingInvoices)
-	h.db.Model(&models.Invoice{}).Where("status = ?", "overdue").Count(&billing.OverdueInvoices)
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).Where("deleted_at IS NULL").Count(&billing.TotalInvoices)
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).Where("status = ?", "paid").Count(&billing.PaidInvoices)
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).Where("status = ?", "pending").Count(&billing.PendingInvoices)
+	requestDB(c, h.db.DB).Model(&models.Invoice{}).Where("status = ?", "overdue").Count(&billing.OverdueInvoices)
 
 	// Subscription distribution
 	type subCount struct {
@@ -1040,7 +1056,7 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 		Count    int64
 	}
 	var subs []subCount
-	h.db.Model(&models.Subscription{}).
+	requestDB(c, h.db.DB).Model(&models.Subscription{}).
 		Select("plan_type, COUNT(*) as count").
 		Where("deleted_at IS NULL AND status = ?", "active").
 		Group("plan_type").
@@ -1054,7 +1070,7 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 	}
 
 	// Recent invoices
-	h.db.Where("deleted_at IS NULL").
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").
 		Order("created_at DESC").
 		Limit(10).
 		Find(&billing.RecentInvoices)
@@ -1065,14 +1081,14 @@ func (h *PlatformHandler) GetBillingOverview(c *gin.Context) {
 // GetInvoices returns all invoices
 func (h *PlatformHandler) GetInvoices(c *gin.Context) {
 	var invoices []models.Invoice
-	h.db.Where("deleted_at IS NULL").Order("created_at DESC").Find(&invoices)
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").Order("created_at DESC").Find(&invoices)
 	c.JSON(http.StatusOK, invoices)
 }
 
 // GetSubscriptions returns all subscriptions
 func (h *PlatformHandler) GetSubscriptions(c *gin.Context) {
 	var subs []models.Subscription
-	h.db.Where("deleted_at IS NULL").Order("created_at DESC").Find(&subs)
+	requestDB(c, h.db.DB).Where("deleted_at IS NULL").Order("created_at DESC").Find(&subs)
 	c.JSON(http.StatusOK, subs)
 }
 
@@ -1083,7 +1099,7 @@ func (h *PlatformHandler) GetSystemLogs(c *gin.Context) {
 	level := c.Query("level")
 	category := c.Query("category")
 
-	query := h.db.Where("deleted_at IS NULL")
+	query := requestDB(c, h.db.DB).Where("deleted_at IS NULL")
 
 	if level != "" {
 		query = query.Where("level = ?", level)
@@ -1106,7 +1122,7 @@ func (h *PlatformHandler) GetLogStats(c *gin.Context) {
 	}
 
 	var stats []LogStat
-	h.db.Model(&models.SystemLog{}).
+	requestDB(c, h.db.DB).Model(&models.SystemLog{}).
 		Select("level, COUNT(*) as count").
 		Where("deleted_at IS NULL").
 		Group("level").
@@ -1118,7 +1134,7 @@ func (h *PlatformHandler) GetLogStats(c *gin.Context) {
 	}
 
 	var catStats []CategoryStat
-	h.db.Model(&models.SystemLog{}).
+	requestDB(c, h.db.DB).Model(&models.SystemLog{}).
 		Select("category, COUNT(*) as count").
 		Where("deleted_at IS NULL").
 		Group("category").
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,39 +22,38 @@ func (h *PrivacyOpsControlsHandler) GetPrivacyControls(c *gin.Context) {
 	var controls []models.PrivacyControl
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&controls).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch privacy controls"})
+	base := requestDB(c, h.db).Model(&models.PrivacyControl{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, privacyControlQuery, &controls)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch privacy controls")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    controls,
+		"success":    true,
+		"data":       controls,
+		"pagination": page,
 	})
 }
 
-func (h *PrivacyOpsControlsHandler) CreatePrivacyControl(c *gin.Context) {
-	var req struct {
-		Name                string `json:"name" binding:"required"`
-		Description         string `json:"description" binding:"required"`
-		ControlType         string `json:"controlType"`
-		ControlDomain       string `json:"controlDomain"`
-		Framework           string `json:"framework"`
-		ImplementationStatus string `json:"implementationStatus"`
-		Effectiveness       string `json:"effectiveness"`
-		TestingFrequency    string `json:"testingFrequency"`
-		Owner               string `json:"owner"`
-		LastTested          string `json:"lastTested"`
-		NextTest            string `json:"nextTest"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createPrivacyControlRequest is the body accepted by CreatePrivacyControl. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createPrivacyControlRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Description         string `json:"description" binding:"required"`
+	ControlType         string `json:"controlType"`
+	ControlDomain       string `json:"controlDomain"`
+	Framework           string `json:"framework"`
+	ImplementationStatus string `json:"implementationStatus"`
+	Effectiveness       string `json:"effectiveness"`
+	TestingFrequency    string `json:"testingFrequency"`
+	Owner               string `json:"owner"`
+	LastTested          string `json:"lastTested"`
+	NextTest            string `json:"nextTest"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreatePrivacyControl stores it
+func (req *createPrivacyControlRequest) toModel(tenantID, userID string) models.PrivacyControl {
 	control := models.PrivacyControl{
 		TenantID:            tenantID,
 		Name:                req.Name,
@@ -79,7 +79,25 @@ func (h *PrivacyOpsControlsHandler) CreatePrivacyControl(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&control).Error; err != nil {
+	return control
+}
+
+func (req *createPrivacyControlRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *PrivacyOpsControlsHandler) CreatePrivacyControl(c *gin.Context) {
+	var req createPrivacyControlRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	control := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&control).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create privacy control"})
 		return
 	}
@@ -91,22 +109,42 @@ func (h *PrivacyOpsControlsHandler) CreatePrivacyControl(c *gin.Context) {
 	})
 }
 
+// updatePrivacyControlRequest is the body accepted by UpdatePrivacyControl
+type updatePrivacyControlRequest struct {
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	ControlType         string `json:"controlType"`
+	ControlDomain       string `json:"controlDomain"`
+	Framework           string `json:"framework"`
+	ImplementationStatus string `json:"implementationStatus"`
+	Effectiveness       string `json:"effectiveness"`
+	TestingFrequency    string `json:"testingFrequency"`
+	Owner               string `json:"owner"`
+	LastTested          string `json:"lastTested"`
+	NextTest            string `json:"nextTest"`
+}
+
+func (h *PrivacyOpsControlsHandler) GetPrivacyControl(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var control models.PrivacyControl
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Privacy control not found"})
+		return
+	}
+
+	setETag(c, &control)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    control,
+	})
+}
+
 func (h *PrivacyOpsControlsHandler) UpdatePrivacyControl(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name                string `json:"name"`
-		Description         string `json:"description"`
-		ControlType         string `json:"controlType"`
-		ControlDomain       string `json:"controlDomain"`
-		Framework           string `json:"framework"`
-		ImplementationStatus string `json:"implementationStatus"`
-		Effectiveness       string `json:"effectiveness"`
-		TestingFrequency    string `json:"testingFrequency"`
-		Owner               string `json:"owner"`
-		LastTested          string `json:"lastTested"`
-		NextTest            string `json:"nextTest"`
-	}
+	var req updatePrivacyControlRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -116,10 +154,13 @@ func (h *PrivacyOpsControlsHandler) UpdatePrivacyControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var control models.PrivacyControl
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Privacy control not found"})
 		return
 	}
+	if !checkIfMatch(c, &control) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -163,27 +204,44 @@ func (h *PrivacyOpsControlsHandler) UpdatePrivacyControl(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&control).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&control).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &control, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update privacy control"})
 		return
 	}
 
+	setETag(c, &control)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Privacy control updated successfully",
+		"data":    control,
 	})
 }
 
+func (h *PrivacyOpsControlsHandler) PatchPrivacyControl(c *gin.Context) {
+	var control models.PrivacyControl
+	patchRecord(c, h.db, patchTarget{resource: "privacy_control"}, &control)
+}
+
 func (h *PrivacyOpsControlsHandler) DeletePrivacyControl(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.PrivacyControl{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var control models.PrivacyControl
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Privacy control not found"})
+		return
+	}
+	if !checkIfMatch(c, &control) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "privacy_control", id, &control); err != nil {
+		if staleWrite(c, h.db, &control, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete privacy control"})
 		return
 	}
@@ -201,15 +259,15 @@ func (h *PrivacyOpsControlsHandler) GetPrivacyControlsStats(c *gin.Context) {
 	var active int64
 	var implemented int64
 
-	h.db.Model(&models.PrivacyControl{}).
+	requestDB(c, h.db).Model(&models.PrivacyControl{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.PrivacyControl{}).
+	requestDB(c, h.db).Model(&models.PrivacyControl{}).
 		Where("tenant_id = ? AND is_deleted = ? AND implementation_status = ?", tenantID, false, "implemented").
 		Count(&active)
 
-	h.db.Model(&models.PrivacyControl{}).
+	requestDB(c, h.db).Model(&models.PrivacyControl{}).
 		Where("tenant_id = ? AND is_deleted = ? AND implementation_status IN (?)", tenantID, false, []string{"implemented", "partially_implemented"}).
 		Count(&implemented)
 
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -20,43 +21,40 @@ func (h *PrivacyOpsDataInventoryHandler) GetDataInventory(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	
 	var items []models.DataInventory
-	query := h.db
+	base := requestDB(c, h.db).Model(&models.DataInventory{})
 	
 	if tenantID != "" {
-		query = query.Where("tenant_id = ?", tenantID)
+		base = base.Where("tenant_id = ?", tenantID)
 	}
 	
-	if err := query.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	page, err := query.List(c, base, dataInventoryQuery, &items)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch data inventory")
 		return
 	}
 	
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    items,
+		"success":    true,
+		"data":       items,
+		"pagination": page,
 	})
 }
 
-func (h *PrivacyOpsDataInventoryHandler) CreateDataItem(c *gin.Context) {
-	var req struct {
-		DataType          string `json:"data_type" binding:"required"`
-		DataSource        string `json:"data_source" binding:"required"`
-		DataCategory      string `json:"data_category" binding:"required"`
-		SensitivityLevel  string `json:"sensitivity_level" binding:"required"`
-		ProcessingPurpose string `json:"processing_purpose" binding:"required"`
-		DataSubjects      string `json:"data_subjects" binding:"required"`
-		StorageLocation   string `json:"storage_location" binding:"required"`
-		RetentionPeriod   int    `json:"retention_period" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	tenantID := c.GetString("tenant_id")
-	userID := c.GetString("user_id")
+// createDataItemRequest is the body accepted by CreateDataItem. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createDataItemRequest struct {
+	DataType          string `json:"data_type" binding:"required"`
+	DataSource        string `json:"data_source" binding:"required"`
+	DataCategory      string `json:"data_category" binding:"required"`
+	SensitivityLevel  string `json:"sensitivity_level" binding:"required"`
+	ProcessingPurpose string `json:"processing_purpose" binding:"required"`
+	DataSubjects      string `json:"data_subjects" binding:"required"`
+	StorageLocation   string `json:"storage_location" binding:"required"`
+	RetentionPeriod   int    `json:"retention_period" binding:"required"`
+}
 
+// toModel builds the record exactly as CreateDataItem stores it
+func (req *createDataItemRequest) toModel(tenantID, userID string) models.DataInventory {
 	item := models.DataInventory{
 		TenantID:          tenantID,
 		DataType:          req.DataType,
@@ -70,7 +68,25 @@ func (h *PrivacyOpsDataInventoryHandler) CreateDataItem(c *gin.Context) {
 		CreatedBy:         userID,
 	}
 
-	if err := h.db.Create(&item).Error; err != nil {
+	return item
+}
+
+func (req *createDataItemRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *PrivacyOpsDataInventoryHandler) CreateDataItem(c *gin.Context) {
+	var req createDataItemRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -82,19 +98,38 @@ func (h *PrivacyOpsDataInventoryHandler) CreateDataItem(c *gin.Context) {
 	})
 }
 
+// updateDataItemRequest is the body accepted by UpdateDataItem
+type updateDataItemRequest struct {
+	DataType          string `json:"data_type"`
+	DataSource        string `json:"data_source"`
+	DataCategory      string `json:"data_category"`
+	SensitivityLevel  string `json:"sensitivity_level"`
+	ProcessingPurpose string `json:"processing_purpose"`
+	DataSubjects      string `json:"data_subjects"`
+	StorageLocation   string `json:"storage_location"`
+	RetentionPeriod   int    `json:"retention_period"`
+}
+
+func (h *PrivacyOpsDataInventoryHandler) GetDataItem(c *gin.Context) {
+	id := c.Param("id")
+
+	var item models.DataInventory
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data item not found"})
+		return
+	}
+
+	setETag(c, &item)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    item,
+	})
+}
+
 func (h *PrivacyOpsDataInventoryHandler) UpdateDataItem(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		DataType          string `json:"data_type"`
-		DataSource        string `json:"data_source"`
-		DataCategory      string `json:"data_category"`
-		SensitivityLevel  string `json:"sensitivity_level"`
-		ProcessingPurpose string `json:"processing_purpose"`
-		DataSubjects      string `json:"data_subjects"`
-		StorageLocation   string `json:"storage_location"`
-		RetentionPeriod   int    `json:"retention_period"`
-	}
+	var req updateDataItemRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -102,10 +137,13 @@ func (h *PrivacyOpsDataInventoryHandler) UpdateDataItem(c *gin.Context) {
 	}
 
 	var item models.DataInventory
-	if err := h.db.Where("id = ?", id).First(&item).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Data item not found"})
 		return
 	}
+	if !checkIfMatch(c, &item) {
+		return
+	}
 
 	if req.DataType != "" {
 		item.DataType = req.DataType
@@ -132,11 +170,15 @@ func (h *PrivacyOpsDataInventoryHandler) UpdateDataItem(c *gin.Context) {
 		item.RetentionPeriod = req.RetentionPeriod
 	}
 
-	if err := h.db.Save(&item).Error; err != nil {
+	if err := requestDB(c, h.db).Save(&item).Error; err != nil {
+		if staleWrite(c, h.db, &item, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, &item)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Data item updated successfully",
@@ -144,10 +186,24 @@ func (h *PrivacyOpsDataInventoryHandler) UpdateDataItem(c *gin.Context) {
 	})
 }
 
+func (h *PrivacyOpsDataInventoryHandler) PatchDataItem(c *gin.Context) {
+	var item models.DataInventory
+	patchRecord(c, h.db, patchTarget{resource: "data_inventory"}, &item)
+}
+
 func (h *PrivacyOpsDataInventoryHandler) DeleteDataItem(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.db.Where("id = ?", id).Delete(&models.DataInventory{}).Error; err != nil {
+	var item models.DataInventory
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Data item not found"})
+		return
+	}
+	if !checkIfMatch(c, &item) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "data_inventory", id, &item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -170,7 +226,7 @@ func (h *PrivacyOpsDataInventoryHandler) GetDataInventoryStats(c *gin.Context) {
 		Public             int64 `json:"public"`
 	}
 	
-	query := h.db.Model(&models.DataInventory{})
+	query := requestDB(c, h.db).Model(&models.DataInventory{})
 	if tenantID != "" {
 		query = query.Where("tenant_id = ?", tenantID)
 	}
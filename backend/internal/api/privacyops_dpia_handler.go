@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,39 +23,38 @@ func (h *PrivacyOpsDPIAHandler) GetDPIAs(c *gin.Context) {
 	var dpias []models.DPIA
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&dpias).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch DPIA records"})
+	base := requestDB(c, h.db).Model(&models.DPIA{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, dpiaQuery, &dpias)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch DPIA records")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    dpias,
+		"success":    true,
+		"data":       dpias,
+		"pagination": page,
 	})
 }
 
-func (h *PrivacyOpsDPIAHandler) CreateDPIA(c *gin.Context) {
-	var req struct {
-		Name               string `json:"name" binding:"required"`
-		Description        string `json:"description" binding:"required"`
-		ProcessingActivity string `json:"processingActivity"`
-		DataCategories     string `json:"dataCategories"`
-		DataSubjects       string `json:"dataSubjects"`
-		Necessity          string `json:"necessity"`
-		Proportionality    string `json:"proportionality"`
-		RiskLevel          string `json:"riskLevel"`
-		RiskAssessment     string `json:"riskAssessment"`
-		MitigationMeasures string `json:"mitigationMeasures"`
-		Reviewer           string `json:"reviewer"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createDPIARequest is the body accepted by CreateDPIA. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createDPIARequest struct {
+	Name               string `json:"name" binding:"required"`
+	Description        string `json:"description" binding:"required"`
+	ProcessingActivity string `json:"processingActivity"`
+	DataCategories     string `json:"dataCategories"`
+	DataSubjects       string `json:"dataSubjects"`
+	Necessity          string `json:"necessity"`
+	Proportionality    string `json:"proportionality"`
+	RiskLevel          string `json:"riskLevel"`
+	RiskAssessment     string `json:"riskAssessment"`
+	MitigationMeasures string `json:"mitigationMeasures"`
+	Reviewer           string `json:"reviewer"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateDPIA stores it
+func (req *createDPIARequest) toModel(tenantID, userID string) models.DPIA {
 	dpia := models.DPIA{
 		TenantID:            tenantID,
 		Name:                req.Name,
@@ -70,7 +71,25 @@ func (h *PrivacyOpsDPIAHandler) CreateDPIA(c *gin.Context) {
 		Reviewer:            req.Reviewer,
 	}
 
-	if err := h.db.Create(&dpia).Error; err != nil {
+	return dpia
+}
+
+func (req *createDPIARequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *PrivacyOpsDPIAHandler) CreateDPIA(c *gin.Context) {
+	var req createDPIARequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dpia := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&dpia).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create DPIA"})
 		return
 	}
@@ -82,24 +101,44 @@ func (h *PrivacyOpsDPIAHandler) CreateDPIA(c *gin.Context) {
 	})
 }
 
+// updateDPIARequest is the body accepted by UpdateDPIA
+type updateDPIARequest struct {
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	ProcessingActivity string `json:"processingActivity"`
+	DataCategories     string `json:"dataCategories"`
+	DataSubjects       string `json:"dataSubjects"`
+	Necessity          string `json:"necessity"`
+	Proportionality    string `json:"proportionality"`
+	RiskLevel          string `json:"riskLevel"`
+	RiskAssessment     string `json:"riskAssessment"`
+	MitigationMeasures string `json:"mitigationMeasures"`
+	Status             string `json:"status"`
+	ApprovalDate       string `json:"approvalDate"`
+	Reviewer           string `json:"reviewer"`
+}
+
+func (h *PrivacyOpsDPIAHandler) GetDPIA(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var dpia models.DPIA
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dpia).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DPIA not found"})
+		return
+	}
+
+	setETag(c, &dpia)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dpia,
+	})
+}
+
 func (h *PrivacyOpsDPIAHandler) UpdateDPIA(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name               string `json:"name"`
-		Description        string `json:"description"`
-		ProcessingActivity string `json:"processingActivity"`
-		DataCategories     string `json:"dataCategories"`
-		DataSubjects       string `json:"dataSubjects"`
-		Necessity          string `json:"necessity"`
-		Proportionality    string `json:"proportionality"`
-		RiskLevel          string `json:"riskLevel"`
-		RiskAssessment     string `json:"riskAssessment"`
-		MitigationMeasures string `json:"mitigationMeasures"`
-		Status             string `json:"status"`
-		ApprovalDate       string `json:"approvalDate"`
-		Reviewer           string `json:"reviewer"`
-	}
+	var req updateDPIARequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -109,10 +148,13 @@ func (h *PrivacyOpsDPIAHandler) UpdateDPIA(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var dpia models.DPIA
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dpia).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dpia).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "DPIA not found"})
 		return
 	}
+	if !checkIfMatch(c, &dpia) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -160,30 +202,50 @@ func (h *PrivacyOpsDPIAHandler) UpdateDPIA(c *gin.Context) {
 		updates["reviewer"] = req.Reviewer
 	}
 
-	if err := h.db.Model(&dpia).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&dpia).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &dpia, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update DPIA"})
 		return
 	}
 
+	setETag(c, &dpia)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "DPIA updated successfully",
+		"data":    dpia,
 	})
 }
 
+func (h *PrivacyOpsDPIAHandler) PatchDPIA(c *gin.Context) {
+	var dpia models.DPIA
+	patchRecord(c, h.db, patchTarget{resource: "dpia"}, &dpia)
+}
+
 func (h *PrivacyOpsDPIAHandler) ApproveDPIA(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
 	approvalDate := time.Now()
 
-	if err := h.db.Model(&models.DPIA{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":       "approved",
-			"approval_date": &approvalDate,
-			"updated_at":   time.Now(),
-		}).Error; err != nil {
+	var dpia models.DPIA
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dpia).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DPIA not found"})
+		return
+	}
+	if !checkIfMatch(c, &dpia) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &dpia, map[string]interface{}{
+		"status":       "approved",
+		"approval_date": &approvalDate,
+		"updated_at":   time.Now(),
+	}, events.DPIAApproved, "dpia", id); err != nil {
+		if staleWrite(c, h.db, &dpia, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve DPIA"})
 		return
 	}
@@ -198,12 +260,19 @@ func (h *PrivacyOpsDPIAHandler) DeleteDPIA(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.DPIA{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var dpia models.DPIA
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dpia).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DPIA not found"})
+		return
+	}
+	if !checkIfMatch(c, &dpia) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "dpia", id, &dpia); err != nil {
+		if staleWrite(c, h.db, &dpia, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DPIA"})
 		return
 	}
@@ -221,15 +290,15 @@ func (h *PrivacyOpsDPIAHandler) GetDPIAStats(c *gin.Context) {
 	var highRisk int64
 	var completed int64
 
-	h.db.Model(&models.DPIA{}).
+	requestDB(c, h.db).Model(&models.DPIA{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.DPIA{}).
+	requestDB(c, h.db).Model(&models.DPIA{}).
 		Where("tenant_id = ? AND is_deleted = ? AND risk_level = ?", tenantID, false, "high").
 		Count(&highRisk)
 
-	h.db.Model(&models.DPIA{}).
+	requestDB(c, h.db).Model(&models.DPIA{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "completed").
 		Count(&completed)
 
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,36 +23,36 @@ func (h *PrivacyOpsDSRHandler) GetDSRs(c *gin.Context) {
 	var dsrs []models.DSRRequest
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&dsrs).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch DSR requests"})
+	base := requestDB(c, h.db).Model(&models.DSRRequest{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, dsrQuery, &dsrs)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch DSR requests")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    dsrs,
+		"success":    true,
+		"data":       dsrs,
+		"pagination": page,
 	})
 }
 
-func (h *PrivacyOpsDSRHandler) CreateDSR(c *gin.Context) {
-	var req struct {
-		RequestType       string `json:"requestType" binding:"required"`
-		DataSubjectName   string `json:"dataSubjectName" binding:"required"`
-		DataSubjectEmail  string `json:"dataSubjectEmail"`
-		DataSubjectType   string `json:"dataSubjectType"`
-		Priority          string `json:"priority" binding:"required"`
-		Description       string `json:"description"`
-		DataCategories    string `json:"dataCategories"`
-		ProcessingActivities string `json:"processingActivities"`
-		Handler           string `json:"handler" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createDSRRequest is the body accepted by CreateDSR. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createDSRRequest struct {
+	RequestType       string `json:"requestType" binding:"required"`
+	DataSubjectName   string `json:"dataSubjectName" binding:"required"`
+	DataSubjectEmail  string `json:"dataSubjectEmail"`
+	DataSubjectType   string `json:"dataSubjectType"`
+	Priority          string `json:"priority" binding:"required"`
+	Description       string `json:"description"`
+	DataCategories    string `json:"dataCategories"`
+	ProcessingActivities string `json:"processingActivities"`
+	Handler           string `json:"handler" binding:"required"`
+}
 
-	tenantID := c.GetString("tenant_id")
+// toModel builds the record exactly as CreateDSR stores it
+func (req *createDSRRequest) toModel(tenantID, userID string) models.DSRRequest {
 	requestDate := time.Now()
 	dueDate := requestDate.AddDate(0, 0, 30) // 30 days deadline
 
@@ -70,7 +72,25 @@ func (h *PrivacyOpsDSRHandler) CreateDSR(c *gin.Context) {
 		Handler:             req.Handler,
 	}
 
-	if err := h.db.Create(&dsr).Error; err != nil {
+	return dsr
+}
+
+func (req *createDSRRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *PrivacyOpsDSRHandler) CreateDSR(c *gin.Context) {
+	var req createDSRRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dsr := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&dsr).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create DSR request"})
 		return
 	}
@@ -82,23 +102,43 @@ func (h *PrivacyOpsDSRHandler) CreateDSR(c *gin.Context) {
 	})
 }
 
+// updateDSRRequest is the body accepted by UpdateDSR
+type updateDSRRequest struct {
+	RequestType       string `json:"requestType"`
+	DataSubjectName   string `json:"dataSubjectName"`
+	DataSubjectEmail  string `json:"dataSubjectEmail"`
+	DataSubjectType   string `json:"dataSubjectType"`
+	Status            string `json:"status"`
+	Priority          string `json:"priority"`
+	Description       string `json:"description"`
+	DataCategories    string `json:"dataCategories"`
+	ProcessingActivities string `json:"processingActivities"`
+	Response          string `json:"response"`
+	Handler           string `json:"handler"`
+	CompletedDate     string `json:"completedDate"`
+}
+
+func (h *PrivacyOpsDSRHandler) GetDSR(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var dsr models.DSRRequest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DSR request not found"})
+		return
+	}
+
+	setETag(c, &dsr)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dsr,
+	})
+}
+
 func (h *PrivacyOpsDSRHandler) UpdateDSR(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		RequestType       string `json:"requestType"`
-		DataSubjectName   string `json:"dataSubjectName"`
-		DataSubjectEmail  string `json:"dataSubjectEmail"`
-		DataSubjectType   string `json:"dataSubjectType"`
-		Status            string `json:"status"`
-		Priority          string `json:"priority"`
-		Description       string `json:"description"`
-		DataCategories    string `json:"dataCategories"`
-		ProcessingActivities string `json:"processingActivities"`
-		Response          string `json:"response"`
-		Handler           string `json:"handler"`
-		CompletedDate     string `json:"completedDate"`
-	}
+	var req updateDSRRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -108,10 +148,13 @@ func (h *PrivacyOpsDSRHandler) UpdateDSR(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var dsr models.DSRRequest
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "DSR request not found"})
 		return
 	}
+	if !checkIfMatch(c, &dsr) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -156,27 +199,44 @@ func (h *PrivacyOpsDSRHandler) UpdateDSR(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&dsr).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&dsr).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &dsr, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update DSR request"})
 		return
 	}
 
+	setETag(c, &dsr)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "DSR updated successfully",
+		"data":    dsr,
 	})
 }
 
+func (h *PrivacyOpsDSRHandler) PatchDSR(c *gin.Context) {
+	var dsr models.DSRRequest
+	patchRecord(c, h.db, patchTarget{resource: "dsr"}, &dsr)
+}
+
 func (h *PrivacyOpsDSRHandler) DeleteDSR(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.DSRRequest{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var dsr models.DSRRequest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DSR request not found"})
+		return
+	}
+	if !checkIfMatch(c, &dsr) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "dsr", id, &dsr); err != nil {
+		if staleWrite(c, h.db, &dsr, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DSR request"})
 		return
 	}
@@ -191,12 +251,22 @@ func (h *PrivacyOpsDSRHandler) ApproveDSR(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.DSRRequest{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":     "approved",
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	var dsr models.DSRRequest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DSR request not found"})
+		return
+	}
+	if !checkIfMatch(c, &dsr) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &dsr, map[string]interface{}{
+		"status":     "approved",
+		"updated_at": time.Now(),
+	}, events.DSRApproved, "dsr", id); err != nil {
+		if staleWrite(c, h.db, &dsr, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve DSR request"})
 		return
 	}
@@ -211,12 +281,22 @@ func (h *PrivacyOpsDSRHandler) RejectDSR(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.DSRRequest{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":     "rejected",
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	var dsr models.DSRRequest
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&dsr).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "DSR request not found"})
+		return
+	}
+	if !checkIfMatch(c, &dsr) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &dsr, map[string]interface{}{
+		"status":     "rejected",
+		"updated_at": time.Now(),
+	}, events.DSRRejected, "dsr", id); err != nil {
+		if staleWrite(c, h.db, &dsr, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject DSR request"})
 		return
 	}
@@ -236,23 +316,23 @@ func (h *PrivacyOpsDSRHandler) GetDSRStats(c *gin.Context) {
 	var completed int64
 	var overdue int64
 
-	h.db.Model(&models.DSRRequest{}).
+	requestDB(c, h.db).Model(&models.DSRRequest{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.DSRRequest{}).
+	requestDB(c, h.db).Model(&models.DSRRequest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "pending").
 		Count(&pending)
 
-	h.db.Model(&models.DSRRequest{}).
+	requestDB(c, h.db).Model(&models.DSRRequest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_progress").
 		Count(&inProgress)
 
-	h.db.Model(&models.DSRRequest{}).
+	requestDB(c, h.db).Model(&models.DSRRequest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "completed").
 		Count(&completed)
 
-	h.db.Model(&models.DSRRequest{}).
+	requestDB(c, h.db).Model(&models.DSRRequest{}).
 		Where("tenant_id = ? AND is_deleted = ? AND due_date < ? AND status NOT IN (?)", 
 			tenantID, false, time.Now(), []string{"completed", "rejected"}).
 		Count(&overdue)
@@ -18,7 +18,7 @@ func NewPrivacyOpsHandler(db *db.Database) *PrivacyOpsHandler {
 
 func (h *PrivacyOpsHandler) GetDataInventory(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var dataInventory []models.DataInventory
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&dataInventory).Error; err != nil {
@@ -31,7 +31,7 @@ func (h *PrivacyOpsHandler) GetDataInventory(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) CreateDataInventory(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var dataInventory models.DataInventory
 	if err := c.ShouldBindJSON(&dataInventory); err != nil {
@@ -51,7 +51,7 @@ func (h *PrivacyOpsHandler) CreateDataInventory(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) UpdateDataInventory(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var dataInventory models.DataInventory
@@ -76,7 +76,7 @@ func (h *PrivacyOpsHandler) UpdateDataInventory(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) DeleteDataInventory(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var dataInventory models.DataInventory
@@ -85,9 +85,7 @@ func (h *PrivacyOpsHandler) DeleteDataInventory(c *gin.Context) {
 		return
 	}
 
-	// Soft delete
-	dataInventory.IsDeleted = true
-	if err := tenantDB.Save(&dataInventory).Error; err != nil {
+	if err := softDelete(tenantDB, c, "data_inventory", id, &dataInventory); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete data inventory"})
 		return
 	}
@@ -98,7 +96,7 @@ func (h *PrivacyOpsHandler) DeleteDataInventory(c *gin.Context) {
 // DSR Request CRUD
 func (h *PrivacyOpsHandler) GetDSRRequests(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var requests []models.DSRRequest
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&requests).Error; err != nil {
@@ -111,7 +109,7 @@ func (h *PrivacyOpsHandler) GetDSRRequests(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) CreateDSRRequest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var request models.DSRRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -131,7 +129,7 @@ func (h *PrivacyOpsHandler) CreateDSRRequest(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) UpdateDSRRequest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var request models.DSRRequest
@@ -156,7 +154,7 @@ func (h *PrivacyOpsHandler) UpdateDSRRequest(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) DeleteDSRRequest(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var request models.DSRRequest
@@ -165,9 +163,7 @@ func (h *PrivacyOpsHandler) DeleteDSRRequest(c *gin.Context) {
 		return
 	}
 
-	request.IsDeleted = true
-	request.Status = "deleted"
-	if err := tenantDB.Save(&request).Error; err != nil {
+	if err := softDelete(tenantDB, c, "dsr", id, &request); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DSR request"})
 		return
 	}
@@ -178,7 +174,7 @@ func (h *PrivacyOpsHandler) DeleteDSRRequest(c *gin.Context) {
 // DPIA CRUD
 func (h *PrivacyOpsHandler) GetDPIAs(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var dpias []models.DPIA
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&dpias).Error; err != nil {
@@ -191,7 +187,7 @@ func (h *PrivacyOpsHandler) GetDPIAs(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) CreateDPIA(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var dpia models.DPIA
 	if err := c.ShouldBindJSON(&dpia); err != nil {
@@ -211,7 +207,7 @@ func (h *PrivacyOpsHandler) CreateDPIA(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) UpdateDPIA(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var dpia models.DPIA
@@ -236,7 +232,7 @@ func (h *PrivacyOpsHandler) UpdateDPIA(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) DeleteDPIA(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var dpia models.DPIA
@@ -245,9 +241,7 @@ func (h *PrivacyOpsHandler) DeleteDPIA(c *gin.Context) {
 		return
 	}
 
-	dpia.IsDeleted = true
-	dpia.Status = "deleted"
-	if err := tenantDB.Save(&dpia).Error; err != nil {
+	if err := softDelete(tenantDB, c, "dpia", id, &dpia); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete DPIA"})
 		return
 	}
@@ -258,7 +252,7 @@ func (h *PrivacyOpsHandler) DeleteDPIA(c *gin.Context) {
 // Privacy Control CRUD
 func (h *PrivacyOpsHandler) GetPrivacyControls(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var controls []models.PrivacyControl
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&controls).Error; err != nil {
@@ -271,7 +265,7 @@ func (h *PrivacyOpsHandler) GetPrivacyControls(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) CreatePrivacyControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var control models.PrivacyControl
 	if err := c.ShouldBindJSON(&control); err != nil {
@@ -291,7 +285,7 @@ func (h *PrivacyOpsHandler) CreatePrivacyControl(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) UpdatePrivacyControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var control models.PrivacyControl
@@ -316,7 +310,7 @@ func (h *PrivacyOpsHandler) UpdatePrivacyControl(c *gin.Context) {
 
 func (h *PrivacyOpsHandler) DeletePrivacyControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var control models.PrivacyControl
@@ -325,8 +319,7 @@ func (h *PrivacyOpsHandler) DeletePrivacyControl(c *gin.Context) {
 		return
 	}
 
-	control.IsDeleted = true
-	if err := tenantDB.Save(&control).Error; err != nil {
+	if err := softDelete(tenantDB, c, "privacy_control", id, &control); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete privacy control"})
 		return
 	}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,44 +23,43 @@ func (h *PrivacyOpsIncidentHandler) GetIncidents(c *gin.Context) {
 	var incidents []models.Incident
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&incidents).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+	base := requestDB(c, h.db).Model(&models.Incident{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, incidentQuery, &incidents)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch incidents")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    incidents,
+		"success":    true,
+		"data":       incidents,
+		"pagination": page,
 	})
 }
 
-func (h *PrivacyOpsIncidentHandler) CreateIncident(c *gin.Context) {
-	var req struct {
-		Name                string `json:"name" binding:"required"`
-		Description         string `json:"description" binding:"required"`
-		IncidentType        string `json:"incidentType" binding:"required"`
-		Severity            string `json:"severity" binding:"required"`
-		DetectionDate       string `json:"detectionDate"`
-		ReportedDate        string `json:"reportedDate"`
-		AffectedData        string `json:"affectedData"`
-		AffectedIndividuals int    `json:"affectedIndividuals"`
-		RootCause           string `json:"rootCause"`
-		ImpactAssessment    string `json:"impactAssessment"`
-		ResponseActions      string `json:"responseActions"`
-		NotificationRequired bool   `json:"notificationRequired"`
-		NotificationDate    string `json:"notificationDate"`
-		NotificationAuthorities string `json:"notificationAuthorities"`
-		NotificationSubjects string `json:"notificationSubjects"`
-		Handler             string `json:"handler" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createIncidentRequest is the body accepted by CreateIncident. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createIncidentRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Description         string `json:"description" binding:"required"`
+	IncidentType        string `json:"incidentType" binding:"required"`
+	Severity            string `json:"severity" binding:"required"`
+	DetectionDate       string `json:"detectionDate"`
+	ReportedDate        string `json:"reportedDate"`
+	AffectedData        string `json:"affectedData"`
+	AffectedIndividuals int    `json:"affectedIndividuals"`
+	RootCause           string `json:"rootCause"`
+	ImpactAssessment    string `json:"impactAssessment"`
+	ResponseActions      string `json:"responseActions"`
+	NotificationRequired bool   `json:"notificationRequired"`
+	NotificationDate    string `json:"notificationDate"`
+	NotificationAuthorities string `json:"notificationAuthorities"`
+	NotificationSubjects string `json:"notificationSubjects"`
+	Handler             string `json:"handler" binding:"required"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateIncident stores it
+func (req *createIncidentRequest) toModel(tenantID, userID string) models.Incident {
 	incident := models.Incident{
 		TenantID:             tenantID,
 		Name:                 req.Name,
@@ -95,7 +96,25 @@ func (h *PrivacyOpsIncidentHandler) CreateIncident(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&incident).Error; err != nil {
+	return incident
+}
+
+func (req *createIncidentRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *PrivacyOpsIncidentHandler) CreateIncident(c *gin.Context) {
+	var req createIncidentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	incident := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&incident).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create incident"})
 		return
 	}
@@ -107,30 +126,50 @@ func (h *PrivacyOpsIncidentHandler) CreateIncident(c *gin.Context) {
 	})
 }
 
+// updateIncidentRequest is the body accepted by UpdateIncident
+type updateIncidentRequest struct {
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	IncidentType        string `json:"incidentType"`
+	Severity            string `json:"severity"`
+	Status              string `json:"status"`
+	DetectionDate       string `json:"detectionDate"`
+	ReportedDate        string `json:"reportedDate"`
+	AffectedData        string `json:"affectedData"`
+	AffectedIndividuals int    `json:"affectedIndividuals"`
+	RootCause           string `json:"rootCause"`
+	ImpactAssessment    string `json:"impactAssessment"`
+	ResponseActions      string `json:"responseActions"`
+	NotificationRequired bool   `json:"notificationRequired"`
+	NotificationDate    string `json:"notificationDate"`
+	NotificationAuthorities string `json:"notificationAuthorities"`
+	NotificationSubjects string `json:"notificationSubjects"`
+	ResolutionDate      string `json:"resolutionDate"`
+	LessonsLearned      string `json:"lessonsLearned"`
+	Handler             string `json:"handler"`
+}
+
+func (h *PrivacyOpsIncidentHandler) GetIncident(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var incident models.Incident
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&incident).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+
+	setETag(c, &incident)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+	})
+}
+
 func (h *PrivacyOpsIncidentHandler) UpdateIncident(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name                string `json:"name"`
-		Description         string `json:"description"`
-		IncidentType        string `json:"incidentType"`
-		Severity            string `json:"severity"`
-		Status              string `json:"status"`
-		DetectionDate       string `json:"detectionDate"`
-		ReportedDate        string `json:"reportedDate"`
-		AffectedData        string `json:"affectedData"`
-		AffectedIndividuals int    `json:"affectedIndividuals"`
-		RootCause           string `json:"rootCause"`
-		ImpactAssessment    string `json:"impactAssessment"`
-		ResponseActions      string `json:"responseActions"`
-		NotificationRequired bool   `json:"notificationRequired"`
-		NotificationDate    string `json:"notificationDate"`
-		NotificationAuthorities string `json:"notificationAuthorities"`
-		NotificationSubjects string `json:"notificationSubjects"`
-		ResolutionDate      string `json:"resolutionDate"`
-		LessonsLearned      string `json:"lessonsLearned"`
-		Handler             string `json:"handler"`
-	}
+	var req updateIncidentRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -140,11 +179,15 @@ func (h *PrivacyOpsIncidentHandler) UpdateIncident(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var incident models.Incident
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&incident).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&incident).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
 		return
 	}
+	if !checkIfMatch(c, &incident) {
+		return
+	}
 
+	previousSeverity := incident.Severity
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
 	}
@@ -213,27 +256,49 @@ func (h *PrivacyOpsIncidentHandler) UpdateIncident(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&incident).Updates(updates).Error; err != nil {
+	if err := withEvents(requestDB(c, h.db), func(tx *gorm.DB) error {
+		if err := tx.Model(&incident).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordSeverityRaised(tx, c, &incident, previousSeverity)
+	}); err != nil {
+		if staleWrite(c, h.db, &incident, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update incident"})
 		return
 	}
 
+	setETag(c, &incident)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Incident updated successfully",
+		"data":    incident,
 	})
 }
 
+func (h *PrivacyOpsIncidentHandler) PatchIncident(c *gin.Context) {
+	var incident models.Incident
+	patchRecord(c, h.db, patchTarget{resource: "incident"}, &incident)
+}
+
 func (h *PrivacyOpsIncidentHandler) DeleteIncident(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Incident{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var incident models.Incident
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&incident).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+	if !checkIfMatch(c, &incident) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "incident", id, &incident); err != nil {
+		if staleWrite(c, h.db, &incident, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete incident"})
 		return
 	}
@@ -250,13 +315,23 @@ func (h *PrivacyOpsIncidentHandler) ResolveIncident(c *gin.Context) {
 
 	resolutionDate := time.Now()
 
-	if err := h.db.Model(&models.Incident{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":        "resolved",
-			"resolution_date": &resolutionDate,
-			"updated_at":    time.Now(),
-		}).Error; err != nil {
+	var incident models.Incident
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&incident).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		return
+	}
+	if !checkIfMatch(c, &incident) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &incident, map[string]interface{}{
+		"status":        "resolved",
+		"resolution_date": &resolutionDate,
+		"updated_at":    time.Now(),
+	}, events.IncidentResolved, "incident", id); err != nil {
+		if staleWrite(c, h.db, &incident, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve incident"})
 		return
 	}
@@ -276,23 +351,23 @@ func (h *PrivacyOpsIncidentHandler) GetIncidentStats(c *gin.Context) {
 	var resolved int64
 	var monitoring int64
 
-	h.db.Model(&models.Incident{}).
+	requestDB(c, h.db).Model(&models.Incident{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.Incident{}).
+	requestDB(c, h.db).Model(&models.Incident{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "open").
 		Count(&open)
 
-	h.db.Model(&models.Incident{}).
+	requestDB(c, h.db).Model(&models.Incident{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_progress").
 		Count(&inProgress)
 
-	h.db.Model(&models.Incident{}).
+	requestDB(c, h.db).Model(&models.Incident{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "resolved").
 		Count(&resolved)
 
-	h.db.Model(&models.Incident{}).
+	requestDB(c, h.db).Model(&models.Incident{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "monitoring").
 		Count(&monitoring)
 
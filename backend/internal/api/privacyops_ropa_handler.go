@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -20,34 +21,39 @@ func (h *PrivacyOpsRoPAHandler) GetProcessingActivities(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	
 	var items []models.DataInventory
-	query := h.db
+	base := requestDB(c, h.db).Model(&models.DataInventory{})
 	
 	if tenantID != "" {
-		query = query.Where("tenant_id = ?", tenantID)
+		base = base.Where("tenant_id = ?", tenantID)
 	}
 	
-	if err := query.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	page, err := query.List(c, base, dataInventoryQuery, &items)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch data inventory")
 		return
 	}
 	
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    items,
+		"success":    true,
+		"data":       items,
+		"pagination": page,
 	})
 }
 
+// createProcessingActivityRequest is the body accepted by CreateProcessingActivity
+type createProcessingActivityRequest struct {
+	DataType          string `json:"data_type" binding:"required"`
+	DataSource        string `json:"data_source" binding:"required"`
+	DataCategory      string `json:"data_category" binding:"required"`
+	SensitivityLevel  string `json:"sensitivity_level" binding:"required"`
+	ProcessingPurpose string `json:"processing_purpose" binding:"required"`
+	DataSubjects      string `json:"data_subjects" binding:"required"`
+	StorageLocation   string `json:"storage_location" binding:"required"`
+	RetentionPeriod   int    `json:"retention_period" binding:"required"`
+}
+
 func (h *PrivacyOpsRoPAHandler) CreateProcessingActivity(c *gin.Context) {
-	var req struct {
-		DataType          string `json:"data_type" binding:"required"`
-		DataSource        string `json:"data_source" binding:"required"`
-		DataCategory      string `json:"data_category" binding:"required"`
-		SensitivityLevel  string `json:"sensitivity_level" binding:"required"`
-		ProcessingPurpose string `json:"processing_purpose" binding:"required"`
-		DataSubjects      string `json:"data_subjects" binding:"required"`
-		StorageLocation   string `json:"storage_location" binding:"required"`
-		RetentionPeriod   int    `json:"retention_period" binding:"required"`
-	}
+	var req createProcessingActivityRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -70,7 +76,7 @@ func (h *PrivacyOpsRoPAHandler) CreateProcessingActivity(c *gin.Context) {
 		CreatedBy:         userID,
 	}
 
-	if err := h.db.Create(&item).Error; err != nil {
+	if err := requestDB(c, h.db).Create(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -82,19 +88,38 @@ func (h *PrivacyOpsRoPAHandler) CreateProcessingActivity(c *gin.Context) {
 	})
 }
 
+// updateProcessingActivityRequest is the body accepted by UpdateProcessingActivity
+type updateProcessingActivityRequest struct {
+	DataType          string `json:"data_type"`
+	DataSource        string `json:"data_source"`
+	DataCategory      string `json:"data_category"`
+	SensitivityLevel  string `json:"sensitivity_level"`
+	ProcessingPurpose string `json:"processing_purpose"`
+	DataSubjects      string `json:"data_subjects"`
+	StorageLocation   string `json:"storage_location"`
+	RetentionPeriod   int    `json:"retention_period"`
+}
+
+func (h *PrivacyOpsRoPAHandler) GetProcessingActivity(c *gin.Context) {
+	id := c.Param("id")
+
+	var item models.DataInventory
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Processing activity not found"})
+		return
+	}
+
+	setETag(c, &item)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    item,
+	})
+}
+
 func (h *PrivacyOpsRoPAHandler) UpdateProcessingActivity(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		DataType          string `json:"data_type"`
-		DataSource        string `json:"data_source"`
-		DataCategory      string `json:"data_category"`
-		SensitivityLevel  string `json:"sensitivity_level"`
-		ProcessingPurpose string `json:"processing_purpose"`
-		DataSubjects      string `json:"data_subjects"`
-		StorageLocation   string `json:"storage_location"`
-		RetentionPeriod   int    `json:"retention_period"`
-	}
+	var req updateProcessingActivityRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -102,10 +127,13 @@ func (h *PrivacyOpsRoPAHandler) UpdateProcessingActivity(c *gin.Context) {
 	}
 
 	var item models.DataInventory
-	if err := h.db.Where("id = ?", id).First(&item).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Processing activity not found"})
 		return
 	}
+	if !checkIfMatch(c, &item) {
+		return
+	}
 
 	if req.DataType != "" {
 		item.DataType = req.DataType
@@ -132,11 +160,15 @@ func (h *PrivacyOpsRoPAHandler) UpdateProcessingActivity(c *gin.Context) {
 		item.RetentionPeriod = req.RetentionPeriod
 	}
 
-	if err := h.db.Save(&item).Error; err != nil {
+	if err := requestDB(c, h.db).Save(&item).Error; err != nil {
+		if staleWrite(c, h.db, &item, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, &item)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Processing activity updated successfully",
@@ -144,10 +176,24 @@ func (h *PrivacyOpsRoPAHandler) UpdateProcessingActivity(c *gin.Context) {
 	})
 }
 
+func (h *PrivacyOpsRoPAHandler) PatchProcessingActivity(c *gin.Context) {
+	var item models.DataInventory
+	patchRecord(c, h.db, patchTarget{resource: "data_inventory"}, &item)
+}
+
 func (h *PrivacyOpsRoPAHandler) DeleteProcessingActivity(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.db.Where("id = ?", id).Delete(&models.DataInventory{}).Error; err != nil {
+	var item models.DataInventory
+	if err := requestDB(c, h.db).Where("id = ?", id).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Processing activity not found"})
+		return
+	}
+	if !checkIfMatch(c, &item) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "data_inventory", id, &item); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -166,7 +212,7 @@ func (h *PrivacyOpsRoPAHandler) GetRoPAStats(c *gin.Context) {
 		ByType    map[string]int64 `json:"byType"`
 	}
 	
-	query := h.db.Model(&models.DataInventory{})
+	query := requestDB(c, h.db).Model(&models.DataInventory{})
 	if tenantID != "" {
 		query = query.Where("tenant_id = ?", tenantID)
 	}
@@ -175,12 +221,12 @@ func (h *PrivacyOpsRoPAHandler) GetRoPAStats(c *gin.Context) {
 	
 	// Count by type
 	var types []string
-	h.db.Model(&models.DataInventory{}).Distinct("data_type").Pluck("data_type", &types)
+	requestDB(c, h.db).Model(&models.DataInventory{}).Distinct("data_type").Pluck("data_type", &types)
 	
 	stats.ByType = make(map[string]int64)
 	for _, t := range types {
 		var count int64
-		h.db.Model(&models.DataInventory{}).Where("data_type = ?", t).Count(&count)
+		requestDB(c, h.db).Model(&models.DataInventory{}).Where("data_type = ?", t).Count(&count)
 		stats.ByType[t] = count
 	}
 	
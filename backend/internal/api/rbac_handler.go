@@ -80,13 +80,13 @@ func (h *RBACHandler) AssignRoleToUser(c *gin.Context) {
 
 	// Update user role
 	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	if err := requestDB(c, h.db.DB).First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	user.Role = input.Role
-	if err := h.db.Save(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
 		return
 	}
@@ -109,13 +109,13 @@ func (h *RBACHandler) RevokeRoleFromUser(c *gin.Context) {
 
 	// Update user role to regular_user
 	var user models.User
-	if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
+	if err := requestDB(c, h.db.DB).First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	user.Role = models.RoleRegularUser
-	if err := h.db.Save(&user).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke user role"})
 		return
 	}
@@ -132,11 +132,11 @@ func (h *RBACHandler) SeedPermissions(c *gin.Context) {
 	// Create permissions
 	for _, perm := range models.AllPermissions {
 		var existingPerm models.Permission
-		result := h.db.Where("id = ?", perm.ID).First(&existingPerm)
+		result := requestDB(c, h.db.DB).Where("id = ?", perm.ID).First(&existingPerm)
 		
 		if result.Error != nil {
 			// Permission doesn't exist, create it
-			if err := h.db.Create(&perm).Error; err != nil {
+			if err := requestDB(c, h.db.DB).Create(&perm).Error; err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create permission"})
 				return
 			}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,39 +22,38 @@ func (h *RegOpsControlsHandler) GetControls(c *gin.Context) {
 	var controls []models.RegOpsControl
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&controls).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch RegOps controls"})
+	base := requestDB(c, h.db).Model(&models.RegOpsControl{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, regOpsControlQuery, &controls)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch RegOps controls")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    controls,
+		"success":    true,
+		"data":       controls,
+		"pagination": page,
 	})
 }
 
-func (h *RegOpsControlsHandler) CreateControl(c *gin.Context) {
-	var req struct {
-		Name                  string `json:"name" binding:"required"`
-		Description           string `json:"description" binding:"required"`
-		ControlType          string `json:"controlType"`
-		ControlFamily        string `json:"controlFamily"`
-		Framework             string `json:"framework"`
-		ImplementationStatus string `json:"implementationStatus"`
-		Effectiveness        string `json:"effectiveness"`
-		TestingFrequency     string `json:"testingFrequency"`
-		Owner                 string `json:"owner" binding:"required"`
-		LastTested           string `json:"lastTested"`
-		NextTest             string `json:"nextTest"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createControlRequest is the body accepted by CreateControl. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createControlRequest struct {
+	Name                  string `json:"name" binding:"required"`
+	Description           string `json:"description" binding:"required"`
+	ControlType          string `json:"controlType"`
+	ControlFamily        string `json:"controlFamily"`
+	Framework             string `json:"framework"`
+	ImplementationStatus string `json:"implementationStatus"`
+	Effectiveness        string `json:"effectiveness"`
+	TestingFrequency     string `json:"testingFrequency"`
+	Owner                 string `json:"owner" binding:"required"`
+	LastTested           string `json:"lastTested"`
+	NextTest             string `json:"nextTest"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateControl stores it
+func (req *createControlRequest) toModel(tenantID, userID string) models.RegOpsControl {
 	control := models.RegOpsControl{
 		TenantID:            tenantID,
 		Name:                  req.Name,
@@ -79,7 +79,25 @@ func (h *RegOpsControlsHandler) CreateControl(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&control).Error; err != nil {
+	return control
+}
+
+func (req *createControlRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RegOpsControlsHandler) CreateControl(c *gin.Context) {
+	var req createControlRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	control := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&control).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create RegOps control"})
 		return
 	}
@@ -91,22 +109,42 @@ func (h *RegOpsControlsHandler) CreateControl(c *gin.Context) {
 	})
 }
 
+// updateControlRequest is the body accepted by UpdateControl
+type updateControlRequest struct {
+	Name                  string `json:"name"`
+	Description           string `json:"description"`
+	ControlType          string `json:"controlType"`
+	ControlFamily        string `json:"controlFamily"`
+	Framework             string `json:"framework"`
+	ImplementationStatus string `json:"implementationStatus"`
+	Effectiveness        string `json:"effectiveness"`
+	TestingFrequency     string `json:"testingFrequency"`
+	Owner                 string `json:"owner"`
+	LastTested           string `json:"lastTested"`
+	NextTest             string `json:"nextTest"`
+}
+
+func (h *RegOpsControlsHandler) GetControl(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var control models.RegOpsControl
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "RegOps control not found"})
+		return
+	}
+
+	setETag(c, &control)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    control,
+	})
+}
+
 func (h *RegOpsControlsHandler) UpdateControl(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name                  string `json:"name"`
-		Description           string `json:"description"`
-		ControlType          string `json:"controlType"`
-		ControlFamily        string `json:"controlFamily"`
-		Framework             string `json:"framework"`
-		ImplementationStatus string `json:"implementationStatus"`
-		Effectiveness        string `json:"effectiveness"`
-		TestingFrequency     string `json:"testingFrequency"`
-		Owner                 string `json:"owner"`
-		LastTested           string `json:"lastTested"`
-		NextTest             string `json:"nextTest"`
-	}
+	var req updateControlRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -116,10 +154,13 @@ func (h *RegOpsControlsHandler) UpdateControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var control models.RegOpsControl
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "RegOps control not found"})
 		return
 	}
+	if !checkIfMatch(c, &control) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -163,27 +204,44 @@ func (h *RegOpsControlsHandler) UpdateControl(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&control).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&control).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &control, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update RegOps control"})
 		return
 	}
 
+	setETag(c, &control)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "RegOps control updated successfully",
+		"data":    control,
 	})
 }
 
+func (h *RegOpsControlsHandler) PatchControl(c *gin.Context) {
+	var control models.RegOpsControl
+	patchRecord(c, h.db, patchTarget{resource: "control"}, &control)
+}
+
 func (h *RegOpsControlsHandler) DeleteControl(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.RegOpsControl{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var control models.RegOpsControl
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&control).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "RegOps control not found"})
+		return
+	}
+	if !checkIfMatch(c, &control) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "control", id, &control); err != nil {
+		if staleWrite(c, h.db, &control, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete RegOps control"})
 		return
 	}
@@ -201,15 +259,15 @@ func (h *RegOpsControlsHandler) GetControlStats(c *gin.Context) {
 	var active int64
 	var implemented int64
 
-	h.db.Model(&models.RegOpsControl{}).
+	requestDB(c, h.db).Model(&models.RegOpsControl{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.RegOpsControl{}).
+	requestDB(c, h.db).Model(&models.RegOpsControl{}).
 		Where("tenant_id = ? AND is_deleted = ? AND implementation_status = ?", tenantID, false, "implemented").
 		Count(&active)
 
-	h.db.Model(&models.RegOpsControl{}).
+	requestDB(c, h.db).Model(&models.RegOpsControl{}).
 		Where("tenant_id = ? AND is_deleted = ? AND implementation_status IN (?)", tenantID, false, []string{"implemented", "partially_implemented"}).
 		Count(&implemented)
 
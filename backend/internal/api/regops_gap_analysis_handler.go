@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,38 +22,37 @@ func (h *RegOpsGapAnalysisHandler) GetComplianceGaps(c *gin.Context) {
 	var gaps []models.GapAnalysis
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&gaps).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch gap analysis records"})
+	base := requestDB(c, h.db).Model(&models.GapAnalysis{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, gapAnalysisQuery, &gaps)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch gap analysis records")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    gaps,
+		"success":    true,
+		"data":       gaps,
+		"pagination": page,
 	})
 }
 
-func (h *RegOpsGapAnalysisHandler) CreateComplianceGap(c *gin.Context) {
-	var req struct {
-		Name              string `json:"name" binding:"required"`
-		Description       string `json:"description" binding:"required"`
-		Framework         string `json:"framework"`
-		RegulationID     string `json:"regulationId"`
-		GapScore         int    `json:"gapScore"`
-		Findings         string `json:"findings"`
-		Recommendations  string `json:"recommendations"`
-		RemediationPlan  string `json:"remediationPlan"`
-		Owner             string `json:"owner" binding:"required"`
-		DueDate          string `json:"dueDate"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createComplianceGapRequest is the body accepted by CreateComplianceGap. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createComplianceGapRequest struct {
+	Name              string `json:"name" binding:"required"`
+	Description       string `json:"description" binding:"required"`
+	Framework         string `json:"framework"`
+	RegulationID     string `json:"regulationId"`
+	GapScore         int    `json:"gapScore"`
+	Findings         string `json:"findings"`
+	Recommendations  string `json:"recommendations"`
+	RemediationPlan  string `json:"remediationPlan"`
+	Owner             string `json:"owner" binding:"required"`
+	DueDate          string `json:"dueDate"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateComplianceGap stores it
+func (req *createComplianceGapRequest) toModel(tenantID, userID string) models.GapAnalysis {
 	gap := models.GapAnalysis{
 		TenantID:         tenantID,
 		RegulationID:     req.RegulationID,
@@ -73,7 +73,25 @@ func (h *RegOpsGapAnalysisHandler) CreateComplianceGap(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&gap).Error; err != nil {
+	return gap
+}
+
+func (req *createComplianceGapRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RegOpsGapAnalysisHandler) CreateComplianceGap(c *gin.Context) {
+	var req createComplianceGapRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gap := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&gap).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create gap analysis"})
 		return
 	}
@@ -85,22 +103,42 @@ func (h *RegOpsGapAnalysisHandler) CreateComplianceGap(c *gin.Context) {
 	})
 }
 
+// updateComplianceGapRequest is the body accepted by UpdateComplianceGap
+type updateComplianceGapRequest struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Framework         string `json:"framework"`
+	RegulationID     string `json:"regulationId"`
+	Status            string `json:"status"`
+	GapScore         int    `json:"gapScore"`
+	Findings         string `json:"findings"`
+	Recommendations  string `json:"recommendations"`
+	RemediationPlan  string `json:"remediationPlan"`
+	Owner             string `json:"owner"`
+	DueDate          string `json:"dueDate"`
+}
+
+func (h *RegOpsGapAnalysisHandler) GetComplianceGap(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var gap models.GapAnalysis
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&gap).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gap analysis not found"})
+		return
+	}
+
+	setETag(c, &gap)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gap,
+	})
+}
+
 func (h *RegOpsGapAnalysisHandler) UpdateComplianceGap(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name              string `json:"name"`
-		Description       string `json:"description"`
-		Framework         string `json:"framework"`
-		RegulationID     string `json:"regulationId"`
-		Status            string `json:"status"`
-		GapScore         int    `json:"gapScore"`
-		Findings         string `json:"findings"`
-		Recommendations  string `json:"recommendations"`
-		RemediationPlan  string `json:"remediationPlan"`
-		Owner             string `json:"owner"`
-		DueDate          string `json:"dueDate"`
-	}
+	var req updateComplianceGapRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -110,10 +148,13 @@ func (h *RegOpsGapAnalysisHandler) UpdateComplianceGap(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var gap models.GapAnalysis
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&gap).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&gap).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Gap analysis not found"})
 		return
 	}
+	if !checkIfMatch(c, &gap) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -155,27 +196,44 @@ func (h *RegOpsGapAnalysisHandler) UpdateComplianceGap(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&gap).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&gap).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &gap, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update gap analysis"})
 		return
 	}
 
+	setETag(c, &gap)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Compliance gap updated successfully",
+		"data":    gap,
 	})
 }
 
+func (h *RegOpsGapAnalysisHandler) PatchComplianceGap(c *gin.Context) {
+	var gap models.GapAnalysis
+	patchRecord(c, h.db, patchTarget{resource: "gap_analysis"}, &gap)
+}
+
 func (h *RegOpsGapAnalysisHandler) DeleteComplianceGap(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.GapAnalysis{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var gap models.GapAnalysis
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&gap).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Gap analysis not found"})
+		return
+	}
+	if !checkIfMatch(c, &gap) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "gap_analysis", id, &gap); err != nil {
+		if staleWrite(c, h.db, &gap, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete gap analysis"})
 		return
 	}
@@ -195,23 +253,23 @@ func (h *RegOpsGapAnalysisHandler) GetGapStats(c *gin.Context) {
 	var medium int64
 	var low int64
 
-	h.db.Model(&models.GapAnalysis{}).
+	requestDB(c, h.db).Model(&models.GapAnalysis{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.GapAnalysis{}).
+	requestDB(c, h.db).Model(&models.GapAnalysis{}).
 		Where("tenant_id = ? AND is_deleted = ? AND gap_score >= ?", tenantID, false, 80).
 		Count(&critical)
 
-	h.db.Model(&models.GapAnalysis{}).
+	requestDB(c, h.db).Model(&models.GapAnalysis{}).
 		Where("tenant_id = ? AND is_deleted = ? AND gap_score >= ? AND gap_score < ?", tenantID, false, 60, 80).
 		Count(&high)
 
-	h.db.Model(&models.GapAnalysis{}).
+	requestDB(c, h.db).Model(&models.GapAnalysis{}).
 		Where("tenant_id = ? AND is_deleted = ? AND gap_score >= ? AND gap_score < ?", tenantID, false, 40, 60).
 		Count(&medium)
 
-	h.db.Model(&models.GapAnalysis{}).
+	requestDB(c, h.db).Model(&models.GapAnalysis{}).
 		Where("tenant_id = ? AND is_deleted = ? AND gap_score < ?", tenantID, false, 40).
 		Count(&low)
 
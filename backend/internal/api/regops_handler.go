@@ -1,11 +1,18 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/versions"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type RegOpsHandler struct {
@@ -16,6 +23,84 @@ func NewRegOpsHandler(db *db.Database) *RegOpsHandler {
 	return &RegOpsHandler{db: db}
 }
 
+// createRegulationRequest is the body accepted by CreateRegulation
+type createRegulationRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description"`
+	Jurisdiction string `json:"jurisdiction"`
+	Type         string `json:"type"`
+	Status       string `json:"status"`
+	Version      string `json:"version"`
+	DocumentURL  string `json:"document_url"`
+}
+
+// toModel builds the record exactly as CreateRegulation stores it
+func (req *createRegulationRequest) toModel(tenantID string) models.Regulation {
+	status := req.Status
+	if status == "" {
+		status = "active"
+	}
+	return models.Regulation{
+		TenantID:      tenantID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Jurisdiction:  req.Jurisdiction,
+		Type:          req.Type,
+		Status:        status,
+		Version:       req.Version,
+		DocumentURL:   req.DocumentURL,
+		ParsedContent: "{}",
+	}
+}
+
+func (req *createRegulationRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID)
+	return &m
+}
+
+// createComplianceAssessmentRequest is the body accepted by
+// CreateComplianceAssessment
+type createComplianceAssessmentRequest struct {
+	RegulationID    string `json:"regulation_id" binding:"required"`
+	AssessmentDate  string `json:"assessment_date" binding:"required,datetime=2006-01-02"`
+	Status          string `json:"status"`
+	Score           int    `json:"score" binding:"min=0,max=100"`
+	Findings        string `json:"findings" binding:"omitempty,json"`
+	Recommendations string `json:"recommendations" binding:"omitempty,json"`
+	Evidence        string `json:"evidence" binding:"omitempty,json"`
+}
+
+// toModel builds the record exactly as CreateComplianceAssessment stores it
+func (req *createComplianceAssessmentRequest) toModel(tenantID, userID string) models.ComplianceAssessment {
+	assessmentDate, _ := time.Parse("2006-01-02", req.AssessmentDate)
+	status := req.Status
+	if status == "" {
+		status = "in_progress"
+	}
+	orEmpty := func(list string) string {
+		if list == "" {
+			return "[]"
+		}
+		return list
+	}
+	return models.ComplianceAssessment{
+		TenantID:        tenantID,
+		RegulationID:    req.RegulationID,
+		AssessmentDate:  assessmentDate,
+		Status:          status,
+		Score:           req.Score,
+		Findings:        orEmpty(req.Findings),
+		Recommendations: orEmpty(req.Recommendations),
+		Evidence:        orEmpty(req.Evidence),
+		CreatedBy:       userID,
+	}
+}
+
+func (req *createComplianceAssessmentRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
 // getTenantDB returns a DB scoped to the current tenant
 func (h *RegOpsHandler) getTenantDB(c *gin.Context) *db.Database {
 	tenantID := c.GetString("tenant_id")
@@ -23,17 +108,18 @@ func (h *RegOpsHandler) getTenantDB(c *gin.Context) *db.Database {
 		return h.db
 	}
 	// Return a new database wrapper with tenant-scoped session
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	return &db.Database{DB: tenantDB}
 }
 
 func (h *RegOpsHandler) GetRegulations(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var regulations []models.Regulation
-	if err := tenantDB.Where("is_deleted = ?", false).Find(&regulations).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch regulations"})
+	base := tenantDB.Model(&models.Regulation{}).Where("is_deleted = ?", false)
+	if _, err := query.List(c, base, regulationQuery, &regulations); err != nil {
+		respondListError(c, err, "Failed to fetch regulations")
 		return
 	}
 
@@ -42,15 +128,15 @@ func (h *RegOpsHandler) GetRegulations(c *gin.Context) {
 
 func (h *RegOpsHandler) CreateRegulation(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
-	var regulation models.Regulation
-	if err := c.ShouldBindJSON(&regulation); err != nil {
+	var req createRegulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	regulation.TenantID = tenantID
+	regulation := req.toModel(tenantID)
 
 	if err := tenantDB.Create(&regulation).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create regulation"})
@@ -60,9 +146,24 @@ func (h *RegOpsHandler) CreateRegulation(c *gin.Context) {
 	c.JSON(http.StatusCreated, regulation)
 }
 
+func (h *RegOpsHandler) GetRegulation(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
+	id := c.Param("id")
+
+	var regulation models.Regulation
+	if err := tenantDB.First(&regulation, "id = ? AND is_deleted = ?", id, false).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
+		return
+	}
+
+	setETag(c, &regulation)
+	c.JSON(http.StatusOK, regulation)
+}
+
 func (h *RegOpsHandler) UpdateRegulation(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var regulation models.Regulation
@@ -70,6 +171,9 @@ func (h *RegOpsHandler) UpdateRegulation(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
 		return
 	}
+	if !checkIfMatch(c, &regulation) {
+		return
+	}
 
 	var updateData models.Regulation
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -78,16 +182,25 @@ func (h *RegOpsHandler) UpdateRegulation(c *gin.Context) {
 	}
 
 	if err := tenantDB.Model(&regulation).Updates(updateData).Error; err != nil {
+		if staleWrite(c, tenantDB, &regulation, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update regulation"})
 		return
 	}
 
+	setETag(c, &regulation)
 	c.JSON(http.StatusOK, regulation)
 }
 
+func (h *RegOpsHandler) PatchRegulation(c *gin.Context) {
+	var regulation models.Regulation
+	patchRecord(c, h.db.DB, patchTarget{resource: "regulation", legacy: true}, &regulation)
+}
+
 func (h *RegOpsHandler) DeleteRegulation(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var regulation models.Regulation
@@ -95,11 +208,14 @@ func (h *RegOpsHandler) DeleteRegulation(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
 		return
 	}
+	if !checkIfMatch(c, &regulation) {
+		return
+	}
 
-	// Soft delete
-	regulation.IsDeleted = true
-	regulation.Status = "deleted"
-	if err := tenantDB.Save(&regulation).Error; err != nil {
+	if err := softDelete(tenantDB, c, "regulation", id, &regulation); err != nil {
+		if staleWrite(c, tenantDB, &regulation, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete regulation"})
 		return
 	}
@@ -110,11 +226,12 @@ func (h *RegOpsHandler) DeleteRegulation(c *gin.Context) {
 // Compliance Assessment CRUD
 func (h *RegOpsHandler) GetComplianceAssessments(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var assessments []models.ComplianceAssessment
-	if err := tenantDB.Where("is_deleted = ?", false).Find(&assessments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch compliance assessments"})
+	base := tenantDB.Model(&models.ComplianceAssessment{}).Where("is_deleted = ?", false)
+	if _, err := query.List(c, base, complianceAssessmentQuery, &assessments); err != nil {
+		respondListError(c, err, "Failed to fetch compliance assessments")
 		return
 	}
 
@@ -123,15 +240,15 @@ func (h *RegOpsHandler) GetComplianceAssessments(c *gin.Context) {
 
 func (h *RegOpsHandler) CreateComplianceAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
-	var assessment models.ComplianceAssessment
-	if err := c.ShouldBindJSON(&assessment); err != nil {
+	var req createComplianceAssessmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	assessment.TenantID = tenantID
+	assessment := req.toModel(tenantID, c.GetString("user_id"))
 
 	if err := tenantDB.Create(&assessment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create compliance assessment"})
@@ -141,9 +258,24 @@ func (h *RegOpsHandler) CreateComplianceAssessment(c *gin.Context) {
 	c.JSON(http.StatusCreated, assessment)
 }
 
+func (h *RegOpsHandler) GetComplianceAssessment(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
+	id := c.Param("id")
+
+	var assessment models.ComplianceAssessment
+	if err := tenantDB.First(&assessment, "id = ? AND is_deleted = ?", id, false).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Compliance assessment not found"})
+		return
+	}
+
+	setETag(c, &assessment)
+	c.JSON(http.StatusOK, assessment)
+}
+
 func (h *RegOpsHandler) UpdateComplianceAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var assessment models.ComplianceAssessment
@@ -151,6 +283,9 @@ func (h *RegOpsHandler) UpdateComplianceAssessment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Compliance assessment not found"})
 		return
 	}
+	if !checkIfMatch(c, &assessment) {
+		return
+	}
 
 	var updateData models.ComplianceAssessment
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -159,16 +294,25 @@ func (h *RegOpsHandler) UpdateComplianceAssessment(c *gin.Context) {
 	}
 
 	if err := tenantDB.Model(&assessment).Updates(updateData).Error; err != nil {
+		if staleWrite(c, tenantDB, &assessment, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update compliance assessment"})
 		return
 	}
 
+	setETag(c, &assessment)
 	c.JSON(http.StatusOK, assessment)
 }
 
+func (h *RegOpsHandler) PatchComplianceAssessment(c *gin.Context) {
+	var assessment models.ComplianceAssessment
+	patchRecord(c, h.db.DB, patchTarget{resource: "compliance_assessment", legacy: true}, &assessment)
+}
+
 func (h *RegOpsHandler) DeleteComplianceAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var assessment models.ComplianceAssessment
@@ -176,10 +320,14 @@ func (h *RegOpsHandler) DeleteComplianceAssessment(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Compliance assessment not found"})
 		return
 	}
+	if !checkIfMatch(c, &assessment) {
+		return
+	}
 
-	assessment.IsDeleted = true
-	assessment.Status = "deleted"
-	if err := tenantDB.Save(&assessment).Error; err != nil {
+	if err := softDelete(tenantDB, c, "compliance_assessment", id, &assessment); err != nil {
+		if staleWrite(c, tenantDB, &assessment, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete compliance assessment"})
 		return
 	}
@@ -190,11 +338,12 @@ func (h *RegOpsHandler) DeleteComplianceAssessment(c *gin.Context) {
 // Policy CRUD
 func (h *RegOpsHandler) GetPolicies(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var policies []models.Policy
-	if err := tenantDB.Where("is_deleted = ?", false).Find(&policies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch policies"})
+	base := tenantDB.Model(&models.Policy{}).Where("is_deleted = ?", false)
+	if _, err := query.List(c, base, policyQuery, &policies); err != nil {
+		respondListError(c, err, "Failed to fetch policies")
 		return
 	}
 
@@ -203,7 +352,7 @@ func (h *RegOpsHandler) GetPolicies(c *gin.Context) {
 
 func (h *RegOpsHandler) CreatePolicy(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var policy models.Policy
 	if err := c.ShouldBindJSON(&policy); err != nil {
@@ -221,9 +370,24 @@ func (h *RegOpsHandler) CreatePolicy(c *gin.Context) {
 	c.JSON(http.StatusCreated, policy)
 }
 
+func (h *RegOpsHandler) GetPolicy(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
+	id := c.Param("id")
+
+	var policy models.Policy
+	if err := tenantDB.First(&policy, "id = ? AND is_deleted = ?", id, false).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
+		return
+	}
+
+	setETag(c, &policy)
+	c.JSON(http.StatusOK, policy)
+}
+
 func (h *RegOpsHandler) UpdatePolicy(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var policy models.Policy
@@ -231,6 +395,9 @@ func (h *RegOpsHandler) UpdatePolicy(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
 		return
 	}
+	if !checkIfMatch(c, &policy) {
+		return
+	}
 
 	var updateData models.Policy
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -239,16 +406,129 @@ func (h *RegOpsHandler) UpdatePolicy(c *gin.Context) {
 	}
 
 	if err := tenantDB.Model(&policy).Updates(updateData).Error; err != nil {
+		if staleWrite(c, tenantDB, &policy, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
 		return
 	}
 
+	setETag(c, &policy)
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *RegOpsHandler) PatchPolicy(c *gin.Context) {
+	var policy models.Policy
+	patchRecord(c, h.db.DB, patchTarget{resource: "policy", legacy: true}, &policy)
+}
+
+// publishPolicyRequest is the body accepted by PublishPolicy. Both fields
+// are optional.
+type publishPolicyRequest struct {
+	// Bump is the part of the version to increase: major, minor (default) or
+	// patch
+	Bump string `json:"bump"`
+	// Version sets the version explicitly; it must be semantic and follow the
+	// current one
+	Version string `json:"version"`
+}
+
+// PublishPolicy makes a policy active under a semantic version. The first
+// publish keeps the policy's version when it is semantic, and uses 1.0.0
+// otherwise; later ones bump the published version.
+func (h *RegOpsHandler) PublishPolicy(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	var req publishPolicyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Bump == "" {
+		req.Bump = versions.BumpMinor
+	}
+	if _, err := (versions.Semver{}).Bump(req.Bump); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var policy models.Policy
+	err := (&db.Database{DB: requestDB(c, h.db.DB)}).TenantTx(tenantID, func(tx *gorm.DB) error {
+		if err := tx.First(&policy, "id = ? AND is_deleted = ?", c.Param("id"), false).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return patchFailed(http.StatusNotFound, "Policy not found")
+			}
+			return err
+		}
+		if !checkIfMatch(c, &policy) {
+			return errPatchResponded
+		}
+
+		next, err := nextPolicyVersion(&policy, req)
+		if err != nil {
+			return patchFailed(http.StatusUnprocessableEntity, err.Error())
+		}
+		now := time.Now()
+		if err := tx.Model(&policy).Updates(map[string]interface{}{
+			"version":       next.String(),
+			"status":        "active",
+			"approval_date": &now,
+			"updated_at":    now,
+		}).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, c, events.PolicyPublished, "policy", policy.ID, &policy)
+	})
+
+	var failure *patchFailure
+	switch {
+	case errors.Is(err, errPatchResponded):
+		return
+	case errors.As(err, &failure):
+		c.JSON(failure.status, failure.body)
+		return
+	case staleWrite(c, requestDB(c, h.db.GetTenantDB(tenantID)), &policy, err):
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish policy"})
+		return
+	}
+	notifyEvents()
+
+	setETag(c, &policy)
 	c.JSON(http.StatusOK, policy)
 }
 
+// nextPolicyVersion is the version a publish gives the policy. A policy
+// without an approval date has not been published yet.
+func nextPolicyVersion(policy *models.Policy, req publishPolicyRequest) (versions.Semver, error) {
+	firstPublish := policy.ApprovalDate == nil
+	current, err := versions.ParseSemver(policy.Version)
+	semantic := err == nil
+	if req.Version != "" {
+		next, err := versions.ParseSemver(req.Version)
+		if err != nil {
+			return next, err
+		}
+		if !firstPublish && semantic && !current.Less(next) {
+			return next, fmt.Errorf("version %s must follow the published version %s", next, current)
+		}
+		return next, nil
+	}
+	switch {
+	case !semantic:
+		// A free-form version set before publishing starts over
+		return versions.Semver{Major: 1}, nil
+	case firstPublish:
+		return current, nil
+	}
+	return current.Bump(req.Bump)
+}
+
 func (h *RegOpsHandler) DeletePolicy(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var policy models.Policy
@@ -256,10 +536,14 @@ func (h *RegOpsHandler) DeletePolicy(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
 		return
 	}
+	if !checkIfMatch(c, &policy) {
+		return
+	}
 
-	policy.IsDeleted = true
-	policy.Status = "deleted"
-	if err := tenantDB.Save(&policy).Error; err != nil {
+	if err := softDelete(tenantDB, c, "policy", id, &policy); err != nil {
+		if staleWrite(c, tenantDB, &policy, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete policy"})
 		return
 	}
@@ -270,11 +554,12 @@ func (h *RegOpsHandler) DeletePolicy(c *gin.Context) {
 // Control CRUD
 func (h *RegOpsHandler) GetControls(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var controls []models.RegOpsControl
-	if err := tenantDB.Where("is_deleted = ?", false).Find(&controls).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch controls"})
+	base := tenantDB.Model(&models.RegOpsControl{}).Where("is_deleted = ?", false)
+	if _, err := query.List(c, base, regOpsControlQuery, &controls); err != nil {
+		respondListError(c, err, "Failed to fetch controls")
 		return
 	}
 
@@ -283,7 +568,7 @@ func (h *RegOpsHandler) GetControls(c *gin.Context) {
 
 func (h *RegOpsHandler) CreateControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var control models.RegOpsControl
 	if err := c.ShouldBindJSON(&control); err != nil {
@@ -303,7 +588,7 @@ func (h *RegOpsHandler) CreateControl(c *gin.Context) {
 
 func (h *RegOpsHandler) UpdateControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var control models.RegOpsControl
@@ -328,7 +613,7 @@ func (h *RegOpsHandler) UpdateControl(c *gin.Context) {
 
 func (h *RegOpsHandler) DeleteControl(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var control models.RegOpsControl
@@ -337,9 +622,7 @@ func (h *RegOpsHandler) DeleteControl(c *gin.Context) {
 		return
 	}
 
-	control.IsDeleted = true
-	control.ImplementationStatus = "deleted"
-	if err := tenantDB.Save(&control).Error; err != nil {
+	if err := softDelete(tenantDB, c, "control", id, &control); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete control"})
 		return
 	}
@@ -350,11 +633,12 @@ func (h *RegOpsHandler) DeleteControl(c *gin.Context) {
 // Recovery endpoints for Regulations
 func (h *RegOpsHandler) GetDeletedRegulations(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var regulations []models.Regulation
-	if err := tenantDB.Where("is_deleted = ?", true).Find(&regulations).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted regulations"})
+	base := tenantDB.Unscoped().Model(&models.Regulation{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, true)
+	if _, err := query.List(c, base, regulationQuery, &regulations); err != nil {
+		respondListError(c, err, "Failed to fetch deleted regulations")
 		return
 	}
 
@@ -362,20 +646,8 @@ func (h *RegOpsHandler) GetDeletedRegulations(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) RestoreRegulation(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var regulation models.Regulation
-	if err := tenantDB.First(&regulation, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
-		return
-	}
-
-	regulation.IsDeleted = false
-	regulation.Status = "active"
-	if err := tenantDB.Save(&regulation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore regulation"})
+	resource, _ := models.LookupResource("regulation")
+	if _, ok := restoreResource(c, h.db, resource); !ok {
 		return
 	}
 
@@ -383,18 +655,8 @@ func (h *RegOpsHandler) RestoreRegulation(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) PermanentDeleteRegulation(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var regulation models.Regulation
-	if err := tenantDB.First(&regulation, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
-		return
-	}
-
-	if err := tenantDB.Unscoped().Delete(&regulation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to permanently delete regulation"})
+	resource, _ := models.LookupResource("regulation")
+	if !purgeResource(c, h.db, resource) {
 		return
 	}
 
@@ -404,11 +666,12 @@ func (h *RegOpsHandler) PermanentDeleteRegulation(c *gin.Context) {
 // Recovery endpoints for Compliance Assessments
 func (h *RegOpsHandler) GetDeletedComplianceAssessments(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var assessments []models.ComplianceAssessment
-	if err := tenantDB.Where("is_deleted = ?", true).Find(&assessments).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted assessments"})
+	base := tenantDB.Unscoped().Model(&models.ComplianceAssessment{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, true)
+	if _, err := query.List(c, base, complianceAssessmentQuery, &assessments); err != nil {
+		respondListError(c, err, "Failed to fetch deleted assessments")
 		return
 	}
 
@@ -416,20 +679,8 @@ func (h *RegOpsHandler) GetDeletedComplianceAssessments(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) RestoreComplianceAssessment(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var assessment models.ComplianceAssessment
-	if err := tenantDB.First(&assessment, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Assessment not found"})
-		return
-	}
-
-	assessment.IsDeleted = false
-	assessment.Status = "in_progress"
-	if err := tenantDB.Save(&assessment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore assessment"})
+	resource, _ := models.LookupResource("compliance_assessment")
+	if _, ok := restoreResource(c, h.db, resource); !ok {
 		return
 	}
 
@@ -437,18 +688,8 @@ func (h *RegOpsHandler) RestoreComplianceAssessment(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) PermanentDeleteComplianceAssessment(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var assessment models.ComplianceAssessment
-	if err := tenantDB.First(&assessment, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Assessment not found"})
-		return
-	}
-
-	if err := tenantDB.Unscoped().Delete(&assessment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to permanently delete assessment"})
+	resource, _ := models.LookupResource("compliance_assessment")
+	if !purgeResource(c, h.db, resource) {
 		return
 	}
 
@@ -458,11 +699,12 @@ func (h *RegOpsHandler) PermanentDeleteComplianceAssessment(c *gin.Context) {
 // Recovery endpoints for Policies
 func (h *RegOpsHandler) GetDeletedPolicies(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var policies []models.Policy
-	if err := tenantDB.Where("is_deleted = ?", true).Find(&policies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted policies"})
+	base := tenantDB.Unscoped().Model(&models.Policy{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, true)
+	if _, err := query.List(c, base, policyQuery, &policies); err != nil {
+		respondListError(c, err, "Failed to fetch deleted policies")
 		return
 	}
 
@@ -470,20 +712,8 @@ func (h *RegOpsHandler) GetDeletedPolicies(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) RestorePolicy(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var policy models.Policy
-	if err := tenantDB.First(&policy, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
-		return
-	}
-
-	policy.IsDeleted = false
-	policy.Status = "active"
-	if err := tenantDB.Save(&policy).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore policy"})
+	resource, _ := models.LookupResource("policy")
+	if _, ok := restoreResource(c, h.db, resource); !ok {
 		return
 	}
 
@@ -491,18 +721,8 @@ func (h *RegOpsHandler) RestorePolicy(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) PermanentDeletePolicy(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var policy models.Policy
-	if err := tenantDB.First(&policy, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
-		return
-	}
-
-	if err := tenantDB.Unscoped().Delete(&policy).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to permanently delete policy"})
+	resource, _ := models.LookupResource("policy")
+	if !purgeResource(c, h.db, resource) {
 		return
 	}
 
@@ -512,11 +732,12 @@ func (h *RegOpsHandler) PermanentDeletePolicy(c *gin.Context) {
 // Recovery endpoints for Controls
 func (h *RegOpsHandler) GetDeletedControls(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var controls []models.RegOpsControl
-	if err := tenantDB.Where("is_deleted = ?", true).Find(&controls).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deleted controls"})
+	base := tenantDB.Unscoped().Model(&models.RegOpsControl{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, true)
+	if _, err := query.List(c, base, regOpsControlQuery, &controls); err != nil {
+		respondListError(c, err, "Failed to fetch deleted controls")
 		return
 	}
 
@@ -524,20 +745,8 @@ func (h *RegOpsHandler) GetDeletedControls(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) RestoreControl(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var control models.RegOpsControl
-	if err := tenantDB.First(&control, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Control not found"})
-		return
-	}
-
-	control.IsDeleted = false
-	control.ImplementationStatus = "not_implemented"
-	if err := tenantDB.Save(&control).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore control"})
+	resource, _ := models.LookupResource("control")
+	if _, ok := restoreResource(c, h.db, resource); !ok {
 		return
 	}
 
@@ -545,18 +754,8 @@ func (h *RegOpsHandler) RestoreControl(c *gin.Context) {
 }
 
 func (h *RegOpsHandler) PermanentDeleteControl(c *gin.Context) {
-	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
-	id := c.Param("id")
-
-	var control models.RegOpsControl
-	if err := tenantDB.First(&control, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Control not found"})
-		return
-	}
-
-	if err := tenantDB.Unscoped().Delete(&control).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to permanently delete control"})
+	resource, _ := models.LookupResource("control")
+	if !purgeResource(c, h.db, resource) {
 		return
 	}
 
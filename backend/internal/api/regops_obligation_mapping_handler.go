@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,39 +22,38 @@ func (h *RegOpsObligationMappingHandler) GetObligations(c *gin.Context) {
 	var obligations []models.ObligationMapping
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&obligations).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch obligation mappings"})
+	base := requestDB(c, h.db).Model(&models.ObligationMapping{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, obligationQuery, &obligations)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch obligation mappings")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    obligations,
+		"success":    true,
+		"data":       obligations,
+		"pagination": page,
 	})
 }
 
-func (h *RegOpsObligationMappingHandler) CreateObligation(c *gin.Context) {
-	var req struct {
-		Name             string `json:"name" binding:"required"`
-		Description      string `json:"description" binding:"required"`
-		ObligationType  string `json:"obligationType" binding:"required"`
-		ControlID        string `json:"controlId"`
-		ControlName      string `json:"controlName"`
-		MappingStatus    string `json:"mappingStatus"`
-		ComplianceStatus string `json:"complianceStatus"`
-		Evidence         string `json:"evidence"`
-		Owner            string `json:"owner" binding:"required"`
-		LastReviewed      string `json:"lastReviewed"`
-		NextReview       string `json:"nextReview"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createObligationRequest is the body accepted by CreateObligation. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createObligationRequest struct {
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description" binding:"required"`
+	ObligationType  string `json:"obligationType" binding:"required"`
+	ControlID        string `json:"controlId"`
+	ControlName      string `json:"controlName"`
+	MappingStatus    string `json:"mappingStatus"`
+	ComplianceStatus string `json:"complianceStatus"`
+	Evidence         string `json:"evidence"`
+	Owner            string `json:"owner" binding:"required"`
+	LastReviewed      string `json:"lastReviewed"`
+	NextReview       string `json:"nextReview"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateObligation stores it
+func (req *createObligationRequest) toModel(tenantID, userID string) models.ObligationMapping {
 	obligation := models.ObligationMapping{
 		TenantID:         tenantID,
 		Name:              req.Name,
@@ -78,7 +78,25 @@ func (h *RegOpsObligationMappingHandler) CreateObligation(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&obligation).Error; err != nil {
+	return obligation
+}
+
+func (req *createObligationRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RegOpsObligationMappingHandler) CreateObligation(c *gin.Context) {
+	var req createObligationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	obligation := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&obligation).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create obligation mapping"})
 		return
 	}
@@ -90,22 +108,42 @@ func (h *RegOpsObligationMappingHandler) CreateObligation(c *gin.Context) {
 	})
 }
 
+// updateObligationRequest is the body accepted by UpdateObligation
+type updateObligationRequest struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	ObligationType  string `json:"obligationType"`
+	ControlID        string `json:"controlId"`
+	ControlName      string `json:"controlName"`
+	MappingStatus    string `json:"mappingStatus"`
+	ComplianceStatus string `json:"complianceStatus"`
+	Evidence         string `json:"evidence"`
+	Owner            string `json:"owner"`
+	LastReviewed      string `json:"lastReviewed"`
+	NextReview       string `json:"nextReview"`
+}
+
+func (h *RegOpsObligationMappingHandler) GetObligation(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var obligation models.ObligationMapping
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&obligation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Obligation mapping not found"})
+		return
+	}
+
+	setETag(c, &obligation)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    obligation,
+	})
+}
+
 func (h *RegOpsObligationMappingHandler) UpdateObligation(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name             string `json:"name"`
-		Description      string `json:"description"`
-		ObligationType  string `json:"obligationType"`
-		ControlID        string `json:"controlId"`
-		ControlName      string `json:"controlName"`
-		MappingStatus    string `json:"mappingStatus"`
-		ComplianceStatus string `json:"complianceStatus"`
-		Evidence         string `json:"evidence"`
-		Owner            string `json:"owner"`
-		LastReviewed      string `json:"lastReviewed"`
-		NextReview       string `json:"nextReview"`
-	}
+	var req updateObligationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -115,10 +153,13 @@ func (h *RegOpsObligationMappingHandler) UpdateObligation(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var obligation models.ObligationMapping
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&obligation).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&obligation).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Obligation mapping not found"})
 		return
 	}
+	if !checkIfMatch(c, &obligation) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -159,27 +200,44 @@ func (h *RegOpsObligationMappingHandler) UpdateObligation(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&obligation).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&obligation).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &obligation, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update obligation mapping"})
 		return
 	}
 
+	setETag(c, &obligation)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Obligation mapping updated successfully",
+		"data":    obligation,
 	})
 }
 
+func (h *RegOpsObligationMappingHandler) PatchObligation(c *gin.Context) {
+	var obligation models.ObligationMapping
+	patchRecord(c, h.db, patchTarget{resource: "obligation"}, &obligation)
+}
+
 func (h *RegOpsObligationMappingHandler) DeleteObligation(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.ObligationMapping{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var obligation models.ObligationMapping
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&obligation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Obligation mapping not found"})
+		return
+	}
+	if !checkIfMatch(c, &obligation) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "obligation", id, &obligation); err != nil {
+		if staleWrite(c, h.db, &obligation, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete obligation mapping"})
 		return
 	}
@@ -198,19 +256,19 @@ func (h *RegOpsObligationMappingHandler) GetObligationStats(c *gin.Context) {
 	var partial int64
 	var nonCompliant int64
 
-	h.db.Model(&models.ObligationMapping{}).
+	requestDB(c, h.db).Model(&models.ObligationMapping{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.ObligationMapping{}).
+	requestDB(c, h.db).Model(&models.ObligationMapping{}).
 		Where("tenant_id = ? AND is_deleted = ? AND compliance_status = ?", tenantID, false, "compliant").
 		Count(&compliant)
 
-	h.db.Model(&models.ObligationMapping{}).
+	requestDB(c, h.db).Model(&models.ObligationMapping{}).
 		Where("tenant_id = ? AND is_deleted = ? AND compliance_status = ?", tenantID, false, "partial").
 		Count(&partial)
 
-	h.db.Model(&models.ObligationMapping{}).
+	requestDB(c, h.db).Model(&models.ObligationMapping{}).
 		Where("tenant_id = ? AND is_deleted = ? AND compliance_status = ?", tenantID, false, "non_compliant").
 		Count(&nonCompliant)
 
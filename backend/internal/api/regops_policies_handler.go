@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,35 +22,34 @@ func (h *RegOpsPoliciesHandler) GetPolicies(c *gin.Context) {
 	var policies []models.Policy
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&policies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch policies"})
+	base := requestDB(c, h.db).Model(&models.Policy{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, policyQuery, &policies)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch policies")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    policies,
+		"success":    true,
+		"data":       policies,
+		"pagination": page,
 	})
 }
 
-func (h *RegOpsPoliciesHandler) CreatePolicy(c *gin.Context) {
-	var req struct {
-		Name         string `json:"name" binding:"required"`
-		Description  string `json:"description" binding:"required"`
-		PolicyType  string `json:"policyType" binding:"required"`
-		Version      string `json:"version" binding:"required"`
-		Owner        string `json:"owner" binding:"required"`
-		ApprovalDate string `json:"approvalDate"`
-		ReviewDate   string `json:"reviewDate"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createPolicyRequest is the body accepted by CreatePolicy. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createPolicyRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description" binding:"required"`
+	PolicyType  string `json:"policyType" binding:"required"`
+	Version      string `json:"version" binding:"required"`
+	Owner        string `json:"owner" binding:"required"`
+	ApprovalDate string `json:"approvalDate"`
+	ReviewDate   string `json:"reviewDate"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreatePolicy stores it
+func (req *createPolicyRequest) toModel(tenantID, userID string) models.Policy {
 	policy := models.Policy{
 		TenantID:   tenantID,
 		Name:         req.Name,
@@ -72,7 +72,25 @@ func (h *RegOpsPoliciesHandler) CreatePolicy(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&policy).Error; err != nil {
+	return policy
+}
+
+func (req *createPolicyRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RegOpsPoliciesHandler) CreatePolicy(c *gin.Context) {
+	var req createPolicyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&policy).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create policy"})
 		return
 	}
@@ -84,19 +102,22 @@ func (h *RegOpsPoliciesHandler) CreatePolicy(c *gin.Context) {
 	})
 }
 
+// updatePolicyRequest is the body accepted by UpdatePolicy
+type updatePolicyRequest struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	PolicyType  string `json:"policyType"`
+	Version      string `json:"version"`
+	Status       string `json:"status"`
+	Owner        string `json:"owner"`
+	ApprovalDate string `json:"approvalDate"`
+	ReviewDate   string `json:"reviewDate"`
+}
+
 func (h *RegOpsPoliciesHandler) UpdatePolicy(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name         string `json:"name"`
-		Description  string `json:"description"`
-		PolicyType  string `json:"policyType"`
-		Version      string `json:"version"`
-		Status       string `json:"status"`
-		Owner        string `json:"owner"`
-		ApprovalDate string `json:"approvalDate"`
-		ReviewDate   string `json:"reviewDate"`
-	}
+	var req updatePolicyRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -106,7 +127,7 @@ func (h *RegOpsPoliciesHandler) UpdatePolicy(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var policy models.Policy
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&policy).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&policy).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
 		return
 	}
@@ -144,7 +165,7 @@ func (h *RegOpsPoliciesHandler) UpdatePolicy(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&policy).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&policy).Updates(updates).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update policy"})
 		return
 	}
@@ -159,12 +180,9 @@ func (h *RegOpsPoliciesHandler) DeletePolicy(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Policy{}).
+	if err := requestDB(c, h.db).Model(&models.Policy{}).
 		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+		Updates(models.TrashUpdates(c.GetString("user_id"), time.Now())).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete policy"})
 		return
 	}
@@ -182,15 +200,15 @@ func (h *RegOpsPoliciesHandler) GetPolicyStats(c *gin.Context) {
 	var active int64
 	var draft int64
 
-	h.db.Model(&models.Policy{}).
+	requestDB(c, h.db).Model(&models.Policy{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.Policy{}).
+	requestDB(c, h.db).Model(&models.Policy{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "active").
 		Count(&active)
 
-	h.db.Model(&models.Policy{}).
+	requestDB(c, h.db).Model(&models.Policy{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "draft").
 		Count(&draft)
 
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/regulations"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// importClausesRequest is the body accepted by ImportRegulationClauses
+type importClausesRequest struct {
+	Format  string `json:"format" binding:"required"` // text, markdown or json
+	Content string `json:"content" binding:"required"`
+	DryRun  bool   `json:"dry_run"` // parse and count without saving
+}
+
+// linkClauseRequest is the body accepted by the clause link endpoints. An
+// empty clause_id unlinks the record.
+type linkClauseRequest struct {
+	ClauseID string `json:"clause_id"`
+}
+
+// clauseDetail is a clause with where it sits in its regulation and what
+// is linked to it
+type clauseDetail struct {
+	Clause      models.RegulationClause    `json:"clause"`
+	Path        []models.RegulationClause  `json:"path"` // ancestors, outermost first
+	Children    []models.RegulationClause  `json:"children"`
+	Obligations []models.ObligationMapping `json:"obligations"`
+	Controls    []models.RegOpsControl     `json:"controls"`
+}
+
+// errDryRun rolls back the transaction of a dry-run import
+var errDryRun = errors.New("dry run")
+
+// RegulationClauseHandler serves the structure of regulations. Regulations
+// live in the tenant schema, their clauses in the public one.
+type RegulationClauseHandler struct {
+	db *db.Database
+}
+
+func NewRegulationClauseHandler(database *db.Database) *RegulationClauseHandler {
+	return &RegulationClauseHandler{db: database}
+}
+
+// regulationExists checks the :id regulation is the tenant's. It writes the
+// error response and returns false when it is not.
+func (h *RegulationClauseHandler) regulationExists(c *gin.Context) bool {
+	var regulation models.Regulation
+	err := (&db.Database{DB: requestDB(c, h.db.DB)}).TenantTx(c.GetString("tenant_id"), func(tx *gorm.DB) error {
+		return tx.Select("id").First(&regulation, "id = ? AND is_deleted = ?", c.Param("id"), false).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation not found"})
+		return false
+	}
+	return true
+}
+
+// GetRegulationClauses lists the clauses of a regulation as a tree, or in
+// order with flat=true. Clauses removed by a later import are left out
+// unless include_removed=true.
+func (h *RegulationClauseHandler) GetRegulationClauses(c *gin.Context) {
+	if !h.regulationExists(c) {
+		return
+	}
+
+	base := requestDB(c, h.db.DB).Where("tenant_id = ? AND regulation_id = ?", c.GetString("tenant_id"), c.Param("id"))
+	if c.Query("include_removed") != "true" {
+		base = base.Where("removed = ?", false)
+	}
+	var clauses []models.RegulationClause
+	if err := base.Order("position").Find(&clauses).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch regulation clauses"})
+		return
+	}
+
+	var data interface{} = regulations.Tree(clauses)
+	if c.Query("flat") == "true" {
+		data = clauses
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ImportRegulationClauses builds the clauses of a regulation from its text.
+// Importing again updates the clauses in place: clauses keep their IDs and
+// links as long as their refs stay the same.
+func (h *RegulationClauseHandler) ImportRegulationClauses(c *gin.Context) {
+	var req importClausesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.regulationExists(c) {
+		return
+	}
+	nodes, err := regulations.Parse(strings.ToLower(req.Format), req.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var result regulations.Result
+	err = requestDB(c, h.db.DB).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if result, err = regulations.Import(tx, c.GetString("tenant_id"), c.Param("id"), nodes); err != nil {
+			return err
+		}
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		log.Printf("Failed to import clauses of regulation %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import regulation clauses"})
+		return
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Regulation clauses parsed; nothing was saved",
+			"data":    result,
+			"preview": nodes,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Regulation clauses imported successfully",
+		"data":    result,
+	})
+}
+
+// GetRegulationClause returns a clause with its ancestors, its children and
+// the obligations and controls linked to it
+func (h *RegulationClauseHandler) GetRegulationClause(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	gdb := requestDB(c, h.db.DB)
+
+	var detail clauseDetail
+	if err := gdb.Where("id = ? AND tenant_id = ?", c.Param("id"), tenantID).First(&detail.Clause).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Regulation clause not found"})
+		return
+	}
+
+	err := func() error {
+		detail.Path = []models.RegulationClause{}
+		for parentID := detail.Clause.ParentID; parentID != nil; {
+			var parent models.RegulationClause
+			if err := gdb.Where("id = ? AND tenant_id = ?", *parentID, tenantID).First(&parent).Error; err != nil {
+				return err
+			}
+			detail.Path = append([]models.RegulationClause{parent}, detail.Path...)
+			parentID = parent.ParentID
+		}
+		if err := gdb.Where("parent_id = ? AND tenant_id = ? AND removed = ?", detail.Clause.ID, tenantID, false).
+			Order("position").Find(&detail.Children).Error; err != nil {
+			return err
+		}
+		if err := gdb.Where("clause_id = ? AND tenant_id = ? AND is_deleted = ?", detail.Clause.ID, tenantID, false).
+			Find(&detail.Obligations).Error; err != nil {
+			return err
+		}
+		return gdb.Where("clause_id = ? AND tenant_id = ? AND is_deleted = ?", detail.Clause.ID, tenantID, false).
+			Find(&detail.Controls).Error
+	}()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch regulation clause"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    detail,
+	})
+}
+
+// linkClause links the :id record to a clause of the tenant and to the
+// clause's regulation. label names the record in messages. It writes the
+// response.
+func linkClause(c *gin.Context, gdb *gorm.DB, record models.Revisioned, label string) {
+	var req linkClauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID := c.GetString("tenant_id")
+	tx := requestDB(c, gdb)
+	if err := tx.Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), tenantID, false).First(record).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": label + " not found"})
+		return
+	}
+	if !checkIfMatch(c, record) {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"clause_id":  "",
+		"updated_at": time.Now(),
+	}
+	message := label + " unlinked from its clause"
+	if req.ClauseID != "" {
+		var clause models.RegulationClause
+		if err := tx.Where("id = ? AND tenant_id = ? AND removed = ?", req.ClauseID, tenantID, false).First(&clause).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Regulation clause not found"})
+			return
+		}
+		updates["clause_id"] = clause.ID
+		updates["regulation_id"] = clause.RegulationID
+		message = label + " linked to " + clause.Ref
+	}
+
+	if err := tx.Model(record).Updates(updates).Error; err != nil {
+		if staleWrite(c, gdb, record, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link " + strings.ToLower(label) + " to the clause"})
+		return
+	}
+
+	setETag(c, record)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    record,
+	})
+}
+
+// LinkObligationClause links an obligation mapping to the regulation clause
+// it derives from
+func (h *RegOpsObligationMappingHandler) LinkObligationClause(c *gin.Context) {
+	var obligation models.ObligationMapping
+	linkClause(c, h.db, &obligation, "Obligation mapping")
+}
+
+// LinkControlClause links a control to the regulation clause it addresses
+func (h *RegOpsControlsHandler) LinkControlClause(c *gin.Context) {
+	var control models.RegOpsControl
+	linkClause(c, h.db, &control, "RegOps control")
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/reminders"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var reminderQuery = query.Spec{
+	Filters:     []string{"source", "resource_type", "resource_id", "stage", "escalated", "due_date", "created_at"},
+	Sorts:       []string{"created_at", "due_date"},
+	DefaultSort: "-created_at",
+}
+
+// reminderRuleRequest sets a rule; omitted fields take the default rule's
+type reminderRuleRequest struct {
+	Enabled           *bool   `json:"enabled"`
+	DaysBefore        []int64 `json:"days_before"`
+	EscalateAfterDays int     `json:"escalate_after_days"`
+	EscalateTo        string  `json:"escalate_to"`
+}
+
+type reminderSettingsRequest struct {
+	Timezone   string `json:"timezone"`
+	QuietStart string `json:"quiet_start"`
+	QuietEnd   string `json:"quiet_end"`
+}
+
+// reminderRuleView is the rule in effect for a source
+type reminderRuleView struct {
+	Source reminders.Source    `json:"source"`
+	Rule   models.ReminderRule `json:"rule"`
+	Custom bool                `json:"custom"` // false when the default rule applies
+}
+
+type ReminderHandler struct {
+	db *gorm.DB
+}
+
+func NewReminderHandler(db *gorm.DB) *ReminderHandler {
+	return &ReminderHandler{db: db}
+}
+
+// reminderSource resolves the :source param. It writes the error response
+// and returns false for an unknown source.
+func reminderSource(c *gin.Context) (reminders.Source, bool) {
+	source, ok := reminders.LookupSource(c.Param("source"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown reminder source"})
+	}
+	return source, ok
+}
+
+// GetReminders lists the reminders and escalations sent to the caller
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	base := requestDB(c, h.db).Model(&models.Reminder{}).
+		Where("tenant_id = ? AND ? = ANY(recipients)", c.GetString("tenant_id"), c.GetString("user_id"))
+	var list []models.Reminder
+	page, err := query.List(c, base, reminderQuery, &list)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch reminders")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       list,
+		"pagination": page,
+	})
+}
+
+// GetReminderRules lists the reminder rule in effect for every source of
+// deadlines
+func (h *ReminderHandler) GetReminderRules(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	var rules []models.ReminderRule
+	if err := requestDB(c, h.db).Where("tenant_id = ?", tenantID).Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder rules"})
+		return
+	}
+	bySource := map[string]models.ReminderRule{}
+	for _, rule := range rules {
+		bySource[rule.Source] = rule
+	}
+
+	views := make([]reminderRuleView, 0, len(reminders.Sources))
+	for _, source := range reminders.Sources {
+		rule, custom := bySource[source.Name]
+		if !custom {
+			rule = reminders.DefaultRule(tenantID, source.Name)
+		}
+		views = append(views, reminderRuleView{Source: source, Rule: rule, Custom: custom})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": views})
+}
+
+// SetReminderRule sets the tenant's reminder rule of a source of deadlines
+func (h *ReminderHandler) SetReminderRule(c *gin.Context) {
+	source, ok := reminderSource(c)
+	if !ok {
+		return
+	}
+	var req reminderRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID := c.GetString("tenant_id")
+	rule := reminders.DefaultRule(tenantID, source.Name)
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	if req.DaysBefore != nil {
+		rule.DaysBefore = pq.Int64Array(req.DaysBefore)
+	}
+	rule.EscalateAfterDays = req.EscalateAfterDays
+	if req.EscalateTo != "" {
+		rule.EscalateTo = req.EscalateTo
+	}
+	rule.UpdatedBy = c.GetString("user_id")
+	if err := reminders.ValidateRule(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gdb := requestDB(c, h.db)
+	var existing models.ReminderRule
+	err := gdb.Where("tenant_id = ? AND source = ?", tenantID, source.Name).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = gdb.Create(&rule).Error
+	case err == nil:
+		err = gdb.Model(&existing).Updates(map[string]interface{}{
+			"enabled":             rule.Enabled,
+			"days_before":         rule.DaysBefore,
+			"escalate_after_days": rule.EscalateAfterDays,
+			"escalate_to":         rule.EscalateTo,
+			"updated_by":          rule.UpdatedBy,
+		}).Error
+		rule = existing
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reminder rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reminder rule saved successfully",
+		"data":    rule,
+	})
+}
+
+// DeleteReminderRule removes the tenant's reminder rule of a source, so the
+// default rule applies again
+func (h *ReminderHandler) DeleteReminderRule(c *gin.Context) {
+	source, ok := reminderSource(c)
+	if !ok {
+		return
+	}
+	result := requestDB(c, h.db).Where("tenant_id = ? AND source = ?", c.GetString("tenant_id"), source.Name).
+		Delete(&models.ReminderRule{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reminder rule"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reminder rule deleted successfully",
+	})
+}
+
+// GetReminderSettings returns the tenant's reminder time zone and quiet hours
+func (h *ReminderHandler) GetReminderSettings(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	settings := reminders.DefaultSettings(tenantID)
+	var saved []models.ReminderSettings
+	if err := requestDB(c, h.db).Where("tenant_id = ?", tenantID).Find(&saved).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder settings"})
+		return
+	}
+	if len(saved) > 0 {
+		settings = saved[0]
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+// SetReminderSettings sets the tenant's reminder time zone and quiet hours.
+// Empty quiet hours turn them off.
+func (h *ReminderHandler) SetReminderSettings(c *gin.Context) {
+	var req reminderSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	settings := reminders.DefaultSettings(c.GetString("tenant_id"))
+	if req.Timezone != "" {
+		settings.Timezone = req.Timezone
+	}
+	settings.QuietStart = req.QuietStart
+	settings.QuietEnd = req.QuietEnd
+	settings.UpdatedBy = c.GetString("user_id")
+	settings.UpdatedAt = time.Now()
+	if err := reminders.ValidateSettings(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := requestDB(c, h.db).Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reminder settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Reminder settings saved successfully",
+		"data":    settings,
+	})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/retention"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var legalHoldQuery = query.Spec{
+	Filters:     []string{"data_class", "placed_by", "created_at", "released_at"},
+	Sorts:       []string{"created_at", "released_at"},
+	DefaultSort: "-created_at",
+}
+
+type setRetentionRequest struct {
+	RetentionDays int `json:"retention_days" binding:"required,min=1"`
+}
+
+type legalHoldRequest struct {
+	DataClass string `json:"data_class"` // empty holds every class
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type RetentionHandler struct {
+	db *gorm.DB
+}
+
+func NewRetentionHandler(db *gorm.DB) *RetentionHandler {
+	return &RetentionHandler{db: db}
+}
+
+// retentionClass resolves the :class param. It writes the error response
+// and returns false for an unknown class.
+func retentionClass(c *gin.Context) (retention.Class, bool) {
+	class, ok := retention.LookupClass(c.Param("class"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown data class"})
+	}
+	return class, ok
+}
+
+func (h *RetentionHandler) respondSettings(c *gin.Context, tenantID string) {
+	settings, err := retention.Settings(requestDB(c, h.db), tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch retention policies"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": settings})
+}
+
+// setPolicy sets the retention period of the :class data of a tenant, or
+// the platform default with an empty tenant
+func (h *RetentionHandler) setPolicy(c *gin.Context, tenantID string) {
+	class, ok := retentionClass(c)
+	if !ok {
+		return
+	}
+	var req setRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gdb := requestDB(c, h.db)
+	var policy models.RetentionPolicy
+	err := gdb.Where("tenant_id = ? AND data_class = ?", tenantID, class.Name).First(&policy).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		policy = models.RetentionPolicy{
+			TenantID:      tenantID,
+			DataClass:     class.Name,
+			RetentionDays: req.RetentionDays,
+			UpdatedBy:     c.GetString("user_id"),
+		}
+		err = gdb.Create(&policy).Error
+	case err == nil:
+		err = gdb.Model(&policy).Updates(map[string]interface{}{
+			"retention_days": req.RetentionDays,
+			"updated_by":     c.GetString("user_id"),
+		}).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save retention policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Retention policy saved successfully",
+		"data":    policy,
+	})
+}
+
+// deletePolicy removes the retention period of the :class data of a tenant,
+// or the platform default with an empty tenant
+func (h *RetentionHandler) deletePolicy(c *gin.Context, tenantID string) {
+	class, ok := retentionClass(c)
+	if !ok {
+		return
+	}
+	result := requestDB(c, h.db).Where("tenant_id = ? AND data_class = ?", tenantID, class.Name).Delete(&models.RetentionPolicy{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete retention policy"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Retention policy not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Retention policy deleted successfully",
+	})
+}
+
+// GetRetention lists the retention of every class of the tenant's data: its
+// own period, the platform default and whether a legal hold covers it
+func (h *RetentionHandler) GetRetention(c *gin.Context) {
+	h.respondSettings(c, c.GetString("tenant_id"))
+}
+
+// SetRetention sets the tenant's retention period of a class of data
+func (h *RetentionHandler) SetRetention(c *gin.Context) {
+	h.setPolicy(c, c.GetString("tenant_id"))
+}
+
+// DeleteRetention removes the tenant's retention period of a class of data,
+// so the platform default applies again
+func (h *RetentionHandler) DeleteRetention(c *gin.Context) {
+	h.deletePolicy(c, c.GetString("tenant_id"))
+}
+
+// GetDefaultRetention lists the platform default retention of every class
+func (h *RetentionHandler) GetDefaultRetention(c *gin.Context) {
+	h.respondSettings(c, "")
+}
+
+// SetDefaultRetention sets the platform default retention period of a class,
+// which also applies to platform data without a tenant
+func (h *RetentionHandler) SetDefaultRetention(c *gin.Context) {
+	h.setPolicy(c, "")
+}
+
+// DeleteDefaultRetention removes the platform default retention period of a
+// class
+func (h *RetentionHandler) DeleteDefaultRetention(c *gin.Context) {
+	h.deletePolicy(c, "")
+}
+
+// PurgeNow queues a purge without waiting for the scheduled one. Its
+// reports are written to the audit log of each tenant.
+func (h *RetentionHandler) PurgeNow(c *gin.Context) {
+	var job *models.Job
+	if err := withJobs(requestDB(c, h.db), func(tx *gorm.DB) error {
+		var err error
+		job, err = retention.PurgeJob.Enqueue(tx, "", struct{}{})
+		return err
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue retention purge"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Retention purge queued",
+		"data":    job,
+	})
+}
+
+// GetLegalHolds lists the tenant's legal holds, active and released
+func (h *RetentionHandler) GetLegalHolds(c *gin.Context) {
+	var holds []models.LegalHold
+	base := requestDB(c, h.db).Model(&models.LegalHold{}).Where("tenant_id = ?", c.GetString("tenant_id"))
+	page, err := query.List(c, base, legalHoldQuery, &holds)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch legal holds")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       holds,
+		"pagination": page,
+	})
+}
+
+// CreateLegalHold suspends purging of a class of the tenant's data, or of
+// all of it, until the hold is released
+func (h *RetentionHandler) CreateLegalHold(c *gin.Context) {
+	var req legalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DataClass != "" {
+		if _, ok := retention.LookupClass(req.DataClass); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown data class"})
+			return
+		}
+	}
+
+	hold := models.LegalHold{
+		TenantID:  c.GetString("tenant_id"),
+		DataClass: req.DataClass,
+		Reason:    req.Reason,
+		PlacedBy:  c.GetString("user_id"),
+	}
+	if err := requestDB(c, h.db).Create(&hold).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to place legal hold"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Legal hold placed successfully",
+		"data":    hold,
+	})
+}
+
+// ReleaseLegalHold ends a legal hold; purging resumes with the next run
+func (h *RetentionHandler) ReleaseLegalHold(c *gin.Context) {
+	var hold models.LegalHold
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND released_at IS NULL", c.Param("id"), c.GetString("tenant_id")).
+		First(&hold).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active legal hold not found"})
+		return
+	}
+
+	if err := requestDB(c, h.db).Model(&hold).Updates(map[string]interface{}{
+		"released_at": time.Now(),
+		"released_by": c.GetString("user_id"),
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release legal hold"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Legal hold released successfully",
+		"data":    hold,
+	})
+}
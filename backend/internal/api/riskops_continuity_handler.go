@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,41 +23,40 @@ func (h *RiskOpsContinuityHandler) GetContinuityPlans(c *gin.Context) {
 	var plans []models.BusinessContinuity
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&plans).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch continuity plans"})
+	base := requestDB(c, h.db).Model(&models.BusinessContinuity{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, continuityQuery, &plans)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch continuity plans")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    plans,
+		"success":    true,
+		"data":       plans,
+		"pagination": page,
 	})
 }
 
-func (h *RiskOpsContinuityHandler) CreateContinuityPlan(c *gin.Context) {
-	var req struct {
-		Name              string  `json:"name" binding:"required"`
-		Description       string  `json:"description" binding:"required"`
-		BusinessFunction string  `json:"businessFunction" binding:"required"`
-		Criticality       string  `json:"criticality" binding:"required"`
-		RTOHours          int     `json:"rtoHours" binding:"required"`
-		RPOHours          int     `json:"rpoHours" binding:"required"`
-		RecoveryStrategy string  `json:"recoveryStrategy" binding:"required"`
-		BackupProcedures  string  `json:"backupProcedures"`
-		TestDate         string  `json:"testDate"`
-		TestResult       string  `json:"testResult"`
-		TestFindings     string  `json:"testFindings"`
-		ImprovementActions string `json:"improvementActions"`
-		Owner             string  `json:"owner" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createContinuityPlanRequest is the body accepted by CreateContinuityPlan. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createContinuityPlanRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Description       string  `json:"description" binding:"required"`
+	BusinessFunction string  `json:"businessFunction" binding:"required"`
+	Criticality       string  `json:"criticality" binding:"required"`
+	RTOHours          int     `json:"rtoHours" binding:"required"`
+	RPOHours          int     `json:"rpoHours" binding:"required"`
+	RecoveryStrategy string  `json:"recoveryStrategy" binding:"required"`
+	BackupProcedures  string  `json:"backupProcedures"`
+	TestDate         string  `json:"testDate"`
+	TestResult       string  `json:"testResult"`
+	TestFindings     string  `json:"testFindings"`
+	ImprovementActions string `json:"improvementActions"`
+	Owner             string  `json:"owner" binding:"required"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateContinuityPlan stores it
+func (req *createContinuityPlanRequest) toModel(tenantID, userID string) models.BusinessContinuity {
 	plan := models.BusinessContinuity{
 		TenantID:          tenantID,
 		Name:               req.Name,
@@ -75,7 +76,25 @@ func (h *RiskOpsContinuityHandler) CreateContinuityPlan(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&plan).Error; err != nil {
+	return plan
+}
+
+func (req *createContinuityPlanRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RiskOpsContinuityHandler) CreateContinuityPlan(c *gin.Context) {
+	var req createContinuityPlanRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&plan).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create continuity plan"})
 		return
 	}
@@ -87,25 +106,45 @@ func (h *RiskOpsContinuityHandler) CreateContinuityPlan(c *gin.Context) {
 	})
 }
 
+// updateContinuityPlanRequest is the body accepted by UpdateContinuityPlan
+type updateContinuityPlanRequest struct {
+	Name              string  `json:"name"`
+	Description       string  `json:"description"`
+	BusinessFunction string  `json:"businessFunction"`
+	Criticality       string  `json:"criticality"`
+	Status            string  `json:"status"`
+	RTOHours          int     `json:"rtoHours"`
+	RPOHours          int     `json:"rpoHours"`
+	RecoveryStrategy string  `json:"recoveryStrategy"`
+	BackupProcedures  string  `json:"backupProcedures"`
+	TestDate         string  `json:"testDate"`
+	TestResult       string  `json:"testResult"`
+	TestFindings     string  `json:"testFindings"`
+	ImprovementActions string  `json:"improvementActions"`
+	Owner             string  `json:"owner"`
+}
+
+func (h *RiskOpsContinuityHandler) GetContinuityPlan(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var plan models.BusinessContinuity
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Continuity plan not found"})
+		return
+	}
+
+	setETag(c, &plan)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
 func (h *RiskOpsContinuityHandler) UpdateContinuityPlan(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name              string  `json:"name"`
-		Description       string  `json:"description"`
-		BusinessFunction string  `json:"businessFunction"`
-		Criticality       string  `json:"criticality"`
-		Status            string  `json:"status"`
-		RTOHours          int     `json:"rtoHours"`
-		RPOHours          int     `json:"rpoHours"`
-		RecoveryStrategy string  `json:"recoveryStrategy"`
-		BackupProcedures  string  `json:"backupProcedures"`
-		TestDate         string  `json:"testDate"`
-		TestResult       string  `json:"testResult"`
-		TestFindings     string  `json:"testFindings"`
-		ImprovementActions string  `json:"improvementActions"`
-		Owner             string  `json:"owner"`
-	}
+	var req updateContinuityPlanRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -115,10 +154,13 @@ func (h *RiskOpsContinuityHandler) UpdateContinuityPlan(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var plan models.BusinessContinuity
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&plan).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&plan).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Continuity plan not found"})
 		return
 	}
+	if !checkIfMatch(c, &plan) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -169,27 +211,44 @@ func (h *RiskOpsContinuityHandler) UpdateContinuityPlan(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&plan).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&plan).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &plan, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update continuity plan"})
 		return
 	}
 
+	setETag(c, &plan)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Continuity plan updated successfully",
+		"data":    plan,
 	})
 }
 
+func (h *RiskOpsContinuityHandler) PatchContinuityPlan(c *gin.Context) {
+	var plan models.BusinessContinuity
+	patchRecord(c, h.db, patchTarget{resource: "continuity_plan"}, &plan)
+}
+
 func (h *RiskOpsContinuityHandler) DeleteContinuityPlan(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.BusinessContinuity{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var plan models.BusinessContinuity
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Continuity plan not found"})
+		return
+	}
+	if !checkIfMatch(c, &plan) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "continuity_plan", id, &plan); err != nil {
+		if staleWrite(c, h.db, &plan, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete continuity plan"})
 		return
 	}
@@ -206,12 +265,22 @@ func (h *RiskOpsContinuityHandler) TestContinuityPlan(c *gin.Context) {
 
 	testDate := time.Now()
 
-	if err := h.db.Model(&models.BusinessContinuity{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"test_date": &testDate,
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	var plan models.BusinessContinuity
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&plan).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Continuity plan not found"})
+		return
+	}
+	if !checkIfMatch(c, &plan) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &plan, map[string]interface{}{
+		"test_date": &testDate,
+		"updated_at": time.Now(),
+	}, events.ContinuityPlanTested, "continuity_plan", id); err != nil {
+		if staleWrite(c, h.db, &plan, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to test continuity plan"})
 		return
 	}
@@ -230,19 +299,19 @@ func (h *RiskOpsContinuityHandler) GetContinuityStats(c *gin.Context) {
 	var critical int64
 	var high int64
 
-	h.db.Model(&models.BusinessContinuity{}).
+	requestDB(c, h.db).Model(&models.BusinessContinuity{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.BusinessContinuity{}).
+	requestDB(c, h.db).Model(&models.BusinessContinuity{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "active").
 		Count(&active)
 
-	h.db.Model(&models.BusinessContinuity{}).
+	requestDB(c, h.db).Model(&models.BusinessContinuity{}).
 		Where("tenant_id = ? AND is_deleted = ? AND criticality = ?", tenantID, false, "critical").
 		Count(&critical)
 
-	h.db.Model(&models.BusinessContinuity{}).
+	requestDB(c, h.db).Model(&models.BusinessContinuity{}).
 		Where("tenant_id = ? AND is_deleted = ? AND criticality = ?", tenantID, false, "high").
 		Count(&high)
 
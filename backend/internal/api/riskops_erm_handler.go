@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,38 +23,37 @@ func (h *RiskOpsERMHandler) GetRiskRegister(c *gin.Context) {
 	var risks []models.RiskRegister
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&risks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch risk register"})
+	base := requestDB(c, h.db).Model(&models.RiskRegister{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, riskRegisterQuery, &risks)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch risk register")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    risks,
+		"success":    true,
+		"data":       risks,
+		"pagination": page,
 	})
 }
 
-func (h *RiskOpsERMHandler) CreateRisk(c *gin.Context) {
-	var req struct {
-		Name             string `json:"name" binding:"required"`
-		Description      string `json:"description" binding:"required"`
-		RiskCategory     string `json:"riskCategory" binding:"required"`
-		RiskType         string `json:"riskType" binding:"required"`
-		Likelihood       string `json:"likelihood" binding:"required"`
-		Impact           string `json:"impact" binding:"required"`
-		Owner            string `json:"owner" binding:"required"`
-		MitigationStrategy string `json:"mitigationStrategy"`
-		MitigationActions string `json:"mitigationActions"`
-		ReviewDate       string `json:"reviewDate"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createRiskRequest is the body accepted by CreateRisk. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createRiskRequest struct {
+	Name             string `json:"name" binding:"required"`
+	Description      string `json:"description" binding:"required"`
+	RiskCategory     string `json:"riskCategory" binding:"required"`
+	RiskType         string `json:"riskType" binding:"required"`
+	Likelihood       string `json:"likelihood" binding:"required"`
+	Impact           string `json:"impact" binding:"required"`
+	Owner            string `json:"owner" binding:"required"`
+	MitigationStrategy string `json:"mitigationStrategy"`
+	MitigationActions string `json:"mitigationActions"`
+	ReviewDate       string `json:"reviewDate"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateRisk stores it
+func (req *createRiskRequest) toModel(tenantID, userID string) models.RiskRegister {
 	// Calculate risk score based on likelihood and impact
 	riskScore := calculateRiskScore(req.Likelihood, req.Impact)
 	riskLevel := calculateRiskLevel(riskScore)
@@ -79,7 +80,30 @@ func (h *RiskOpsERMHandler) CreateRisk(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&risk).Error; err != nil {
+	return risk
+}
+
+func (req *createRiskRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RiskOpsERMHandler) CreateRisk(c *gin.Context) {
+	var req createRiskRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	risk := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := withEvents(requestDB(c, h.db), func(tx *gorm.DB) error {
+		if err := tx.Create(&risk).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, c, events.RiskCreated, "risk", risk.ID, risk)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create risk"})
 		return
 	}
@@ -91,24 +115,44 @@ func (h *RiskOpsERMHandler) CreateRisk(c *gin.Context) {
 	})
 }
 
+// updateRiskRequest is the body accepted by UpdateRisk
+type updateRiskRequest struct {
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	RiskCategory     string `json:"riskCategory"`
+	RiskType         string `json:"riskType"`
+	Likelihood       string `json:"likelihood"`
+	Impact           string `json:"impact"`
+	Status           string `json:"status"`
+	Owner            string `json:"owner"`
+	MitigationStrategy string `json:"mitigationStrategy"`
+	MitigationActions string `json:"mitigationActions"`
+	ResidualRiskScore int    `json:"residualRiskScore"`
+	ResidualRiskLevel string `json:"residualRiskLevel"`
+	ReviewDate       string `json:"reviewDate"`
+}
+
+func (h *RiskOpsERMHandler) GetRisk(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var risk models.RiskRegister
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&risk).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Risk not found"})
+		return
+	}
+
+	setETag(c, &risk)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    risk,
+	})
+}
+
 func (h *RiskOpsERMHandler) UpdateRisk(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name             string `json:"name"`
-		Description      string `json:"description"`
-		RiskCategory     string `json:"riskCategory"`
-		RiskType         string `json:"riskType"`
-		Likelihood       string `json:"likelihood"`
-		Impact           string `json:"impact"`
-		Status           string `json:"status"`
-		Owner            string `json:"owner"`
-		MitigationStrategy string `json:"mitigationStrategy"`
-		MitigationActions string `json:"mitigationActions"`
-		ResidualRiskScore int    `json:"residualRiskScore"`
-		ResidualRiskLevel string `json:"residualRiskLevel"`
-		ReviewDate       string `json:"reviewDate"`
-	}
+	var req updateRiskRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -118,10 +162,13 @@ func (h *RiskOpsERMHandler) UpdateRisk(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var risk models.RiskRegister
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&risk).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&risk).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Risk not found"})
 		return
 	}
+	if !checkIfMatch(c, &risk) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -185,27 +232,47 @@ func (h *RiskOpsERMHandler) UpdateRisk(c *gin.Context) {
 		updates["risk_level"] = riskLevel
 	}
 
-	if err := h.db.Model(&risk).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&risk).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &risk, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update risk"})
 		return
 	}
 
+	setETag(c, &risk)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Risk updated successfully",
+		"data":    risk,
 	})
 }
 
+func (h *RiskOpsERMHandler) PatchRisk(c *gin.Context) {
+	var risk models.RiskRegister
+	patchRecord(c, h.db, patchTarget{resource: "risk"}, &risk)
+}
+
 func (h *RiskOpsERMHandler) CloseRisk(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.RiskRegister{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":     "closed",
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	var risk models.RiskRegister
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&risk).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Risk not found"})
+		return
+	}
+	if !checkIfMatch(c, &risk) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &risk, map[string]interface{}{
+		"status":     "closed",
+		"updated_at": time.Now(),
+	}, events.RiskClosed, "risk", id); err != nil {
+		if staleWrite(c, h.db, &risk, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close risk"})
 		return
 	}
@@ -224,19 +291,19 @@ func (h *RiskOpsERMHandler) GetRiskStats(c *gin.Context) {
 	var inMitigation int64
 	var closed int64
 
-	h.db.Model(&models.RiskRegister{}).
+	requestDB(c, h.db).Model(&models.RiskRegister{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.RiskRegister{}).
+	requestDB(c, h.db).Model(&models.RiskRegister{}).
 		Where("tenant_id = ? AND is_deleted = ? AND risk_level = ?", tenantID, false, "high").
 		Count(&highRisk)
 
-	h.db.Model(&models.RiskRegister{}).
+	requestDB(c, h.db).Model(&models.RiskRegister{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_mitigation").
 		Count(&inMitigation)
 
-	h.db.Model(&models.RiskRegister{}).
+	requestDB(c, h.db).Model(&models.RiskRegister{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "closed").
 		Count(&closed)
 
@@ -18,7 +18,7 @@ func NewRiskOpsHandler(db *db.Database) *RiskOpsHandler {
 
 func (h *RiskOpsHandler) GetRiskRegister(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var riskRegister []models.RiskRegister
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&riskRegister).Error; err != nil {
@@ -31,7 +31,7 @@ func (h *RiskOpsHandler) GetRiskRegister(c *gin.Context) {
 
 func (h *RiskOpsHandler) CreateRisk(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var risk models.RiskRegister
 	if err := c.ShouldBindJSON(&risk); err != nil {
@@ -51,7 +51,7 @@ func (h *RiskOpsHandler) CreateRisk(c *gin.Context) {
 
 func (h *RiskOpsHandler) UpdateRisk(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var risk models.RiskRegister
@@ -76,7 +76,7 @@ func (h *RiskOpsHandler) UpdateRisk(c *gin.Context) {
 
 func (h *RiskOpsHandler) DeleteRisk(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var risk models.RiskRegister
@@ -85,10 +85,7 @@ func (h *RiskOpsHandler) DeleteRisk(c *gin.Context) {
 		return
 	}
 
-	// Soft delete
-	risk.IsDeleted = true
-	risk.Status = "deleted"
-	if err := tenantDB.Save(&risk).Error; err != nil {
+	if err := softDelete(tenantDB, c, "risk", id, &risk); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete risk"})
 		return
 	}
@@ -99,7 +96,7 @@ func (h *RiskOpsHandler) DeleteRisk(c *gin.Context) {
 // Vulnerability CRUD
 func (h *RiskOpsHandler) GetVulnerabilities(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var vulnerabilities []models.Vulnerability
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&vulnerabilities).Error; err != nil {
@@ -112,7 +109,7 @@ func (h *RiskOpsHandler) GetVulnerabilities(c *gin.Context) {
 
 func (h *RiskOpsHandler) CreateVulnerability(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var vulnerability models.Vulnerability
 	if err := c.ShouldBindJSON(&vulnerability); err != nil {
@@ -132,7 +129,7 @@ func (h *RiskOpsHandler) CreateVulnerability(c *gin.Context) {
 
 func (h *RiskOpsHandler) UpdateVulnerability(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var vulnerability models.Vulnerability
@@ -157,7 +154,7 @@ func (h *RiskOpsHandler) UpdateVulnerability(c *gin.Context) {
 
 func (h *RiskOpsHandler) DeleteVulnerability(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var vulnerability models.Vulnerability
@@ -166,9 +163,7 @@ func (h *RiskOpsHandler) DeleteVulnerability(c *gin.Context) {
 		return
 	}
 
-	vulnerability.IsDeleted = true
-	vulnerability.Status = "deleted"
-	if err := tenantDB.Save(&vulnerability).Error; err != nil {
+	if err := softDelete(tenantDB, c, "vulnerability", id, &vulnerability); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vulnerability"})
 		return
 	}
@@ -179,7 +174,7 @@ func (h *RiskOpsHandler) DeleteVulnerability(c *gin.Context) {
 // Vendor Assessment CRUD
 func (h *RiskOpsHandler) GetVendorAssessments(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var assessments []models.VendorAssessment
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&assessments).Error; err != nil {
@@ -192,7 +187,7 @@ func (h *RiskOpsHandler) GetVendorAssessments(c *gin.Context) {
 
 func (h *RiskOpsHandler) CreateVendorAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var assessment models.VendorAssessment
 	if err := c.ShouldBindJSON(&assessment); err != nil {
@@ -212,7 +207,7 @@ func (h *RiskOpsHandler) CreateVendorAssessment(c *gin.Context) {
 
 func (h *RiskOpsHandler) UpdateVendorAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var assessment models.VendorAssessment
@@ -237,7 +232,7 @@ func (h *RiskOpsHandler) UpdateVendorAssessment(c *gin.Context) {
 
 func (h *RiskOpsHandler) DeleteVendorAssessment(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var assessment models.VendorAssessment
@@ -246,8 +241,7 @@ func (h *RiskOpsHandler) DeleteVendorAssessment(c *gin.Context) {
 		return
 	}
 
-	assessment.IsDeleted = true
-	if err := tenantDB.Save(&assessment).Error; err != nil {
+	if err := softDelete(tenantDB, c, "vendor", id, &assessment); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vendor assessment"})
 		return
 	}
@@ -258,7 +252,7 @@ func (h *RiskOpsHandler) DeleteVendorAssessment(c *gin.Context) {
 // Business Continuity CRUD
 func (h *RiskOpsHandler) GetBusinessContinuity(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var plans []models.BusinessContinuity
 	if err := tenantDB.Where("is_deleted = ?", false).Find(&plans).Error; err != nil {
@@ -271,7 +265,7 @@ func (h *RiskOpsHandler) GetBusinessContinuity(c *gin.Context) {
 
 func (h *RiskOpsHandler) CreateBusinessContinuity(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 
 	var plan models.BusinessContinuity
 	if err := c.ShouldBindJSON(&plan); err != nil {
@@ -291,7 +285,7 @@ func (h *RiskOpsHandler) CreateBusinessContinuity(c *gin.Context) {
 
 func (h *RiskOpsHandler) UpdateBusinessContinuity(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var plan models.BusinessContinuity
@@ -316,7 +310,7 @@ func (h *RiskOpsHandler) UpdateBusinessContinuity(c *gin.Context) {
 
 func (h *RiskOpsHandler) DeleteBusinessContinuity(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
-	tenantDB := h.db.GetTenantDB(tenantID)
+	tenantDB := requestDB(c, h.db.GetTenantDB(tenantID))
 	id := c.Param("id")
 
 	var plan models.BusinessContinuity
@@ -325,9 +319,7 @@ func (h *RiskOpsHandler) DeleteBusinessContinuity(c *gin.Context) {
 		return
 	}
 
-	plan.IsDeleted = true
-	plan.Status = "deleted"
-	if err := tenantDB.Save(&plan).Error; err != nil {
+	if err := softDelete(tenantDB, c, "continuity_plan", id, &plan); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete business continuity plan"})
 		return
 	}
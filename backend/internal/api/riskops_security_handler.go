@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/cyber/backend/internal/events"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,39 +23,38 @@ func (h *RiskOpsSecurityHandler) GetVulnerabilities(c *gin.Context) {
 	var vulnerabilities []models.Vulnerability
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&vulnerabilities).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vulnerabilities"})
+	base := requestDB(c, h.db).Model(&models.Vulnerability{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, vulnerabilityQuery, &vulnerabilities)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch vulnerabilities")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    vulnerabilities,
+		"success":    true,
+		"data":       vulnerabilities,
+		"pagination": page,
 	})
 }
 
-func (h *RiskOpsSecurityHandler) CreateVulnerability(c *gin.Context) {
-	var req struct {
-		Name           string  `json:"name" binding:"required"`
-		Description    string  `json:"description" binding:"required"`
-		CVEID          string  `json:"cveId"`
-		CVSSScore      float64 `json:"cvssScore"`
-		AffectedSystems string  `json:"affectedSystems"`
-		AffectedAssets  string  `json:"affectedAssets"`
-		DiscoveryDate   string  `json:"discoveryDate"`
-		Mitigation     string  `json:"mitigation" binding:"required"`
-		RemediationPlan string `json:"remediationPlan"`
-		RemediationDate string `json:"remediationDate"`
-		AssignedTo     string `json:"assignedTo" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createVulnerabilityRequest is the body accepted by CreateVulnerability. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createVulnerabilityRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Description    string  `json:"description" binding:"required"`
+	CVEID          string  `json:"cveId"`
+	CVSSScore      float64 `json:"cvssScore"`
+	AffectedSystems string  `json:"affectedSystems"`
+	AffectedAssets  string  `json:"affectedAssets"`
+	DiscoveryDate   string  `json:"discoveryDate"`
+	Mitigation     string  `json:"mitigation" binding:"required"`
+	RemediationPlan string `json:"remediationPlan"`
+	RemediationDate string `json:"remediationDate"`
+	AssignedTo     string `json:"assignedTo" binding:"required"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateVulnerability stores it
+func (req *createVulnerabilityRequest) toModel(tenantID, userID string) models.Vulnerability {
 	// Determine severity based on CVSS score
 	severity := "low"
 	if req.CVSSScore >= 9.0 {
@@ -91,7 +92,25 @@ func (h *RiskOpsSecurityHandler) CreateVulnerability(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&vulnerability).Error; err != nil {
+	return vulnerability
+}
+
+func (req *createVulnerabilityRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RiskOpsSecurityHandler) CreateVulnerability(c *gin.Context) {
+	var req createVulnerabilityRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vulnerability := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&vulnerability).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vulnerability"})
 		return
 	}
@@ -103,24 +122,44 @@ func (h *RiskOpsSecurityHandler) CreateVulnerability(c *gin.Context) {
 	})
 }
 
+// updateVulnerabilityRequest is the body accepted by UpdateVulnerability
+type updateVulnerabilityRequest struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	CVEID          string  `json:"cveId"`
+	CVSSScore      float64 `json:"cvssScore"`
+	Severity        string  `json:"severity"`
+	Status          string  `json:"status"`
+	AffectedSystems string  `json:"affectedSystems"`
+	AffectedAssets  string  `json:"affectedAssets"`
+	DiscoveryDate   string  `json:"discoveryDate"`
+	Mitigation     string  `json:"mitigation"`
+	RemediationPlan string `json:"remediationPlan"`
+	RemediationDate string `json:"remediationDate"`
+	AssignedTo     string  `json:"assignedTo"`
+}
+
+func (h *RiskOpsSecurityHandler) GetVulnerability(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var vulnerability models.Vulnerability
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vulnerability).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vulnerability not found"})
+		return
+	}
+
+	setETag(c, &vulnerability)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vulnerability,
+	})
+}
+
 func (h *RiskOpsSecurityHandler) UpdateVulnerability(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		Name           string  `json:"name"`
-		Description    string  `json:"description"`
-		CVEID          string  `json:"cveId"`
-		CVSSScore      float64 `json:"cvssScore"`
-		Severity        string  `json:"severity"`
-		Status          string  `json:"status"`
-		AffectedSystems string  `json:"affectedSystems"`
-		AffectedAssets  string  `json:"affectedAssets"`
-		DiscoveryDate   string  `json:"discoveryDate"`
-		Mitigation     string  `json:"mitigation"`
-		RemediationPlan string `json:"remediationPlan"`
-		RemediationDate string `json:"remediationDate"`
-		AssignedTo     string  `json:"assignedTo"`
-	}
+	var req updateVulnerabilityRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -130,10 +169,13 @@ func (h *RiskOpsSecurityHandler) UpdateVulnerability(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var vulnerability models.Vulnerability
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vulnerability).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vulnerability).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Vulnerability not found"})
 		return
 	}
+	if !checkIfMatch(c, &vulnerability) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -183,27 +225,44 @@ func (h *RiskOpsSecurityHandler) UpdateVulnerability(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&vulnerability).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&vulnerability).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &vulnerability, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vulnerability"})
 		return
 	}
 
+	setETag(c, &vulnerability)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Vulnerability updated successfully",
+		"data":    vulnerability,
 	})
 }
 
+func (h *RiskOpsSecurityHandler) PatchVulnerability(c *gin.Context) {
+	var vulnerability models.Vulnerability
+	patchRecord(c, h.db, patchTarget{resource: "vulnerability"}, &vulnerability)
+}
+
 func (h *RiskOpsSecurityHandler) DeleteVulnerability(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Vulnerability{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var vulnerability models.Vulnerability
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vulnerability).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vulnerability not found"})
+		return
+	}
+	if !checkIfMatch(c, &vulnerability) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "vulnerability", id, &vulnerability); err != nil {
+		if staleWrite(c, h.db, &vulnerability, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vulnerability"})
 		return
 	}
@@ -218,12 +277,22 @@ func (h *RiskOpsSecurityHandler) ResolveVulnerability(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.Vulnerability{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"status":     "resolved",
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	var vulnerability models.Vulnerability
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vulnerability).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vulnerability not found"})
+		return
+	}
+	if !checkIfMatch(c, &vulnerability) {
+		return
+	}
+
+	if err := updateWithEvent(c, h.db, &vulnerability, map[string]interface{}{
+		"status":     "resolved",
+		"updated_at": time.Now(),
+	}, events.VulnerabilityResolved, "vulnerability", id); err != nil {
+		if staleWrite(c, h.db, &vulnerability, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve vulnerability"})
 		return
 	}
@@ -246,35 +315,35 @@ func (h *RiskOpsSecurityHandler) GetVulnerabilityStats(c *gin.Context) {
 	var inProgress int64
 	var resolved int64
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND severity = ?", tenantID, false, "critical").
 		Count(&critical)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND severity = ?", tenantID, false, "high").
 		Count(&high)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND severity = ?", tenantID, false, "medium").
 		Count(&medium)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND severity = ?", tenantID, false, "low").
 		Count(&low)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "open").
 		Count(&open)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "in_progress").
 		Count(&inProgress)
 
-	h.db.Model(&models.Vulnerability{}).
+	requestDB(c, h.db).Model(&models.Vulnerability{}).
 		Where("tenant_id = ? AND is_deleted = ? AND status = ?", tenantID, false, "resolved").
 		Count(&resolved)
 
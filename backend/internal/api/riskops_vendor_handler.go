@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -21,44 +22,43 @@ func (h *RiskOpsVendorHandler) GetVendors(c *gin.Context) {
 	var vendors []models.VendorAssessment
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Where("tenant_id = ? AND is_deleted = ?", tenantID, false).Find(&vendors).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor assessments"})
+	base := requestDB(c, h.db).Model(&models.VendorAssessment{}).Where("tenant_id = ? AND is_deleted = ?", tenantID, false)
+	page, err := query.List(c, base, vendorQuery, &vendors)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch vendor assessments")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    vendors,
+		"success":    true,
+		"data":       vendors,
+		"pagination": page,
 	})
 }
 
-func (h *RiskOpsVendorHandler) CreateVendor(c *gin.Context) {
-	var req struct {
-		VendorName        string  `json:"vendorName" binding:"required"`
-		VendorType        string  `json:"vendorType" binding:"required"`
-		Description       string  `json:"description" binding:"required"`
-		ContactPerson     string  `json:"contactPerson"`
-		ContactEmail       string  `json:"contactEmail"`
-		RiskLevel         string  `json:"riskLevel" binding:"required"`
-		AssessmentDate    string  `json:"assessmentDate"`
-		NextAssessmentDate string `json:"nextAssessmentDate"`
-		DataShared         string  `json:"dataShared"`
-		DataProcessing     string  `json:"dataProcessing"`
-		SecurityControls  string  `json:"securityControls"`
-		ComplianceStatus string  `json:"complianceStatus"`
-		SLACompliance     string  `json:"slaCompliance"`
-		Findings          string  `json:"findings"`
-		Recommendations   string  `json:"recommendations"`
-		Owner             string  `json:"owner" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// createVendorRequest is the body accepted by CreateVendor. Bulk import binds
+// spreadsheet rows to the same type so both share validation rules.
+type createVendorRequest struct {
+	VendorName        string  `json:"vendorName" binding:"required"`
+	VendorType        string  `json:"vendorType" binding:"required"`
+	Description       string  `json:"description" binding:"required"`
+	ContactPerson     string  `json:"contactPerson"`
+	ContactEmail       string  `json:"contactEmail"`
+	RiskLevel         string  `json:"riskLevel" binding:"required"`
+	AssessmentDate    string  `json:"assessmentDate"`
+	NextAssessmentDate string `json:"nextAssessmentDate"`
+	DataShared         string  `json:"dataShared"`
+	DataProcessing     string  `json:"dataProcessing"`
+	SecurityControls  string  `json:"securityControls"`
+	ComplianceStatus string  `json:"complianceStatus"`
+	SLACompliance     string  `json:"slaCompliance"`
+	Findings          string  `json:"findings"`
+	Recommendations   string  `json:"recommendations"`
+	Owner             string  `json:"owner" binding:"required"`
+}
 
-	tenantID := c.GetString("tenant_id")
-	
+// toModel builds the record exactly as CreateVendor stores it
+func (req *createVendorRequest) toModel(tenantID, userID string) models.VendorAssessment {
 	vendor := models.VendorAssessment{
 		TenantID:           tenantID,
 		VendorName:         req.VendorName,
@@ -89,7 +89,25 @@ func (h *RiskOpsVendorHandler) CreateVendor(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Create(&vendor).Error; err != nil {
+	return vendor
+}
+
+func (req *createVendorRequest) record(tenantID, userID string) interface{} {
+	m := req.toModel(tenantID, userID)
+	return &m
+}
+
+func (h *RiskOpsVendorHandler) CreateVendor(c *gin.Context) {
+	var req createVendorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vendor := req.toModel(c.GetString("tenant_id"), c.GetString("user_id"))
+
+	if err := requestDB(c, h.db).Create(&vendor).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vendor assessment"})
 		return
 	}
@@ -101,27 +119,47 @@ func (h *RiskOpsVendorHandler) CreateVendor(c *gin.Context) {
 	})
 }
 
+// updateVendorRequest is the body accepted by UpdateVendor
+type updateVendorRequest struct {
+	VendorName        string  `json:"vendorName"`
+	Description       string  `json:"description"`
+	VendorType        string  `json:"vendorType"`
+	ContactPerson     string  `json:"contactPerson"`
+	ContactEmail       string  `json:"contactEmail"`
+	RiskLevel         string  `json:"riskLevel"`
+	AssessmentDate    string  `json:"assessmentDate"`
+	NextAssessmentDate string  `json:"nextAssessmentDate"`
+	DataShared         string  `json:"dataShared"`
+	DataProcessing     string  `json:"dataProcessing"`
+	SecurityControls  string  `json:"securityControls"`
+	ComplianceStatus string  `json:"complianceStatus"`
+	SLACompliance     string  `json:"slaCompliance"`
+	Findings          string  `json:"findings"`
+	Recommendations   string  `json:"recommendations"`
+	Owner             string  `json:"owner"`
+}
+
+func (h *RiskOpsVendorHandler) GetVendor(c *gin.Context) {
+	id := c.Param("id")
+	tenantID := c.GetString("tenant_id")
+
+	var vendor models.VendorAssessment
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vendor).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor assessment not found"})
+		return
+	}
+
+	setETag(c, &vendor)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    vendor,
+	})
+}
+
 func (h *RiskOpsVendorHandler) UpdateVendor(c *gin.Context) {
 	id := c.Param("id")
 	
-	var req struct {
-		VendorName        string  `json:"vendorName"`
-		Description       string  `json:"description"`
-		VendorType        string  `json:"vendorType"`
-		ContactPerson     string  `json:"contactPerson"`
-		ContactEmail       string  `json:"contactEmail"`
-		RiskLevel         string  `json:"riskLevel"`
-		AssessmentDate    string  `json:"assessmentDate"`
-		NextAssessmentDate string  `json:"nextAssessmentDate"`
-		DataShared         string  `json:"dataShared"`
-		DataProcessing     string  `json:"dataProcessing"`
-		SecurityControls  string  `json:"securityControls"`
-		ComplianceStatus string  `json:"complianceStatus"`
-		SLACompliance     string  `json:"slaCompliance"`
-		Findings          string  `json:"findings"`
-		Recommendations   string  `json:"recommendations"`
-		Owner             string  `json:"owner"`
-	}
+	var req updateVendorRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -131,10 +169,13 @@ func (h *RiskOpsVendorHandler) UpdateVendor(c *gin.Context) {
 	tenantID := c.GetString("tenant_id")
 	var vendor models.VendorAssessment
 	
-	if err := h.db.Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vendor).Error; err != nil {
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vendor).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor assessment not found"})
 		return
 	}
+	if !checkIfMatch(c, &vendor) {
+		return
+	}
 
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -193,27 +234,44 @@ func (h *RiskOpsVendorHandler) UpdateVendor(c *gin.Context) {
 		}
 	}
 
-	if err := h.db.Model(&vendor).Updates(updates).Error; err != nil {
+	if err := requestDB(c, h.db).Model(&vendor).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, &vendor, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vendor assessment"})
 		return
 	}
 
+	setETag(c, &vendor)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Vendor assessment updated successfully",
+		"data":    vendor,
 	})
 }
 
+func (h *RiskOpsVendorHandler) PatchVendor(c *gin.Context) {
+	var vendor models.VendorAssessment
+	patchRecord(c, h.db, patchTarget{resource: "vendor"}, &vendor)
+}
+
 func (h *RiskOpsVendorHandler) DeleteVendor(c *gin.Context) {
 	id := c.Param("id")
 	tenantID := c.GetString("tenant_id")
 
-	if err := h.db.Model(&models.VendorAssessment{}).
-		Where("id = ? AND tenant_id = ?", id, tenantID).
-		Updates(map[string]interface{}{
-			"is_deleted": true,
-			"deleted_at": time.Now(),
-		}).Error; err != nil {
+	var vendor models.VendorAssessment
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).First(&vendor).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor assessment not found"})
+		return
+	}
+	if !checkIfMatch(c, &vendor) {
+		return
+	}
+
+	if err := softDelete(requestDB(c, h.db), c, "vendor", id, &vendor); err != nil {
+		if staleWrite(c, h.db, &vendor, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vendor assessment"})
 		return
 	}
@@ -232,19 +290,19 @@ func (h *RiskOpsVendorHandler) GetVendorStats(c *gin.Context) {
 	var mediumRisk int64
 	var lowRisk int64
 
-	h.db.Model(&models.VendorAssessment{}).
+	requestDB(c, h.db).Model(&models.VendorAssessment{}).
 		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
 		Count(&total)
 
-	h.db.Model(&models.VendorAssessment{}).
+	requestDB(c, h.db).Model(&models.VendorAssessment{}).
 		Where("tenant_id = ? AND is_deleted = ? AND risk_level = ?", tenantID, false, "high").
 		Count(&highRisk)
 
-	h.db.Model(&models.VendorAssessment{}).
+	requestDB(c, h.db).Model(&models.VendorAssessment{}).
 		Where("tenant_id = ? AND is_deleted = ? AND risk_level = ?", tenantID, false, "medium").
 		Count(&mediumRisk)
 
-	h.db.Model(&models.VendorAssessment{}).
+	requestDB(c, h.db).Model(&models.VendorAssessment{}).
 		Where("tenant_id = ? AND is_deleted = ? AND risk_level = ?", tenantID, false, "low").
 		Count(&lowRisk)
 
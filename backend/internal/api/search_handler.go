@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/search"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SearchHandler struct {
+	db *db.Database
+}
+
+func NewSearchHandler(db *db.Database) *SearchHandler {
+	return &SearchHandler{db: db}
+}
+
+// Search runs a tenant-wide full-text search.
+// Query params: q (required), types (comma separated resource names),
+// from/to (YYYY-MM-DD on updated_at), limit, offset.
+// Only resources the caller's role may view are searched.
+func (h *SearchHandler) Search(c *gin.Context) {
+	tenantID := c.GetString("tenant_id")
+	role := c.GetString("user_role")
+
+	q := strings.TrimSpace(c.Query("q"))
+	if len(q) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' must be at least 2 characters"})
+		return
+	}
+
+	req := search.Request{
+		TenantID: tenantID,
+		Query:    q,
+		Limit:    20,
+		Allowed: func(r models.Resource) bool {
+			return models.HasPermission(role, r.Permission("view"))
+		},
+		TenantSchema: func(resource string) bool {
+			return legacyResources[resource]
+		},
+	}
+
+	if types := c.Query("types"); types != "" {
+		req.Types = strings.Split(types, ",")
+	}
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			req.Limit = n
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			req.Offset = n
+		}
+	}
+	for param, dest := range map[string]**time.Time{"from": &req.From, "to": &req.To} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid '" + param + "' date, expected YYYY-MM-DD"})
+				return
+			}
+			*dest = &t
+		}
+	}
+
+	// Legacy resources are read from the tenant schema and the rest from
+	// public, in one query so they are ranked and paged together
+	var result *search.Result
+	err := h.db.TenantTx(tenantID, func(tx *gorm.DB) error {
+		var err error
+		result, err = search.Search(tx, req)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"query":   q,
+		"data":    result.Hits,
+		"facets":  result.Facets,
+		"total":   result.Total,
+	})
+}
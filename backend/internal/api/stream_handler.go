@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cyber/backend/internal/middleware"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// How often a comment is sent on an idle stream, so proxies do not close it
+	streamHeartbeat = 25 * time.Second
+	// How long a stream ticket can be redeemed
+	streamTicketTTL = 30 * time.Second
+)
+
+// streamTicketResponse is a new stream ticket. It is only returned once.
+type streamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type StreamHandler struct {
+	db        *gorm.DB
+	hub       *realtime.Hub
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewStreamHandler(db *gorm.DB, hub *realtime.Hub) *StreamHandler {
+	return &StreamHandler{db: db, hub: hub, closing: make(chan struct{})}
+}
+
+// Close ends the open streams, so the server can shut down; clients
+// reconnect to another replica
+func (h *StreamHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// CreateStreamTicket issues a ticket for opening the caller's event stream.
+// The ticket is bound to the tenant and role of the caller's token, works
+// once and expires after streamTicketTTL. Clients request a new one for
+// each reconnect.
+func (h *StreamHandler) CreateStreamTicket(c *gin.Context) {
+	tenantID := c.GetString(middleware.TokenTenantKey)
+	userID := c.GetString("user_id")
+	if tenantID == "" || userID == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token has no tenant"})
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+		return
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(raw)
+	record := models.StreamTicket{
+		TokenHash: hashStreamTicket(ticket),
+		TenantID:  tenantID,
+		UserID:    userID,
+		UserRole:  c.GetString("user_role"),
+		ExpiresAt: time.Now().Add(streamTicketTTL),
+	}
+
+	gdb := requestDB(c, h.db)
+	gdb.Where("expires_at < ?", time.Now()).Delete(&models.StreamTicket{})
+	if err := gdb.Create(&record).Error; err != nil {
+		log.Printf("Failed to store stream ticket: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    streamTicketResponse{Ticket: ticket, ExpiresAt: record.ExpiresAt},
+	})
+}
+
+func hashStreamTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
+
+// redeemStreamTicket deletes the ticket of the request, so it cannot be used
+// again, and returns it. ok is false when the ticket is missing, unknown or
+// expired.
+func (h *StreamHandler) redeemStreamTicket(c *gin.Context) (ticket models.StreamTicket, ok bool) {
+	raw := c.Query("ticket")
+	if raw == "" {
+		return ticket, false
+	}
+	result := requestDB(c, h.db).Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ?", hashStreamTicket(raw), time.Now()).
+		Delete(&ticket)
+	if result.Error != nil {
+		log.Printf("Failed to redeem stream ticket: %v", result.Error)
+		return ticket, false
+	}
+	return ticket, result.RowsAffected == 1
+}
+
+// Stream is the Server-Sent Events stream of change notifications in the
+// tenant of the ticket query parameter's holder, filtered by their
+// permissions. The SSE event name is the message type. Messages sent while
+// disconnected are not replayed; clients refetch after reconnecting.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	ticket, ok := h.redeemStreamTicket(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Valid stream ticket required"})
+		return
+	}
+	client := h.hub.Connect(ticket.TenantID, ticket.UserID, ticket.UserRole)
+	defer h.hub.Disconnect(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.closing:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case msg := <-client.Messages():
+			// The delivery filters are not part of the payload
+			msg.UserID, msg.Permission = "", ""
+			data, err := json.Marshal(msg)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", msg.Type, data)
+		}
+		c.Writer.Flush()
+	}
+}
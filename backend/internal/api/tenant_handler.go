@@ -18,7 +18,7 @@ func NewTenantHandler(db *db.Database) *TenantHandler {
 
 func (h *TenantHandler) GetAll(c *gin.Context) {
 	var tenants []models.Tenant
-	if err := h.db.Find(&tenants).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Find(&tenants).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tenants"})
 		return
 	}
@@ -29,7 +29,7 @@ func (h *TenantHandler) GetAll(c *gin.Context) {
 func (h *TenantHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 	var tenant models.Tenant
-	if err := h.db.First(&tenant, "id = ?", id).Error; err != nil {
+	if err := requestDB(c, h.db.DB).First(&tenant, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -46,12 +46,12 @@ func (h *TenantHandler) Create(c *gin.Context) {
 
 	// Check if tenant with same domain already exists
 	var existingTenant models.Tenant
-	if err := h.db.First(&existingTenant, "domain = ?", tenant.Domain).Error; err == nil {
+	if err := requestDB(c, h.db.DB).First(&existingTenant, "domain = ?", tenant.Domain).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Tenant with this domain already exists"})
 		return
 	}
 
-	if err := h.db.Create(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Create(&tenant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tenant"})
 		return
 	}
@@ -62,7 +62,7 @@ func (h *TenantHandler) Create(c *gin.Context) {
 func (h *TenantHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var tenant models.Tenant
-	if err := h.db.First(&tenant, "id = ?", id).Error; err != nil {
+	if err := requestDB(c, h.db.DB).First(&tenant, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -74,7 +74,7 @@ func (h *TenantHandler) Update(c *gin.Context) {
 	}
 
 	// Update tenant
-	if err := h.db.Model(&tenant).Updates(updateData).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Model(&tenant).Updates(updateData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tenant"})
 		return
 	}
@@ -85,7 +85,7 @@ func (h *TenantHandler) Update(c *gin.Context) {
 func (h *TenantHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	var tenant models.Tenant
-	if err := h.db.First(&tenant, "id = ?", id).Error; err != nil {
+	if err := requestDB(c, h.db.DB).First(&tenant, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tenant not found"})
 		return
 	}
@@ -93,7 +93,7 @@ func (h *TenantHandler) Delete(c *gin.Context) {
 	// Soft delete - mark as deleted but keep record
 	tenant.IsDeleted = true
 	tenant.Status = "deleted"
-	if err := h.db.Save(&tenant).Error; err != nil {
+	if err := requestDB(c, h.db.DB).Save(&tenant).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tenant"})
 		return
 	}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Deleting a record moves it to the trash (see models.TrashUpdates) together
+// with its children (models.ResourceLinks). From there it can be restored,
+// bringing back the children deleted with it, or permanently deleted.
+
+var trashQuery = query.Spec{
+	Filters:     []string{"deleted_by", "deleted_at"},
+	Sorts:       []string{"deleted_at", "created_at"},
+	DefaultSort: "-deleted_at",
+}
+
+// softDelete moves record, loaded by the caller, to the trash with the
+// children linked to it. The record's revision guards the update.
+func softDelete(tx *gorm.DB, c *gin.Context, resourceName, id string, record models.Revisioned) error {
+	userID := c.GetString("user_id")
+	now := time.Now()
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(record).Updates(models.TrashUpdates(userID, now)).Error; err != nil {
+			return err
+		}
+		return trashChildren(tx, c.GetString("tenant_id"), resourceName, id, userID, now)
+	})
+}
+
+func trashChildren(tx *gorm.DB, tenantID, resourceName, id, userID string, at time.Time) error {
+	for _, link := range models.ChildLinks(resourceName) {
+		child, _ := models.LookupResource(link.Child)
+		var ids []string
+		if err := tx.Model(child.New()).Where(link.Column+" = ? AND tenant_id = ? AND is_deleted = ?", id, tenantID, false).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		if err := tx.Model(child.New()).Where("id IN ?", ids).Updates(models.TrashUpdates(userID, at)).Error; err != nil {
+			return err
+		}
+		for _, childID := range ids {
+			if err := trashChildren(tx, tenantID, child.Name, childID, userID, at); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadTrashed loads the :id record of resource from the tenant's trash
+func loadTrashed(tx *gorm.DB, c *gin.Context, resource models.Resource) (models.Trashable, error) {
+	record := resource.New().(models.Trashable)
+	if err := tx.Unscoped().Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), c.GetString("tenant_id"), true).
+		First(record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, patchFailed(http.StatusNotFound, resource.Label+" not found in the trash")
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// restoreTrashed brings the :id record of resource back from the trash with
+// the children deleted along with it, and returns it. A record whose parent
+// is still in the trash cannot be restored on its own. Honours If-Match.
+func restoreTrashed(tx *gorm.DB, c *gin.Context, resource models.Resource) (models.Trashable, error) {
+	tenantID := c.GetString("tenant_id")
+	id := c.Param("id")
+	record, err := loadTrashed(tx, c, resource)
+	if err != nil {
+		return nil, err
+	}
+	if !checkIfMatch(c, record) {
+		return record, errPatchResponded
+	}
+
+	for _, link := range models.ParentLinks(resource.Name) {
+		parent, _ := models.LookupResource(link.Parent)
+		linked := tx.Unscoped().Model(resource.New()).Select(link.Column).Where("id = ?", id)
+		var count int64
+		if err := tx.Unscoped().Model(parent.New()).
+			Where("tenant_id = ? AND is_deleted = ? AND id::text IN (?)", tenantID, true, linked).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, patchFailed(http.StatusConflict, "The "+strings.ToLower(parent.Label)+" it belongs to is in the trash, restore it first")
+		}
+	}
+
+	// Children deleted with the record carry its deletion time
+	deletedAt := record.GetDeletedAt()
+	if err := tx.Unscoped().Model(record).Updates(models.RestoreUpdates()).Error; err != nil {
+		return record, err
+	}
+	if deletedAt.Valid {
+		if err := restoreChildren(tx, tenantID, resource.Name, id, deletedAt.Time); err != nil {
+			return nil, err
+		}
+	}
+	return record, tx.First(record).Error
+}
+
+func restoreChildren(tx *gorm.DB, tenantID, resourceName, id string, deletedAt time.Time) error {
+	for _, link := range models.ChildLinks(resourceName) {
+		child, _ := models.LookupResource(link.Child)
+		var ids []string
+		if err := tx.Unscoped().Model(child.New()).
+			Where(link.Column+" = ? AND tenant_id = ? AND is_deleted = ? AND deleted_at = ?", id, tenantID, true, deletedAt).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		if err := tx.Unscoped().Model(child.New()).Where("id IN ?", ids).Updates(models.RestoreUpdates()).Error; err != nil {
+			return err
+		}
+		for _, childID := range ids {
+			if err := restoreChildren(tx, tenantID, child.Name, childID, deletedAt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// purgeTrashed permanently deletes the :id record of resource from the trash,
+// with its children in the trash. Honours If-Match.
+func purgeTrashed(tx *gorm.DB, c *gin.Context, resource models.Resource) error {
+	record, err := loadTrashed(tx, c, resource)
+	if err != nil {
+		return err
+	}
+	if !checkIfMatch(c, record) {
+		return errPatchResponded
+	}
+	return models.PurgeTrashed(tx, c.GetString("tenant_id"), resource.Name, []string{c.Param("id")})
+}
+
+// restoreResource restores the :id record of resource from the tenant's
+// trash and returns it. It writes the error response and returns false on
+// failure.
+func restoreResource(c *gin.Context, database *db.Database, resource models.Resource) (models.Trashable, bool) {
+	gdb := &db.Database{DB: requestDB(c, database.DB)}
+	schemaTenant := resourceSchema(resource.Name, c.GetString("tenant_id"))
+	var record models.Trashable
+	err := gdb.TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		var err error
+		record, err = restoreTrashed(tx, c, resource)
+		return err
+	})
+	if err == nil {
+		return record, true
+	}
+	if record != nil && errors.Is(err, models.ErrStaleRevision) {
+		// Lost a race with another write after If-Match was checked; the
+		// current revision is read from the record's schema
+		if txErr := gdb.TenantTx(schemaTenant, func(tx *gorm.DB) error {
+			respondTrashFailure(c, tx, resource, record, "restore", err)
+			return nil
+		}); txErr == nil {
+			return nil, false
+		}
+	}
+	respondTrashFailure(c, nil, resource, nil, "restore", err)
+	return nil, false
+}
+
+// purgeResource permanently deletes the :id record of resource from the
+// tenant's trash. It writes the error response and returns false on failure.
+func purgeResource(c *gin.Context, database *db.Database, resource models.Resource) bool {
+	schemaTenant := resourceSchema(resource.Name, c.GetString("tenant_id"))
+	err := (&db.Database{DB: requestDB(c, database.DB)}).TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		return purgeTrashed(tx, c, resource)
+	})
+	if err != nil {
+		respondTrashFailure(c, nil, resource, nil, "permanently delete", err)
+		return false
+	}
+	return true
+}
+
+// respondTrashFailure writes the response for a failed restore or permanent
+// delete
+func respondTrashFailure(c *gin.Context, gdb *gorm.DB, resource models.Resource, record models.Trashable, action string, err error) {
+	var failure *patchFailure
+	switch {
+	case errors.Is(err, errPatchResponded):
+	case errors.Is(err, models.ErrLegalHold):
+		c.JSON(http.StatusConflict, gin.H{"error": resource.Label + " is under a legal hold and cannot be permanently deleted"})
+	case errors.As(err, &failure):
+		c.JSON(failure.status, failure.body)
+	case record != nil && staleWrite(c, gdb.Unscoped(), record, err):
+	default:
+		log.Printf("Failed to %s %s %s: %v", action, resource.Name, c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " " + strings.ToLower(resource.Label)})
+	}
+}
+
+type TrashHandler struct {
+	db *db.Database
+}
+
+func NewTrashHandler(database *db.Database) *TrashHandler {
+	return &TrashHandler{db: database}
+}
+
+// trashResource resolves the :resource param and checks the caller may
+// perform action on it. It writes the error response and returns false on
+// failure.
+func trashResource(c *gin.Context, action string) (models.Resource, bool) {
+	resource, ok := models.LookupResource(c.Param("resource"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown resource"})
+		return resource, false
+	}
+	if !models.HasPermission(c.GetString("user_role"), resource.Permission(action)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return resource, false
+	}
+	return resource, true
+}
+
+// GetTrash lists the tenant's records of a resource that are in the trash,
+// most recently deleted first
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	resource, ok := trashResource(c, "view")
+	if !ok {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+
+	model := resource.New()
+	list := reflect.New(reflect.SliceOf(reflect.TypeOf(model).Elem()))
+	var page *query.Meta
+	err := (&db.Database{DB: requestDB(c, h.db.DB)}).TenantTx(resourceSchema(resource.Name, tenantID), func(tx *gorm.DB) error {
+		base := tx.Unscoped().Model(model).Where("tenant_id = ? AND is_deleted = ?", tenantID, true)
+		var err error
+		page, err = query.List(c, base, trashQuery, list.Interface())
+		return err
+	})
+	if err != nil {
+		respondListError(c, err, "Failed to fetch trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       list.Elem().Interface(),
+		"pagination": page,
+	})
+}
+
+// RestoreFromTrash brings a record back from the trash, with the children
+// that were deleted along with it
+func (h *TrashHandler) RestoreFromTrash(c *gin.Context) {
+	resource, ok := trashResource(c, "update")
+	if !ok {
+		return
+	}
+	record, ok := restoreResource(c, h.db, resource)
+	if !ok {
+		return
+	}
+
+	setETag(c, record)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": resource.Label + " restored successfully",
+		"data":    record,
+	})
+}
+
+// PermanentDelete removes a record in the trash, and its children in the
+// trash, for good
+func (h *TrashHandler) PermanentDelete(c *gin.Context) {
+	resource, ok := trashResource(c, "delete")
+	if !ok {
+		return
+	}
+	if !purgeResource(c, h.db, resource) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": resource.Label + " permanently deleted",
+	})
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/versions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var recordVersionQuery = query.Spec{
+	Filters:     []string{"action", "changed_by", "created_at"},
+	Sorts:       []string{"version", "created_at"},
+	DefaultSort: "-version",
+}
+
+// versionDiff is the response of DiffVersions
+type versionDiff struct {
+	From    int                    `json:"from"`
+	To      int                    `json:"to"`
+	Changes []versions.FieldChange `json:"changes"`
+}
+
+type VersionHandler struct {
+	db *db.Database
+}
+
+func NewVersionHandler(database *db.Database) *VersionHandler {
+	return &VersionHandler{db: database}
+}
+
+// versionedResource resolves the :resource param and checks it keeps history
+// and the caller may perform action on it. It writes the error response and
+// returns false on failure.
+func versionedResource(c *gin.Context, action string) (models.Resource, bool) {
+	resource, ok := models.LookupResource(c.Param("resource"))
+	if !ok || !versions.Versioned[resource.Name] {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resource does not keep version history"})
+		return resource, false
+	}
+	if !models.HasPermission(c.GetString("user_role"), resource.Permission(action)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		return resource, false
+	}
+	return resource, true
+}
+
+// loadVersion returns the version named by param. It writes the error
+// response and returns nil on failure.
+func (h *VersionHandler) loadVersion(c *gin.Context, resource models.Resource, param string) *models.RecordVersion {
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version must be a positive number"})
+		return nil
+	}
+	version, err := versions.Get(requestDB(c, h.db.DB), c.GetString("tenant_id"), resource.Name, c.Param("id"), n)
+	if err != nil {
+		if errors.Is(err, versions.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version"})
+		}
+		return nil
+	}
+	return version
+}
+
+// GetVersions lists the versions of a record, newest first. With ?at (RFC
+// 3339) it returns the single version that was current at that time.
+func (h *VersionHandler) GetVersions(c *gin.Context) {
+	resource, ok := versionedResource(c, "view")
+	if !ok {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'at', expected an RFC 3339 time"})
+			return
+		}
+		version, err := versions.At(requestDB(c, h.db.DB), tenantID, resource.Name, c.Param("id"), t)
+		if err != nil {
+			if errors.Is(err, versions.ErrNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "No version at that time"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch version"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": version})
+		return
+	}
+
+	var list []models.RecordVersion
+	base := requestDB(c, h.db.DB).Model(&models.RecordVersion{}).
+		Where("tenant_id = ? AND resource_type = ? AND resource_id = ?", tenantID, resource.Name, c.Param("id"))
+	page, err := query.List(c, base, recordVersionQuery, &list)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch versions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       list,
+		"pagination": page,
+	})
+}
+
+// GetVersion returns one version of a record
+func (h *VersionHandler) GetVersion(c *gin.Context) {
+	resource, ok := versionedResource(c, "view")
+	if !ok {
+		return
+	}
+	version := h.loadVersion(c, resource, c.Param("version"))
+	if version == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": version})
+}
+
+// DiffVersions returns the fields that differ between the versions ?from and
+// ?to. to defaults to the latest version and from to the one before to.
+func (h *VersionHandler) DiffVersions(c *gin.Context) {
+	resource, ok := versionedResource(c, "view")
+	if !ok {
+		return
+	}
+
+	toParam := c.Query("to")
+	if toParam == "" {
+		var latest int
+		if err := requestDB(c, h.db.DB).Model(&models.RecordVersion{}).
+			Where("tenant_id = ? AND resource_type = ? AND resource_id = ?", c.GetString("tenant_id"), resource.Name, c.Param("id")).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch versions"})
+			return
+		}
+		toParam = strconv.Itoa(latest)
+	}
+	to := h.loadVersion(c, resource, toParam)
+	if to == nil {
+		return
+	}
+	fromParam := c.Query("from")
+	if fromParam == "" {
+		fromParam = strconv.Itoa(to.Version - 1)
+	}
+	from := h.loadVersion(c, resource, fromParam)
+	if from == nil {
+		return
+	}
+
+	changes, err := versions.Diff(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare versions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versionDiff{From: from.Version, To: to.Version, Changes: changes},
+	})
+}
+
+// RevertVersion brings a record back to the content of an earlier version.
+// The revert is a new change, recorded as the next version; fields managed by
+// the server are kept. Honours If-Match.
+func (h *VersionHandler) RevertVersion(c *gin.Context) {
+	resource, ok := versionedResource(c, "update")
+	if !ok {
+		return
+	}
+	version := h.loadVersion(c, resource, c.Param("version"))
+	if version == nil {
+		return
+	}
+	tenantID := c.GetString("tenant_id")
+
+	record := resource.New()
+	ctx := versions.WithRevert(c.Request.Context(), version.Version)
+	gdb := &db.Database{DB: h.db.DB.WithContext(ctx)}
+	schemaTenant := resourceSchema(resource.Name, tenantID)
+	err := gdb.TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), tenantID, false).
+			First(record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return patchFailed(http.StatusNotFound, resource.Label+" not found")
+			}
+			return err
+		}
+		if !checkIfMatch(c, record.(models.Revisioned)) {
+			return errPatchResponded
+		}
+		updates, err := versions.RevertUpdates(tx, record, version)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(record).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(record).Error
+	})
+
+	var failure *patchFailure
+	switch {
+	case errors.Is(err, errPatchResponded):
+		return
+	case errors.As(err, &failure):
+		c.JSON(failure.status, failure.body)
+		return
+	case errors.Is(err, models.ErrStaleRevision):
+		// Lost a race with another write after If-Match was checked
+		if err := gdb.TenantTx(schemaTenant, func(tx *gorm.DB) error {
+			return tx.First(record).Error
+		}); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": resource.Label + " not found"})
+			return
+		}
+		preconditionFailed(c, record.(models.Revisioned))
+		return
+	case err != nil:
+		log.Printf("Failed to revert %s %s to version %d: %v", resource.Name, c.Param("id"), version.Version, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert " + strings.ToLower(resource.Label)})
+		return
+	}
+
+	setETag(c, record.(models.Revisioned))
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": resource.Label + " reverted to version " + strconv.Itoa(version.Version),
+		"data":    record,
+	})
+}
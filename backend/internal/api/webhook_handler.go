@@ -0,0 +1,332 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cyber/backend/internal/crypto"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"github.com/cyber/backend/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var webhookDeliveryQuery = query.Spec{
+	Filters: []string{"subscription_id", "event_type", "event_id", "status", "response_status", "created_at"},
+	Sorts:   []string{"created_at", "last_attempt_at", "next_attempt_at", "attempts", "status"},
+}
+
+type WebhookHandler struct {
+	db         *gorm.DB
+	dispatcher *webhook.Dispatcher
+}
+
+func NewWebhookHandler(db *gorm.DB, dispatcher *webhook.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{db: db, dispatcher: dispatcher}
+}
+
+// webhookRequest is the body accepted by CreateWebhook and UpdateWebhook
+type webhookRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// validate checks the URL, which must not point into the server's own
+// network, and the event filter. It writes the error response
+// and returns false on failure.
+func (req *webhookRequest) validate(c *gin.Context) bool {
+	if err := egress.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	for _, event := range req.Events {
+		if !webhook.ValidEventType(event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event type: " + event, "events": webhook.EventTypes})
+			return false
+		}
+	}
+	return true
+}
+
+func (h *WebhookHandler) GetEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    webhook.EventTypes,
+	})
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	var subs []models.WebhookSubscription
+	if err := requestDB(c, h.db).Where("tenant_id = ? AND is_deleted = ?", c.GetString("tenant_id"), false).
+		Order("created_at DESC").Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    subs,
+	})
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	sub, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	setETag(c, sub)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sub,
+	})
+}
+
+// CreateWebhook registers a subscription. The signing secret is only
+// returned in this response.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.validate(c) {
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	encrypted, err := crypto.Encrypt(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt secret"})
+		return
+	}
+
+	sub := models.WebhookSubscription{
+		TenantID:    c.GetString("tenant_id"),
+		Name:        req.Name,
+		URL:         req.URL,
+		Secret:      encrypted,
+		Events:      pq.StringArray(req.Events),
+		Description: req.Description,
+		Active:      true,
+		CreatedBy:   c.GetString("user_id"),
+	}
+	if err := requestDB(c, h.db).Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+	// Created active (the column default); apply an explicit "active": false
+	if req.Active != nil && !*req.Active {
+		requestDB(c, h.db).Model(&sub).Update("active", false)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Webhook created successfully. Store the secret now; it is not shown again.",
+		"data":    sub,
+		"secret":  secret,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	sub, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(c, sub) {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.validate(c) {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"url":         req.URL,
+		"events":      pq.StringArray(req.Events),
+		"description": req.Description,
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if err := requestDB(c, h.db).Model(sub).Updates(updates).Error; err != nil {
+		if staleWrite(c, h.db, sub, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	setETag(c, sub)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook updated successfully",
+		"data":    sub,
+	})
+}
+
+// RotateWebhookSecret replaces the signing secret and returns the new one
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	sub, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+		return
+	}
+	encrypted, err := crypto.Encrypt(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt secret"})
+		return
+	}
+	if err := requestDB(c, h.db).Model(sub).Update("secret", encrypted).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Secret rotated. Store the secret now; it is not shown again.",
+		"data":    sub,
+		"secret":  secret,
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	sub, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+	if !checkIfMatch(c, sub) {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if err := requestDB(c, h.db).Model(sub).Updates(map[string]interface{}{
+		"is_deleted": true,
+		"deleted_by": &userID,
+		"active":     false,
+	}).Error; err != nil {
+		if staleWrite(c, h.db, sub, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// PingWebhook sends a signed ping event to the subscription's URL and
+// returns the outcome of the attempt
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	sub, ok := h.findWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.dispatcher.Ping(c.Request.Context(), sub)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send ping"})
+		return
+	}
+	requestDB(c, h.db).First(delivery, "id = ?", delivery.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": delivery.Status == webhook.StatusDelivered,
+		"data":    delivery,
+	})
+}
+
+// GetWebhookDeliveries is the delivery log, newest first
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	base := requestDB(c, h.db).Model(&models.WebhookDelivery{}).Where("tenant_id = ?", c.GetString("tenant_id"))
+	h.listDeliveries(c, base)
+}
+
+// GetDeadLetters lists deliveries that ran out of attempts. They stay here
+// until redelivered.
+func (h *WebhookHandler) GetDeadLetters(c *gin.Context) {
+	base := requestDB(c, h.db).Model(&models.WebhookDelivery{}).Where("tenant_id = ? AND status = ?", c.GetString("tenant_id"), webhook.StatusDead)
+	h.listDeliveries(c, base)
+}
+
+func (h *WebhookHandler) listDeliveries(c *gin.Context, base *gorm.DB) {
+	var deliveries []models.WebhookDelivery
+	page, err := query.List(c, base, webhookDeliveryQuery, &deliveries)
+	if err != nil {
+		respondListError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       deliveries,
+		"pagination": page,
+	})
+}
+
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	var delivery models.WebhookDelivery
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ?", c.Param("id"), c.GetString("tenant_id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    delivery,
+	})
+}
+
+// RedeliverWebhook queues a delivery to be sent again, typically a dead
+// letter after the receiver was fixed
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	var delivery models.WebhookDelivery
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ?", c.Param("id"), c.GetString("tenant_id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+		return
+	}
+
+	if err := h.dispatcher.Redeliver(&delivery); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue redelivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Delivery queued",
+		"data":    delivery,
+	})
+}
+
+func (h *WebhookHandler) findWebhook(c *gin.Context) (*models.WebhookSubscription, bool) {
+	var sub models.WebhookSubscription
+	if err := requestDB(c, h.db).Where("id = ? AND tenant_id = ? AND is_deleted = ?", c.Param("id"), c.GetString("tenant_id"), false).
+		First(&sub).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+	return &sub, true
+}
@@ -0,0 +1,45 @@
+// Package audit records every change to a tenant model in AuditLog, with
+// the row before and after the change and who made it.
+//
+// The actor travels in the statement context: handlers run their statements
+// with the request context (see middleware.Audit), background jobs with a
+// context from WithActor. Changes made without one are recorded as made by
+// "system".
+//
+// Entries are append-only and hash-chained per tenant (see chain.go), with
+// the chain heads periodically signed by a Checkpointer. Verify walks a chain
+// and reports its first broken link.
+package audit
+
+import (
+	"context"
+)
+
+// SystemActor is the user ID of changes made without an actor
+const SystemActor = "system"
+
+// Actor is who made a change and from where
+type Actor struct {
+	UserID    string
+	TenantID  string
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor in ctx, or the system actor
+func ActorFrom(ctx context.Context) Actor {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+			return actor
+		}
+	}
+	return Actor{UserID: SystemActor}
+}
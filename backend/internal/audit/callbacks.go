@@ -0,0 +1,364 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Actions recorded for changes
+const (
+	ActionCreate          = "create"
+	ActionUpdate          = "update"
+	ActionDelete          = "delete" // soft delete
+	ActionRestore         = "restore"
+	ActionPermanentDelete = "permanent_delete"
+)
+
+const (
+	beforeKey = "audit:before"
+	// Rows snapshotted per bulk update or delete. Rows past it are changed
+	// without an entry; the cap keeps a mass update from loading the table.
+	maxRows = 1000
+	// Value stored in place of secrets
+	redacted = "[REDACTED]"
+)
+
+// Tenant models whose writes are bookkeeping rather than changes users make
+var skipped = []interface{}{
+	&models.AuditLog{},
+	&models.SystemLog{},
+	&models.APIUsage{},
+	&models.SystemMetric{},
+	&models.OutboxEvent{},
+	&models.WebhookDelivery{},
+	&models.IdempotencyKey{},
+	&models.ImportJob{},
+	&models.Job{},
+	&models.Reminder{},
+	&models.Notification{},
+	&models.RecordVersion{},
+}
+
+// RegisterCallbacks audits creates, updates and deletes of every model with
+// a TenantID. Entries are written in the transaction of the change, so a
+// change that cannot be audited is rolled back. Every AuditLog created, by
+// these callbacks or directly, is linked to its tenant's hash chain.
+func RegisterCallbacks(db *gorm.DB) error {
+	r := &recorder{names: map[reflect.Type]string{}, skip: map[reflect.Type]bool{}}
+	for _, res := range models.TenantResources {
+		r.names[reflect.TypeOf(res.New()).Elem()] = res.Name
+	}
+	for _, m := range skipped {
+		r.skip[reflect.TypeOf(m).Elem()] = true
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").After("gorm:begin_transaction").Register("audit:chain", chain); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", r.afterCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:before_update", r.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", r.after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:before_delete", r.before); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", r.after)
+}
+
+// Change is a row change recorded by the callbacks
+type Change struct {
+	Action       string
+	ResourceType string // resource name, or table of unregistered models
+	ResourceID   string
+	TenantID     string
+}
+
+// Observer is called with each audited change, in its transaction. tx
+// carries the statement context. An error rolls the change back.
+type Observer func(tx *gorm.DB, change Change) error
+
+var observers []Observer
+
+// Observe registers fn for every audited change. Observers are registered
+// at startup, before any statement runs.
+func Observe(fn Observer) {
+	observers = append(observers, fn)
+}
+
+type recorder struct {
+	names map[reflect.Type]string
+	skip  map[reflect.Type]bool
+}
+
+// audited reports whether the statement writes an audited model
+func (r *recorder) audited(db *gorm.DB) bool {
+	s := db.Statement.Schema
+	return s != nil && !db.DryRun && !r.skip[s.ModelType] && s.LookUpField("TenantID") != nil
+}
+
+// resourceType is the resource name of the model, or its table
+func (r *recorder) resourceType(db *gorm.DB) string {
+	if name, ok := r.names[db.Statement.Schema.ModelType]; ok {
+		return name
+	}
+	return db.Statement.Schema.Table
+}
+
+func (r *recorder) afterCreate(db *gorm.DB) {
+	if db.Error != nil || !r.audited(db) {
+		return
+	}
+	var entries []models.AuditLog
+	for _, record := range records(db) {
+		entries = append(entries, r.entry(db, ActionCreate, nil, snapshot(db, record)))
+	}
+	r.write(db, entries)
+}
+
+// before loads the rows the update or delete is about to change
+func (r *recorder) before(db *gorm.DB) {
+	if db.Error != nil || !r.audited(db) {
+		return
+	}
+	q, ok := r.matching(db)
+	if !ok {
+		return
+	}
+	var rows []map[string]interface{}
+	if err := q.Limit(maxRows + 1).Find(&rows).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: failed to load rows before change: %w", err))
+		return
+	}
+	if len(rows) > maxRows {
+		log.Printf("audit: %s change matches more than %d rows, auditing the first %d", db.Statement.Table, maxRows, maxRows)
+		rows = rows[:maxRows]
+	}
+	db.InstanceSet(beforeKey, rows)
+}
+
+// after reloads the changed rows and records an entry for each
+func (r *recorder) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(beforeKey)
+	if !ok || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	before := v.([]map[string]interface{})
+	if len(before) == 0 {
+		return
+	}
+
+	pk := primaryKey(db)
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[pk])
+	}
+	var rows []map[string]interface{}
+	if err := newSession(db).Table(db.Statement.Table).Where(clause.IN{Column: clause.Column{Name: pk}, Values: ids}).
+		Find(&rows).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: failed to load rows after change: %w", err))
+		return
+	}
+	after := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		after[fmt.Sprint(row[pk])] = row
+	}
+
+	var entries []models.AuditLog
+	for _, old := range before {
+		current, exists := after[fmt.Sprint(old[pk])]
+		action := ActionUpdate
+		switch {
+		case !exists:
+			action = ActionPermanentDelete
+		case isDeleted(current) && !isDeleted(old):
+			action = ActionDelete
+		case !isDeleted(current) && isDeleted(old):
+			action = ActionRestore
+		case unchanged(old, current):
+			continue
+		}
+		entries = append(entries, r.entry(db, action, old, current))
+	}
+	r.write(db, entries)
+}
+
+// matching builds a query for the rows the statement changes: those of its
+// conditions and, for a loaded record, its primary key
+func (r *recorder) matching(db *gorm.DB) (*gorm.DB, bool) {
+	q := newSession(db).Table(db.Statement.Table)
+	conditions := false
+	if c, ok := db.Statement.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			q = q.Clauses(clause.Where{Exprs: where.Exprs})
+			conditions = true
+		}
+	}
+	pk := db.Statement.Schema.PrioritizedPrimaryField
+	if pk != nil && db.Statement.ReflectValue.IsValid() && reflect.Indirect(db.Statement.ReflectValue).Kind() == reflect.Struct {
+		if id, zero := pk.ValueOf(db.Statement.Context, reflect.Indirect(db.Statement.ReflectValue)); !zero {
+			q = q.Where(clause.Eq{Column: clause.Column{Name: pk.DBName}, Value: id})
+			conditions = true
+		}
+	}
+	// Statements without conditions are refused by GORM
+	return q, conditions
+}
+
+func (r *recorder) entry(db *gorm.DB, action string, old, current map[string]interface{}) models.AuditLog {
+	actor := ActorFrom(db.Statement.Context)
+	row := current
+	if row == nil {
+		row = old
+	}
+	tenantID, _ := row["tenant_id"].(string)
+	if tenantID == "" {
+		tenantID = actor.TenantID
+	}
+	return models.AuditLog{
+		TenantID:     tenantID,
+		UserID:       actor.UserID,
+		Action:       action,
+		ResourceType: r.resourceType(db),
+		ResourceID:   fmt.Sprint(row[primaryKey(db)]),
+		OldValues:    marshal(old),
+		NewValues:    marshal(current),
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		RequestID:    actor.RequestID,
+	}
+}
+
+// write inserts entries in the statement's transaction and passes the
+// changes to the observers, failing the statement when either fails
+func (r *recorder) write(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := newSession(db).CreateInBatches(&entries, 500).Error; err != nil {
+		db.AddError(fmt.Errorf("audit: failed to record change: %w", err))
+		return
+	}
+	for _, observe := range observers {
+		for _, e := range entries {
+			change := Change{Action: e.Action, ResourceType: e.ResourceType, ResourceID: e.ResourceID, TenantID: e.TenantID}
+			if err := observe(newSession(db), change); err != nil {
+				db.AddError(fmt.Errorf("audit: observer failed on %s %s: %w", change.ResourceType, change.ResourceID, err))
+				return
+			}
+		}
+	}
+}
+
+// newSession is a fresh statement on the connection, and so the transaction,
+// of db
+func newSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+func primaryKey(db *gorm.DB) string {
+	if pk := db.Statement.Schema.PrioritizedPrimaryField; pk != nil {
+		return pk.DBName
+	}
+	return "id"
+}
+
+// records returns the structs the statement wrote
+func records(db *gorm.DB) []reflect.Value {
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice, reflect.Array:
+		out := make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out = append(out, reflect.Indirect(rv.Index(i)))
+		}
+		return out
+	}
+	return nil
+}
+
+// snapshot maps the columns of record to their values
+func snapshot(db *gorm.DB, record reflect.Value) map[string]interface{} {
+	row := map[string]interface{}{}
+	for _, field := range db.Statement.Schema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		value, _ := field.ValueOf(db.Statement.Context, record)
+		row[field.DBName] = value
+	}
+	return row
+}
+
+func isDeleted(row map[string]interface{}) bool {
+	if deleted, ok := row["is_deleted"].(bool); ok && deleted {
+		return true
+	}
+	deletedAt, ok := row["deleted_at"]
+	return ok && deletedAt != nil && !reflect.ValueOf(deletedAt).IsZero()
+}
+
+// unchanged reports whether only bookkeeping columns differ, as when a
+// record is saved without changes
+func unchanged(old, current map[string]interface{}) bool {
+	for column, value := range current {
+		if column == "updated_at" || column == "revision" {
+			continue
+		}
+		if marshalValue(value) != marshalValue(old[column]) {
+			return false
+		}
+	}
+	return true
+}
+
+// marshal encodes a snapshot with secrets redacted. Nil is an empty object.
+func marshal(row map[string]interface{}) string {
+	if row == nil {
+		return "{}"
+	}
+	clean := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if secret(column) {
+			value = redacted
+		} else if raw, ok := value.([]byte); ok {
+			value = string(raw)
+		}
+		clean[column] = value
+	}
+	raw, err := json.Marshal(clean)
+	if err != nil {
+		return "{}"
+	}
+	return string(raw)
+}
+
+func marshalValue(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+// secret reports whether a column holds credentials, which are not copied
+// into the audit log
+func secret(column string) bool {
+	for _, part := range []string{"password", "secret", "token", "api_key"} {
+		if strings.Contains(column, part) {
+			return true
+		}
+	}
+	return strings.HasSuffix(column, "_key")
+}
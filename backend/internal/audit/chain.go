@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Version of the hashed encoding of an entry
+const hashVersion = "v1"
+
+var auditLogType = reflect.TypeOf(models.AuditLog{})
+
+// chain links the AuditLog entries being created to their tenant's chain.
+// The tenant's chain lock is held until the transaction ends, so concurrent
+// writers append one after the other.
+func chain(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || db.Statement.Schema == nil || db.Statement.Schema.ModelType != auditLogType {
+		return
+	}
+	byTenant := map[string][]*models.AuditLog{}
+	for _, record := range records(db) {
+		if !record.CanAddr() {
+			db.AddError(errors.New("audit: entries must be created from addressable values"))
+			return
+		}
+		entry := record.Addr().Interface().(*models.AuditLog)
+		byTenant[entry.TenantID] = append(byTenant[entry.TenantID], entry)
+	}
+	tenants := make([]string, 0, len(byTenant))
+	for tenantID := range byTenant {
+		tenants = append(tenants, tenantID)
+	}
+	// Locks are taken in one order, so writers of several tenants do not
+	// deadlock
+	sort.Strings(tenants)
+
+	for _, tenantID := range tenants {
+		if err := lockChain(db, tenantID); err != nil {
+			db.AddError(fmt.Errorf("audit: failed to lock chain: %w", err))
+			return
+		}
+		head, err := chainHead(db, tenantID)
+		if err != nil {
+			db.AddError(fmt.Errorf("audit: failed to load chain head: %w", err))
+			return
+		}
+		for _, entry := range byTenant[tenantID] {
+			if entry.CreatedAt.IsZero() {
+				entry.CreatedAt = time.Now()
+			}
+			// Postgres keeps microseconds; the hash must match what is read back
+			entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+			entry.Sequence = head.Sequence + 1
+			entry.PrevHash = head.Hash
+			entry.Hash = entryHash(entry)
+			head = *entry
+		}
+	}
+}
+
+// lockChain takes the transaction-scoped lock of a tenant's chain
+func lockChain(db *gorm.DB, tenantID string) error {
+	return newSession(db).Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit_chain:"+tenantID).Error
+}
+
+// chainHead returns the last chained entry of a tenant, or a zero entry when
+// the chain is empty
+func chainHead(db *gorm.DB, tenantID string) (models.AuditLog, error) {
+	var head models.AuditLog
+	err := newSession(db).Unscoped().Select("sequence", "hash").
+		Where("tenant_id = ? AND sequence > 0", tenantID).Order("sequence DESC").Take(&head).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.AuditLog{}, nil
+	}
+	return head, err
+}
+
+// entryHash is the SHA-256 of the entry's contents, sequence and previous
+// hash. The JSON values are canonicalized, as Postgres reformats jsonb.
+func entryHash(e *models.AuditLog) string {
+	raw, _ := json.Marshal([]interface{}{
+		hashVersion,
+		e.TenantID,
+		e.Sequence,
+		e.PrevHash,
+		e.UserID,
+		e.Action,
+		e.ResourceType,
+		e.ResourceID,
+		canonicalJSON(e.OldValues),
+		canonicalJSON(e.NewValues),
+		e.IPAddress,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalJSON re-encodes a JSON document with sorted keys and no
+// insignificant whitespace
+func canonicalJSON(s string) string {
+	var v interface{}
+	if s == "" || json.Unmarshal([]byte(s), &v) != nil {
+		return s
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return s
+	}
+	return string(raw)
+}
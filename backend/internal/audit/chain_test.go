@@ -0,0 +1,236 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+)
+
+func testEntry() models.AuditLog {
+	return models.AuditLog{
+		BaseModel:    models.BaseModel{ID: "entry-1", CreatedAt: time.Date(2024, 5, 1, 8, 30, 0, 123456000, time.UTC)},
+		TenantID:     "tenant-1",
+		UserID:       "user-1",
+		Action:       "update",
+		ResourceType: "risk",
+		ResourceID:   "risk-1",
+		OldValues:    `{"status":"open","score":12}`,
+		NewValues:    `{"status":"closed","score":12}`,
+		IPAddress:    "10.0.0.1",
+		UserAgent:    "test",
+		RequestID:    "req-1",
+		Sequence:     1,
+	}
+}
+
+func TestEntryHash(t *testing.T) {
+	base := testEntry()
+	baseHash := entryHash(&base)
+	if len(baseHash) != 64 {
+		t.Fatalf("entryHash = %q, want 64 hex digits", baseHash)
+	}
+	if again := testEntry(); entryHash(&again) != baseHash {
+		t.Error("entryHash is not deterministic")
+	}
+
+	same := []struct {
+		name   string
+		change func(e *models.AuditLog)
+	}{
+		{"keys reordered", func(e *models.AuditLog) { e.OldValues = `{"score":12,"status":"open"}` }},
+		{"whitespace", func(e *models.AuditLog) { e.NewValues = "{ \"status\": \"closed\",\n \"score\": 12 }" }},
+		{"time zone", func(e *models.AuditLog) { e.CreatedAt = e.CreatedAt.In(time.FixedZone("WIB", 7*60*60)) }},
+		{"hash fields", func(e *models.AuditLog) { e.Hash = "ignored" }},
+	}
+	for _, tt := range same {
+		e := testEntry()
+		tt.change(&e)
+		if got := entryHash(&e); got != baseHash {
+			t.Errorf("%s: hash changed", tt.name)
+		}
+	}
+
+	changed := []struct {
+		name   string
+		change func(e *models.AuditLog)
+	}{
+		{"tenant", func(e *models.AuditLog) { e.TenantID = "tenant-2" }},
+		{"sequence", func(e *models.AuditLog) { e.Sequence = 2 }},
+		{"previous hash", func(e *models.AuditLog) { e.PrevHash = strings.Repeat("0", 64) }},
+		{"user", func(e *models.AuditLog) { e.UserID = "user-2" }},
+		{"action", func(e *models.AuditLog) { e.Action = "delete" }},
+		{"resource type", func(e *models.AuditLog) { e.ResourceType = "incident" }},
+		{"resource", func(e *models.AuditLog) { e.ResourceID = "risk-2" }},
+		{"old values", func(e *models.AuditLog) { e.OldValues = `{"status":"open","score":13}` }},
+		{"new values", func(e *models.AuditLog) { e.NewValues = `{"status":"open","score":12}` }},
+		{"ip address", func(e *models.AuditLog) { e.IPAddress = "10.0.0.2" }},
+		{"user agent", func(e *models.AuditLog) { e.UserAgent = "other" }},
+		{"request", func(e *models.AuditLog) { e.RequestID = "req-2" }},
+		{"time", func(e *models.AuditLog) { e.CreatedAt = e.CreatedAt.Add(time.Microsecond) }},
+	}
+	for _, tt := range changed {
+		e := testEntry()
+		tt.change(&e)
+		if got := entryHash(&e); got == baseHash {
+			t.Errorf("%s: hash did not change", tt.name)
+		}
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"b":1,"a":[1, 2]}`, `{"a":[1,2],"b":1}`},
+		{` {"a" : {"d":null, "c":"x"}} `, `{"a":{"c":"x","d":null}}`},
+		{`""`, `""`},
+		{"", ""},
+		{"not json", "not json"},
+	}
+	for _, tt := range tests {
+		if got := canonicalJSON(tt.in); got != tt.want {
+			t.Errorf("canonicalJSON(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// testChain links n entries as chain does
+func testChain(n int) []models.AuditLog {
+	entries := make([]models.AuditLog, n)
+	prev := models.AuditLog{}
+	for i := range entries {
+		e := testEntry()
+		e.ID = "entry-" + string(rune('a'+i))
+		e.Sequence = prev.Sequence + 1
+		e.PrevHash = prev.Hash
+		e.CreatedAt = e.CreatedAt.Add(time.Duration(i) * time.Second)
+		e.Hash = entryHash(&e)
+		entries[i] = e
+		prev = e
+	}
+	return entries
+}
+
+func TestCheckLink(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(entries []models.AuditLog) (prev, entry *models.AuditLog)
+		wantReason string
+		wantSeq    int64
+	}{
+		{"first entry", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			return &models.AuditLog{}, &e[0]
+		}, "", 0},
+		{"next entry", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			return &e[0], &e[1]
+		}, "", 0},
+		{"repeated sequence", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			return &e[1], &e[1]
+		}, "sequence 2 appears more than once", 2},
+		{"missing entries", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			return &e[0], &e[2]
+		}, "entries 2 to 2 are missing", 2},
+		{"relinked", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			e[1].PrevHash = strings.Repeat("0", 64)
+			return &e[0], &e[1]
+		}, "previous hash does not match entry 1", 2},
+		{"edited", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			e[1].NewValues = `{"status":"accepted"}`
+			return &e[0], &e[1]
+		}, "hash does not match the entry's contents", 2},
+		{"rehashed after the next entry", func(e []models.AuditLog) (*models.AuditLog, *models.AuditLog) {
+			e[1].NewValues = `{"status":"accepted"}`
+			e[1].Hash = entryHash(&e[1])
+			return &e[1], &e[2]
+		}, "previous hash does not match entry 2", 3},
+	}
+	for _, tt := range tests {
+		prev, entry := tt.tamper(testChain(3))
+		got := checkLink(prev, entry)
+		switch {
+		case tt.wantReason == "" && got != nil:
+			t.Errorf("%s: unexpected break %+v", tt.name, got)
+		case tt.wantReason != "" && got == nil:
+			t.Errorf("%s: no break, want %q", tt.name, tt.wantReason)
+		case got != nil && (got.Reason != tt.wantReason || got.Sequence != tt.wantSeq):
+			t.Errorf("%s: break at %d %q, want at %d %q", tt.name, got.Sequence, got.Reason, tt.wantSeq, tt.wantReason)
+		}
+	}
+}
+
+func TestCheckCheckpoint(t *testing.T) {
+	signer, err := NewSigner("", "test secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewSigner("", "other secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := testChain(1)[0]
+	signed := func(s *Signer) models.AuditCheckpoint {
+		cp := models.AuditCheckpoint{ID: 7, TenantID: entry.TenantID, Sequence: entry.Sequence, Hash: entry.Hash, CreatedAt: time.Now()}
+		s.sign(&cp)
+		return cp
+	}
+
+	tests := []struct {
+		name       string
+		checkpoint func() models.AuditCheckpoint
+		wantReason string
+	}{
+		{"valid", func() models.AuditCheckpoint { return signed(signer) }, ""},
+		{"other key", func() models.AuditCheckpoint { return signed(other) }, "checkpoint 7 has an invalid signature"},
+		{"hash edited after signing", func() models.AuditCheckpoint {
+			cp := signed(signer)
+			cp.Hash = strings.Repeat("0", 64)
+			return cp
+		}, "checkpoint 7 has an invalid signature"},
+		{"signature not base64", func() models.AuditCheckpoint {
+			cp := signed(signer)
+			cp.Signature = "%%%"
+			return cp
+		}, "checkpoint 7 has an invalid signature"},
+		{"signed another hash", func() models.AuditCheckpoint {
+			cp := models.AuditCheckpoint{ID: 7, TenantID: entry.TenantID, Sequence: entry.Sequence, Hash: strings.Repeat("0", 64), CreatedAt: time.Now()}
+			signer.sign(&cp)
+			return cp
+		}, "hash differs from checkpoint 7"},
+	}
+	for _, tt := range tests {
+		cp := tt.checkpoint()
+		got := checkCheckpoint(signer.PublicKey(), &cp, &entry)
+		switch {
+		case tt.wantReason == "" && got != nil:
+			t.Errorf("%s: unexpected break %+v", tt.name, got)
+		case tt.wantReason != "" && (got == nil || got.Reason != tt.wantReason):
+			t.Errorf("%s: break %+v, want %q", tt.name, got, tt.wantReason)
+		}
+	}
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"derived", "", false},
+		{"seed", "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=", false},
+		{"not base64", "not base64!", true},
+		{"short seed", "AAECAw==", true},
+	}
+	for _, tt := range tests {
+		s, err := NewSigner(tt.encoded, "fallback")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && len(s.PublicKey()) != ed25519.PublicKeySize {
+			t.Errorf("%s: public key has %d bytes", tt.name, len(s.PublicKey()))
+		}
+	}
+}
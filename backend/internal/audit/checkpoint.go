@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Signer signs checkpoints with an Ed25519 key. Auditors verify them with
+// the public key alone.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner uses the base64 Ed25519 seed in encoded. Without one the key is
+// derived from fallback, which is only acceptable outside production.
+func NewSigner(encoded, fallback string) (*Signer, error) {
+	var seed []byte
+	if encoded == "" {
+		sum := sha256.Sum256([]byte("audit checkpoint key:" + fallback))
+		seed = sum[:]
+	} else {
+		var err error
+		if seed, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("audit signing key is not base64: %w", err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("audit signing key must be a %d byte Ed25519 seed", ed25519.SeedSize)
+		}
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+// PublicKey returns the key that verifies the signer's checkpoints
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyID is the fingerprint of a public key stored with its checkpoints
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkpointMessage is the signed statement of a checkpoint
+func checkpointMessage(cp *models.AuditCheckpoint) []byte {
+	return []byte(fmt.Sprintf("audit-checkpoint:%s:%s:%d:%s:%s",
+		hashVersion, cp.TenantID, cp.Sequence, cp.Hash, cp.CreatedAt.UTC().Format(time.RFC3339Nano)))
+}
+
+func (s *Signer) sign(cp *models.AuditCheckpoint) {
+	cp.KeyID = s.keyID
+	cp.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, checkpointMessage(cp)))
+}
+
+// validSignature reports whether key signed the checkpoint
+func validSignature(key ed25519.PublicKey, cp *models.AuditCheckpoint) bool {
+	sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+	return err == nil && cp.KeyID == KeyID(key) && ed25519.Verify(key, checkpointMessage(cp), sig)
+}
+
+// Checkpointer periodically signs the head of every tenant chain that grew
+// since its last checkpoint
+type Checkpointer struct {
+	db       *gorm.DB
+	signer   *Signer
+	interval time.Duration
+}
+
+func NewCheckpointer(db *gorm.DB, signer *Signer, interval time.Duration) *Checkpointer {
+	return &Checkpointer{db: db, signer: signer, interval: interval}
+}
+
+// Run writes checkpoints until ctx is cancelled
+func (c *Checkpointer) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.checkpoint(ctx); err != nil {
+			log.Printf("Failed to write audit checkpoints: %v", err)
+		}
+	}
+}
+
+func (c *Checkpointer) checkpoint(ctx context.Context) error {
+	var heads []struct {
+		TenantID string
+		Sequence int64
+	}
+	err := c.db.WithContext(ctx).Raw(`SELECT l.tenant_id, MAX(l.sequence) AS sequence FROM audit_logs l
+		GROUP BY l.tenant_id
+		HAVING MAX(l.sequence) > COALESCE((SELECT MAX(cp.sequence) FROM audit_checkpoints cp WHERE cp.tenant_id = l.tenant_id), 0)`).
+		Scan(&heads).Error
+	if err != nil {
+		return err
+	}
+	for _, head := range heads {
+		if err := c.checkpointTenant(ctx, head.TenantID); err != nil {
+			return fmt.Errorf("tenant %s: %w", head.TenantID, err)
+		}
+	}
+	return nil
+}
+
+// checkpointTenant signs the tenant's chain head. The chain lock keeps
+// entries from being appended meanwhile, and other replicas from writing the
+// same checkpoint.
+func (c *Checkpointer) checkpointTenant(ctx context.Context, tenantID string) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockChain(tx, tenantID); err != nil {
+			return err
+		}
+		head, err := chainHead(tx, tenantID)
+		if err != nil {
+			return err
+		}
+		var last int64
+		if err := tx.Model(&models.AuditCheckpoint{}).Where("tenant_id = ?", tenantID).
+			Select("COALESCE(MAX(sequence), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		if head.Sequence <= last {
+			return nil
+		}
+		cp := models.AuditCheckpoint{
+			TenantID:  tenantID,
+			Sequence:  head.Sequence,
+			Hash:      head.Hash,
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		c.signer.sign(&cp)
+		return tx.Create(&cp).Error
+	})
+}
@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Entries loaded per query while walking a chain
+const verifyBatchSize = 1000
+
+// Report is the outcome of verifying a tenant's audit chain
+type Report struct {
+	TenantID     string    `json:"tenant_id"`
+	Valid        bool      `json:"valid"`
+	Entries      int64     `json:"entries"`   // chained entries checked
+	Unchained    int64     `json:"unchained"` // entries from before chaining, not covered
+	Checkpoints  int       `json:"checkpoints"`
+	HeadSequence int64     `json:"head_sequence"`
+	HeadHash     string    `json:"head_hash"`
+	KeyID        string    `json:"key_id"`     // key the checkpoints were verified with
+	PublicKey    string    `json:"public_key"` // base64
+	Break        *Break    `json:"break,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Break is the first broken link of a chain
+type Break struct {
+	Sequence int64  `json:"sequence"`
+	EntryID  string `json:"entry_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// Verify walks a tenant's chain from the first entry, checking that
+// sequences are consecutive, that each entry links to the hash of the one
+// before, that each hash matches the entry's contents, and that the chain
+// passes through every checkpoint signed with key. It stops at the first
+// broken link.
+func Verify(ctx context.Context, db *gorm.DB, tenantID string, key ed25519.PublicKey) (*Report, error) {
+	db = db.WithContext(ctx)
+	report := &Report{
+		TenantID:  tenantID,
+		KeyID:     KeyID(key),
+		PublicKey: base64.StdEncoding.EncodeToString(key),
+		CheckedAt: time.Now(),
+	}
+	if err := db.Model(&models.AuditLog{}).Unscoped().Where("COALESCE(tenant_id, '') = ? AND sequence = 0", tenantID).
+		Count(&report.Unchained).Error; err != nil {
+		return nil, err
+	}
+
+	var checkpoints []models.AuditCheckpoint
+	if err := db.Where("tenant_id = ?", tenantID).Order("sequence, id").Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	report.Checkpoints = len(checkpoints)
+	bySequence := map[int64][]models.AuditCheckpoint{}
+	for _, cp := range checkpoints {
+		bySequence[cp.Sequence] = append(bySequence[cp.Sequence], cp)
+	}
+
+	fail := func(sequence int64, entryID, format string, args ...interface{}) (*Report, error) {
+		report.Break = &Break{Sequence: sequence, EntryID: entryID, Reason: fmt.Sprintf(format, args...)}
+		return report, nil
+	}
+
+	var prev models.AuditLog
+	for {
+		var batch []models.AuditLog
+		if err := db.Unscoped().Where("tenant_id = ? AND sequence > ?", tenantID, prev.Sequence).
+			Order("sequence, id").Limit(verifyBatchSize).Find(&batch).Error; err != nil {
+			return nil, err
+		}
+		for i := range batch {
+			entry := &batch[i]
+			if report.Break = checkLink(&prev, entry); report.Break != nil {
+				return report, nil
+			}
+			for _, cp := range bySequence[entry.Sequence] {
+				if report.Break = checkCheckpoint(key, &cp, entry); report.Break != nil {
+					return report, nil
+				}
+			}
+			report.Entries++
+			prev = *entry
+		}
+		if len(batch) < verifyBatchSize {
+			break
+		}
+		// A sequence repeated across the batch boundary would be skipped by
+		// "sequence >", so the last one is checked explicitly
+		var count int64
+		if err := db.Model(&models.AuditLog{}).Unscoped().Where("tenant_id = ? AND sequence = ?", tenantID, prev.Sequence).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 1 {
+			return fail(prev.Sequence, "", "sequence %d appears more than once", prev.Sequence)
+		}
+	}
+
+	// Checkpoints past the head mean entries were removed from the end
+	for _, cp := range checkpoints {
+		if cp.Sequence > prev.Sequence {
+			return fail(cp.Sequence, "", "chain ends at %d but checkpoint %d was signed at %d: entries were removed", prev.Sequence, cp.ID, cp.Sequence)
+		}
+	}
+
+	report.HeadSequence = prev.Sequence
+	report.HeadHash = prev.Hash
+	report.Valid = true
+	return report, nil
+}
+
+// checkLink returns the break when entry does not follow prev in a chain,
+// or nil. prev is a zero entry for the first one.
+func checkLink(prev, entry *models.AuditLog) *Break {
+	switch {
+	case entry.Sequence == prev.Sequence:
+		return &Break{Sequence: entry.Sequence, EntryID: entry.ID, Reason: fmt.Sprintf("sequence %d appears more than once", entry.Sequence)}
+	case entry.Sequence != prev.Sequence+1:
+		return &Break{Sequence: prev.Sequence + 1, Reason: fmt.Sprintf("entries %d to %d are missing", prev.Sequence+1, entry.Sequence-1)}
+	case entry.PrevHash != prev.Hash:
+		return &Break{Sequence: entry.Sequence, EntryID: entry.ID, Reason: fmt.Sprintf("previous hash does not match entry %d", prev.Sequence)}
+	case entryHash(entry) != entry.Hash:
+		return &Break{Sequence: entry.Sequence, EntryID: entry.ID, Reason: "hash does not match the entry's contents"}
+	}
+	return nil
+}
+
+// checkCheckpoint returns the break when cp, signed at entry, was not
+// signed with key or states another hash, or nil
+func checkCheckpoint(key ed25519.PublicKey, cp *models.AuditCheckpoint, entry *models.AuditLog) *Break {
+	if !validSignature(key, cp) {
+		return &Break{Sequence: cp.Sequence, EntryID: entry.ID, Reason: fmt.Sprintf("checkpoint %d has an invalid signature", cp.ID)}
+	}
+	if cp.Hash != entry.Hash {
+		return &Break{Sequence: cp.Sequence, EntryID: entry.ID, Reason: fmt.Sprintf("hash differs from checkpoint %d", cp.ID)}
+	}
+	return nil
+}
+
+// VerifyAll verifies the chain of every tenant with audit entries. Entries
+// without a tenant are reported under the empty tenant ID.
+func VerifyAll(ctx context.Context, db *gorm.DB, key ed25519.PublicKey) ([]*Report, error) {
+	var tenants []string
+	// Entries from before tenants were recorded have none
+	if err := db.WithContext(ctx).Raw("SELECT DISTINCT COALESCE(tenant_id, '') FROM audit_logs ORDER BY 1").
+		Scan(&tenants).Error; err != nil {
+		return nil, err
+	}
+	reports := make([]*Report, 0, len(tenants))
+	for _, tenantID := range tenants {
+		report, err := Verify(ctx, db, tenantID, key)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/cyber/backend/internal/metrics"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -36,6 +38,11 @@ func NewRedisClient(addr, password string) (*RedisClient, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	// Trace commands without their arguments, which hold cached data
+	if err := redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false)); err != nil {
+		return nil, fmt.Errorf("failed to instrument Redis: %w", err)
+	}
+
 	return &RedisClient{client: rdb}, nil
 }
 
@@ -60,6 +67,7 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
 	if err != nil {
 		if err == redis.Nil {
 			// Key doesn't exist
+			metrics.CacheRequests.WithLabelValues("miss").Inc()
 			return nil
 		}
 		return fmt.Errorf("failed to get from cache: %w", err)
@@ -74,8 +82,10 @@ func (r *RedisClient) Get(ctx context.Context, key string, dest interface{}) err
 	if time.Now().Unix() > item.Expiration {
 		// Remove expired key
 		r.client.Del(ctx, key)
+		metrics.CacheRequests.WithLabelValues("miss").Inc()
 		return nil
 	}
+	metrics.CacheRequests.WithLabelValues("hit").Inc()
 
 	// Marshal to destination
 	itemData, err := json.Marshal(item.Value)
@@ -131,6 +141,26 @@ func (r *RedisClient) Decrement(ctx context.Context, key string) (int64, error)
 	return r.client.Decr(ctx, key).Result()
 }
 
+// Publish sends message to the subscribers of a pub/sub channel
+func (r *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe listens on pub/sub channels. Close the returned PubSub when done.
+func (r *RedisClient) Subscribe(ctx context.Context, channels ...string) *redis.PubSub {
+	return r.client.Subscribe(ctx, channels...)
+}
+
+// RunScript runs a Lua script, loading it on first use
+func (r *RedisClient) RunScript(ctx context.Context, script *redis.Script, keys []string, args ...interface{}) *redis.Cmd {
+	return script.Run(ctx, r.client, keys, args...)
+}
+
+// Ping checks the connection to Redis
+func (r *RedisClient) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Close closes the Redis connection
 func (r *RedisClient) Close() error {
 	return r.client.Close()
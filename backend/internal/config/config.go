@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/cyber/backend/internal/crypto"
 	"github.com/joho/godotenv"
 )
 
@@ -12,12 +13,79 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	SMTP     SMTPConfig
+	// EncryptionKey encrypts stored secrets; empty uses the default key
+	EncryptionKey string
+	// AuditSigningKey is the base64 Ed25519 seed signing audit checkpoints;
+	// empty derives one from the JWT secret
+	AuditSigningKey string
 }
 
+// DefaultJWTSecret is used when JWT_SECRET is not set. It is public, so
+// production refuses to start with it.
+const DefaultJWTSecret = "your-secret-key"
+
 type ServerConfig struct {
 	Port     string
 	Host     string
 	Env      string
+	// OpenAPIValidation rejects request bodies that do not match the
+	// generated OpenAPI document
+	OpenAPIValidation bool
+	// RequireIfMatch rejects PUT and DELETE on records without If-Match. It
+	// is off until the bundled frontend sends the header; If-Match is
+	// checked whenever a client sends it.
+	RequireIfMatch bool
+	// IdempotencyKeyTTL is how many hours a response is kept for replay
+	IdempotencyKeyTTL int
+	// IdempotencyMaxBody is the largest body, in megabytes, of a request
+	// with an Idempotency-Key. It must fit the largest upload.
+	IdempotencyMaxBody int
+	// WebhookMaxAttempts is how often a webhook delivery is tried before it
+	// becomes a dead letter
+	WebhookMaxAttempts int
+	// WebhookAllowPrivate lets webhook and notification URLs point at
+	// localhost and private addresses, to test against a local receiver.
+	// Production refuses to start with it.
+	WebhookAllowPrivate bool
+	// RateLimiting enforces the per-plan request limits
+	RateLimiting bool
+	// RequestLogSampleRate is the share of successful requests logged, from
+	// 0 to 1. Failed requests are always logged.
+	RequestLogSampleRate float64
+	// MetricsToken is the bearer token required to scrape /metrics; empty
+	// leaves it open
+	MetricsToken string
+	// TracingExporter is where spans go: otlp, stdout or empty for none
+	TracingExporter string
+	// TracingEndpoint is the OTLP collector URL
+	TracingEndpoint string
+	// TracingSampleRate is the share of new traces recorded, from 0 to 1
+	TracingSampleRate float64
+	// ShutdownDrainDelay is how many seconds readiness fails before the
+	// server stops accepting requests, so load balancers notice
+	ShutdownDrainDelay int
+	// ShutdownTimeout is how many seconds in-flight requests and background
+	// workers get to finish
+	ShutdownTimeout int
+	// AuditCheckpointInterval is how many minutes apart the audit chain
+	// heads are signed
+	AuditCheckpointInterval int
+	// RetentionPurgeSchedule is the cron schedule of the purge of data past
+	// its retention period
+	RetentionPurgeSchedule string
+	// JobWorker runs the background job worker in the server. Turn it off
+	// when cmd/worker runs the jobs instead.
+	JobWorker bool
+	// JobConcurrency is how many jobs a worker runs at once
+	JobConcurrency int
+	// ReminderSchedule is the cron schedule of the deadline reminder run.
+	// Tenants' reminder days and quiet hours are checked at each run.
+	ReminderSchedule string
+	// NotificationDigestSchedule is the cron schedule, in server time, of
+	// the daily digest of low-priority notices. The default is 08:00 in
+	// Jakarta on UTC servers.
+	NotificationDigestSchedule string
 }
 
 type DatabaseConfig struct {
@@ -29,6 +97,16 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// SMTPConfig is the mail server notification emails are sent through.
+// Emails are not sent when Host is empty.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
 type JWTConfig struct {
 	SecretKey     string
 	ExpiresIn    int
@@ -46,6 +124,26 @@ func Load() (*Config, error) {
 			Port:     getEnv("SERVER_PORT", "8080"),
 			Host:     getEnv("SERVER_HOST", "localhost"),
 			Env:      getEnv("ENV", "development"),
+			OpenAPIValidation:       getEnv("OPENAPI_VALIDATION", "false") == "true",
+			RequireIfMatch:          getEnv("REQUIRE_IF_MATCH", "false") == "true",
+			IdempotencyKeyTTL:       getEnvAsInt("IDEMPOTENCY_KEY_TTL", 24),
+			IdempotencyMaxBody:      getEnvAsInt("IDEMPOTENCY_MAX_BODY_MB", 32),
+			WebhookMaxAttempts:      getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookAllowPrivate:     getEnv("WEBHOOK_ALLOW_PRIVATE", "false") == "true",
+			RateLimiting:            getEnv("RATE_LIMITING", "true") != "false",
+			RequestLogSampleRate:    getEnvAsFloat("REQUEST_LOG_SAMPLE_RATE", 1),
+			MetricsToken:            getEnv("METRICS_TOKEN", ""),
+			TracingExporter:         getEnv("TRACING_EXPORTER", ""),
+			TracingEndpoint:         getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			TracingSampleRate:       getEnvAsFloat("TRACING_SAMPLE_RATE", 1),
+			ShutdownDrainDelay:      getEnvAsInt("SHUTDOWN_DRAIN_DELAY", 5),
+			ShutdownTimeout:         getEnvAsInt("SHUTDOWN_TIMEOUT", 30),
+			AuditCheckpointInterval: getEnvAsInt("AUDIT_CHECKPOINT_INTERVAL", 60),
+			RetentionPurgeSchedule:  getEnv("RETENTION_PURGE_SCHEDULE", "0 3 * * *"),
+			JobWorker:               getEnv("JOB_WORKER", "true") != "false",
+			JobConcurrency:          getEnvAsInt("JOB_CONCURRENCY", 4),
+			ReminderSchedule:        getEnv("REMINDER_SCHEDULE", "*/15 * * * *"),
+			NotificationDigestSchedule: getEnv("NOTIFICATION_DIGEST_SCHEDULE", "0 1 * * *"),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -56,13 +154,50 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		JWT: JWTConfig{
-			SecretKey:  getEnv("JWT_SECRET", "your-secret-key"),
+			SecretKey:  getEnv("JWT_SECRET", DefaultJWTSecret),
 			ExpiresIn:  getEnvAsInt("JWT_EXPIRES_IN", 24),
 			Issuer:    getEnv("JWT_ISSUER", "komplai"),
 		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvAsInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "Komplai <no-reply@komplai.local>"),
+		},
+		EncryptionKey:   getEnv("ENCRYPTION_KEY", ""),
+		AuditSigningKey: getEnv("AUDIT_SIGNING_KEY", ""),
 	}, nil
 }
 
+// IsProduction reports whether the server runs in production
+func (c *Config) IsProduction() bool {
+	return c.Server.Env == "production" || c.Server.Env == "prod"
+}
+
+// Validate rejects settings the server must not run with. In production the
+// JWT secret and encryption key must be set and differ from the defaults, and
+// the audit signing key must be set, and webhooks may not reach private
+// addresses.
+func (c *Config) Validate() error {
+	if !c.IsProduction() {
+		return nil
+	}
+	if c.Server.WebhookAllowPrivate {
+		return fmt.Errorf("WEBHOOK_ALLOW_PRIVATE is for development and cannot be set in production")
+	}
+	if c.JWT.SecretKey == "" || c.JWT.SecretKey == DefaultJWTSecret {
+		return fmt.Errorf("JWT_SECRET must be set in production")
+	}
+	if c.EncryptionKey == "" || c.EncryptionKey == crypto.DefaultEncryptionKey {
+		return fmt.Errorf("ENCRYPTION_KEY must be set in production")
+	}
+	if c.AuditSigningKey == "" {
+		return fmt.Errorf("AUDIT_SIGNING_KEY must be set in production")
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -76,4 +211,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
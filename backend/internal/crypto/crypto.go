@@ -10,8 +10,12 @@ import (
 	"io"
 )
 
+// DefaultEncryptionKey is used when ENCRYPTION_KEY is not set. It is public,
+// so production refuses to start with it.
+const DefaultEncryptionKey = "your-32-byte-encryption-key-here"
+
 // EncryptionKey should be 32 bytes for AES-256
-var EncryptionKey = []byte("your-32-byte-encryption-key-here")
+var EncryptionKey = []byte(DefaultEncryptionKey)
 
 // Encrypt encrypts plaintext using AES-256-GCM
 func Encrypt(plaintext string) (string, error) {
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/cyber/backend/internal/config"
 	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/search"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -24,6 +26,20 @@ func Init(cfg *config.DatabaseConfig) (*Database, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if err := models.RegisterRevisionCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register revision callbacks: %w", err)
+	}
+
+	// Existing tables need the revision column before anything writes to them
+	if err := (&Database{db}).EnsureRevisionColumns(); err != nil {
+		return nil, fmt.Errorf("failed to add revision columns: %w", err)
+	}
+	if err := (&Database{db}).EnsureTrashMarkers(); err != nil {
+		return nil, fmt.Errorf("failed to normalize soft delete markers: %w", err)
+	}
+	if err := (&Database{db}).EnsureClauseColumns(); err != nil {
+		return nil, fmt.Errorf("failed to add clause columns: %w", err)
+	}
 
 	// Only migrate PUBLIC schema tables on startup
 	if err := migratePublicSchema(db); err != nil {
@@ -40,8 +56,13 @@ func Init(cfg *config.DatabaseConfig) (*Database, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
+	database := &Database{db}
+	if err := database.EnsureSearchIndexes(); err != nil {
+		log.Printf("Warning: failed to create search indexes: %v", err)
+	}
+
 	log.Println("Database connected successfully")
-	return &Database{db}, nil
+	return database, nil
 }
 
 // migratePublicSchema only migrates shared/platform tables
@@ -64,6 +85,36 @@ func migratePublicSchema(db *gorm.DB) error {
 		&models.Payment{},
 		// System logs
 		&models.SystemLog{},
+		// Bulk import history
+		&models.ImportJob{},
+		// Replayable responses for Idempotency-Key
+		&models.IdempotencyKey{},
+		// Single-use tickets opening event streams
+		&models.StreamTicket{},
+		// Outbound webhooks
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		// Domain events awaiting delivery
+		&models.OutboxEvent{},
+		// Version history of versioned records
+		&models.RecordVersion{},
+		// Retention periods and legal holds of the purge job
+		&models.RetentionPolicy{},
+		&models.LegalHold{},
+		// Background jobs, recurring schedules and the scheduler's leadership
+		&models.Job{},
+		&models.JobSchedule{},
+		&models.Lease{},
+		// Deadline reminder rules, quiet hours and the reminders sent
+		&models.ReminderRule{},
+		&models.ReminderSettings{},
+		&models.Reminder{},
+		// Notification inboxes, template overrides and chat channels
+		&models.Notification{},
+		&models.NotificationTemplate{},
+		&models.ChatChannel{},
+		// Structure of regulations that obligations and controls link to
+		&models.RegulationClause{},
 	}
 
 	for _, model := range publicModels {
@@ -75,6 +126,7 @@ func migratePublicSchema(db *gorm.DB) error {
 	// PRIVATE schema - audit/metrics (platform-wide)
 	privateModels := []interface{}{
 		&models.AuditLog{},
+		&models.AuditCheckpoint{},
 		&models.SystemMetric{},
 		&models.APIUsage{},
 		&models.MigrationHistory{},
@@ -85,6 +137,13 @@ func migratePublicSchema(db *gorm.DB) error {
 			return err
 		}
 	}
+	if err := ensureAppendOnly(db, "audit_logs", "audit_checkpoints"); err != nil {
+		return err
+	}
+
+	if err := recordSchemaVersion(db); err != nil {
+		return err
+	}
 
 	log.Println("Public schema migrated successfully")
 
@@ -94,6 +153,69 @@ func migratePublicSchema(db *gorm.DB) error {
 	return nil
 }
 
+// ensureAppendOnly installs triggers rejecting UPDATE, DELETE and TRUNCATE
+// on tables, so records in them cannot be changed through the application's
+// database role
+func ensureAppendOnly(db *gorm.DB, tables ...string) error {
+	if err := db.Exec(`CREATE OR REPLACE FUNCTION reject_append_only_change() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION '% on %.% is not allowed: the table is append-only', TG_OP, TG_TABLE_SCHEMA, TG_TABLE_NAME;
+END
+$$ LANGUAGE plpgsql`).Error; err != nil {
+		return err
+	}
+	for _, table := range tables {
+		for trigger, def := range map[string]string{
+			table + "_append_only":          "BEFORE UPDATE OR DELETE ON %q FOR EACH ROW",
+			table + "_append_only_truncate": "BEFORE TRUNCATE ON %q FOR EACH STATEMENT",
+		} {
+			if err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %q ON %q", trigger, table)).Error; err != nil {
+				return err
+			}
+			stmt := fmt.Sprintf("CREATE TRIGGER %q "+def+" EXECUTE FUNCTION reject_append_only_change()", trigger, table)
+			if err := db.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SchemaVersion is the version of the schema this build migrates to: the
+// latest file in migrations/, whose changes the startup migration includes.
+// Readiness fails while the database reports an older version.
+const SchemaVersion = "023"
+
+// recordSchemaVersion notes in MigrationHistory that the startup migration
+// brought the schema to SchemaVersion
+func recordSchemaVersion(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.MigrationHistory{}).Where("version = ? AND status = ?", SchemaVersion, "success").Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Create(&models.MigrationHistory{
+		Version:     SchemaVersion,
+		Description: "startup migration",
+		ExecutedAt:  time.Now(),
+		Status:      "success",
+	}).Error
+}
+
+// CurrentSchemaVersion is the latest version MigrationHistory records as
+// applied, or empty when there is none
+func (d *Database) CurrentSchemaVersion(ctx context.Context) (string, error) {
+	var versions []string
+	err := d.WithContext(ctx).Model(&models.MigrationHistory{}).Where("status = ?", "success").
+		Order("version DESC").Limit(1).Pluck("version", &versions).Error
+	if err != nil || len(versions) == 0 {
+		return "", err
+	}
+	return versions[0], nil
+}
+
 // seedSampleData adds sample data for platform demo
 func seedSampleData(db *gorm.DB) {
 	// Check if we already have subscriptions
@@ -211,6 +333,11 @@ func (d *Database) CreateTenantSchema(tenantID string) error {
 		}
 	}
 
+	// Full-text search indexes for the new tables
+	if err := search.EnsureIndexes(d.DB); err != nil {
+		return err
+	}
+
 	// Reset search path to public
 	d.Exec("SET search_path TO public")
 
@@ -218,6 +345,142 @@ func (d *Database) CreateTenantSchema(tenantID string) error {
 	return nil
 }
 
+// TenantSchema returns the schema name for a tenant
+func TenantSchema(tenantID string) string {
+	return fmt.Sprintf("tenant_%s", strings.ReplaceAll(tenantID, "-", ""))
+}
+
+// TenantTx runs fn in a transaction whose search_path is pinned to the
+// tenant schema. Unlike GetTenantDB, the setting is guaranteed to apply to
+// every statement because they all share the transaction's connection.
+func (d *Database) TenantTx(tenantID string, fn func(tx *gorm.DB) error) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		if tenantID != "" {
+			if err := tx.Exec(fmt.Sprintf(`SET LOCAL search_path TO "%s", public`, TenantSchema(tenantID))).Error; err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+// EnsureSearchIndexes creates full-text search indexes in the public schema
+// and in every existing tenant schema
+func (d *Database) EnsureSearchIndexes() error {
+	if err := search.EnsureIndexes(d.DB); err != nil {
+		return err
+	}
+
+	var schemas []string
+	if err := d.Raw("SELECT schema_name FROM information_schema.schemata WHERE schema_name LIKE 'tenant_%'").
+		Scan(&schemas).Error; err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		err := d.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf(`SET LOCAL search_path TO "%s"`, schema)).Error; err != nil {
+				return err
+			}
+			return search.EnsureIndexes(tx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureRevisionColumns adds the revision column of BaseModel to tables
+// created before it existed. Tables of models embedding BaseModel are found by
+// their deleted_by column, in the public schema and every tenant schema.
+func (d *Database) EnsureRevisionColumns() error {
+	var tables []struct {
+		TableSchema string
+		TableName   string
+	}
+	err := d.Raw(`SELECT c.table_schema, c.table_name FROM information_schema.columns c
+		WHERE c.column_name = 'deleted_by'
+		AND (c.table_schema = 'public' OR c.table_schema LIKE 'tenant_%')
+		AND NOT EXISTS (SELECT 1 FROM information_schema.columns r
+			WHERE r.table_schema = c.table_schema AND r.table_name = c.table_name AND r.column_name = 'revision')`).
+		Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		stmt := fmt.Sprintf(`ALTER TABLE %q.%q ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT 1`, t.TableSchema, t.TableName)
+		if err := d.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureClauseColumns adds the clause_id column linking obligations and
+// controls to a regulation clause, in the public schema and every tenant
+// schema
+func (d *Database) EnsureClauseColumns() error {
+	var tables []struct {
+		TableSchema string
+		TableName   string
+	}
+	err := d.Raw(`SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_name IN ('obligation_mappings', 'reg_ops_controls')
+		AND (table_schema = 'public' OR table_schema LIKE 'tenant_%')`).
+		Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		table := fmt.Sprintf("%q.%q", t.TableSchema, t.TableName)
+		if err := d.Exec(`ALTER TABLE ` + table + ` ADD COLUMN IF NOT EXISTS clause_id text`).Error; err != nil {
+			return err
+		}
+		index := fmt.Sprintf("%q", "idx_"+t.TableName+"_clause_id")
+		if err := d.Exec(`CREATE INDEX IF NOT EXISTS ` + index + ` ON ` + table + ` (clause_id)`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureTrashMarkers brings the rows of tenant resources soft-deleted before
+// the trash to its markers (see models.TrashUpdates): rows with only
+// deleted_at set are flagged is_deleted, and rows with only is_deleted set get
+// their last update as deletion time. It covers the public schema and every
+// tenant schema.
+func (d *Database) EnsureTrashMarkers() error {
+	names := make([]string, 0, len(models.TenantResources))
+	for _, r := range models.TenantResources {
+		stmt := &gorm.Statement{DB: d.DB}
+		if err := stmt.Parse(r.New()); err != nil {
+			return err
+		}
+		names = append(names, stmt.Schema.Table)
+	}
+
+	var tables []struct {
+		TableSchema string
+		TableName   string
+	}
+	err := d.Raw(`SELECT table_schema, table_name FROM information_schema.tables
+		WHERE table_name IN ? AND (table_schema = 'public' OR table_schema LIKE 'tenant_%')`, names).
+		Scan(&tables).Error
+	if err != nil {
+		return err
+	}
+	for _, t := range tables {
+		table := fmt.Sprintf("%q.%q", t.TableSchema, t.TableName)
+		if err := d.Exec(`UPDATE ` + table + ` SET is_deleted = true WHERE deleted_at IS NOT NULL AND is_deleted IS NOT TRUE`).Error; err != nil {
+			return err
+		}
+		if err := d.Exec(`UPDATE ` + table + ` SET deleted_at = updated_at WHERE is_deleted AND deleted_at IS NULL`).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WithTenant returns a new DB session scoped to tenant schema
 func (d *Database) WithTenant(tenantID string) *gorm.DB {
 	sanitizedID := strings.ReplaceAll(tenantID, "-", "")
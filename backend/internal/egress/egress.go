@@ -0,0 +1,199 @@
+// Package egress sends HTTP requests to URLs tenants configure, such as
+// webhook receivers and chat channels, without letting them reach the
+// server's own network. Destinations are checked when the connection is
+// dialled, after DNS resolution, so a name that resolves to a public address
+// when saved and to a private one when used is still refused.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cyber/backend/internal/tracing"
+)
+
+// ErrBlocked is returned, wrapped, for a destination in a blocked range
+var ErrBlocked = errors.New("destination address is not allowed")
+
+// maxRedirects is the number of redirects a client follows
+const maxRedirects = 5
+
+// blocked lists the ranges outside the public internet: loopback, private,
+// shared (carrier-grade NAT), link-local (including cloud metadata at
+// 169.254.169.254), and reserved or special-purpose ranges
+var blocked = mustPrefixes(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func mustPrefixes(cidrs ...string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, len(cidrs))
+	for i, cidr := range cidrs {
+		prefixes[i] = netip.MustParsePrefix(cidr)
+	}
+	return prefixes
+}
+
+// local lists the loopback and private ranges AllowPrivate opens. Link-local
+// addresses, where cloud metadata lives, stay blocked.
+var local = mustPrefixes(
+	"10.0.0.0/8",
+	"127.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+)
+
+var allowPrivate atomic.Bool
+
+// AllowPrivate lets requests reach loopback and private addresses, and
+// localhost, so webhooks can be tested against a receiver on the developer's
+// machine. It is for development only; config.Validate refuses it in
+// production.
+func AllowPrivate(allow bool) {
+	allowPrivate.Store(allow)
+}
+
+// Allowed reports whether addr is a public address requests may go to
+func Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range blocked {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// permitted reports whether requests may go to addr: a public address, or a
+// local one when AllowPrivate is on
+func permitted(addr netip.Addr) bool {
+	if Allowed(addr) {
+		return true
+	}
+	if !allowPrivate.Load() {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range local {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// control refuses connections to blocked addresses. It runs for every
+// connection, including those made for redirects.
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !permitted(addr) {
+		return fmt.Errorf("%w: %s", ErrBlocked, addr)
+	}
+	return nil
+}
+
+// Client returns an HTTP client that only connects to public addresses and
+// follows at most a few redirects, to http and https URLs only. It ignores
+// proxy settings, since a proxy would dial on its behalf.
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: tracing.Transport(transport),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return checkURL(req.URL)
+		},
+	}
+}
+
+// ValidateURL checks a URL a tenant configures: it must be an absolute http
+// or https URL whose host is not a blocked address, nor a name resolving to
+// one. Names that cannot be resolved now pass; the dial-time check still
+// applies when the URL is used.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return errors.New("url is not a valid URL")
+	}
+	if err := checkURL(u); err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !permitted(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrBlocked, u.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// checkURL checks the scheme and, when the host is an address or localhost,
+// the host of u
+func checkURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if (host == "localhost" || strings.HasSuffix(host, ".localhost")) && !allowPrivate.Load() {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !permitted(addr) {
+		return fmt.Errorf("%w: %s", ErrBlocked, addr.Unmap())
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"1.1.1.1", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:8.8.8.8", true},
+	}
+	for _, tt := range tests {
+		if got := Allowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Allowed(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+		blocked bool
+	}{
+		{"https://8.8.8.8/hook", false, false},
+		{"ftp://8.8.8.8/hook", true, false},
+		{"/relative", true, false},
+		{"http://127.0.0.1:8080/", true, true},
+		{"http://localhost/", true, true},
+		{"http://api.localhost./", true, true},
+		{"http://169.254.169.254/latest/meta-data/", true, true},
+		{"http://[::1]/", true, true},
+		{"http://[::ffff:10.0.0.1]/", true, true},
+	}
+	for _, tt := range tests {
+		err := ValidateURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateURL(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got := errors.Is(err, ErrBlocked); got != tt.blocked {
+			t.Errorf("ValidateURL(%q) blocked = %v, want %v", tt.url, got, tt.blocked)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	resp, err := Client(time.Second).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback server succeeded")
+	}
+	if !errors.Is(err, ErrBlocked) {
+		t.Fatalf("error = %v, want ErrBlocked", err)
+	}
+}
+
+func TestAllowPrivate(t *testing.T) {
+	AllowPrivate(true)
+	defer AllowPrivate(false)
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"http://localhost:9000/", false},
+		{"http://127.0.0.1:9000/", false},
+		{"http://192.168.1.10/hook", false},
+		{"http://[::1]:9000/", false},
+		{"http://169.254.169.254/latest/meta-data/", true},
+		{"http://100.100.100.200/", true},
+		{"http://0.0.0.0/", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateURL(context.Background(), tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateURL(%q) = %v, want error %v", tt.url, err, tt.wantErr)
+		}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("local"))
+	}))
+	defer server.Close()
+	resp, err := Client(time.Second).Get(server.URL)
+	if err != nil {
+		t.Fatalf("request to a loopback server failed: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Outbox statuses
+const (
+	StatusPending   = "pending"
+	StatusProcessed = "processed"
+	StatusFailed    = "failed" // a subscriber kept failing; kept for inspection
+)
+
+const (
+	// How often the bus polls when it is not notified of new events
+	pollInterval = 5 * time.Second
+	// Events claimed per poll
+	batchSize = 50
+	// A claimed event is not picked up again for this long, so a replica that
+	// dies mid-delivery does not lose it
+	claimLease = 2 * time.Minute
+	// Tries per subscriber before the event is marked failed
+	maxAttempts = 10
+	// Processed events are deleted after this long. Events with a Key are
+	// kept so the key keeps deduplicating.
+	retention = 7 * 24 * time.Hour
+)
+
+// Handler reacts to an event. Delivery is at least once, so handlers must
+// tolerate seeing the same event ID again. A returned error retries the
+// event for this handler only.
+type Handler func(ctx context.Context, e Event) error
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus delivers outbox events to subscribers
+type Bus struct {
+	db          *gorm.DB
+	subscribers []subscriber
+	wake        chan struct{}
+}
+
+func NewBus(db *gorm.DB) *Bus {
+	return &Bus{db: db, wake: make(chan struct{}, 1)}
+}
+
+// Subscribe registers handler for every event. name records which
+// subscribers have handled an event, so it must be unique and stable across
+// releases. Subscribe before Run.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.subscribers = append(b.subscribers, subscriber{name: name, handler: handler})
+}
+
+// Notify wakes the bus after a transaction with events has committed, so
+// they are delivered without waiting for the next poll
+func (b *Bus) Notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers events until ctx is cancelled. Several replicas can run it
+// at once; each event is claimed by one of them.
+func (b *Bus) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastPrune := time.Time{}
+	for {
+		for {
+			due, err := b.claim()
+			if err != nil {
+				log.Printf("Failed to claim outbox events: %v", err)
+				break
+			}
+			for i := range due {
+				b.deliver(ctx, &due[i])
+			}
+			if len(due) < batchSize {
+				break
+			}
+		}
+		if time.Since(lastPrune) > time.Hour {
+			b.prune()
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks a batch of due events and pushes their next attempt past the
+// lease, so other replicas skip them
+func (b *Bus) claim() ([]models.OutboxEvent, error) {
+	var due []models.OutboxEvent
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Order("occurred_at").
+			Limit(batchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]string, len(due))
+		for i := range due {
+			ids[i] = due[i].ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return due, err
+}
+
+// deliver passes the event to every subscriber that has not handled it yet
+// and records which ones succeeded
+func (b *Bus) deliver(ctx context.Context, row *models.OutboxEvent) {
+	event := fromRow(row)
+	done := map[string]bool{}
+	for _, name := range row.Completed {
+		done[name] = true
+	}
+
+	completed := append(pq.StringArray{}, row.Completed...)
+	var lastErr error
+	for _, s := range b.subscribers {
+		if done[s.name] {
+			continue
+		}
+		if err := safeCall(ctx, s, event); err != nil {
+			lastErr = fmt.Errorf("%s: %w", s.name, err)
+			log.Printf("Event %s %s failed in %v", event.Type, event.ID, lastErr)
+			continue
+		}
+		completed = append(completed, s.name)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"completed": completed,
+		"attempts":  row.Attempts + 1,
+	}
+	switch {
+	case lastErr == nil:
+		updates["status"] = StatusProcessed
+		updates["processed_at"] = &now
+		updates["last_error"] = ""
+	case row.Attempts+1 >= maxAttempts:
+		updates["status"] = StatusFailed
+		updates["last_error"] = lastErr.Error()
+	default:
+		updates["next_attempt_at"] = now.Add(backoff(row.Attempts + 1))
+		updates["last_error"] = lastErr.Error()
+	}
+	if err := b.db.Model(row).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record outbox event %s: %v", row.ID, err)
+	}
+}
+
+// safeCall runs a subscriber, turning a panic into an error so one bad
+// handler cannot stop the bus
+func safeCall(ctx context.Context, s subscriber, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.handler(ctx, e)
+}
+
+func (b *Bus) prune() {
+	if err := b.db.Where("status = ? AND processed_at < ? AND key IS NULL", StatusProcessed, time.Now().Add(-retention)).
+		Delete(&models.OutboxEvent{}).Error; err != nil {
+		log.Printf("Failed to prune outbox: %v", err)
+	}
+}
+
+// backoff returns the wait before retrying after attempt failures: 10s,
+// doubling up to an hour
+func backoff(attempt int) time.Duration {
+	wait := 10 * time.Second
+	for i := 1; i < attempt && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	if wait > time.Hour {
+		wait = time.Hour
+	}
+	return wait
+}
@@ -0,0 +1,130 @@
+// Package events implements domain events with a transactional outbox.
+// Handlers record an event with the same transaction as the change it
+// describes, so an event exists exactly when the change was committed. The
+// Bus then delivers committed events to the registered subscribers at least
+// once.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event types, named <resource>.<what happened>
+const (
+	RiskCreated            = "risk.created"
+	RiskClosed             = "risk.closed"
+	IncidentSeverityRaised = "incident.severity_raised"
+	IncidentResolved       = "incident.resolved"
+	DSRApproved            = "dsr.approved"
+	DSRRejected            = "dsr.rejected"
+	DSROverdue             = "dsr.overdue"
+	DPIAApproved           = "dpia.approved"
+	VulnerabilityResolved  = "vulnerability.resolved"
+	ContinuityPlanTested   = "continuity_plan.tested"
+	ControlTestRun         = "control_test.run"
+	EvidenceApproved       = "evidence.approved"
+	EvidenceRejected       = "evidence.rejected"
+	AuditReportGenerated   = "audit_report.generated"
+	PolicyPublished        = "policy.published"
+	// Deadline reminders, about a record of ResourceType (see package
+	// reminders)
+	ReminderDue       = "reminder.due"
+	ReminderEscalated = "reminder.escalated"
+)
+
+// Types lists every event type
+var Types = []string{
+	RiskCreated,
+	RiskClosed,
+	IncidentSeverityRaised,
+	IncidentResolved,
+	DSRApproved,
+	DSRRejected,
+	DSROverdue,
+	DPIAApproved,
+	VulnerabilityResolved,
+	ContinuityPlanTested,
+	ControlTestRun,
+	EvidenceApproved,
+	EvidenceRejected,
+	AuditReportGenerated,
+	PolicyPublished,
+	ReminderDue,
+	ReminderEscalated,
+}
+
+// Event is something that happened to a record
+type Event struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	TenantID     string          `json:"tenant_id"`
+	ResourceType string          `json:"resource_type"` // see models.TenantResources
+	ResourceID   string          `json:"resource_id"`
+	ActorID      string          `json:"actor_id,omitempty"` // empty for events raised by the system
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Data         json.RawMessage `json:"data"`
+
+	// Key makes recording idempotent: an event with the key of an event
+	// already in the outbox is dropped. Optional.
+	Key string `json:"-"`
+}
+
+// New builds an event with data as its payload
+func New(eventType, tenantID, resourceType, resourceID, actorID string, data interface{}) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Type:         eventType,
+		TenantID:     tenantID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ActorID:      actorID,
+		OccurredAt:   time.Now(),
+		Data:         raw,
+	}, nil
+}
+
+// Decode unmarshals the payload into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Record writes e to the outbox with tx. Use the transaction that makes the
+// change, so the event is committed or rolled back with it.
+func Record(tx *gorm.DB, e Event) error {
+	row := models.OutboxEvent{
+		TenantID:      e.TenantID,
+		Type:          e.Type,
+		ResourceType:  e.ResourceType,
+		ResourceID:    e.ResourceID,
+		ActorID:       e.ActorID,
+		Payload:       string(e.Data),
+		OccurredAt:    e.OccurredAt,
+		Status:        StatusPending,
+		NextAttemptAt: e.OccurredAt,
+	}
+	if e.Key != "" {
+		row.Key = &e.Key
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+}
+
+func fromRow(row *models.OutboxEvent) Event {
+	return Event{
+		ID:           row.ID,
+		Type:         row.Type,
+		TenantID:     row.TenantID,
+		ResourceType: row.ResourceType,
+		ResourceID:   row.ResourceID,
+		ActorID:      row.ActorID,
+		OccurredAt:   row.OccurredAt,
+		Data:         json.RawMessage(row.Payload),
+	}
+}
@@ -0,0 +1,345 @@
+// Package export streams query results to CSV, XLSX or NDJSON without
+// loading the whole result set into memory.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Supported formats
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+// Supported header languages
+const (
+	LangEnglish    = "en"
+	LangIndonesian = "id"
+)
+
+// Rows between flushes of the response writer
+const flushEvery = 500
+
+// Excel limits
+const (
+	xlsxMaxRows      = 1048576
+	xlsxMaxCellChars = 32767
+)
+
+// hiddenColumns are internal bookkeeping columns that are never exported
+var hiddenColumns = map[string]bool{
+	"tenant_id":  true,
+	"deleted_at": true,
+	"deleted_by": true,
+	"is_deleted": true,
+}
+
+// ContentType returns the MIME type of a format, or "" if it is not supported
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return ""
+}
+
+// Column is an exported column and its header
+type Column struct {
+	Name   string `json:"name"`
+	Header string `json:"header"`
+	field  *schema.Field
+}
+
+// Columns resolves column names against the model schema. An empty list
+// selects every exportable column in declaration order. headers overrides
+// the localized header of individual columns.
+func Columns(sch *schema.Schema, names []string, lang string, headers map[string]string) ([]Column, error) {
+	if len(names) == 0 {
+		for _, f := range sch.Fields {
+			if f.DBName != "" && !hiddenColumns[f.DBName] {
+				names = append(names, f.DBName)
+			}
+		}
+	}
+
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		f := sch.LookUpField(name)
+		if f == nil || f.DBName == "" || hiddenColumns[f.DBName] {
+			return nil, fmt.Errorf("column %q cannot be exported", name)
+		}
+		header := Label(f.DBName, lang)
+		if h, ok := headers[f.DBName]; ok && h != "" {
+			header = h
+		}
+		columns = append(columns, Column{Name: f.DBName, Header: header, field: f})
+	}
+	return columns, nil
+}
+
+// Writer encodes rows in one output format
+type Writer interface {
+	Header(columns []Column) error
+	Row(columns []Column, values []interface{}) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format that writes to w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{out: w, w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &xlsxWriter{out: w, file: f, stream: sw}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+type csvWriter struct {
+	out io.Writer
+	w   *csv.Writer
+}
+
+func (cw *csvWriter) Header(columns []Column) error {
+	// Excel needs the BOM to open UTF-8 CSV files correctly
+	if _, err := io.WriteString(cw.out, "\ufeff"); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.Header
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Row(columns []Column, values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = escapeFormula(formatText(v))
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// escapeFormula stops spreadsheet applications from evaluating cell text
+// that starts like a formula (CSV injection).
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// xlsxWriter uses excelize's stream writer, which spills rows to a temporary
+// file, so memory stays bounded. The workbook is written to out on Close.
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func (xw *xlsxWriter) Header(columns []Column) error {
+	cells := make([]interface{}, len(columns))
+	for i, col := range columns {
+		cells[i] = col.Header
+	}
+	return xw.next(cells)
+}
+
+func (xw *xlsxWriter) Row(columns []Column, values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil:
+			cells[i] = nil
+		case string:
+			if len(v) > xlsxMaxCellChars {
+				v = v[:xlsxMaxCellChars]
+			}
+			cells[i] = v
+		case time.Time:
+			cells[i] = formatText(v)
+		default:
+			cells[i] = v
+		}
+	}
+	return xw.next(cells)
+}
+
+func (xw *xlsxWriter) next(cells []interface{}) error {
+	if xw.row >= xlsxMaxRows {
+		return errors.New("export exceeds the XLSX row limit, use CSV or NDJSON")
+	}
+	xw.row++
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, cells)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.out)
+}
+
+// abort releases the temporary files of an export that failed part way
+func (xw *xlsxWriter) abort() {
+	xw.file.Close()
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Header(columns []Column) error {
+	return nil
+}
+
+func (nw *ndjsonWriter) Row(columns []Column, values []interface{}) error {
+	// json.Marshal sorts map keys, so build the object by hand to keep
+	// the column order
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.Name)
+		val, err := json.Marshal(values[i])
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return nw.enc.Encode(json.RawMessage(b.String()))
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// Stream runs db (already filtered and ordered, with its model set) row by
+// row and writes each row to w. flush is called periodically so the client
+// receives data while the query is still running. It returns the number of
+// rows written. Nothing is written to w if the query itself fails.
+func Stream(db *gorm.DB, columns []Column, w Writer, flush func()) (count int64, err error) {
+	defer func() {
+		if a, ok := w.(interface{ abort() }); ok && err != nil {
+			a.abort()
+		}
+	}()
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+
+	rows, err := db.Select(names).Rows()
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if err := w.Header(columns); err != nil {
+		return 0, err
+	}
+
+	modelType := reflect.Indirect(reflect.ValueOf(db.Statement.Model)).Type()
+	ctx := context.Background()
+	for rows.Next() {
+		dest := reflect.New(modelType)
+		if err := db.ScanRows(rows, dest.Interface()); err != nil {
+			return count, err
+		}
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			values[i] = plain(col.field.ReflectValueOf(ctx, dest.Elem()))
+		}
+		if err := w.Row(columns, values); err != nil {
+			return count, err
+		}
+		count++
+		if flush != nil && count%flushEvery == 0 {
+			flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, w.Close()
+}
+
+// plain dereferences pointers and unwraps gorm types so writers only see
+// nil, strings, numbers, booleans and times.
+func plain(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case gorm.DeletedAt:
+		if !x.Valid {
+			return nil
+		}
+		return x.Time
+	case time.Time:
+		if x.IsZero() {
+			return nil
+		}
+		return x
+	}
+	return v.Interface()
+}
+
+func formatText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
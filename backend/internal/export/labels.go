@@ -0,0 +1,204 @@
+package export
+
+import "strings"
+
+// indonesianLabels translates column names to Indonesian headers. Columns
+// missing from the table fall back to the English header.
+var indonesianLabels = map[string]string{
+	"id":                       "ID",
+	"created_at":               "Dibuat Pada",
+	"updated_at":               "Diperbarui Pada",
+	"affected_assets":          "Aset Terdampak",
+	"affected_data":            "Data Terdampak",
+	"affected_individuals":     "Individu Terdampak",
+	"affected_systems":         "Sistem Terdampak",
+	"ai_model":                 "Model AI",
+	"analysis_type":            "Jenis Analisis",
+	"approval_date":            "Tanggal Persetujuan",
+	"approved_by":              "Disetujui Oleh",
+	"assessment_date":          "Tanggal Penilaian",
+	"assigned_to":              "Ditugaskan Kepada",
+	"audit_id":                 "ID Audit",
+	"audit_type":               "Jenis Audit",
+	"auditor":                  "Auditor",
+	"backup_procedures":        "Prosedur Cadangan",
+	"budget":                   "Anggaran",
+	"business_function":        "Fungsi Bisnis",
+	"charter":                  "Piagam",
+	"collected_by":             "Dikumpulkan Oleh",
+	"committee_name":           "Nama Komite",
+	"completed_date":           "Tanggal Selesai",
+	"compliance_requirements":  "Persyaratan Kepatuhan",
+	"compliance_status":        "Status Kepatuhan",
+	"confidence_score":         "Skor Keyakinan",
+	"contact_email":            "Email Kontak",
+	"contact_person":           "Narahubung",
+	"content":                  "Konten",
+	"control_domain":           "Domain Kontrol",
+	"control_family":           "Kelompok Kontrol",
+	"control_id":               "ID Kontrol",
+	"control_name":             "Nama Kontrol",
+	"control_type":             "Jenis Kontrol",
+	"created_by":               "Dibuat Oleh",
+	"criticality":              "Kekritisan",
+	"cve_id":                   "ID CVE",
+	"cvss_score":               "Skor CVSS",
+	"data_categories":          "Kategori Data",
+	"data_category":            "Kategori Data",
+	"data_processing":          "Pemrosesan Data",
+	"data_shared":              "Data yang Dibagikan",
+	"data_source":              "Sumber Data",
+	"data_subject_email":       "Email Subjek Data",
+	"data_subject_name":        "Nama Subjek Data",
+	"data_subject_type":        "Jenis Subjek Data",
+	"data_subjects":            "Subjek Data",
+	"data_type":                "Jenis Data",
+	"description":              "Deskripsi",
+	"detection_date":           "Tanggal Deteksi",
+	"discovery_date":           "Tanggal Penemuan",
+	"distribution_list":        "Daftar Distribusi",
+	"document_id":              "ID Dokumen",
+	"document_type":            "Jenis Dokumen",
+	"document_url":             "URL Dokumen",
+	"due_date":                 "Tenggat Waktu",
+	"effectiveness":            "Efektivitas",
+	"end_date":                 "Tanggal Berakhir",
+	"evidence":                 "Bukti",
+	"evidence_type":            "Jenis Bukti",
+	"executive_summary":        "Ringkasan Eksekutif",
+	"file_format":              "Format Berkas",
+	"file_path":                "Lokasi Berkas",
+	"file_size":                "Ukuran Berkas",
+	"file_type":                "Jenis Berkas",
+	"findings":                 "Temuan",
+	"follow_up_date":           "Tanggal Tindak Lanjut",
+	"follow_up_required":       "Perlu Tindak Lanjut",
+	"framework":                "Kerangka Kerja",
+	"gap_score":                "Skor Kesenjangan",
+	"governance_type":          "Jenis Tata Kelola",
+	"handler":                  "Penangan",
+	"impact":                   "Dampak",
+	"impact_assessment":        "Penilaian Dampak",
+	"implementation_status":    "Status Implementasi",
+	"improvement_actions":      "Tindakan Perbaikan",
+	"incident_type":            "Jenis Insiden",
+	"is_generated":             "Dihasilkan AI",
+	"jurisdiction":             "Yurisdiksi",
+	"key_points":               "Poin Utama",
+	"last_meeting_date":        "Tanggal Rapat Terakhir",
+	"last_reviewed":            "Terakhir Ditinjau",
+	"last_tested":              "Terakhir Diuji",
+	"lessons_learned":          "Pelajaran yang Dipetik",
+	"likelihood":               "Kemungkinan",
+	"mapping_status":           "Status Pemetaan",
+	"meeting_frequency":        "Frekuensi Rapat",
+	"mitigation":               "Mitigasi",
+	"mitigation_actions":       "Tindakan Mitigasi",
+	"mitigation_measures":      "Langkah Mitigasi",
+	"mitigation_strategy":      "Strategi Mitigasi",
+	"name":                     "Nama",
+	"necessity":                "Kebutuhan",
+	"next_assessment_date":     "Tanggal Penilaian Berikutnya",
+	"next_meeting_date":        "Tanggal Rapat Berikutnya",
+	"next_review":              "Tinjauan Berikutnya",
+	"next_test":                "Pengujian Berikutnya",
+	"notification_authorities": "Notifikasi Otoritas",
+	"notification_date":        "Tanggal Notifikasi",
+	"notification_required":    "Perlu Notifikasi",
+	"notification_subjects":    "Notifikasi Subjek Data",
+	"objectives":               "Tujuan",
+	"obligation_type":          "Jenis Kewajiban",
+	"overall_rating":           "Peringkat Keseluruhan",
+	"oversight_areas":          "Area Pengawasan",
+	"owner":                    "Pemilik",
+	"patch_available":          "Patch Tersedia",
+	"patch_version":            "Versi Patch",
+	"period_end":               "Akhir Periode",
+	"period_start":             "Awal Periode",
+	"policy_type":              "Jenis Kebijakan",
+	"prepared_by":              "Disiapkan Oleh",
+	"priority":                 "Prioritas",
+	"processing_activities":    "Aktivitas Pemrosesan",
+	"processing_activity":      "Aktivitas Pemrosesan",
+	"processing_purpose":       "Tujuan Pemrosesan",
+	"proportionality":          "Proporsionalitas",
+	"recommendations":          "Rekomendasi",
+	"recovery_strategy":        "Strategi Pemulihan",
+	"regulation_id":            "ID Regulasi",
+	"remediation_date":         "Tanggal Remediasi",
+	"remediation_plan":         "Rencana Remediasi",
+	"report_date":              "Tanggal Laporan",
+	"report_name":              "Nama Laporan",
+	"report_type":              "Jenis Laporan",
+	"reported_date":            "Tanggal Pelaporan",
+	"request_date":             "Tanggal Permintaan",
+	"request_type":             "Jenis Permintaan",
+	"residual_risk_level":      "Tingkat Risiko Residual",
+	"residual_risk_score":      "Skor Risiko Residual",
+	"resolution_date":          "Tanggal Penyelesaian",
+	"resources":                "Sumber Daya",
+	"response":                 "Tanggapan",
+	"response_actions":         "Tindakan Respons",
+	"review_date":              "Tanggal Tinjauan",
+	"review_notes":             "Catatan Tinjauan",
+	"reviewed_by":              "Ditinjau Oleh",
+	"reviewer":                 "Peninjau",
+	"risk_assessment":          "Penilaian Risiko",
+	"risk_category":            "Kategori Risiko",
+	"risk_level":               "Tingkat Risiko",
+	"risk_score":               "Skor Risiko",
+	"risk_type":                "Jenis Risiko",
+	"roles_responsibilities":   "Peran dan Tanggung Jawab",
+	"root_cause":               "Akar Masalah",
+	"rpo_hours":                "RPO (Jam)",
+	"rto_hours":                "RTO (Jam)",
+	"scope":                    "Ruang Lingkup",
+	"score":                    "Skor",
+	"security_controls":        "Kontrol Keamanan",
+	"sensitivity_level":        "Tingkat Sensitivitas",
+	"severity":                 "Tingkat Keparahan",
+	"sla_compliance":           "Kepatuhan SLA",
+	"source_url":               "URL Sumber",
+	"start_date":               "Tanggal Mulai",
+	"status":                   "Status",
+	"storage_location":         "Lokasi Penyimpanan",
+	"summary":                  "Ringkasan",
+	"template_type":            "Jenis Templat",
+	"test_date":                "Tanggal Pengujian",
+	"test_findings":            "Temuan Pengujian",
+	"test_procedure":           "Prosedur Pengujian",
+	"test_result":              "Hasil Pengujian",
+	"test_type":                "Jenis Pengujian",
+	"tester":                   "Penguji",
+	"testing_frequency":        "Frekuensi Pengujian",
+	"title":                    "Judul",
+	"type":                     "Jenis",
+	"upload_date":              "Tanggal Unggah",
+	"vendor_name":              "Nama Vendor",
+	"vendor_type":              "Jenis Vendor",
+	"version":                  "Versi",
+}
+
+// englishAcronyms are kept upper case in English headers
+var englishAcronyms = map[string]bool{
+	"id": true, "ai": true, "cve": true, "cvss": true, "url": true,
+	"sla": true, "rto": true, "rpo": true,
+}
+
+// Label returns the header for a column in the given language ("id" or "en")
+func Label(column, lang string) string {
+	if lang == LangIndonesian {
+		if label, ok := indonesianLabels[column]; ok {
+			return label
+		}
+	}
+	words := strings.Split(column, "_")
+	for i, w := range words {
+		if englishAcronyms[w] {
+			words[i] = strings.ToUpper(w)
+		} else if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,272 @@
+// Package importer reads CSV and XLSX spreadsheets and binds their rows to
+// the request structs used by the create handlers, so bulk imports are
+// validated with exactly the same rules as single creates.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheet is a parsed spreadsheet: the header row and the non-blank data rows.
+// RowNumbers holds the spreadsheet row number of each entry in Rows.
+type Sheet struct {
+	Format     string
+	Headers    []string
+	Rows       [][]string
+	RowNumbers []int
+}
+
+// RowError reports a problem with a single cell or row.
+// Row is the 1-based spreadsheet row number, counting the header as row 1.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Read parses a CSV or XLSX file, detected from the file name extension.
+// For XLSX only the first worksheet is read.
+func Read(r io.Reader, filename string) (*Sheet, error) {
+	var sheet *Sheet
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		sheet, err = readCSV(r)
+	case ".xlsx":
+		sheet, err = readXLSX(r)
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, expected .csv or .xlsx", filepath.Ext(filename))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sheet.Headers) == 0 {
+		return nil, errors.New("file has no header row")
+	}
+	return sheet, nil
+}
+
+func readCSV(r io.Reader) (*Sheet, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	return toSheet("csv", records), nil
+}
+
+func readXLSX(r io.Reader) (*Sheet, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no worksheets")
+	}
+	records, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read worksheet: %w", err)
+	}
+	return toSheet("xlsx", records), nil
+}
+
+func toSheet(format string, records [][]string) *Sheet {
+	sheet := &Sheet{Format: format}
+	if len(records) == 0 {
+		return sheet
+	}
+	for _, h := range records[0] {
+		// Strip a UTF-8 BOM left by Excel CSV exports
+		sheet.Headers = append(sheet.Headers, strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	}
+	for i, row := range records[1:] {
+		if !blank(row) {
+			sheet.Rows = append(sheet.Rows, row)
+			sheet.RowNumbers = append(sheet.RowNumbers, i+2)
+		}
+	}
+	return sheet
+}
+
+func blank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize folds a column name so that "Risk Category", "risk_category"
+// and "riskCategory" all match the same field.
+func Normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Fields returns the JSON field names of a request struct, in declaration order
+func Fields(dest interface{}) []string {
+	t := reflect.Indirect(reflect.ValueOf(dest)).Type()
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Columns resolves each header to a JSON field of dest. mapping overrides the
+// automatic match and maps a header to a JSON field name. Headers that match
+// nothing resolve to "" and are ignored.
+func Columns(headers []string, mapping map[string]string, dest interface{}) []string {
+	byName := map[string]string{}
+	for _, name := range Fields(dest) {
+		byName[Normalize(name)] = name
+	}
+
+	columns := make([]string, len(headers))
+	for i, h := range headers {
+		if target, ok := mapping[h]; ok {
+			columns[i] = byName[Normalize(target)]
+			continue
+		}
+		columns[i] = byName[Normalize(h)]
+	}
+	return columns
+}
+
+// Bind copies one row into dest (a pointer to a request struct) and validates
+// it with gin's binding validator, the same one ShouldBindJSON uses.
+func Bind(rowNum int, columns []string, row []string, dest interface{}) []RowError {
+	v := reflect.ValueOf(dest).Elem()
+	t := v.Type()
+	fieldIndex := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		fieldIndex[jsonName(t.Field(i))] = i
+	}
+
+	var errs []RowError
+	for i, col := range columns {
+		if col == "" || i >= len(row) {
+			continue
+		}
+		raw := strings.TrimSpace(row[i])
+		if raw == "" {
+			continue
+		}
+		if err := setField(v.Field(fieldIndex[col]), raw); err != nil {
+			errs = append(errs, RowError{Row: rowNum, Field: col, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if err := binding.Validator.ValidateStruct(dest); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return []RowError{{Row: rowNum, Message: err.Error()}}
+		}
+		for _, fe := range verrs {
+			field := fe.Field()
+			if sf, ok := t.FieldByName(fe.StructField()); ok {
+				field = jsonName(sf)
+			}
+			errs = append(errs, RowError{Row: rowNum, Field: field, Message: ValidationMessage(fe)})
+		}
+	}
+	return errs
+}
+
+func setField(f reflect.Value, raw string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			// Spreadsheets often store integers as "5.0"
+			fl, ferr := strconv.ParseFloat(raw, 64)
+			if ferr != nil || fl != float64(int64(fl)) {
+				return fmt.Errorf("%q is not a valid integer", raw)
+			}
+			n = int64(fl)
+		}
+		f.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", raw)
+		}
+		f.SetFloat(n)
+	case reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "1", "true", "yes", "y", "ya":
+			f.SetBool(true)
+		case "0", "false", "no", "n", "tidak":
+			f.SetBool(false)
+		default:
+			return fmt.Errorf("%q is not a valid boolean", raw)
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Kind())
+	}
+	return nil
+}
+
+// ValidationMessage describes a failed binding rule in words
+func ValidationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "datetime":
+		return "must be a date like " + fe.Param()
+	case "json":
+		return "must be valid JSON"
+	}
+	return "failed " + fe.Tag() + " validation"
+}
+
+func jsonName(f reflect.StructField) string {
+	tag := strings.Split(f.Tag.Get("json"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return f.Name
+	}
+	return tag
+}
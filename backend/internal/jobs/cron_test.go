@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"*/15 * * * *", false},
+		{"0 3 * * *", false},
+		{"0 9-17 * * 1-5", false},
+		{"0 0 1,15 * *", false},
+		{"5 4 * * 7", false},
+		{"0 0 29 2 *", false},
+		{"@hourly", false},
+		{"@daily", false},
+		{"@weekly", false},
+		{"@monthly", false},
+		{"@yearly", false},
+		{"  0 1 * * *  ", false},
+		{"", true},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * 32 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"1-x * * * *", true},
+		{"@every 5m", true},
+		{"0 0 30 2 *", true},
+		{"0 0 31 4,6,9,11 *", true},
+	}
+	for _, tt := range tests {
+		_, err := ParseCron(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCron(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", utc(2024, 1, 1, 10, 0), utc(2024, 1, 1, 10, 1)},
+		{"* * * * *", time.Date(2024, 1, 1, 10, 0, 59, 999, time.UTC), utc(2024, 1, 1, 10, 1)},
+		{"*/15 * * * *", utc(2024, 1, 1, 10, 7), utc(2024, 1, 1, 10, 15)},
+		{"*/15 * * * *", utc(2024, 1, 1, 10, 45), utc(2024, 1, 1, 11, 0)},
+		{"0 3 * * *", utc(2024, 1, 1, 3, 0), utc(2024, 1, 2, 3, 0)},
+		{"0 3 * * *", utc(2024, 12, 31, 4, 0), utc(2025, 1, 1, 3, 0)},
+		{"30 9 * * 1-5", utc(2024, 6, 7, 10, 0), utc(2024, 6, 10, 9, 30)}, // Friday to Monday
+		{"0 0 * * 7", utc(2024, 6, 3, 0, 0), utc(2024, 6, 9, 0, 0)},       // 7 is Sunday
+		{"0 0 31 * *", utc(2024, 4, 1, 0, 0), utc(2024, 5, 31, 0, 0)},
+		{"0 0 29 2 *", utc(2024, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"@monthly", utc(2024, 1, 15, 12, 0), utc(2024, 2, 1, 0, 0)},
+		{"@yearly", utc(2024, 1, 1, 0, 0), utc(2025, 1, 1, 0, 0)},
+		// Day of month or day of week when both are restricted
+		{"0 0 13 * 5", utc(2024, 9, 1, 0, 0), utc(2024, 9, 6, 0, 0)},
+		{"0 0 13 * 5", utc(2024, 9, 12, 0, 0), utc(2024, 9, 13, 0, 0)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	c, err := ParseCron("0 8 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 1, 9, 0, 0, 0, jakarta)
+	want := time.Date(2024, 1, 2, 8, 0, 0, 0, jakarta)
+	if got := c.Next(from); !got.Equal(want) || got.Location() != jakarta {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
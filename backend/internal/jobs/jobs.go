@@ -0,0 +1,402 @@
+// Package jobs runs background work from a queue in Postgres. Jobs are
+// enqueued with the transaction of the change that asks for them, claimed by
+// one worker at a time with SELECT ... FOR UPDATE SKIP LOCKED, and retried
+// with backoff until they succeed or run out of attempts. Any number of
+// replicas, servers or cmd/worker, can run the queue at once.
+//
+// Recurring jobs follow a cron schedule. The replica holding the scheduler
+// lease enqueues them, so each run is enqueued once however many replicas
+// there are.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed" // out of attempts; kept until retried or pruned
+	StatusCancelled = "cancelled"
+)
+
+const (
+	// How often idle workers poll when they are not notified of new jobs
+	pollInterval = 2 * time.Second
+	// A running job's claim lasts this long and is renewed while it runs.
+	// A job whose claim lapsed, because its worker died, is claimed again.
+	claimLease = time.Minute
+	// Tries of a job unless its kind sets MaxAttempts
+	DefaultMaxAttempts = 5
+	// Succeeded and cancelled jobs are deleted after this long, failed ones
+	// after failedRetention
+	retention       = 7 * 24 * time.Hour
+	failedRetention = 30 * 24 * time.Hour
+)
+
+// ErrNotRetryable and ErrNotCancellable are returned by Retry and Cancel
+// for jobs in a status that does not allow it
+var (
+	ErrNotRetryable   = errors.New("only failed or cancelled jobs can be retried")
+	ErrNotCancellable = errors.New("only pending or running jobs can be cancelled")
+)
+
+// Handler runs a job. A returned error retries the job, unless it is
+// Permanent. Runs are at least once, so handlers must tolerate running the
+// same job again. ctx is cancelled when the job is cancelled or the worker
+// stops, and carries the job's creator as the audit actor.
+type Handler func(ctx context.Context, job *models.Job) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying: the job fails at once
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Kind is a job type whose payload is a T
+type Kind[T any] struct {
+	Name string
+	// Tries before the job fails; DefaultMaxAttempts when zero
+	MaxAttempts int
+}
+
+func (k Kind[T]) maxAttempts() int {
+	if k.MaxAttempts > 0 {
+		return k.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// Handle registers fn to run jobs of the kind. Register before Run.
+func (k Kind[T]) Handle(q *Queue, fn func(ctx context.Context, job *models.Job, payload T) error) {
+	q.handlers[k.Name] = func(ctx context.Context, job *models.Job) error {
+		var payload T
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return Permanent(fmt.Errorf("invalid payload: %w", err))
+		}
+		return fn(ctx, job, payload)
+	}
+}
+
+// Enqueue adds a job of the kind for the tenant, empty for platform jobs,
+// to run as soon as a worker is free. Use the transaction of the change
+// that asks for the job, so the job is committed or rolled back with it.
+// The actor of tx's context is recorded as the job's creator.
+func (k Kind[T]) Enqueue(tx *gorm.DB, tenantID string, payload T) (*models.Job, error) {
+	return k.EnqueueAt(tx, tenantID, payload, time.Now())
+}
+
+// EnqueueAt is Enqueue for a job that runs from runAt
+func (k Kind[T]) EnqueueAt(tx *gorm.DB, tenantID string, payload T, runAt time.Time) (*models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &models.Job{
+		TenantID:    tenantID,
+		Type:        k.Name,
+		Payload:     string(raw),
+		Status:      StatusPending,
+		RunAt:       runAt,
+		MaxAttempts: k.maxAttempts(),
+		CreatedBy:   audit.ActorFrom(tx.Statement.Context).UserID,
+	}
+	if err := tx.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Queue runs jobs with the registered handlers
+type Queue struct {
+	db        *gorm.DB
+	worker    string
+	handlers  map[string]Handler
+	schedules []schedule
+	wake      chan struct{}
+	// Jobs run at once by Run
+	Concurrency int
+}
+
+func NewQueue(db *gorm.DB) *Queue {
+	host, _ := os.Hostname()
+	return &Queue{
+		db:          db,
+		worker:      fmt.Sprintf("%s-%d", host, os.Getpid()),
+		handlers:    map[string]Handler{},
+		wake:        make(chan struct{}, 1),
+		Concurrency: 4,
+	}
+}
+
+// Notify wakes an idle worker after a transaction with jobs has committed,
+// so they start without waiting for the next poll. Only this replica's
+// workers are woken; the others find the jobs when they poll.
+func (q *Queue) Notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs jobs and enqueues the recurring ones until ctx is cancelled.
+// Jobs running when ctx is cancelled are released to be run again.
+func (q *Queue) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for i := 0; i < q.Concurrency; i++ {
+		worker := fmt.Sprintf("%s/%d", q.worker, i)
+		go func() {
+			defer func() { done <- struct{}{} }()
+			q.work(ctx, worker)
+		}()
+	}
+	go func() {
+		defer func() { done <- struct{}{} }()
+		q.schedule(ctx)
+	}()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for running := q.Concurrency + 1; running > 0; {
+		select {
+		case <-done:
+			running--
+		case <-ticker.C:
+			q.prune()
+		}
+	}
+}
+
+// work runs one job after another as worker, waiting for more when none is
+// due
+func (q *Queue) work(ctx context.Context, worker string) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		job, err := q.claim(worker)
+		if err != nil {
+			log.Printf("Failed to claim a job: %v", err)
+		}
+		if job != nil {
+			q.execute(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// types returns the job types this queue has handlers for
+func (q *Queue) types() []string {
+	types := make([]string, 0, len(q.handlers))
+	for name := range q.handlers {
+		types = append(types, name)
+	}
+	return types
+}
+
+// claim locks the next due job, or one whose worker's claim lapsed, and
+// marks it running under worker's claim. It returns nil when no job is due.
+func (q *Queue) claim(worker string) (*models.Job, error) {
+	if len(q.handlers) == 0 {
+		return nil, nil
+	}
+	var job models.Job
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("type IN ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))",
+				q.types(), StatusPending, now, StatusRunning, now).
+			Order("run_at").
+			Limit(1).
+			Find(&job)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		lockedUntil := now.Add(claimLease)
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       StatusRunning,
+			"attempts":     job.Attempts + 1,
+			"locked_by":    worker,
+			"locked_until": &lockedUntil,
+			"started_at":   &now,
+		}).Error
+	})
+	if err != nil || job.ID == "" {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// claimed scopes an update to the job while the claim it was run under
+// holds, so a cancelled or reclaimed job is left alone
+func (q *Queue) claimed(job *models.Job) *gorm.DB {
+	return q.db.Model(&models.Job{}).Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy)
+}
+
+// execute runs a claimed job, renewing the claim while it runs, and
+// records the outcome
+func (q *Queue) execute(ctx context.Context, job *models.Job) {
+	runCtx, cancel := context.WithCancel(audit.WithActor(ctx, audit.Actor{
+		UserID:    job.CreatedBy,
+		TenantID:  job.TenantID,
+		RequestID: "job:" + job.ID,
+	}))
+	defer cancel()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(claimLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			result := q.claimed(job).Update("locked_until", time.Now().Add(claimLease))
+			if result.Error == nil && result.RowsAffected == 0 {
+				// Cancelled, or the claim lapsed and another worker took it
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err := q.run(runCtx, job)
+	cancel()
+	<-renewed
+	q.finish(ctx, job, err)
+}
+
+// run calls the job's handler, turning a panic into an error so one bad
+// job cannot stop the worker
+func (q *Queue) run(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return q.handlers[job.Type](ctx, job)
+}
+
+// finish records the outcome of a run: success, a retry after backoff, or
+// failure once the job is out of attempts. A job interrupted by the worker
+// stopping is released without counting the attempt.
+func (q *Queue) finish(ctx context.Context, job *models.Job, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	var permanent permanentError
+	switch {
+	case err == nil:
+		updates["status"] = StatusSucceeded
+		updates["finished_at"] = &now
+		updates["last_error"] = ""
+	case ctx.Err() != nil:
+		updates["status"] = StatusPending
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		updates["status"] = StatusFailed
+		updates["finished_at"] = &now
+		updates["last_error"] = err.Error()
+	default:
+		updates["status"] = StatusPending
+		updates["run_at"] = now.Add(backoff(job.Attempts))
+		updates["last_error"] = err.Error()
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Job %s %s failed (attempt %d of %d): %v", job.Type, job.ID, job.Attempts, job.MaxAttempts, err)
+	}
+	if err := q.claimed(job).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record job %s: %v", job.ID, err)
+	}
+}
+
+func (q *Queue) prune() {
+	now := time.Now()
+	if err := q.db.Where("(status IN ? AND finished_at < ?) OR (status = ? AND finished_at < ?)",
+		[]string{StatusSucceeded, StatusCancelled}, now.Add(-retention), StatusFailed, now.Add(-failedRetention)).
+		Delete(&models.Job{}).Error; err != nil {
+		log.Printf("Failed to prune jobs: %v", err)
+	}
+}
+
+// backoff returns the wait before retrying after attempt failures: 30s,
+// doubling up to six hours
+func backoff(attempt int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempt && wait < 6*time.Hour; i++ {
+		wait *= 2
+	}
+	if wait > 6*time.Hour {
+		wait = 6 * time.Hour
+	}
+	return wait
+}
+
+// Retry queues a failed or cancelled job to run again now, with its
+// attempts reset
+func Retry(tx *gorm.DB, job *models.Job) error {
+	result := tx.Model(job).Where("status IN ?", []string{StatusFailed, StatusCancelled}).Updates(map[string]interface{}{
+		"status":      StatusPending,
+		"attempts":    0,
+		"run_at":      time.Now(),
+		"finished_at": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotRetryable
+	}
+	return nil
+}
+
+// Cancel stops a pending or running job. A running job's context is
+// cancelled when its worker next renews the claim.
+func Cancel(tx *gorm.DB, job *models.Job) error {
+	now := time.Now()
+	result := tx.Model(job).Where("status IN ?", []string{StatusPending, StatusRunning}).Updates(map[string]interface{}{
+		"status":       StatusCancelled,
+		"finished_at":  &now,
+		"locked_by":    "",
+		"locked_until": nil,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotCancellable
+	}
+	return nil
+}
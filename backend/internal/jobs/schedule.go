@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Lease held by the replica that enqueues recurring jobs
+	schedulerLease = "jobs:scheduler"
+	// How long the scheduler lease lasts unless renewed
+	leaseTTL = time.Minute
+	// How often the leader enqueues due recurring jobs and renews its lease,
+	// and other replicas try to take the lease over
+	scheduleInterval = 15 * time.Second
+)
+
+type schedule struct {
+	name        string
+	spec        string
+	cron        *Cron
+	payload     string
+	maxAttempts int
+}
+
+// Schedule runs a job of the kind with payload on the cron spec, as a
+// platform job. Runs missed while no replica was up are made up by a single
+// run. Every replica running the queue must register the same schedules.
+// Register before Run.
+func (k Kind[T]) Schedule(q *Queue, spec string, payload T) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	q.schedules = append(q.schedules, schedule{
+		name:        k.Name,
+		spec:        spec,
+		cron:        cron,
+		payload:     string(raw),
+		maxAttempts: k.maxAttempts(),
+	})
+	return nil
+}
+
+// schedule enqueues due recurring jobs while this replica holds the
+// scheduler lease, until ctx is cancelled
+func (q *Queue) schedule(ctx context.Context) {
+	if len(q.schedules) == 0 {
+		return
+	}
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	leader := false
+	for {
+		held, err := q.acquireLease(schedulerLease)
+		if err != nil {
+			log.Printf("Failed to acquire the job scheduler lease: %v", err)
+		}
+		if held && !leader {
+			log.Printf("Job scheduler leadership taken by %s", q.worker)
+		} else if !held && leader {
+			log.Printf("Job scheduler leadership lost by %s", q.worker)
+		}
+		leader = held
+		if leader {
+			for _, s := range q.schedules {
+				if err := q.enqueueDue(s); err != nil {
+					log.Printf("Failed to enqueue recurring job %s: %v", s.name, err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if leader {
+				q.releaseLease(schedulerLease)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquireLease takes the named lease, or renews it when this replica holds
+// it, and reports whether this replica holds it. Expiry is measured on the
+// database clock, so replicas' clocks need not agree.
+func (q *Queue) acquireLease(name string) (bool, error) {
+	result := q.db.Exec(`INSERT INTO leases (name, holder, expires_at) VALUES (?, ?, NOW() + ?::interval)
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < NOW()`,
+		name, q.worker, fmt.Sprintf("%d seconds", int(leaseTTL.Seconds())))
+	return result.RowsAffected == 1, result.Error
+}
+
+// releaseLease gives the named lease up, so another replica takes over
+// without waiting for it to expire
+func (q *Queue) releaseLease(name string) {
+	if err := q.db.Where("name = ? AND holder = ?", name, q.worker).Delete(&models.Lease{}).Error; err != nil {
+		log.Printf("Failed to release lease %s: %v", name, err)
+	}
+}
+
+// enqueueDue enqueues the recurring job when its next run is due and moves
+// the next run on. The run's time keys the job, so a run is enqueued once
+// even when two replicas briefly both believe they lead.
+func (q *Queue) enqueueDue(s schedule) error {
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var state models.JobSchedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", s.name).First(&state).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && state.Spec != s.spec) {
+			// New or changed schedule: the first run is the next one
+			state = models.JobSchedule{Name: s.name, Spec: s.spec, JobType: s.name, NextRunAt: s.cron.Next(now),
+				LastRunAt: state.LastRunAt, LastJobID: state.LastJobID}
+			return tx.Save(&state).Error
+		}
+		if err != nil || state.NextRunAt.After(now) {
+			return err
+		}
+
+		key := fmt.Sprintf("%s@%s", s.name, state.NextRunAt.UTC().Format(time.RFC3339))
+		job := models.Job{
+			Key:         &key,
+			Type:        s.name,
+			Payload:     s.payload,
+			Status:      StatusPending,
+			RunAt:       now,
+			MaxAttempts: s.maxAttempts,
+			Schedule:    s.name,
+			CreatedBy:   audit.SystemActor,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&job).Error; err != nil {
+			return err
+		}
+		lastRun := state.NextRunAt
+		return tx.Model(&state).Updates(map[string]interface{}{
+			"next_run_at": s.cron.Next(now),
+			"last_run_at": &lastRun,
+			"last_job_id": job.ID,
+		}).Error
+	})
+}
@@ -0,0 +1,67 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396) on decoded JSON
+// values, and the field-level diff recorded in the audit log.
+package mergepatch
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ContentType is the media type of a merge patch document
+const ContentType = "application/merge-patch+json"
+
+// Merge applies patch to target as described in RFC 7396: object members
+// are merged recursively, null removes a member and any other value
+// replaces the target. target is not modified.
+func Merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	out := make(map[string]interface{}, len(t))
+	for k, v := range t {
+		out[k] = v
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		out[k] = Merge(out[k], v)
+	}
+	return out
+}
+
+// Diff returns the members of before and after whose values differ, as the
+// old and new values. Members missing on one side are reported as null.
+func Diff(before, after map[string]interface{}) (oldValues, newValues map[string]interface{}) {
+	oldValues = map[string]interface{}{}
+	newValues = map[string]interface{}{}
+	for _, k := range keys(before, after) {
+		if !reflect.DeepEqual(before[k], after[k]) {
+			oldValues[k] = before[k]
+			newValues[k] = after[k]
+		}
+	}
+	return oldValues, newValues
+}
+
+func keys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				out = append(out, k)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,78 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+// The examples of RFC 7396 appendix A
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got := Merge(decode(t, tt.target), decode(t, tt.patch))
+		if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+			t.Errorf("Merge(%s, %s) = %v, want %v", tt.target, tt.patch, got, want)
+		}
+	}
+}
+
+func TestMergeLeavesTargetUnchanged(t *testing.T) {
+	target := decode(t, `{"a":{"b":"c"},"d":"e"}`)
+	Merge(target, decode(t, `{"a":{"b":null},"d":null}`))
+	if want := decode(t, `{"a":{"b":"c"},"d":"e"}`); !reflect.DeepEqual(target, want) {
+		t.Errorf("target changed to %v", target)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name             string
+		before, after    string
+		wantOld, wantNew string
+	}{
+		{"equal", `{"a":1,"b":"x"}`, `{"a":1,"b":"x"}`, `{}`, `{}`},
+		{"changed", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`, `{"a":1}`, `{"a":2}`},
+		{"added", `{"a":1}`, `{"a":1,"b":"x"}`, `{"b":null}`, `{"b":"x"}`},
+		{"removed", `{"a":1,"b":"x"}`, `{"a":1}`, `{"b":"x"}`, `{"b":null}`},
+		{"nested", `{"a":{"b":[1,2]}}`, `{"a":{"b":[1,3]}}`, `{"a":{"b":[1,2]}}`, `{"a":{"b":[1,3]}}`},
+	}
+	for _, tt := range tests {
+		before := decode(t, tt.before).(map[string]interface{})
+		after := decode(t, tt.after).(map[string]interface{})
+		oldValues, newValues := Diff(before, after)
+		if want := decode(t, tt.wantOld); !reflect.DeepEqual(oldValues, want) {
+			t.Errorf("%s: old values = %v, want %v", tt.name, oldValues, want)
+		}
+		if want := decode(t, tt.wantNew); !reflect.DeepEqual(newValues, want) {
+			t.Errorf("%s: new values = %v, want %v", tt.name, newValues, want)
+		}
+	}
+}
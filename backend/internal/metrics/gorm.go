@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// registrar is a position in a GORM callback chain
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// RegisterGORM times every statement run through db
+func RegisterGORM(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation     string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("*"), cb.Create().After("*")},
+		{"query", cb.Query().Before("*"), cb.Query().After("*")},
+		{"update", cb.Update().Before("*"), cb.Update().After("*")},
+		{"delete", cb.Delete().Before("*"), cb.Delete().After("*")},
+		{"row", cb.Row().Before("*"), cb.Row().After("*")},
+		{"raw", cb.Raw().Before("*"), cb.Raw().After("*")},
+	}
+	for _, p := range processors {
+		if err := p.before.Register("metrics:before_"+p.operation, startTimer); err != nil {
+			return err
+		}
+		if err := p.after.Register("metrics:after_"+p.operation, observe(p.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		DBQueryDuration.WithLabelValues(operation, table).Observe(time.Since(v.(time.Time)).Seconds())
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+// Business KPI names, used as the SystemMetric.MetricName of snapshots
+const (
+	KPIOpenCriticalRisks   = "open_critical_risks"
+	KPIOverdueDSRs         = "overdue_dsrs"
+	KPIFailingControlTests = "failing_control_tests"
+)
+
+// KPINames lists the business KPIs
+var KPINames = []string{KPIOpenCriticalRisks, KPIOverdueDSRs, KPIFailingControlTests}
+
+var kpiGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "kpi",
+	Help:      "Business KPIs per tenant: open_critical_risks, overdue_dsrs, failing_control_tests.",
+}, []string{"kpi", "tenant_id"})
+
+// How often the KPI gauges are recomputed
+const kpiInterval = time.Minute
+
+// SnapshotJob stores the KPIs as SystemMetric snapshots. It runs hourly on
+// the job queue, so one snapshot is taken however many replicas there are.
+var SnapshotJob = jobs.Kind[struct{}]{Name: "metrics.kpi_snapshot", MaxAttempts: 3}
+
+// snapshotSchedule is the cron schedule of SnapshotJob
+const snapshotSchedule = "@hourly"
+
+// KPICollector computes the business KPIs of every tenant for the gauges
+// and stores hourly snapshots for the in-app trend charts
+type KPICollector struct {
+	db *gorm.DB
+}
+
+func NewKPICollector(db *gorm.DB) *KPICollector {
+	return &KPICollector{db: db}
+}
+
+// Run updates the gauges until ctx is cancelled. Every replica keeps its
+// own gauges; snapshots are left to SnapshotJob.
+func (k *KPICollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(kpiInterval)
+	defer ticker.Stop()
+	for {
+		values, err := k.compute()
+		if err != nil {
+			log.Printf("Failed to compute KPIs: %v", err)
+		} else {
+			k.publish(values)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Schedule registers SnapshotJob with q and runs it hourly. Register it
+// with the queue of every process running jobs.
+func (k *KPICollector) Schedule(q *jobs.Queue) error {
+	SnapshotJob.Handle(q, func(ctx context.Context, _ *models.Job, _ struct{}) error {
+		values, err := (&KPICollector{db: k.db.WithContext(ctx)}).compute()
+		if err != nil {
+			return err
+		}
+		return k.snapshot(ctx, values)
+	})
+	return SnapshotJob.Schedule(q, snapshotSchedule, struct{}{})
+}
+
+// compute returns the KPIs by name and tenant. Every active tenant is
+// present, with zeros where nothing matches.
+func (k *KPICollector) compute() (map[string]map[string]float64, error) {
+	var tenantIDs []string
+	if err := k.db.Model(&models.Tenant{}).Where("is_deleted = ? AND status = ?", false, "active").Pluck("id", &tenantIDs).Error; err != nil {
+		return nil, err
+	}
+	values := map[string]map[string]float64{}
+	for _, name := range KPINames {
+		values[name] = make(map[string]float64, len(tenantIDs))
+		for _, id := range tenantIDs {
+			values[name][id] = 0
+		}
+	}
+
+	now := time.Now()
+	queries := map[string]*gorm.DB{
+		KPIOpenCriticalRisks: k.db.Model(&models.RiskRegister{}).
+			Where("is_deleted = ? AND risk_level = ? AND status <> ?", false, "critical", "closed"),
+		KPIOverdueDSRs: k.db.Model(&models.DSRRequest{}).
+			Where("is_deleted = ? AND due_date < ? AND status NOT IN ?", false, now, models.ClosedDSRStatuses),
+		KPIFailingControlTests: k.db.Model(&models.ControlTest{}).
+			Where("is_deleted = ? AND test_result = ?", false, "fail"),
+	}
+	for name, q := range queries {
+		var rows []struct {
+			TenantID string
+			Count    float64
+		}
+		if err := q.Select("tenant_id, COUNT(*) AS count").Group("tenant_id").Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			values[name][row.TenantID] = row.Count
+		}
+	}
+	return values, nil
+}
+
+func (k *KPICollector) publish(values map[string]map[string]float64) {
+	kpiGauge.Reset()
+	for name, byTenant := range values {
+		for tenantID, v := range byTenant {
+			kpiGauge.WithLabelValues(name, tenantID).Set(v)
+		}
+	}
+}
+
+// snapshot stores values in one transaction, so a retried job does not
+// store part of them twice
+func (k *KPICollector) snapshot(ctx context.Context, values map[string]map[string]float64) error {
+	now := time.Now()
+	var rows []models.SystemMetric
+	for name, byTenant := range values {
+		for tenantID, v := range byTenant {
+			rows = append(rows, models.SystemMetric{MetricName: name, Value: v, Timestamp: now, TenantID: tenantID})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return k.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&rows, 500).Error
+	})
+}
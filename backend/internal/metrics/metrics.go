@@ -0,0 +1,77 @@
+// Package metrics defines the Prometheus metrics of the API, served on
+// /metrics
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "komplai"
+
+// HTTP metrics, labelled by route template rather than path so IDs do not
+// create new series
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+)
+
+// Database metrics
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "GORM statement latency by operation and table.",
+	Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+// CacheRequests counts Redis cache lookups by result: hit or miss
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_requests_total",
+	Help:      "Redis cache lookups by result.",
+}, []string{"result"})
+
+// AI provider metrics
+var (
+	AIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI provider latency by provider, model and outcome.",
+		Buckets:   []float64{.25, .5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"provider", "model", "outcome"})
+
+	AITokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_tokens_total",
+		Help:      "AI tokens by provider, model and kind: prompt or output.",
+	}, []string{"provider", "model", "kind"})
+)
+
+// ObserveAIRequest records one AI provider call
+func ObserveAIRequest(provider, model string, elapsed time.Duration, promptTokens, outputTokens int, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	AIRequestDuration.WithLabelValues(provider, model, outcome).Observe(elapsed.Seconds())
+	AITokens.WithLabelValues(provider, model, "prompt").Add(float64(promptTokens))
+	AITokens.WithLabelValues(provider, model, "output").Add(float64(outputTokens))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/cyber/backend/internal/audit"
+	"github.com/gin-gonic/gin"
+)
+
+// Audit puts who is making the request into its context, for the audit
+// callbacks to record with the changes its statements make. It runs after
+// AuthMiddleware; before it, changes are recorded as made by the system.
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			userID = audit.SystemActor
+		}
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			UserID:    userID,
+			TenantID:  c.GetString("tenant_id"),
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("request_id"),
+		}))
+		c.Next()
+	}
+}
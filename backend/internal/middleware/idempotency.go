@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyHeader lets clients retry a POST without repeating its effect
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Longest accepted Idempotency-Key
+const maxIdempotencyKey = 255
+
+// Response headers stored with the body and sent again on replay
+var replayHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Request bodies up to this size are kept in memory for the handler; larger
+// ones, such as file uploads, are spooled to a temporary file
+const memoryBodyLimit = 1 << 20
+
+// Idempotency makes POST requests that carry an Idempotency-Key safe to
+// retry. The first request with a key runs normally and its response is
+// stored for ttl. A retry with the same key and the same method, path and
+// body gets the stored response with Idempotent-Replayed: true. The same key
+// with a different request is rejected with 422, and a retry that arrives
+// while the first request is still running with 409.
+//
+// Keys are scoped to the tenant and user. Server errors are not stored, so a
+// request that failed with a 5xx can be retried with the same key. The body
+// is hashed as it is read, before any handler limits it, so keyed requests
+// larger than maxBody bytes are rejected with 413.
+func Idempotency(db *gorm.DB, ttl time.Duration, maxBody int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		sum := newFingerprint(c.Request)
+		body, err := spoolBody(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody), sum)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		defer body.Close()
+		c.Request.Body = body
+
+		record := models.IdempotencyKey{
+			TenantID:    c.GetString("tenant_id"),
+			UserID:      c.GetString("user_id"),
+			Key:         key,
+			Fingerprint: hex.EncodeToString(sum.Sum(nil)),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		scope := db.Where("tenant_id = ? AND user_id = ? AND key = ?", record.TenantID, record.UserID, key).Session(&gorm.Session{})
+
+		// Claim the key. An expired claim is dropped first so the key can be reused.
+		scope.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			log.Printf("Failed to store idempotency key: %v", result.Error)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			var existing models.IdempotencyKey
+			if err := scope.First(&existing).Error; err != nil {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+				return
+			}
+			replay(c, &existing, record.Fingerprint)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		stored := false
+		defer func() {
+			// Release the key when the handler failed, so the client can retry
+			if !stored {
+				db.Delete(&models.IdempotencyKey{}, "id = ?", record.ID)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		headers := map[string]string{}
+		for _, name := range replayHeaders {
+			if v := c.Writer.Header().Get(name); v != "" {
+				headers[name] = v
+			}
+		}
+		headersJSON, _ := json.Marshal(headers)
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"status_code": status,
+			"headers":     string(headersJSON),
+			"body":        writer.body.Bytes(),
+		}).Error; err != nil {
+			log.Printf("Failed to store response for idempotency key: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replay answers a retried request from the stored record
+func replay(c *gin.Context, existing *models.IdempotencyKey, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if existing.StatusCode == 0 {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+		return
+	}
+
+	var headers map[string]string
+	json.Unmarshal([]byte(existing.Headers), &headers)
+	for name, v := range headers {
+		c.Header(name, v)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Status(existing.StatusCode)
+	c.Writer.Write(existing.Body)
+	c.Abort()
+}
+
+// newFingerprint starts the hash identifying a request by its method, path,
+// query and body. The body is written to it as it is read.
+func newFingerprint(r *http.Request) hash.Hash {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	return h
+}
+
+// spoolBody reads body into sum and returns a copy for the handler: in
+// memory when it is small, otherwise in a temporary file removed on Close
+func spoolBody(body io.Reader, sum hash.Hash) (io.ReadCloser, error) {
+	r := io.TeeReader(body, sum)
+	var head bytes.Buffer
+	_, err := io.CopyN(&head, r, memoryBodyLimit+1)
+	if err == io.EOF {
+		return io.NopCloser(&head), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "idempotency-body-*")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &spooledBody{File: f}
+	if _, err := f.Write(head.Bytes()); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	return spooled, nil
+}
+
+// spooledBody is a request body in a temporary file
+type spooledBody struct {
+	*os.File
+}
+
+func (b *spooledBody) Close() error {
+	err := b.File.Close()
+	os.Remove(b.Name())
+	return err
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
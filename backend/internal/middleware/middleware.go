@@ -1,26 +1,105 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cyber/backend/internal/config"
+	"github.com/cyber/backend/internal/reqlog"
+	"github.com/cyber/backend/internal/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func Logger() gin.HandlerFunc {
+// Longest X-Request-ID accepted from clients; longer ones are replaced
+const maxRequestIDLength = 128
+
+// TokenTenantKey is the context key of the tenant_id claim of the verified
+// token. Unlike tenant_id, which a client may set with X-Tenant-ID, it is
+// only set by AuthMiddleware.
+const TokenTenantKey = "token_tenant_id"
+
+// Logger assigns each request an ID, returned in X-Request-ID, and logs the
+// finished request as structured fields. Requests the recorder samples are
+// logged and queued for the SystemLog and APIUsage tables.
+func Logger(logger *slog.Logger, recorder *reqlog.Recorder) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Log request details
+		start := time.Now()
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
+
 		c.Next()
+
+		status := c.Writer.Status()
+		if !recorder.Sampled(status) {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		entry := reqlog.Entry{
+			RequestID: requestID,
+			Method:    c.Request.Method,
+			Route:     route,
+			Path:      c.Request.URL.Path,
+			Status:    status,
+			Latency:   time.Since(start),
+			TenantID:  c.GetString("tenant_id"),
+			UserID:    c.GetString("user_id"),
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			Bytes:     c.Writer.Size(),
+			Errors:    c.Errors.ByType(gin.ErrorTypePrivate).String(),
+			At:        start,
+		}
+
+		level := slog.LevelInfo
+		switch entry.Level() {
+		case "error":
+			level = slog.LevelError
+		case "warning":
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request",
+			slog.String("request_id", entry.RequestID),
+			slog.String("method", entry.Method),
+			slog.String("route", entry.Route),
+			slog.String("path", entry.Path),
+			slog.Int("status", entry.Status),
+			slog.Int64("latency_ms", entry.Latency.Milliseconds()),
+			slog.String("tenant_id", entry.TenantID),
+			slog.String("user_id", entry.UserID),
+			slog.String("ip", entry.IPAddress),
+			slog.String("trace_id", tracing.TraceID(c.Request.Context())),
+		)
+		recorder.Record(entry)
 	}
 }
 
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
 func Cors() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, Idempotency-Key, X-Request-ID")
+		c.Header("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusOK)
@@ -31,15 +110,23 @@ func Cors() gin.HandlerFunc {
 	}
 }
 
+// bearerToken returns the JWT of the request. Event streams authenticate
+// with a stream ticket instead, since EventSource cannot set headers.
+func bearerToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return ""
+}
+
 func TenantMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get tenant ID from header or JWT
 		tenantID := c.GetHeader("X-Tenant-ID")
 		if tenantID == "" {
 			// Try to get from JWT
-			tokenString := c.GetHeader("Authorization")
+			tokenString := bearerToken(c)
 			if tokenString != "" {
-				tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 				token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 					cfg, err := config.Load()
 					if err != nil {
@@ -66,13 +153,12 @@ func TenantMiddleware() gin.HandlerFunc {
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
+		tokenString := bearerToken(c)
 		if tokenString == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			return
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			cfg, err := config.Load()
 			if err != nil {
@@ -91,6 +177,12 @@ func AuthMiddleware() gin.HandlerFunc {
 			if userRole, exists := claims["user_role"]; exists {
 				c.Set("user_role", userRole)
 			}
+			if userID, ok := claims["user_id"].(string); ok {
+				c.Set("user_id", userID)
+			}
+			if tenantID, ok := claims["tenant_id"].(string); ok {
+				c.Set(TokenTenantKey, tenantID)
+			}
 		}
 
 		c.Next()
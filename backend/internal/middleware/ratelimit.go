@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyber/backend/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// Context key of the most restrictive bucket seen by the request, so a budget
+// checked after the plan limits only replaces the headers when it is tighter
+const rateLimitResultKey = "rate_limit_result"
+
+type rateCheck struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+// RateLimit enforces the limits of the tenant's plan: one bucket for the
+// tenant and one for the user. It runs after AuthMiddleware and keys the
+// buckets on the token's claims, never on the X-Tenant-ID header, so a
+// caller cannot spend another tenant's limits or claim its plan. A nil
+// limiter disables it.
+func RateLimit(limiter *ratelimit.Limiter, policies *ratelimit.Policies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetString(TokenTenantKey)
+		if limiter == nil || tenantID == "" {
+			c.Next()
+			return
+		}
+
+		policy := policies.For(tenantID)
+		checks := []rateCheck{{key: "tenant:" + tenantID, limit: policy.Tenant}}
+		if userID := c.GetString("user_id"); userID != "" {
+			checks = append(checks, rateCheck{key: "user:" + userID, limit: policy.User})
+		}
+		if !enforceRateLimits(c, limiter, checks) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateBudget charges the request to one of the budgets of the token's
+// tenant for expensive routes, such as ratelimit.BudgetAI. A nil limiter
+// disables it.
+func RateBudget(limiter *ratelimit.Limiter, policies *ratelimit.Policies, budget string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetString(TokenTenantKey)
+		if limiter == nil || tenantID == "" {
+			c.Next()
+			return
+		}
+
+		limit := policies.For(tenantID).Budgets[budget]
+		if !enforceRateLimits(c, limiter, []rateCheck{{key: budget + ":" + tenantID, limit: limit}}) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// enforceRateLimits takes a token from each bucket. It writes the 429
+// response and returns false when one is empty. The limiter failing lets the
+// request through.
+func enforceRateLimits(c *gin.Context, limiter *ratelimit.Limiter, checks []rateCheck) bool {
+	var reported *ratelimit.Result
+	if prev, ok := c.Get(rateLimitResultKey); ok {
+		res := prev.(ratelimit.Result)
+		reported = &res
+	}
+
+	for _, check := range checks {
+		if !check.limit.Enabled() {
+			continue
+		}
+		res, err := limiter.Allow(c.Request.Context(), check.key, check.limit)
+		if err != nil {
+			log.Printf("Rate limiter unavailable, allowing request: %v", err)
+			continue
+		}
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			setRateLimitHeaders(c, res)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded",
+				"retry_after": retryAfter,
+			})
+			return false
+		}
+		if reported == nil || res.Remaining < reported.Remaining {
+			reported = &res
+		}
+	}
+
+	if reported != nil {
+		c.Set(rateLimitResultKey, *reported)
+		setRateLimitHeaders(c, *reported)
+	}
+	return true
+}
+
+// setRateLimitHeaders writes the RateLimit-* headers of the IETF
+// ratelimit-headers draft
+func setRateLimitHeaders(c *gin.Context, res ratelimit.Result) {
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	c.Header("RateLimit-Policy", strconv.Itoa(res.Limit.Requests)+";w="+strconv.Itoa(int(res.Limit.Period.Seconds())))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
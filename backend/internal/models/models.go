@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +15,7 @@ type BaseModel struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 	DeletedBy *string        `json:"deleted_by"`
 	IsDeleted bool           `gorm:"default:false" json:"is_deleted"`
+	Revision  Revision       `gorm:"not null;default:1" json:"revision"`
 }
 
 // Public Schema Models
@@ -39,6 +41,7 @@ type User struct {
 	Preferences  string     `gorm:"type:jsonb" json:"preferences"`
 	LastLogin    *time.Time `json:"last_login"`
 	IsSuperAdmin bool       `gorm:"default:false" json:"is_super_admin"`
+	ManagerID    string     `json:"manager_id"` // receives the user's escalated reminders
 }
 
 type License struct {
@@ -125,8 +128,13 @@ type SystemLog struct {
 
 // Private Schema Models
 
+// AuditLog is append-only: the database rejects updates and deletes. The
+// entries of a tenant form a hash chain in Sequence order, each Hash covering
+// the entry and PrevHash, so an edit or removal breaks every later link.
+// Entries from before chaining have Sequence 0 and are not covered.
 type AuditLog struct {
 	BaseModel
+	TenantID     string `gorm:"index;index:idx_audit_logs_chain,priority:1" json:"tenant_id"`
 	UserID       string `gorm:"not null" json:"user_id"`
 	Action       string `gorm:"not null" json:"action"`
 	ResourceType string `gorm:"not null" json:"resource_type"`
@@ -135,6 +143,157 @@ type AuditLog struct {
 	NewValues    string `gorm:"type:jsonb" json:"new_values"`
 	IPAddress    string `json:"ip_address"`
 	UserAgent    string `json:"user_agent"`
+	RequestID    string `gorm:"index" json:"request_id"`
+	// EventID is the domain event an entry logs, when the event subscriber
+	// wrote it. It is unique, so a redelivered event is logged once.
+	EventID  *string `gorm:"uniqueIndex" json:"event_id,omitempty"`
+	Sequence int64   `gorm:"not null;default:0;index:idx_audit_logs_chain,priority:2" json:"sequence"`
+	PrevHash string  `json:"prev_hash"`
+	Hash     string  `json:"hash"`
+}
+
+// AuditCheckpoint is a signed statement of a tenant's audit chain head. A
+// chain that no longer reaches a checkpoint, or reaches it with another
+// hash, was truncated or rewritten.
+type AuditCheckpoint struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  string    `gorm:"not null;index" json:"tenant_id"`
+	Sequence  int64     `gorm:"not null" json:"sequence"`
+	Hash      string    `gorm:"not null" json:"hash"`
+	KeyID     string    `gorm:"not null" json:"key_id"`    // fingerprint of the signing key
+	Signature string    `gorm:"not null" json:"signature"` // base64 Ed25519
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecordVersion is the state of a versioned record after a change. Snapshot
+// is the record as the API returns it.
+type RecordVersion struct {
+	ID           string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     string    `gorm:"not null;index" json:"tenant_id"`
+	ResourceType string    `gorm:"not null;uniqueIndex:idx_record_versions_version,priority:1" json:"resource_type"`
+	ResourceID   string    `gorm:"not null;uniqueIndex:idx_record_versions_version,priority:2" json:"resource_id"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_record_versions_version,priority:3" json:"version"`
+	Action       string    `gorm:"not null" json:"action"`  // create, update, restore, revert
+	RevertedFrom *int      `json:"reverted_from,omitempty"` // version a revert went back to
+	Snapshot     string    `gorm:"type:jsonb;not null" json:"snapshot"`
+	ChangedBy    string    `json:"changed_by"`
+	RequestID    string    `json:"request_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RetentionPolicy keeps the data of a class (see package retention) for
+// RetentionDays, after which the purge job removes it. A policy with an empty
+// TenantID is the platform default for tenants without their own.
+type RetentionPolicy struct {
+	ID            string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID      string    `gorm:"not null;default:'';uniqueIndex:idx_retention_policies_scope,priority:1" json:"tenant_id"`
+	DataClass     string    `gorm:"not null;uniqueIndex:idx_retention_policies_scope,priority:2" json:"data_class"`
+	RetentionDays int       `gorm:"not null" json:"retention_days"`
+	UpdatedBy     string    `json:"updated_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// LegalHold suspends purging of a tenant's data until it is released. A hold
+// with an empty DataClass covers every class.
+type LegalHold struct {
+	ID         string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID   string     `gorm:"not null;index" json:"tenant_id"`
+	DataClass  string     `gorm:"not null;default:''" json:"data_class"`
+	Reason     string     `gorm:"not null" json:"reason"`
+	PlacedBy   string     `json:"placed_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `json:"released_at"`
+	ReleasedBy string     `json:"released_by"`
+}
+
+// ReminderRule sets when a tenant's owners are reminded of a kind of
+// deadline (see package reminders) and when missed ones are escalated.
+// Sources without a rule follow reminders.DefaultRule.
+type ReminderRule struct {
+	ID                string        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID          string        `gorm:"not null;uniqueIndex:idx_reminder_rules_source,priority:1" json:"tenant_id"`
+	Source            string        `gorm:"not null;uniqueIndex:idx_reminder_rules_source,priority:2" json:"source"`
+	Enabled           bool          `gorm:"not null" json:"enabled"`
+	DaysBefore        pq.Int64Array `gorm:"type:integer[]" json:"days_before"`             // owners are reminded this many days before the date
+	EscalateAfterDays int           `gorm:"not null" json:"escalate_after_days"`           // days past the date before escalating
+	EscalateTo        string        `gorm:"not null;default:'manager'" json:"escalate_to"` // manager or tenant_admins
+	UpdatedBy         string        `json:"updated_by"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// ReminderSettings holds a tenant's time zone and quiet hours. Reminders due
+// during quiet hours go out when they end.
+type ReminderSettings struct {
+	TenantID   string    `gorm:"primaryKey" json:"tenant_id"`
+	Timezone   string    `gorm:"not null;default:'Asia/Jakarta'" json:"timezone"`
+	QuietStart string    `json:"quiet_start"` // HH:MM; no quiet hours when empty
+	QuietEnd   string    `json:"quiet_end"`   // HH:MM
+	UpdatedBy  string    `json:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Reminder is a reminder or escalation sent about a deadline. Key makes
+// each go out once per record, due date and stage.
+type Reminder struct {
+	ID           string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Key          string         `gorm:"not null;uniqueIndex" json:"key"`
+	TenantID     string         `gorm:"not null;index" json:"tenant_id"`
+	Source       string         `gorm:"not null" json:"source"`
+	ResourceType string         `json:"resource_type"`
+	ResourceID   string         `gorm:"index" json:"resource_id"`
+	Title        string         `json:"title"`
+	DueDate      time.Time      `json:"due_date"`
+	Stage        string         `json:"stage"` // before:<days> or escalation
+	Escalated    bool           `json:"escalated"`
+	Recipients   pq.StringArray `gorm:"type:text[]" json:"recipients"` // user IDs
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// Notification is a notice in a user's in-app inbox. Email and chat copies
+// are sent from it, at once or, for low-priority notices of users in digest
+// mode, in the daily digest.
+type Notification struct {
+	ID            string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Key           string     `gorm:"not null;uniqueIndex" json:"-"` // <event ID>:<user ID>, so an event notifies a user once
+	TenantID      string     `gorm:"not null;index" json:"tenant_id"`
+	UserID        string     `gorm:"not null;index:idx_notifications_inbox,priority:1" json:"user_id"`
+	Template      string     `gorm:"not null" json:"template"`
+	Priority      string     `gorm:"not null;default:'normal'" json:"priority"` // high, normal or low
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	ResourceType  string     `json:"resource_type"`
+	ResourceID    string     `json:"resource_id"`
+	DigestPending bool       `gorm:"not null;index" json:"digest_pending"` // awaiting the daily digest
+	ReadAt        *time.Time `json:"read_at"`
+	CreatedAt     time.Time  `gorm:"index:idx_notifications_inbox,priority:2" json:"created_at"`
+}
+
+// NotificationTemplate overrides a built-in notification template for a
+// tenant in one language
+type NotificationTemplate struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  string    `gorm:"not null;uniqueIndex:idx_notification_templates_key,priority:1" json:"tenant_id"`
+	Key       string    `gorm:"not null;uniqueIndex:idx_notification_templates_key,priority:2" json:"key"`
+	Language  string    `gorm:"not null;uniqueIndex:idx_notification_templates_key,priority:3" json:"language"` // id or en
+	Subject   string    `gorm:"not null" json:"subject"`                                                        // text/template
+	Body      string    `gorm:"not null" json:"body"`                                                           // text/template
+	UpdatedBy string    `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ChatChannel is a chat incoming webhook of a tenant, such as a Slack,
+// Mattermost or Google Chat channel. Users choose one in their preferences
+// to receive their notices there.
+type ChatChannel struct {
+	BaseModel
+	TenantID  string `gorm:"not null;index" json:"tenant_id"`
+	Name      string `gorm:"not null" json:"name"`
+	URL       string `gorm:"not null" json:"-"` // encrypted; the URL is the credential
+	Active    bool   `gorm:"not null" json:"active"`
+	CreatedBy string `json:"created_by"`
 }
 
 type SystemMetric struct {
@@ -177,6 +336,36 @@ type Regulation struct {
 	ParsedContent string `gorm:"type:jsonb" json:"parsed_content"`
 }
 
+// Kinds of regulation clauses, from the outermost
+const (
+	ClauseChapter     = "chapter"     // BAB
+	ClauseSection     = "section"     // Bagian, Paragraf
+	ClauseArticle     = "article"     // Pasal
+	ClauseClause      = "clause"      // ayat
+	ClausePoint       = "point"       // huruf, angka
+	ClauseRequirement = "requirement" // a requirement drawn from the text
+)
+
+// RegulationClause is a node of a regulation's structure. Ref identifies the
+// node within its regulation across imports, so obligations and controls
+// link to it. Nodes a re-import no longer has are marked Removed rather than
+// deleted, keeping their links.
+type RegulationClause struct {
+	ID           string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     string    `gorm:"not null;index" json:"tenant_id"`
+	RegulationID string    `gorm:"not null;uniqueIndex:idx_regulation_clauses_ref,priority:1" json:"regulation_id"`
+	Ref          string    `gorm:"not null;uniqueIndex:idx_regulation_clauses_ref,priority:2" json:"ref"` // e.g. ch-I, art-5.2.a
+	ParentID     *string   `gorm:"type:uuid;index" json:"parent_id"`
+	Kind         string    `gorm:"not null" json:"kind"`
+	Number       string    `json:"number"`
+	Title        string    `json:"title"`
+	Text         string    `gorm:"type:text" json:"text"`
+	Position     int       `gorm:"not null" json:"position"` // order in the regulation
+	Removed      bool      `gorm:"not null" json:"removed"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 type ComplianceAssessment struct {
 	BaseModel
 	TenantID        string    `gorm:"not null" json:"tenant_id"`
@@ -210,6 +399,7 @@ type ObligationMapping struct {
 	BaseModel
 	TenantID         string     `gorm:"not null" json:"tenant_id"`
 	RegulationID     string     `json:"regulation_id"`
+	ClauseID         string     `gorm:"index" json:"clause_id"` // the RegulationClause the obligation derives from
 	Name             string     `gorm:"not null" json:"name"`
 	Description      string     `json:"description"`
 	ObligationType   string     `json:"obligation_type"`
@@ -241,6 +431,7 @@ type RegOpsControl struct {
 	BaseModel
 	TenantID             string     `gorm:"not null" json:"tenant_id"`
 	RegulationID         string     `json:"regulation_id"`
+	ClauseID             string     `gorm:"index" json:"clause_id"` // the RegulationClause the control addresses
 	Name                 string     `gorm:"not null" json:"name"`
 	Description          string     `json:"description"`
 	ControlType          string     `json:"control_type"`
@@ -270,6 +461,10 @@ type DataInventory struct {
 	CreatedBy         string `gorm:"not null" json:"created_by"`
 }
 
+// ClosedDSRStatuses are the DSR statuses that no longer run against the due
+// date
+var ClosedDSRStatuses = []string{"completed", "rejected"}
+
 type DSRRequest struct {
 	BaseModel
 	TenantID             string     `gorm:"not null" json:"tenant_id"`
@@ -618,3 +813,153 @@ type AIUsageLog struct {
 	Success      bool    `gorm:"default:true" json:"success"`
 	ErrorMessage string  `json:"error_message,omitempty"`
 }
+
+// ImportJob - tracks a bulk CSV/XLSX import so it can be reviewed and rolled back
+type ImportJob struct {
+	BaseModel
+	TenantID     string     `gorm:"not null;index" json:"tenant_id"`
+	Resource     string     `gorm:"not null" json:"resource"`
+	FileName     string     `json:"file_name"`
+	FileFormat   string     `json:"file_format"`                              // csv, xlsx
+	Status       string     `gorm:"not null;default:'pending'" json:"status"` // pending, running, completed, failed, rolled_back
+	Mapping      string     `gorm:"type:jsonb;default:'{}'" json:"mapping"`   // header -> field overrides
+	TotalRows    int        `json:"total_rows"`
+	ImportedRows int        `json:"imported_rows"`
+	ErrorRows    int        `json:"error_rows"`
+	Errors       string     `gorm:"type:jsonb;default:'[]'" json:"errors"`     // []importer.RowError
+	RecordIDs    string     `gorm:"type:jsonb;default:'[]'" json:"record_ids"` // IDs created by this job
+	ErrorMessage string     `json:"error_message,omitempty"`
+	CreatedBy    string     `json:"created_by"`
+	StartedAt    *time.Time `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	RolledBackAt *time.Time `json:"rolled_back_at"`
+	RolledBackBy string     `json:"rolled_back_by,omitempty"`
+	// Sheet holds the rows of an import queued to run in the background
+	// until it finishes
+	Sheet *string `gorm:"type:jsonb" json:"-"`
+}
+
+// IdempotencyKey - the stored response of a POST sent with an Idempotency-Key
+// header, replayed when the client retries with the same key
+type IdempotencyKey struct {
+	ID          string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID    string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope" json:"tenant_id"`
+	UserID      string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope" json:"user_id"`
+	Key         string    `gorm:"not null;uniqueIndex:idx_idempotency_keys_scope" json:"key"`
+	Fingerprint string    `gorm:"not null" json:"fingerprint"` // SHA-256 of method, path and body
+	StatusCode  int       `json:"status_code"`                 // 0 while the first request is in flight
+	Headers     string    `gorm:"type:jsonb;default:'{}'" json:"headers"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// StreamTicket - a short-lived, single-use credential for opening an event
+// stream. EventSource cannot send the Authorization header, and a JWT in the
+// query string would end up in access logs.
+type StreamTicket struct {
+	ID        string    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	TokenHash string    `gorm:"not null;uniqueIndex" json:"-"` // SHA-256 of the ticket
+	TenantID  string    `gorm:"not null" json:"-"`
+	UserID    string    `gorm:"not null" json:"-"`
+	UserRole  string    `json:"-"`
+	CreatedAt time.Time `json:"-"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// WebhookSubscription - a tenant endpoint that receives signed event payloads
+type WebhookSubscription struct {
+	BaseModel
+	TenantID    string         `gorm:"not null;index" json:"tenant_id"`
+	Name        string         `gorm:"not null" json:"name"`
+	URL         string         `gorm:"not null" json:"url"`
+	Secret      string         `gorm:"not null" json:"-"`         // encrypted HMAC-SHA256 key
+	Events      pq.StringArray `gorm:"type:text[]" json:"events"` // event types, or "*" for all
+	Description string         `json:"description"`
+	Active      bool           `gorm:"default:true" json:"active"`
+	CreatedBy   string         `json:"created_by"`
+}
+
+// WebhookDelivery - one event sent to one subscription, with its retry state
+type WebhookDelivery struct {
+	ID             string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID       string     `gorm:"not null;index" json:"tenant_id"`
+	SubscriptionID string     `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event" json:"subscription_id"`
+	EventID        string     `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event" json:"event_id"`
+	EventType      string     `gorm:"not null" json:"event_type"`
+	Payload        string     `gorm:"type:jsonb" json:"payload"`
+	Status         string     `gorm:"not null;default:'pending';index" json:"status"` // pending, retrying, delivered, dead
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body"`
+	Error          string     `json:"error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// OutboxEvent - a domain event, written in the same transaction as the change
+// it describes and delivered to subscribers by the event bus
+type OutboxEvent struct {
+	ID            string         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Key           *string        `gorm:"uniqueIndex" json:"key,omitempty"` // optional deduplication key
+	TenantID      string         `gorm:"index" json:"tenant_id"`
+	Type          string         `gorm:"not null;index" json:"type"`
+	ResourceType  string         `json:"resource_type"`
+	ResourceID    string         `json:"resource_id"`
+	ActorID       string         `json:"actor_id"`
+	Payload       string         `gorm:"type:jsonb" json:"payload"`
+	OccurredAt    time.Time      `gorm:"not null" json:"occurred_at"`
+	Status        string         `gorm:"not null;default:'pending';index" json:"status"` // pending, processed, failed
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt time.Time      `gorm:"index" json:"next_attempt_at"`
+	Completed     pq.StringArray `gorm:"type:text[]" json:"completed"` // subscribers that handled the event
+	LastError     string         `json:"last_error,omitempty"`
+	ProcessedAt   *time.Time     `json:"processed_at"`
+}
+
+// Job - a unit of background work, claimed by one worker at a time and
+// retried with backoff until it succeeds or runs out of attempts (see
+// package jobs)
+type Job struct {
+	ID          string     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Key         *string    `gorm:"uniqueIndex" json:"key,omitempty"`           // optional deduplication key
+	TenantID    string     `gorm:"not null;default:'';index" json:"tenant_id"` // empty for platform jobs
+	Type        string     `gorm:"not null;index" json:"type"`
+	Payload     string     `gorm:"type:jsonb" json:"payload"`
+	Status      string     `gorm:"not null;default:'pending';index:idx_jobs_due,priority:1" json:"status"` // pending, running, succeeded, failed, cancelled
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_due,priority:2" json:"run_at"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	LockedBy    string     `json:"locked_by,omitempty"` // worker running the job
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	Schedule    string     `json:"schedule,omitempty"` // recurring schedule that enqueued the job
+	CreatedBy   string     `json:"created_by"`
+	StartedAt   *time.Time `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobSchedule - the state of a recurring job, advanced by the scheduler
+// leader
+type JobSchedule struct {
+	Name      string     `gorm:"primaryKey" json:"name"`
+	Spec      string     `gorm:"not null" json:"spec"` // cron expression
+	JobType   string     `gorm:"not null" json:"job_type"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	LastJobID string     `json:"last_job_id,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Lease - a named role held by one replica at a time until it expires,
+// such as leading the job scheduler
+type Lease struct {
+	Name      string    `gorm:"primaryKey" json:"name"`
+	Holder    string    `gorm:"not null" json:"holder"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+// Resource describes a tenant-scoped domain entity. The registry is shared by
+// the cross-cutting features (search, export, import, trash) so they agree
+// on the names and permissions of each entity.
+type Resource struct {
+	Name   string             // stable identifier used in URLs, e.g. "risk"
+	Domain string             // permission prefix: regops, privacyops, riskops, auditops, document
+	Label  string             // human readable name
+	New    func() interface{} // returns a pointer to a zero value of the model
+}
+
+// Permission returns the permission required for the given action on the resource
+func (r Resource) Permission(action string) string {
+	return r.Domain + "." + action
+}
+
+// TenantResources lists every tenant-scoped domain entity
+var TenantResources = []Resource{
+	// RegOps
+	{Name: "regulation", Domain: "regops", Label: "Regulation", New: func() interface{} { return &Regulation{} }},
+	{Name: "compliance_assessment", Domain: "regops", Label: "Compliance Assessment", New: func() interface{} { return &ComplianceAssessment{} }},
+	{Name: "gap_analysis", Domain: "regops", Label: "Gap Analysis", New: func() interface{} { return &GapAnalysis{} }},
+	{Name: "obligation", Domain: "regops", Label: "Obligation Mapping", New: func() interface{} { return &ObligationMapping{} }},
+	{Name: "policy", Domain: "regops", Label: "Policy", New: func() interface{} { return &Policy{} }},
+	{Name: "control", Domain: "regops", Label: "Control", New: func() interface{} { return &RegOpsControl{} }},
+	// PrivacyOps
+	{Name: "data_inventory", Domain: "privacyops", Label: "Data Inventory", New: func() interface{} { return &DataInventory{} }},
+	{Name: "dsr", Domain: "privacyops", Label: "Data Subject Request", New: func() interface{} { return &DSRRequest{} }},
+	{Name: "dpia", Domain: "privacyops", Label: "DPIA", New: func() interface{} { return &DPIA{} }},
+	{Name: "privacy_control", Domain: "privacyops", Label: "Privacy Control", New: func() interface{} { return &PrivacyControl{} }},
+	{Name: "incident", Domain: "privacyops", Label: "Incident", New: func() interface{} { return &Incident{} }},
+	// RiskOps
+	{Name: "risk", Domain: "riskops", Label: "Risk", New: func() interface{} { return &RiskRegister{} }},
+	{Name: "vulnerability", Domain: "riskops", Label: "Vulnerability", New: func() interface{} { return &Vulnerability{} }},
+	{Name: "vendor", Domain: "riskops", Label: "Vendor", New: func() interface{} { return &VendorAssessment{} }},
+	{Name: "continuity_plan", Domain: "riskops", Label: "Business Continuity Plan", New: func() interface{} { return &BusinessContinuity{} }},
+	// AuditOps
+	{Name: "audit_plan", Domain: "auditops", Label: "Audit Plan", New: func() interface{} { return &AuditPlan{} }},
+	{Name: "governance", Domain: "auditops", Label: "Governance", New: func() interface{} { return &Governance{} }},
+	{Name: "evidence", Domain: "auditops", Label: "Audit Evidence", New: func() interface{} { return &AuditEvidence{} }},
+	{Name: "control_test", Domain: "auditops", Label: "Control Test", New: func() interface{} { return &ControlTest{} }},
+	{Name: "audit_report", Domain: "auditops", Label: "Audit Report", New: func() interface{} { return &AuditReport{} }},
+	// Documents
+	{Name: "document", Domain: "document", Label: "Document", New: func() interface{} { return &Document{} }},
+	{Name: "document_analysis", Domain: "document", Label: "Document Analysis", New: func() interface{} { return &DocumentAnalysis{} }},
+}
+
+// LookupResource finds a registered resource by name
+func LookupResource(name string) (Resource, bool) {
+	for _, r := range TenantResources {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Resource{}, false
+}
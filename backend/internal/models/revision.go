@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrStaleRevision is returned by an update of a record that was changed by
+// someone else since it was read
+var ErrStaleRevision = errors.New("record was modified by another request")
+
+// revisionGuarded marks statements whose WHERE clause checks the revision
+const revisionGuarded = "revision:guarded"
+
+// Revision is the optimistic concurrency counter of a record. It starts at 1
+// and every update through GORM increments it. When the record being updated
+// was loaded from the database, the update only applies if the row still has
+// the loaded revision; otherwise it fails with ErrStaleRevision.
+//
+// The column is called revision rather than version because Policy and
+// Regulation already use version for the document version.
+type Revision int64
+
+// ETag returns the entity tag of the revision
+func (r Revision) ETag() string {
+	return fmt.Sprintf(`"%d"`, r)
+}
+
+// Revisioned is implemented by every model embedding BaseModel
+type Revisioned interface {
+	GetRevision() Revision
+}
+
+// GetRevision returns the record's revision
+func (m BaseModel) GetRevision() Revision {
+	return m.Revision
+}
+
+// UpdateClauses implements schema.UpdateClausesInterface
+func (Revision) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{revisionClause{field: f}}
+}
+
+// revisionClause adds the revision check and increment to UPDATE statements
+type revisionClause struct {
+	field *schema.Field
+}
+
+func (revisionClause) Name() string               { return "" }
+func (revisionClause) Build(clause.Builder)       {}
+func (revisionClause) MergeClause(*clause.Clause) {}
+
+func (rc revisionClause) ModifyStatement(stmt *gorm.Statement) {
+	var current Revision
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		if v, zero := rc.field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			current, _ = v.(Revision)
+		}
+	}
+
+	if current > 0 {
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: rc.field.DBName}, Value: current},
+		}})
+		stmt.Settings.Store(revisionGuarded, true)
+	}
+
+	if _, ok := stmt.Dest.(map[string]interface{}); ok {
+		stmt.SetColumn(rc.field.DBName, gorm.Expr(stmt.Quote(rc.field.DBName)+" + 1"), true)
+		if current > 0 && stmt.ReflectValue.CanAddr() {
+			rc.field.Set(stmt.Context, stmt.ReflectValue, current+1)
+		}
+		return
+	}
+	if current > 0 {
+		stmt.SetColumn(rc.field.DBName, current+1, true)
+	} else {
+		// A struct without a loaded revision must not reset the counter
+		stmt.Omits = append(stmt.Omits, rc.field.DBName)
+	}
+}
+
+// RegisterRevisionCallbacks makes guarded updates that matched no row fail
+// with ErrStaleRevision. The caller has just read the row, so a miss means its
+// revision changed.
+func RegisterRevisionCallbacks(db *gorm.DB) error {
+	return db.Callback().Update().After("gorm:update").Register("revision:check", func(tx *gorm.DB) {
+		if tx.Error != nil || tx.DryRun {
+			return
+		}
+		if guarded, _ := tx.Statement.Settings.Load(revisionGuarded); guarded == true && tx.RowsAffected == 0 {
+			tx.AddError(ErrStaleRevision)
+		}
+	})
+}
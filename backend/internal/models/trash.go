@@ -0,0 +1,204 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A record is in the trash when IsDeleted is set. DeletedBy and DeletedAt
+// record who moved it there and when; the three are always written
+// together, through TrashUpdates and RestoreUpdates. Because DeletedAt is
+// set, GORM's default scope hides trashed records: queries on the trash are
+// Unscoped and select on is_deleted.
+
+// Trashable is implemented by every model embedding BaseModel
+type Trashable interface {
+	Revisioned
+	GetDeletedAt() gorm.DeletedAt
+}
+
+// GetDeletedAt returns when the record was moved to the trash
+func (m BaseModel) GetDeletedAt() gorm.DeletedAt {
+	return m.DeletedAt
+}
+
+// TrashUpdates returns the column updates that move a record to the trash
+func TrashUpdates(userID string, at time.Time) map[string]interface{} {
+	var deletedBy *string
+	if userID != "" {
+		deletedBy = &userID
+	}
+	return map[string]interface{}{
+		"is_deleted": true,
+		"deleted_at": at,
+		"deleted_by": deletedBy,
+	}
+}
+
+// RestoreUpdates returns the column updates that bring a record back from
+// the trash
+func RestoreUpdates() map[string]interface{} {
+	return map[string]interface{}{
+		"is_deleted": false,
+		"deleted_at": nil,
+		"deleted_by": nil,
+	}
+}
+
+// ResourceLink ties a child resource to the parent it belongs to. Children
+// follow their parent to the trash, back from it and out of it.
+type ResourceLink struct {
+	Parent string // parent resource name
+	Child  string // child resource name
+	Column string // column of the child holding the parent's ID
+}
+
+// ResourceLinks lists the parent-child links between tenant resources. A
+// child always shares its parent's domain, so the parent's permissions
+// cover it.
+var ResourceLinks = []ResourceLink{
+	{Parent: "regulation", Child: "compliance_assessment", Column: "regulation_id"},
+	{Parent: "regulation", Child: "gap_analysis", Column: "regulation_id"},
+	{Parent: "regulation", Child: "obligation", Column: "regulation_id"},
+	{Parent: "audit_plan", Child: "evidence", Column: "audit_id"},
+	{Parent: "audit_plan", Child: "audit_report", Column: "audit_id"},
+	{Parent: "document", Child: "document_analysis", Column: "document_id"},
+}
+
+// ChildLinks returns the links of the resource's children
+func ChildLinks(name string) []ResourceLink {
+	var links []ResourceLink
+	for _, link := range ResourceLinks {
+		if link.Parent == name {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ParentLinks returns the links of the resource's parents
+func ParentLinks(name string) []ResourceLink {
+	var links []ResourceLink
+	for _, link := range ResourceLinks {
+		if link.Child == name {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// purgeHooks remove the records belonging to purged records of a resource
+// that have no trash of their own
+var purgeHooks = map[string]func(tx *gorm.DB, tenantID string, ids []string) error{
+	"regulation": purgeRegulationClauses,
+}
+
+// purgeRegulationClauses deletes the clauses of purged regulations and
+// unlinks the obligations and controls tied to them
+func purgeRegulationClauses(tx *gorm.DB, tenantID string, ids []string) error {
+	clauses := tx.Model(&RegulationClause{}).Select("id").Where("regulation_id IN ? AND tenant_id = ?", ids, tenantID)
+	for _, model := range []interface{}{&ObligationMapping{}, &RegOpsControl{}} {
+		if err := tx.Unscoped().Model(model).Where("clause_id IN (?) AND tenant_id = ?", clauses, tenantID).
+			UpdateColumn("clause_id", "").Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("regulation_id IN ? AND tenant_id = ?", ids, tenantID).Delete(&RegulationClause{}).Error
+}
+
+// TrashClassPrefix starts the retention class of a resource's records in the
+// trash, followed by the resource name, e.g. "trash.risk"
+const TrashClassPrefix = "trash."
+
+// ErrLegalHold is returned by PurgeTrashed while a legal hold covers the
+// records
+var ErrLegalHold = errors.New("records are under a legal hold")
+
+// checkLegalHolds fails with ErrLegalHold while an active hold of the tenant
+// covers every class, or the trash class of the resource or of any resource
+// its children cascade to
+func checkLegalHolds(tx *gorm.DB, tenantID, resourceName string) error {
+	classes := []string{""}
+	var add func(name string)
+	add = func(name string) {
+		classes = append(classes, TrashClassPrefix+name)
+		for _, link := range ChildLinks(name) {
+			add(link.Child)
+		}
+	}
+	add(resourceName)
+
+	var held int64
+	if err := tx.Model(&LegalHold{}).Where("tenant_id = ? AND released_at IS NULL AND data_class IN ?", tenantID, classes).
+		Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		return ErrLegalHold
+	}
+	return nil
+}
+
+// Records deleted per statement by PurgeTrashed
+const purgeBatchSize = 500
+
+// PurgeTrashed permanently deletes the tenant's records of the named resource
+// with the given IDs, and their children in the trash. Records not in the
+// trash are left alone. Nothing is deleted while a legal hold covers the
+// resource or its children.
+func PurgeTrashed(tx *gorm.DB, tenantID, resourceName string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := checkLegalHolds(tx, tenantID, resourceName); err != nil {
+		return err
+	}
+	return purgeTrashed(tx, tenantID, resourceName, ids)
+}
+
+func purgeTrashed(tx *gorm.DB, tenantID, resourceName string, ids []string) error {
+	resource, ok := LookupResource(resourceName)
+	if !ok {
+		return fmt.Errorf("unknown resource %q", resourceName)
+	}
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > purgeBatchSize {
+			batch = batch[:purgeBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		for _, link := range ChildLinks(resourceName) {
+			child, _ := LookupResource(link.Child)
+			var childIDs []string
+			if err := tx.Unscoped().Model(child.New()).
+				Where(link.Column+" IN ? AND tenant_id = ? AND is_deleted = ?", batch, tenantID, true).
+				Pluck("id", &childIDs).Error; err != nil {
+				return err
+			}
+			if err := purgeTrashed(tx, tenantID, child.Name, childIDs); err != nil {
+				return err
+			}
+		}
+		if hook, ok := purgeHooks[resourceName]; ok {
+			var trashed []string
+			if err := tx.Unscoped().Model(resource.New()).Where("id IN ? AND tenant_id = ? AND is_deleted = ?", batch, tenantID, true).
+				Pluck("id", &trashed).Error; err != nil {
+				return err
+			}
+			if len(trashed) > 0 {
+				if err := hook(tx, tenantID, trashed); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Unscoped().Where("id IN ? AND tenant_id = ? AND is_deleted = ?", batch, tenantID, true).
+			Delete(resource.New()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/cyber/backend/internal/config"
+	"github.com/cyber/backend/internal/crypto"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+)
+
+// mailer sends plain text email through an SMTP server, with STARTTLS when
+// the server offers it
+type mailer struct {
+	cfg config.SMTPConfig
+}
+
+func (m mailer) enabled() bool {
+	return m.cfg.Host != ""
+}
+
+func (m mailer) send(to, subject, body string) error {
+	if !m.enabled() {
+		return jobs.Permanent(errors.New("SMTP is not configured"))
+	}
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid SMTP_FROM: %w", err))
+	}
+	if _, err := mail.ParseAddress(to); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid recipient address: %w", err))
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	return smtp.SendMail(addr, auth, from.Address, []string{to}, msg.Bytes())
+}
+
+// postChat posts text to a chat channel's incoming webhook as
+// {"text": ...}, which Slack, Mattermost, Rocket.Chat and Google Chat
+// accept. Client errors other than rate limiting, and channels pointing
+// into the server's own network, are not retried.
+func (s *Service) postChat(ctx context.Context, chat *models.ChatChannel, text string) error {
+	url, err := crypto.Decrypt(chat.URL)
+	if err != nil {
+		return jobs.Permanent(fmt.Errorf("decrypt chat channel URL: %w", err))
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if errors.Is(err, egress.ErrBlocked) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		err := fmt.Errorf("chat channel answered %s", resp.Status)
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// TestChat posts a test message to a chat channel
+func (s *Service) TestChat(ctx context.Context, chat *models.ChatChannel) error {
+	return s.postChat(ctx, chat, "Komplai: test message for "+chat.Name)
+}
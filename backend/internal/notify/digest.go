@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// sendDigests sends every user with low-priority notices pending their
+// digest. A user whose digest fails is logged and skipped; the error of the
+// last one is returned, so the job is retried for them.
+func (s *Service) sendDigests(ctx context.Context) error {
+	var userIDs []string
+	if err := s.db.WithContext(ctx).Model(&models.Notification{}).Where("digest_pending = ?", true).
+		Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	var lastErr error
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.sendDigest(ctx, userID); err != nil {
+			log.Printf("Failed to send the notification digest of user %s: %v", userID, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// sendDigest sends a user the notices pending their digest that they have
+// not read in the app, over the channels they have on
+func (s *Service) sendDigest(ctx context.Context, userID string) error {
+	tx := s.db.WithContext(ctx)
+	var pending []models.Notification
+	if err := tx.Where("user_id = ? AND digest_pending = ?", userID, true).Order("created_at").
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	ids := make([]string, len(pending))
+	var notices []map[string]interface{}
+	for i, n := range pending {
+		ids[i] = n.ID
+		if n.ReadAt == nil {
+			notices = append(notices, map[string]interface{}{
+				"subject":    n.Subject,
+				"body":       n.Body,
+				"created_at": n.CreatedAt,
+			})
+		}
+	}
+
+	var user models.User
+	err := tx.Where("id = ? AND status = ?", userID, "active").First(&user).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		notices = nil // gone or disabled: the digest is dropped
+	case err != nil:
+		return err
+	}
+
+	if len(notices) > 0 {
+		prefs := LoadPreferences(&user)
+		subject, body, err := Render(tx, user.TenantID, DigestTemplate, prefs.Language, withRecipient(map[string]interface{}{
+			"count":   len(notices),
+			"notices": notices,
+		}, &user))
+		if err != nil {
+			return err
+		}
+		for _, channel := range s.channels(prefs) {
+			if err := s.sendCopy(ctx, &user, channel, subject, body); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Model(&models.Notification{}).Where("id IN ?", ids).Update("digest_pending", false).Error
+}
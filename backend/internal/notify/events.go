@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// eventRule says who a domain event notifies, and how urgently. The event
+// type is the key of the notice's template.
+type eventRule struct {
+	recipients func(tx *gorm.DB, e events.Event, data map[string]interface{}) ([]string, error)
+	priority   func(data map[string]interface{}) string
+}
+
+// eventRules lists the domain events users are notified of
+var eventRules = map[string]eventRule{
+	events.ReminderDue:            {listedRecipients, reminderPriority},
+	events.ReminderEscalated:      {listedRecipients, fixed(PriorityHigh)},
+	events.DSROverdue:             {tenantAdmins, fixed(PriorityHigh)},
+	events.IncidentSeverityRaised: {tenantAdmins, fixed(PriorityHigh)},
+	events.IncidentResolved:       {tenantAdmins, fixed(PriorityNormal)},
+	events.RiskCreated:            {tenantAdmins, fixed(PriorityLow)},
+	events.DPIAApproved:           {tenantAdmins, fixed(PriorityLow)},
+	events.PolicyPublished:        {tenantUsers, fixed(PriorityLow)},
+	events.AuditReportGenerated:   {actor, fixed(PriorityNormal)},
+}
+
+// HandleEvent notifies the users concerned by a domain event. Subscribe it
+// to the event bus.
+func (s *Service) HandleEvent(ctx context.Context, e events.Event) error {
+	rule, ok := eventRules[e.Type]
+	if !ok {
+		return nil
+	}
+	data := map[string]interface{}{}
+	if len(e.Data) > 0 {
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			data = map[string]interface{}{}
+		}
+	}
+	userIDs, err := rule.recipients(s.db.WithContext(ctx), e, data)
+	if err != nil {
+		return err
+	}
+	return s.Send(ctx, Notice{
+		TenantID:     e.TenantID,
+		UserIDs:      userIDs,
+		Template:     e.Type,
+		Priority:     rule.priority(data),
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		Data:         data,
+		Key:          e.ID,
+	})
+}
+
+func fixed(priority string) func(map[string]interface{}) string {
+	return func(map[string]interface{}) string { return priority }
+}
+
+// reminderPriority makes reminders due within a day normal and earlier
+// ones low, so they can wait for the digest
+func reminderPriority(data map[string]interface{}) string {
+	stage, _ := data["stage"].(string)
+	if stage == "before:0" || stage == "before:1" {
+		return PriorityNormal
+	}
+	return PriorityLow
+}
+
+// listedRecipients notifies the users listed in the event's recipients
+func listedRecipients(_ *gorm.DB, _ events.Event, data map[string]interface{}) ([]string, error) {
+	listed, _ := data["recipients"].([]interface{})
+	ids := make([]string, 0, len(listed))
+	for _, id := range listed {
+		if s, ok := id.(string); ok {
+			ids = append(ids, s)
+		}
+	}
+	return ids, nil
+}
+
+func tenantAdmins(tx *gorm.DB, e events.Event, _ map[string]interface{}) ([]string, error) {
+	var ids []string
+	err := tx.Model(&models.User{}).Where("tenant_id = ? AND role = ? AND status = ?", e.TenantID, models.RoleTenantAdmin, "active").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func tenantUsers(tx *gorm.DB, e events.Event, _ map[string]interface{}) ([]string, error) {
+	var ids []string
+	err := tx.Model(&models.User{}).Where("tenant_id = ? AND status = ?", e.TenantID, "active").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// actor notifies the user whose action raised the event, such as the
+// requester of a report generated in the background
+func actor(_ *gorm.DB, e events.Event, _ map[string]interface{}) ([]string, error) {
+	if e.ActorID == "" || e.ActorID == audit.SystemActor {
+		return nil, nil
+	}
+	return []string{e.ActorID}, nil
+}
@@ -0,0 +1,344 @@
+// Package notify tells users about what concerns them. Every notice lands
+// in the user's in-app inbox and, as the user prefers, is emailed or posted
+// to a chat channel. Notices are rendered from templates in Indonesian or
+// English, which tenants may override. Low-priority notices of users in
+// digest mode are emailed and posted once a day, together, instead of one
+// by one.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/config"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/realtime"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Priorities
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low" // batched in the daily digest for users in digest mode
+)
+
+// Channels besides the in-app inbox, which every notice goes to
+const (
+	ChannelEmail = "email"
+	ChannelChat  = "chat"
+)
+
+// Channels lists the channels users can turn on and off
+var Channels = []string{ChannelEmail, ChannelChat}
+
+// Jobs sending the email and chat copies of notices
+var (
+	deliverJob = jobs.Kind[deliverPayload]{Name: "notification.deliver", MaxAttempts: 5}
+	digestJob  = jobs.Kind[struct{}]{Name: "notification.digest", MaxAttempts: 3}
+)
+
+type deliverPayload struct {
+	NotificationID string `json:"notification_id"`
+	Channel        string `json:"channel"`
+}
+
+// Preferences are a user's notification settings, kept under
+// "notifications" in User.Preferences
+type Preferences struct {
+	Language      string          `json:"language"`        // id or en
+	Channels      map[string]bool `json:"channels"`        // email and chat; the in-app inbox is always on
+	ChatChannelID string          `json:"chat_channel_id"` // the tenant chat channel chat copies go to
+	Digest        bool            `json:"digest"`          // send low-priority notices in the daily digest
+}
+
+// preferencesKey is the key of the notification settings in
+// User.Preferences, next to the settings of other features
+const preferencesKey = "notifications"
+
+// DefaultPreferences are the settings of a user who has set none: Indonesian,
+// by email, with the daily digest
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Language: DefaultLanguage,
+		Channels: map[string]bool{ChannelEmail: true, ChannelChat: false},
+		Digest:   true,
+	}
+}
+
+// LoadPreferences returns the user's notification settings, with the
+// defaults for what the user has not set
+func LoadPreferences(user *models.User) Preferences {
+	prefs := DefaultPreferences()
+	var all map[string]json.RawMessage
+	if user.Preferences == "" || json.Unmarshal([]byte(user.Preferences), &all) != nil {
+		return prefs
+	}
+	if raw, ok := all[preferencesKey]; ok {
+		if err := json.Unmarshal(raw, &prefs); err != nil {
+			return DefaultPreferences()
+		}
+	}
+	if !validLanguage(prefs.Language) {
+		prefs.Language = DefaultLanguage
+	}
+	return prefs
+}
+
+// StorePreferences sets the user's notification settings in
+// User.Preferences, keeping the settings of other features
+func StorePreferences(user *models.User, prefs Preferences) error {
+	all := map[string]json.RawMessage{}
+	if user.Preferences != "" {
+		// Preferences that are not an object are replaced
+		_ = json.Unmarshal([]byte(user.Preferences), &all)
+	}
+	raw, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+	all[preferencesKey] = raw
+	encoded, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	user.Preferences = string(encoded)
+	return nil
+}
+
+// ValidatePreferences checks the language and channels of prefs
+func ValidatePreferences(prefs *Preferences) error {
+	if !validLanguage(prefs.Language) {
+		return fmt.Errorf("language must be one of %v", Languages)
+	}
+	for channel := range prefs.Channels {
+		if channel != ChannelEmail && channel != ChannelChat {
+			return fmt.Errorf("unknown channel %q; channels are %v", channel, Channels)
+		}
+	}
+	if prefs.Channels[ChannelChat] && prefs.ChatChannelID == "" {
+		return errors.New("chat_channel_id is required to receive notices by chat")
+	}
+	return nil
+}
+
+// Notice is something to tell users about
+type Notice struct {
+	TenantID     string
+	UserIDs      []string
+	Template     string // key of the template rendering the notice
+	Priority     string
+	ResourceType string // see models.TenantResources; optional
+	ResourceID   string
+	Data         map[string]interface{} // template data
+	// Key identifies the notice: a notice is sent to a user once per key,
+	// however often it is sent. Required.
+	Key string
+}
+
+// Service sends notices. It sends the email and chat copies with jobs, so
+// Register it with the queue of every process running jobs.
+type Service struct {
+	db     *gorm.DB
+	mail   mailer
+	client *http.Client
+	hub    *realtime.Hub
+	queue  *jobs.Queue
+}
+
+// NewService returns a service sending email through smtp. hub may be nil
+// when no stream should be told about new notices.
+func NewService(db *gorm.DB, smtp config.SMTPConfig, hub *realtime.Hub) *Service {
+	return &Service{
+		db:     db,
+		mail:   mailer{cfg: smtp},
+		client: egress.Client(10 * time.Second),
+		hub:    hub,
+	}
+}
+
+// Register registers the jobs sending notices with q and runs the daily
+// digest on the cron spec. Register before q runs.
+func (s *Service) Register(q *jobs.Queue, digestSpec string) error {
+	s.queue = q
+	deliverJob.Handle(q, func(ctx context.Context, job *models.Job, p deliverPayload) error {
+		return s.deliver(ctx, job, p)
+	})
+	digestJob.Handle(q, func(ctx context.Context, _ *models.Job, _ struct{}) error {
+		return s.sendDigests(ctx)
+	})
+	return digestJob.Schedule(q, digestSpec, struct{}{})
+}
+
+// Send puts the notice in the inboxes of its active users and queues its
+// email and chat copies. Users it was already sent to are skipped.
+func (s *Service) Send(ctx context.Context, notice Notice) error {
+	if notice.Key == "" {
+		return errors.New("notice without a key")
+	}
+	if len(notice.UserIDs) == 0 {
+		return nil
+	}
+	if notice.Priority == "" {
+		notice.Priority = PriorityNormal
+	}
+
+	var created []models.Notification
+	queued := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Where("tenant_id = ? AND id IN ? AND status = ?", notice.TenantID, notice.UserIDs, "active").
+			Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			prefs := LoadPreferences(&user)
+			subject, body, err := Render(tx, notice.TenantID, notice.Template, prefs.Language, withRecipient(notice.Data, &user))
+			if err != nil {
+				return err
+			}
+			channels := s.channels(prefs)
+			n := models.Notification{
+				Key:           notice.Key + ":" + user.ID,
+				TenantID:      notice.TenantID,
+				UserID:        user.ID,
+				Template:      notice.Template,
+				Priority:      notice.Priority,
+				Subject:       subject,
+				Body:          body,
+				ResourceType:  notice.ResourceType,
+				ResourceID:    notice.ResourceID,
+				DigestPending: notice.Priority == PriorityLow && prefs.Digest && len(channels) > 0,
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&n)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			created = append(created, n)
+			if n.DigestPending {
+				continue
+			}
+			for _, channel := range channels {
+				if _, err := deliverJob.Enqueue(tx, notice.TenantID, deliverPayload{NotificationID: n.ID, Channel: channel}); err != nil {
+					return err
+				}
+				queued = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if queued && s.queue != nil {
+		s.queue.Notify()
+	}
+	for i := range created {
+		s.publish(&created[i])
+	}
+	return nil
+}
+
+// channels returns the channels besides the inbox a user with prefs gets
+// notices on. Email is left out when no mail server is configured.
+func (s *Service) channels(prefs Preferences) []string {
+	var channels []string
+	if prefs.Channels[ChannelEmail] && s.mail.enabled() {
+		channels = append(channels, ChannelEmail)
+	}
+	if prefs.Channels[ChannelChat] && prefs.ChatChannelID != "" {
+		channels = append(channels, ChannelChat)
+	}
+	return channels
+}
+
+// publish tells the user's open streams about a new notice
+func (s *Service) publish(n *models.Notification) {
+	if s.hub == nil {
+		return
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return
+	}
+	s.hub.Publish(realtime.Message{
+		Type:       realtime.Notification,
+		TenantID:   n.TenantID,
+		Resource:   n.ResourceType,
+		ResourceID: n.ResourceID,
+		Data:       data,
+		UserID:     n.UserID,
+	})
+}
+
+// deliver sends the email or chat copy of a notice
+func (s *Service) deliver(ctx context.Context, job *models.Job, p deliverPayload) error {
+	tx := s.db.WithContext(ctx)
+	var n models.Notification
+	if err := tx.Where("id = ? AND tenant_id = ?", p.NotificationID, job.TenantID).First(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	var user models.User
+	if err := tx.Where("id = ? AND tenant_id = ?", n.UserID, n.TenantID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	return s.sendCopy(ctx, &user, p.Channel, n.Subject, n.Body)
+}
+
+// sendCopy emails a notice to the user or posts it to the user's chat
+// channel
+func (s *Service) sendCopy(ctx context.Context, user *models.User, channel, subject, body string) error {
+	switch channel {
+	case ChannelEmail:
+		return s.mail.send(user.Email, subject, body)
+	case ChannelChat:
+		chat, err := s.chatChannel(ctx, user.TenantID, LoadPreferences(user).ChatChannelID)
+		if err != nil {
+			return err
+		}
+		return s.postChat(ctx, chat, subject+"\n\n"+body)
+	}
+	return jobs.Permanent(fmt.Errorf("unknown channel %q", channel))
+}
+
+// chatChannel loads an active chat channel of the tenant. A channel that is
+// gone or inactive fails the copy for good.
+func (s *Service) chatChannel(ctx context.Context, tenantID, id string) (*models.ChatChannel, error) {
+	var chat models.ChatChannel
+	err := s.db.WithContext(ctx).Where("id = ? AND tenant_id = ? AND is_deleted = ? AND active = ?", id, tenantID, false, true).
+		First(&chat).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, jobs.Permanent(errors.New("chat channel not found or inactive"))
+	}
+	return &chat, err
+}
+
+// withRecipient returns the template data of a notice to user
+func withRecipient(data map[string]interface{}, user *models.User) map[string]interface{} {
+	out := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		out[k] = v
+	}
+	out["recipient"] = user.FirstName
+	if user.FirstName == "" {
+		out["recipient"] = user.Email
+	}
+	return out
+}
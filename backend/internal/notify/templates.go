@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Languages notices are written in
+const (
+	LanguageIndonesian = "id"
+	LanguageEnglish    = "en"
+	DefaultLanguage    = LanguageIndonesian
+)
+
+// Languages lists the languages notices are written in
+var Languages = []string{LanguageIndonesian, LanguageEnglish}
+
+func validLanguage(language string) bool {
+	return language == LanguageIndonesian || language == LanguageEnglish
+}
+
+// Template renders a notice. Subject and Body are text/template templates
+// over the notice's data: the payload of the event raising it, and
+// recipient, the first name of the user it is sent to. The date function
+// formats a timestamp as YYYY-MM-DD.
+type Template struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// DigestTemplate renders the daily digest. Its data is count and notices,
+// each with subject, body and created_at.
+const DigestTemplate = "digest"
+
+// builtin holds the templates of every notice, by key and language
+var builtin = map[string]map[string]Template{
+	"reminder.due": {
+		LanguageIndonesian: {
+			Subject: "Pengingat: {{.title}} jatuh tempo {{date .due_date}}",
+			Body:    "Halo {{.recipient}},\n\nIni pengingat bahwa {{.title}} ({{.resource_type}}) jatuh tempo pada {{date .due_date}}.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Reminder: {{.title}} is due {{date .due_date}}",
+			Body:    "Hello {{.recipient}},\n\nThis is a reminder that {{.title}} ({{.resource_type}}) is due on {{date .due_date}}.\n",
+		},
+	},
+	"reminder.escalated": {
+		LanguageIndonesian: {
+			Subject: "Terlambat: {{.title}} jatuh tempo {{date .due_date}}",
+			Body:    "Halo {{.recipient}},\n\n{{.title}} ({{.resource_type}}) telah melewati tenggat {{date .due_date}} dan dieskalasi.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Overdue: {{.title}} was due {{date .due_date}}",
+			Body:    "Hello {{.recipient}},\n\n{{.title}} ({{.resource_type}}) missed its deadline of {{date .due_date}} and has been escalated.\n",
+		},
+	},
+	"dsr.overdue": {
+		LanguageIndonesian: {
+			Subject: "Permintaan subjek data terlambat: {{.data_subject_name}}",
+			Body:    "Halo {{.recipient}},\n\nPermintaan {{.request_type}} dari {{.data_subject_name}} jatuh tempo pada {{date .due_date}} dan masih berstatus {{.status}}.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Data subject request overdue: {{.data_subject_name}}",
+			Body:    "Hello {{.recipient}},\n\nThe {{.request_type}} request of {{.data_subject_name}} was due on {{date .due_date}} and is still {{.status}}.\n",
+		},
+	},
+	"incident.severity_raised": {
+		LanguageIndonesian: {
+			Subject: "Tingkat keparahan insiden naik menjadi {{.incident.severity}}: {{.incident.name}}",
+			Body:    "Halo {{.recipient}},\n\nTingkat keparahan insiden {{.incident.name}} dinaikkan dari {{.previous_severity}} menjadi {{.incident.severity}}.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Incident severity raised to {{.incident.severity}}: {{.incident.name}}",
+			Body:    "Hello {{.recipient}},\n\nThe severity of incident {{.incident.name}} was raised from {{.previous_severity}} to {{.incident.severity}}.\n",
+		},
+	},
+	"incident.resolved": {
+		LanguageIndonesian: {
+			Subject: "Insiden diselesaikan: {{.name}}",
+			Body:    "Halo {{.recipient}},\n\nInsiden {{.name}} telah diselesaikan pada {{date .resolution_date}}.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Incident resolved: {{.name}}",
+			Body:    "Hello {{.recipient}},\n\nIncident {{.name}} was resolved on {{date .resolution_date}}.\n",
+		},
+	},
+	"risk.created": {
+		LanguageIndonesian: {
+			Subject: "Risiko baru: {{.name}}",
+			Body:    "Halo {{.recipient}},\n\nRisiko {{.name}} dengan tingkat {{.risk_level}} ditambahkan ke register risiko.\n",
+		},
+		LanguageEnglish: {
+			Subject: "New risk: {{.name}}",
+			Body:    "Hello {{.recipient}},\n\nThe {{.risk_level}} risk {{.name}} was added to the risk register.\n",
+		},
+	},
+	"policy.published": {
+		LanguageIndonesian: {
+			Subject: "Kebijakan diterbitkan: {{.name}} {{.version}}",
+			Body:    "Halo {{.recipient}},\n\nVersi {{.version}} dari kebijakan {{.name}} telah diterbitkan.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Policy published: {{.name}} {{.version}}",
+			Body:    "Hello {{.recipient}},\n\nVersion {{.version}} of the policy {{.name}} has been published.\n",
+		},
+	},
+	"dpia.approved": {
+		LanguageIndonesian: {
+			Subject: "DPIA disetujui: {{.name}}",
+			Body:    "Halo {{.recipient}},\n\nDPIA {{.name}} telah disetujui.\n",
+		},
+		LanguageEnglish: {
+			Subject: "DPIA approved: {{.name}}",
+			Body:    "Hello {{.recipient}},\n\nThe DPIA {{.name}} has been approved.\n",
+		},
+	},
+	"audit_report.generated": {
+		LanguageIndonesian: {
+			Subject: "Laporan audit siap: {{.report_name}}",
+			Body:    "Halo {{.recipient}},\n\nLaporan audit {{.report_name}} telah dibuat.\n",
+		},
+		LanguageEnglish: {
+			Subject: "Audit report ready: {{.report_name}}",
+			Body:    "Hello {{.recipient}},\n\nThe audit report {{.report_name}} has been generated.\n",
+		},
+	},
+	DigestTemplate: {
+		LanguageIndonesian: {
+			Subject: "Ringkasan harian: {{.count}} pemberitahuan",
+			Body:    "Halo {{.recipient}},\n\nBerikut pemberitahuan sejak ringkasan terakhir Anda:\n\n{{range .notices}}- {{.subject}}\n{{end}}",
+		},
+		LanguageEnglish: {
+			Subject: "Daily digest: {{.count}} notices",
+			Body:    "Hello {{.recipient}},\n\nHere is what happened since your last digest:\n\n{{range .notices}}- {{.subject}}\n{{end}}",
+		},
+	},
+}
+
+// TemplateKeys lists the keys of the templates tenants can override
+func TemplateKeys() []string {
+	keys := make([]string, 0, len(builtin))
+	for key := range builtin {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// BuiltinTemplate returns the built-in template of a key in a language
+func BuiltinTemplate(key, language string) (Template, bool) {
+	t, ok := builtin[key][language]
+	return t, ok
+}
+
+// LookupTemplate returns the template of a key in a language: the tenant's
+// override, else the built-in one. custom reports an override.
+func LookupTemplate(tx *gorm.DB, tenantID, key, language string) (t Template, custom bool, err error) {
+	var overrides []models.NotificationTemplate
+	if err := tx.Where("tenant_id = ? AND key = ? AND language = ?", tenantID, key, language).
+		Limit(1).Find(&overrides).Error; err != nil {
+		return Template{}, false, err
+	}
+	if len(overrides) > 0 {
+		return Template{Subject: overrides[0].Subject, Body: overrides[0].Body}, true, nil
+	}
+	t, ok := BuiltinTemplate(key, language)
+	if !ok {
+		return Template{}, false, fmt.Errorf("no %s template %q", language, key)
+	}
+	return t, false, nil
+}
+
+// Render renders the subject and body of a notice with the tenant's
+// template of key in language
+func Render(tx *gorm.DB, tenantID, key, language string, data map[string]interface{}) (subject, body string, err error) {
+	t, _, err := LookupTemplate(tx, tenantID, key, language)
+	if err != nil {
+		return "", "", err
+	}
+	if subject, err = execute(key+".subject", t.Subject, data); err != nil {
+		return "", "", err
+	}
+	if body, err = execute(key+".body", t.Body, data); err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+// ValidateTemplate checks that the subject and body of t parse
+func ValidateTemplate(t Template) error {
+	if t.Subject == "" || t.Body == "" {
+		return fmt.Errorf("subject and body are required")
+	}
+	for name, text := range map[string]string{"subject": t.Subject, "body": t.Body} {
+		if _, err := parse(name, text); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+var funcs = template.FuncMap{"date": formatDate}
+
+func parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+func execute(name, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := parse(name, text)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// formatDate formats a timestamp, from JSON or not, as YYYY-MM-DD. Other
+// values are printed as they are.
+func formatDate(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format("2006-01-02")
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t.Format("2006-01-02")
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
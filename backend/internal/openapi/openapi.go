@@ -0,0 +1,390 @@
+// Package openapi generates an OpenAPI 3.1 document from the gin route table
+// and the request/response types registered for each handler, and provides
+// middleware that validates request bodies against it.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cyber/backend/internal/query"
+	"github.com/gin-gonic/gin"
+)
+
+// Endpoint describes the contract of one handler. Routes are matched to
+// endpoints by handler function, so the same handler mounted on several
+// paths shares one description.
+type Endpoint struct {
+	Handler     interface{} // method expression, e.g. (*api.RiskOpsERMHandler).CreateRisk
+	Summary     string      // defaults to the handler name in words
+	Description string
+	Request     interface{} // zero value of the JSON request body
+	Response    interface{} // zero value of the response payload; a slice for lists
+	Envelope    bool        // payload is wrapped in {"success", "message", "data"}
+	Query       *query.Spec // list filters and sort keys
+	Status      int         // success status; defaults to 201 for POST, 200 otherwise
+	Public      bool        // no bearer token required
+	Produces    string      // non-JSON response content type, e.g. text/csv
+	Consumes    string      // non-JSON request content type, e.g. application/merge-patch+json
+}
+
+// Info is the document's info object
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+
+	// operations indexes operations by "METHOD /gin/:path" for validation
+	operations map[string]*Operation
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"` // empty for public operations
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"` // path, query, header
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const jsonContent = "application/json"
+
+// Build generates the document for every route whose path starts with /api
+func Build(routes gin.RoutesInfo, endpoints []Endpoint, info Info) *Document {
+	byHandler := map[string]Endpoint{}
+	for _, e := range endpoints {
+		byHandler[funcName(e.Handler)] = e
+	}
+
+	gen := newSchemaGenerator()
+	errorSchema := gen.schemaOf(errorBody{})
+	metaSchema := gen.schemaOf(query.Meta{})
+
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+			Parameters: map[string]*Parameter{
+				"TenantID": {
+					Name:        "X-Tenant-ID",
+					In:          "header",
+					Description: "Tenant to act on; defaults to the tenant in the token",
+					Schema:      &Schema{Type: "string"},
+				},
+				"IfMatch": {
+					Name:        "If-Match",
+					In:          "header",
+					Description: "ETag of the record being changed; 412 with the current record when it is stale",
+					Schema:      &Schema{Type: "string"},
+				},
+				"IdempotencyKey": {
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Description: "Unique key for this request, at most 255 characters; a retry with the same key replays the first response",
+					Schema:      &Schema{Type: "string"},
+				},
+			},
+		},
+		Security:   []map[string][]string{{"bearerAuth": {}}},
+		operations: map[string]*Operation{},
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	tags := map[string]bool{}
+	operationIDs := map[string]bool{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		name := strings.TrimSuffix(route.Handler, "-fm")
+		e, described := byHandler[name]
+		method := strings.ToLower(route.Method)
+		path, params := convertPath(route.Path)
+
+		op := &Operation{
+			OperationID: methodName(name),
+			Summary:     e.Summary,
+			Description: e.Description,
+			Tags:        []string{tag(route.Path)},
+			Parameters:  params,
+			Responses:   map[string]*Response{},
+		}
+		if op.Summary == "" {
+			op.Summary = words(op.OperationID)
+		}
+		// Operation IDs must be unique. Handlers with the same method name
+		// are qualified by their type; a handler mounted twice by its method.
+		if operationIDs[op.OperationID] {
+			op.OperationID = receiverName(name) + op.OperationID
+		}
+		if operationIDs[op.OperationID] {
+			op.OperationID += "_" + method
+		}
+		operationIDs[op.OperationID] = true
+		tags[op.Tags[0]] = true
+
+		if e.Public {
+			op.Security = &[]map[string][]string{}
+		} else {
+			op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/TenantID"})
+			if (route.Method == http.MethodPut || route.Method == http.MethodPatch || route.Method == http.MethodDelete) && len(params) > 0 {
+				op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/IfMatch"})
+			}
+			if route.Method == http.MethodPost {
+				op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/IdempotencyKey"})
+			}
+		}
+		if e.Query != nil {
+			op.Parameters = append(op.Parameters, listParameters(*e.Query)...)
+		}
+		if e.Request != nil {
+			content := map[string]*MediaType{jsonContent: {Schema: gen.schemaOf(e.Request)}}
+			if e.Consumes != "" {
+				content[e.Consumes] = content[jsonContent]
+			}
+			op.RequestBody = &RequestBody{Required: true, Content: content}
+		}
+
+		status := e.Status
+		if status == 0 {
+			status = http.StatusOK
+			if route.Method == http.MethodPost {
+				status = http.StatusCreated
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = successResponse(gen, e, described, metaSchema)
+		op.Responses["default"] = &Response{
+			Description: "Error",
+			Content:     map[string]*MediaType{jsonContent: {Schema: errorSchema}},
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][method] = op
+		doc.operations[route.Method+" "+route.Path] = op
+	}
+
+	for name := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: name})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	doc.Components.Schemas = gen.components
+	return doc
+}
+
+// errorBody is the error response shared by every handler
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func successResponse(gen *schemaGenerator, e Endpoint, described bool, metaSchema *Schema) *Response {
+	resp := &Response{Description: "Success"}
+	if e.Produces != "" {
+		resp.Content = map[string]*MediaType{e.Produces: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		return resp
+	}
+
+	var payload *Schema
+	switch {
+	case e.Response != nil:
+		payload = gen.schemaOf(e.Response)
+	case described:
+		// Described endpoints without a payload only return a message
+		payload = nil
+	default:
+		payload = &Schema{}
+	}
+
+	if !e.Envelope {
+		if payload == nil {
+			payload = &Schema{Type: "object", Properties: map[string]*Schema{"message": {Type: "string"}}}
+		}
+		resp.Content = map[string]*MediaType{jsonContent: {Schema: payload}}
+		return resp
+	}
+
+	envelope := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"success"},
+	}
+	if payload != nil {
+		envelope.Properties["data"] = payload
+	}
+	if e.Query != nil {
+		envelope.Properties["pagination"] = metaSchema
+	}
+	resp.Content = map[string]*MediaType{jsonContent: {Schema: envelope}}
+	return resp
+}
+
+// listParameters documents the query string accepted by package query
+func listParameters(spec query.Spec) []*Parameter {
+	params := []*Parameter{
+		{Name: "limit", In: "query", Description: "Page size", Schema: &Schema{Type: "integer", Minimum: float(1), Maximum: float(query.MaxLimit)}},
+		{Name: "offset", In: "query", Description: "Rows to skip; cannot be combined with cursor", Schema: &Schema{Type: "integer", Minimum: float(0)}},
+		{Name: "cursor", In: "query", Description: "Opaque cursor from X-Next-Cursor", Schema: &Schema{Type: "string"}},
+		{Name: "count", In: "query", Description: "Set to false to skip the total count", Schema: &Schema{Type: "boolean"}},
+	}
+	if len(spec.Sorts) > 0 {
+		params = append(params, &Parameter{
+			Name:        "sort",
+			In:          "query",
+			Description: "Comma separated sort keys, prefix with - for descending. Allowed: " + strings.Join(spec.Sorts, ", "),
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	for _, col := range spec.Filters {
+		params = append(params, &Parameter{
+			Name:        col,
+			In:          "query",
+			Description: "Filter as value or op:value; ops: eq, ne, in, nin, lt, lte, gt, gte, like, null",
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	return params
+}
+
+func float(n float64) *float64 {
+	return &n
+}
+
+// convertPath turns /a/:id/*rest into /a/{id}/{rest} and returns the path parameters
+func convertPath(path string) (string, []*Parameter) {
+	var params []*Parameter
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	out := strings.Join(segments, "/")
+	if len(out) > 1 {
+		out = strings.TrimSuffix(out, "/")
+	}
+	return out, params
+}
+
+// tag groups operations by the first path segment after /api
+func tag(path string) string {
+	segments := strings.Split(strings.TrimPrefix(path, "/api/"), "/")
+	return segments[0]
+}
+
+// funcName returns the runtime name of a function value
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return ""
+	}
+	return strings.TrimSuffix(runtime.FuncForPC(v.Pointer()).Name(), "-fm")
+}
+
+// methodName extracts "CreateRisk" from "pkg.(*Handler).CreateRisk"
+func methodName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// receiverName extracts "RiskOpsERM" from "pkg.(*RiskOpsERMHandler).CreateRisk"
+func receiverName(name string) string {
+	parts := strings.Split(name, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+	recv := parts[len(parts)-2]
+	if !strings.HasPrefix(recv, "(") {
+		return ""
+	}
+	recv = strings.Trim(recv, "(*)")
+	return strings.TrimSuffix(recv, "Handler")
+}
+
+// words turns "GetRiskStats" into "Get risk stats"
+func words(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte(' ')
+		}
+		if i > 0 && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,313 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request bodies larger than this are not validated (and not buffered)
+const maxValidatedBody = 10 << 20
+
+// FieldError is one validation failure
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validator checks JSON request bodies against a document. The document is
+// loaded after the routes are registered, so the middleware can be installed
+// first and starts validating once Load is called.
+type Validator struct {
+	mu  sync.RWMutex
+	doc *Document
+}
+
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// Load sets the document requests are validated against
+func (v *Validator) Load(doc *Document) {
+	v.mu.Lock()
+	v.doc = doc
+	v.mu.Unlock()
+}
+
+// Middleware rejects JSON bodies that do not match the operation's request
+// schema with 400. Operations without a documented body pass through.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v.mu.RLock()
+		doc := v.doc
+		v.mu.RUnlock()
+		if doc == nil || c.Request.Body == nil || c.Request.ContentLength > maxValidatedBody {
+			c.Next()
+			return
+		}
+
+		op := doc.operations[c.Request.Method+" "+c.FullPath()]
+		if op == nil || op.RequestBody == nil {
+			c.Next()
+			return
+		}
+		media := op.RequestBody.Content[jsonContent]
+		if media == nil || !strings.HasPrefix(c.ContentType(), jsonContent) {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBody))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		errs := doc.ValidateJSON(media.Schema, body)
+		if len(errs) > 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Request body does not match the API contract",
+				"details": errs,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ValidateJSON validates a JSON document against schema, resolving
+// references against the document's components
+func (d *Document) ValidateJSON(schema *Schema, body []byte) []FieldError {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return []FieldError{{Field: "", Message: "invalid JSON: " + err.Error()}}
+	}
+	var errs []FieldError
+	d.validate(schema, value, "", &errs)
+	return errs
+}
+
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+	}
+	return s
+}
+
+func (d *Document) validate(s *Schema, value interface{}, path string, errs *[]FieldError) {
+	s = d.resolve(s)
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.AnyOf) > 0 {
+		for _, alt := range s.AnyOf {
+			var altErrs []FieldError
+			d.validate(alt, value, path, &altErrs)
+			if len(altErrs) == 0 {
+				return
+			}
+		}
+		fail("does not match any allowed type")
+		return
+	}
+
+	if types := schemaTypes(s); len(types) > 0 && !matchesType(types, value) {
+		fail("must be of type %s", strings.Join(types, " or "))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, FieldError{Field: join(path, name), Message: "is required"})
+			}
+		}
+		for name, prop := range s.Properties {
+			if pv, ok := v[name]; ok {
+				d.validate(prop, pv, join(path, name), errs)
+			}
+		}
+		if s.AdditionalProperties != nil {
+			for name, pv := range v {
+				if _, known := s.Properties[name]; !known {
+					d.validate(s.AdditionalProperties, pv, join(path, name), errs)
+				}
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must contain at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if v != "" {
+			validateFormat(s.Format, v, fail)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func validateFormat(format, v string, fail func(string, ...interface{})) {
+	switch format {
+	case "email":
+		if _, err := mail.ParseAddress(v); err != nil {
+			fail("must be a valid email address")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			fail("must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func schemaTypes(s *Schema) []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func matchesType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case json.Number:
+			if t == "number" {
+				return true
+			}
+			if t == "integer" {
+				if _, err := v.Int64(); err == nil {
+					return true
+				}
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inEnum reports whether value is one of enum. Numbers are compared by
+// value, whether they were decoded as json.Number or float64 or declared as
+// any integer type.
+func inEnum(enum []interface{}, value interface{}) bool {
+	n, isNumber := numeric(value)
+	for _, e := range enum {
+		if isNumber {
+			if en, ok := numeric(e); ok && en == n {
+				return true
+			}
+			continue
+		}
+		if value == e {
+			return true
+		}
+	}
+	return false
+}
+
+// numeric returns v as a float64 when it is a number
+func numeric(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,31 @@
+package query
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// List parses the request's query string against spec and loads one page
+// into dest (a pointer to a slice). Pagination metadata is also exposed as
+// X-Total-Count / X-Next-Cursor headers for endpoints that return a bare array.
+func List(c *gin.Context, db *gorm.DB, spec Spec, dest interface{}) (*Meta, error) {
+	p, err := Parse(db, dest, spec, c.Request.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := Find(db, p, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Total != nil {
+		c.Header("X-Total-Count", strconv.FormatInt(*meta.Total, 10))
+	}
+	if meta.NextCursor != "" {
+		c.Header("X-Next-Cursor", meta.NextCursor)
+	}
+	return meta, nil
+}
@@ -0,0 +1,531 @@
+// Package query implements the shared pagination, filtering and sorting layer
+// used by every list endpoint.
+//
+// Query string syntax:
+//
+//	?status=open&severity=in:high,critical&due_date=lt:2026-12-01
+//	&sort=-risk_score,name&limit=50&offset=100
+//	&cursor=<opaque token from a previous page>
+//
+// A filter value without an operator prefix is an equality match. Supported
+// operators are eq, ne, in, nin, lt, lte, gt, gte, like and null. Only the
+// columns whitelisted in a Spec may be filtered or sorted on.
+package query
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Reserved query parameters that are never treated as filters
+var reserved = map[string]bool{
+	"limit":  true,
+	"offset": true,
+	"cursor": true,
+	"sort":   true,
+	"count":  true,
+}
+
+// Spec whitelists the columns a list endpoint accepts for filtering and sorting.
+type Spec struct {
+	Filters     []string // column names allowed as filters
+	Sorts       []string // column names allowed in ?sort=
+	DefaultSort string   // e.g. "-created_at"
+}
+
+func (s Spec) allowsFilter(column string) bool {
+	return contains(s.Filters, column)
+}
+
+func (s Spec) allowsSort(column string) bool {
+	return contains(s.Sorts, column) || column == "id"
+}
+
+// Filter is a single parsed filter condition
+type Filter struct {
+	Column string
+	Op     string
+	Values []interface{}
+}
+
+// Sort is a single parsed sort key
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Params is the parsed representation of a list request
+type Params struct {
+	Filters   []Filter
+	Sorts     []Sort
+	Limit     int
+	Offset    int
+	Cursor    []interface{}
+	WithTotal bool
+
+	schema *schema.Schema
+}
+
+// Meta describes the page that was returned
+type Meta struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Total      *int64 `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Error is returned for malformed or non-whitelisted query parameters.
+// Handlers should map it to 400 Bad Request.
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// IsInvalid reports whether err was caused by bad query parameters
+func IsInvalid(err error) bool {
+	var qe *Error
+	return errors.As(err, &qe)
+}
+
+var schemaCache = &sync.Map{}
+
+// Schema parses the GORM schema for a model, slice or pointer to slice
+func Schema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	return schema.Parse(model, schemaCache, db.NamingStrategy)
+}
+
+// Parse validates the query string against spec and the model's schema
+func Parse(db *gorm.DB, model interface{}, spec Spec, values url.Values) (*Params, error) {
+	sch, err := Schema(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Params{Limit: DefaultLimit, WithTotal: true, schema: sch}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, invalid("limit must be a positive integer")
+		}
+		if n > MaxLimit {
+			n = MaxLimit
+		}
+		p.Limit = n
+	}
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, invalid("offset must be a non-negative integer")
+		}
+		p.Offset = n
+	}
+	if v := values.Get("count"); v != "" {
+		p.WithTotal = v != "false" && v != "0"
+	}
+
+	sortExpr := values.Get("sort")
+	if sortExpr == "" {
+		sortExpr = spec.DefaultSort
+	}
+	if sortExpr == "" {
+		sortExpr = "-created_at"
+	}
+	for _, key := range strings.Split(sortExpr, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		s := Sort{Column: key}
+		if strings.HasPrefix(key, "-") {
+			s.Desc = true
+			s.Column = key[1:]
+		} else if strings.HasPrefix(key, "+") {
+			s.Column = key[1:]
+		}
+		if !spec.allowsSort(s.Column) || sch.LookUpField(s.Column) == nil {
+			return nil, invalid("sorting by %q is not allowed", s.Column)
+		}
+		p.Sorts = append(p.Sorts, s)
+	}
+
+	for key, raws := range values {
+		if reserved[key] {
+			continue
+		}
+		field := sch.LookUpField(key)
+		if !spec.allowsFilter(key) || field == nil || field.DBName == "" {
+			return nil, invalid("filtering by %q is not allowed", key)
+		}
+		for _, raw := range raws {
+			f, err := parseFilter(field, raw)
+			if err != nil {
+				return nil, err
+			}
+			p.Filters = append(p.Filters, f)
+		}
+	}
+
+	if v := values.Get("cursor"); v != "" {
+		if p.Offset > 0 {
+			return nil, invalid("cursor and offset cannot be combined")
+		}
+		cursor, err := decodeCursor(p, v)
+		if err != nil {
+			return nil, err
+		}
+		p.Cursor = cursor
+	}
+
+	return p, nil
+}
+
+func parseFilter(field *schema.Field, raw string) (Filter, error) {
+	f := Filter{Column: field.DBName, Op: "eq"}
+	value := raw
+	if i := strings.Index(raw, ":"); i > 0 {
+		if op := raw[:i]; isOperator(op) {
+			f.Op = op
+			value = raw[i+1:]
+		}
+	}
+
+	switch f.Op {
+	case "in", "nin":
+		for _, part := range strings.Split(value, ",") {
+			v, err := convert(field, strings.TrimSpace(part))
+			if err != nil {
+				return f, err
+			}
+			f.Values = append(f.Values, v)
+		}
+	case "like":
+		f.Values = []interface{}{"%" + value + "%"}
+	case "null":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, invalid("%s: null expects true or false", field.DBName)
+		}
+		f.Values = []interface{}{b}
+	default:
+		v, err := convert(field, value)
+		if err != nil {
+			return f, err
+		}
+		f.Values = []interface{}{v}
+	}
+	return f, nil
+}
+
+func isOperator(op string) bool {
+	switch op {
+	case "eq", "ne", "in", "nin", "lt", "lte", "gt", "gte", "like", "null":
+		return true
+	}
+	return false
+}
+
+// convert parses a raw string into the Go type of the model field
+func convert(field *schema.Field, raw string) (interface{}, error) {
+	t := field.FieldType
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) || t == reflect.TypeOf(gorm.DeletedAt{}) {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if ts, err := time.Parse(layout, raw); err == nil {
+				return ts, nil
+			}
+		}
+		return nil, invalid("%s: %q is not a valid date", field.DBName, raw)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, invalid("%s: %q is not a valid integer", field.DBName, raw)
+		}
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalid("%s: %q is not a valid number", field.DBName, raw)
+		}
+		return n, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalid("%s: %q is not a valid boolean", field.DBName, raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// Scope applies the filters of p to db without ordering or pagination.
+// It is shared by list and export endpoints so both honor the same filters.
+func (p *Params) Scope(db *gorm.DB) *gorm.DB {
+	for _, f := range p.Filters {
+		col := quote(f.Column)
+		switch f.Op {
+		case "eq":
+			db = db.Where(col+" = ?", f.Values[0])
+		case "ne":
+			db = db.Where(col+" <> ?", f.Values[0])
+		case "lt":
+			db = db.Where(col+" < ?", f.Values[0])
+		case "lte":
+			db = db.Where(col+" <= ?", f.Values[0])
+		case "gt":
+			db = db.Where(col+" > ?", f.Values[0])
+		case "gte":
+			db = db.Where(col+" >= ?", f.Values[0])
+		case "in":
+			db = db.Where(col+" IN ?", f.Values)
+		case "nin":
+			db = db.Where(col+" NOT IN ?", f.Values)
+		case "like":
+			db = db.Where(col+" ILIKE ?", f.Values[0])
+		case "null":
+			if f.Values[0].(bool) {
+				db = db.Where(col + " IS NULL")
+			} else {
+				db = db.Where(col + " IS NOT NULL")
+			}
+		}
+	}
+	return db
+}
+
+// Order applies the sort keys of p, with id as the final tie-breaker
+func (p *Params) Order(db *gorm.DB) *gorm.DB {
+	for _, s := range p.orderKeys() {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		db = db.Order(quote(s.Column) + " " + dir)
+	}
+	return db
+}
+
+func (p *Params) orderKeys() []Sort {
+	keys := append([]Sort{}, p.Sorts...)
+	for _, s := range keys {
+		if s.Column == "id" {
+			return keys
+		}
+	}
+	return append(keys, Sort{Column: "id"})
+}
+
+// Find runs the paginated query. db must already be scoped to the tenant
+// and to the model, e.g. db.Model(&models.RiskRegister{}).Where("tenant_id = ?", id).
+func Find(db *gorm.DB, p *Params, dest interface{}) (*Meta, error) {
+	db = p.Scope(db)
+	meta := &Meta{Limit: p.Limit, Offset: p.Offset}
+
+	if p.WithTotal {
+		var total int64
+		if err := db.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		meta.Total = &total
+	}
+
+	page := p.Order(db.Session(&gorm.Session{}))
+	if p.Cursor != nil {
+		cond := p.keysetCondition()
+		page = page.Where(cond.SQL, cond.Args...)
+	} else if p.Offset > 0 {
+		page = page.Offset(p.Offset)
+	}
+
+	if err := page.Limit(p.Limit + 1).Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > p.Limit {
+		meta.HasMore = true
+		rows.Set(rows.Slice(0, p.Limit))
+	}
+	if meta.HasMore {
+		cursor, err := p.encodeCursor(rows.Index(rows.Len() - 1))
+		if err != nil {
+			return nil, err
+		}
+		meta.NextCursor = cursor
+	}
+	return meta, nil
+}
+
+// keysetCondition builds the "row comes after the cursor" predicate for
+// the current ordering. Postgres sorts NULLs last for ASC and first for DESC.
+func (p *Params) keysetCondition() clauseExpr {
+	keys := p.orderKeys()
+	var ors []string
+	var args []interface{}
+	var eqSQL []string
+	var eqArgs []interface{}
+
+	for i, s := range keys {
+		col := quote(s.Column)
+		v := p.Cursor[i]
+
+		var after string
+		var afterArgs []interface{}
+		var equal string
+		var equalArgs []interface{}
+
+		switch {
+		case v == nil && !s.Desc:
+			after = "FALSE"
+			equal = col + " IS NULL"
+		case v == nil && s.Desc:
+			after = col + " IS NOT NULL"
+			equal = col + " IS NULL"
+		case !s.Desc:
+			after = "(" + col + " > ? OR " + col + " IS NULL)"
+			afterArgs = []interface{}{v}
+			equal = col + " = ?"
+			equalArgs = []interface{}{v}
+		default:
+			after = col + " < ?"
+			afterArgs = []interface{}{v}
+			equal = col + " = ?"
+			equalArgs = []interface{}{v}
+		}
+
+		term := append(append([]string{}, eqSQL...), after)
+		ors = append(ors, "("+strings.Join(term, " AND ")+")")
+		args = append(append(args, eqArgs...), afterArgs...)
+
+		eqSQL = append(eqSQL, equal)
+		eqArgs = append(eqArgs, equalArgs...)
+	}
+
+	return clauseExpr{SQL: "(" + strings.Join(ors, " OR ") + ")", Args: args}
+}
+
+type clauseExpr struct {
+	SQL  string
+	Args []interface{}
+}
+
+func (p *Params) encodeCursor(row reflect.Value) (string, error) {
+	keys := p.orderKeys()
+	values := make([]*string, len(keys))
+	for i, s := range keys {
+		field := p.schema.LookUpField(s.Column)
+		v, zero := field.ValueOf(context.Background(), reflect.Indirect(row))
+		if rv := reflect.ValueOf(v); zero && rv.Kind() == reflect.Ptr && rv.IsNil() {
+			continue
+		}
+		str := formatValue(v)
+		values[i] = &str
+	}
+	payload, err := json.Marshal(cursorPayload{Sort: p.sortSignature(), Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func decodeCursor(p *Params, token string) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid("cursor is malformed")
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, invalid("cursor is malformed")
+	}
+	keys := p.orderKeys()
+	if payload.Sort != p.sortSignature() || len(payload.Values) != len(keys) {
+		return nil, invalid("cursor does not match the requested sort order")
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, s := range keys {
+		if payload.Values[i] == nil {
+			continue
+		}
+		v, err := convert(p.schema.LookUpField(s.Column), *payload.Values[i])
+		if err != nil {
+			return nil, invalid("cursor is malformed")
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+type cursorPayload struct {
+	Sort   string    `json:"s"`
+	Values []*string `json:"v"`
+}
+
+func (p *Params) sortSignature() string {
+	parts := make([]string, 0, len(p.Sorts))
+	for _, s := range p.orderKeys() {
+		if s.Desc {
+			parts = append(parts, "-"+s.Column)
+		} else {
+			parts = append(parts, s.Column)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case gorm.DeletedAt:
+		return t.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Interface())
+}
+
+func quote(column string) string {
+	return `"` + strings.ReplaceAll(column, `"`, `""`) + `"`
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+package query
+
+import (
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type testRecord struct {
+	ID        string
+	Name      string
+	Score     *int
+	DueDate   *time.Time
+	CreatedAt time.Time
+}
+
+var testSpec = Spec{
+	Filters:     []string{"name", "score", "due_date"},
+	Sorts:       []string{"name", "score", "due_date", "created_at"},
+	DefaultSort: "-created_at",
+}
+
+// testDB is enough of a *gorm.DB for Parse, which only reads the naming strategy
+var testDB = &gorm.DB{Config: &gorm.Config{NamingStrategy: schema.NamingStrategy{}}}
+
+func parse(t *testing.T, query string) (*Params, error) {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(testDB, &testRecord{}, testSpec, values)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantErr   bool
+		wantLimit int
+		wantSorts []Sort
+	}{
+		{"", false, DefaultLimit, []Sort{{Column: "created_at", Desc: true}}},
+		{"limit=1", false, 1, nil},
+		{"limit=500", false, MaxLimit, nil},
+		{"limit=501", false, MaxLimit, nil},
+		{"limit=0", true, 0, nil},
+		{"limit=-5", true, 0, nil},
+		{"limit=ten", true, 0, nil},
+		{"offset=-1", true, 0, nil},
+		{"sort=-score,+name", false, DefaultLimit, []Sort{{Column: "score", Desc: true}, {Column: "name"}}},
+		{"sort=secret", true, 0, nil},
+		{"name=audit", false, DefaultLimit, nil},
+		{"status=open", true, 0, nil},
+		{"created_at=2024-01-01", true, 0, nil},
+		{"score=gt:ten", true, 0, nil},
+		{"due_date=lt:tomorrow", true, 0, nil},
+		{"name=null:maybe", true, 0, nil},
+		{"cursor=abc&offset=10", true, 0, nil},
+		{"cursor=!!!", true, 0, nil},
+	}
+	for _, tt := range tests {
+		p, err := parse(t, tt.query)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			if !IsInvalid(err) {
+				t.Errorf("Parse(%q) error %v is not a 400", tt.query, err)
+			}
+			continue
+		}
+		if p.Limit != tt.wantLimit {
+			t.Errorf("Parse(%q) limit = %d, want %d", tt.query, p.Limit, tt.wantLimit)
+		}
+		if tt.wantSorts != nil && !reflect.DeepEqual(p.Sorts, tt.wantSorts) {
+			t.Errorf("Parse(%q) sorts = %+v, want %+v", tt.query, p.Sorts, tt.wantSorts)
+		}
+	}
+}
+
+func TestParseFilters(t *testing.T) {
+	due := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query string
+		want  Filter
+	}{
+		{"name=audit", Filter{Column: "name", Op: "eq", Values: []interface{}{"audit"}}},
+		{"name=like:aud", Filter{Column: "name", Op: "like", Values: []interface{}{"%aud%"}}},
+		{"name=a:b", Filter{Column: "name", Op: "eq", Values: []interface{}{"a:b"}}},
+		{"score=in:1,2", Filter{Column: "score", Op: "in", Values: []interface{}{int64(1), int64(2)}}},
+		{"score=gte:7", Filter{Column: "score", Op: "gte", Values: []interface{}{int64(7)}}},
+		{"due_date=lt:2026-12-01", Filter{Column: "due_date", Op: "lt", Values: []interface{}{due}}},
+		{"due_date=null:true", Filter{Column: "due_date", Op: "null", Values: []interface{}{true}}},
+	}
+	for _, tt := range tests {
+		p, err := parse(t, tt.query)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.query, err)
+			continue
+		}
+		if len(p.Filters) != 1 || !reflect.DeepEqual(p.Filters[0], tt.want) {
+			t.Errorf("Parse(%q) filters = %+v, want %+v", tt.query, p.Filters, tt.want)
+		}
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorts    []Sort
+		cursor   []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			"ascending",
+			[]Sort{{Column: "score"}},
+			[]interface{}{int64(5), "r1"},
+			`((("score" > ? OR "score" IS NULL)) OR ("score" = ? AND ("id" > ? OR "id" IS NULL)))`,
+			[]interface{}{int64(5), int64(5), "r1"},
+		},
+		{
+			"descending",
+			[]Sort{{Column: "score", Desc: true}},
+			[]interface{}{int64(5), "r1"},
+			`(("score" < ?) OR ("score" = ? AND ("id" > ? OR "id" IS NULL)))`,
+			[]interface{}{int64(5), int64(5), "r1"},
+		},
+		{
+			// NULLs sort last ascending, so only rows tied on NULL come after
+			"ascending after null",
+			[]Sort{{Column: "score"}},
+			[]interface{}{nil, "r1"},
+			`((FALSE) OR ("score" IS NULL AND ("id" > ? OR "id" IS NULL)))`,
+			[]interface{}{"r1"},
+		},
+		{
+			// NULLs sort first descending, so every non-NULL row comes after
+			"descending after null",
+			[]Sort{{Column: "score", Desc: true}},
+			[]interface{}{nil, "r1"},
+			`(("score" IS NOT NULL) OR ("score" IS NULL AND ("id" > ? OR "id" IS NULL)))`,
+			[]interface{}{"r1"},
+		},
+		{
+			"id only",
+			[]Sort{{Column: "id", Desc: true}},
+			[]interface{}{"r1"},
+			`(("id" < ?))`,
+			[]interface{}{"r1"},
+		},
+	}
+	for _, tt := range tests {
+		p := &Params{Sorts: tt.sorts, Cursor: tt.cursor}
+		got := p.keysetCondition()
+		if got.SQL != tt.wantSQL {
+			t.Errorf("%s: SQL = %s, want %s", tt.name, got.SQL, tt.wantSQL)
+		}
+		if !reflect.DeepEqual(got.Args, tt.wantArgs) {
+			t.Errorf("%s: args = %v, want %v", tt.name, got.Args, tt.wantArgs)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	score := 42
+	due := time.Date(2026, 3, 1, 9, 30, 0, 500, time.FixedZone("WIB", 7*60*60))
+	tests := []struct {
+		name string
+		sort string
+		row  testRecord
+		want []interface{}
+	}{
+		{"string", "name", testRecord{ID: "r1", Name: "Access review"}, []interface{}{"Access review", "r1"}},
+		{"integer", "-score", testRecord{ID: "r1", Score: &score}, []interface{}{int64(42), "r1"}},
+		{"null", "-score", testRecord{ID: "r1"}, []interface{}{nil, "r1"}},
+		{"time", "due_date", testRecord{ID: "r1", DueDate: &due}, []interface{}{due.UTC(), "r1"}},
+		{"id", "-id", testRecord{ID: "r1"}, []interface{}{"r1"}},
+	}
+	for _, tt := range tests {
+		p, err := parse(t, "sort="+tt.sort)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		token, err := p.encodeCursor(reflect.ValueOf(tt.row))
+		if err != nil {
+			t.Errorf("%s: encodeCursor error = %v", tt.name, err)
+			continue
+		}
+		next, err := parse(t, "sort="+tt.sort+"&cursor="+token)
+		if err != nil {
+			t.Errorf("%s: cursor rejected: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(next.Cursor, tt.want) {
+			t.Errorf("%s: cursor = %#v, want %#v", tt.name, next.Cursor, tt.want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	p, err := parse(t, "sort=-score")
+	if err != nil {
+		t.Fatal(err)
+	}
+	score := 42
+	token, err := p.encodeCursor(reflect.ValueOf(testRecord{ID: "r1", Score: &score}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"not base64", "sort=-score&cursor=" + token + "*"},
+		{"not json", "sort=-score&cursor=bm90IGpzb24"},
+		{"other sort", "sort=score&cursor=" + token},
+		{"extra value", "sort=-score&cursor=" + encode(`{"s":"-score,id","v":["1","r1","x"]}`)},
+		{"sort rewritten", "sort=-score&cursor=" + encode(`{"s":"score,id","v":["1","r1"]}`)},
+		{"value of the wrong type", "sort=-score&cursor=" + encode(`{"s":"-score,id","v":["high","r1"]}`)},
+	}
+	for _, tt := range tests {
+		_, err := parse(t, tt.query)
+		if err == nil {
+			t.Errorf("%s: cursor accepted", tt.name)
+			continue
+		}
+		if !IsInvalid(err) {
+			t.Errorf("%s: error %v is not a 400", tt.name, err)
+		}
+		if !strings.Contains(err.Error(), "cursor") {
+			t.Errorf("%s: error %q does not mention the cursor", tt.name, err)
+		}
+	}
+}
+
+func encode(payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Budgets for expensive routes, shared by the whole tenant
+const (
+	BudgetAI               = "ai"
+	BudgetDocumentAnalysis = "document_analysis"
+)
+
+// Policy holds the limits of a tenant
+type Policy struct {
+	Plan    string
+	Tenant  Limit // all requests of the tenant
+	User    Limit // requests of one user
+	Budgets map[string]Limit
+}
+
+// Plans are the default policies by Subscription.PlanType. Tenants without
+// an active subscription get basic.
+var Plans = map[string]Policy{
+	"basic": {
+		Tenant: Limit{Requests: 600, Period: time.Minute},
+		User:   Limit{Requests: 120, Period: time.Minute},
+		Budgets: map[string]Limit{
+			BudgetAI:               {Requests: 100, Period: 24 * time.Hour, Burst: 20},
+			BudgetDocumentAnalysis: {Requests: 20, Period: 24 * time.Hour, Burst: 5},
+		},
+	},
+	"pro": {
+		Tenant: Limit{Requests: 3000, Period: time.Minute},
+		User:   Limit{Requests: 300, Period: time.Minute},
+		Budgets: map[string]Limit{
+			BudgetAI:               {Requests: 1000, Period: 24 * time.Hour, Burst: 50},
+			BudgetDocumentAnalysis: {Requests: 200, Period: 24 * time.Hour, Burst: 20},
+		},
+	},
+	"enterprise": {
+		Tenant: Limit{Requests: 12000, Period: time.Minute},
+		User:   Limit{Requests: 1200, Period: time.Minute},
+		Budgets: map[string]Limit{
+			BudgetAI:               {Requests: 10000, Period: 24 * time.Hour, Burst: 200},
+			BudgetDocumentAnalysis: {Requests: 2000, Period: 24 * time.Hour, Burst: 100},
+		},
+	},
+}
+
+const defaultPlan = "basic"
+
+// How long a tenant's policy is cached before it is read again
+const policyTTL = time.Minute
+
+// limitOverride is a limit in the "rate_limits" object of
+// Subscription.Limits or License.Limits, e.g.
+//
+//	{"rate_limits": {"user": {"requests": 500, "period": "1m"},
+//	                 "ai": {"requests": 300, "period": "24h", "burst": 30}}}
+//
+// The keys are tenant, user and the budget names. Omitted fields
+// keep the plan's value.
+type limitOverride struct {
+	Requests *int   `json:"requests"`
+	Period   string `json:"period"`
+	Burst    *int   `json:"burst"`
+}
+
+// Policies resolves the policy of each tenant from its subscription and
+// license
+type Policies struct {
+	db *gorm.DB
+
+	mu     sync.Mutex
+	cached map[string]cachedPolicy
+}
+
+type cachedPolicy struct {
+	policy  Policy
+	expires time.Time
+}
+
+func NewPolicies(db *gorm.DB) *Policies {
+	return &Policies{db: db, cached: map[string]cachedPolicy{}}
+}
+
+// For returns the policy of a tenant
+func (p *Policies) For(tenantID string) Policy {
+	p.mu.Lock()
+	cached, ok := p.cached[tenantID]
+	p.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.policy
+	}
+
+	policy := p.load(tenantID)
+	p.mu.Lock()
+	p.cached[tenantID] = cachedPolicy{policy: policy, expires: time.Now().Add(policyTTL)}
+	p.mu.Unlock()
+	return policy
+}
+
+func (p *Policies) load(tenantID string) Policy {
+	var sub models.Subscription
+	hasSub := p.db.Where("tenant_id = ? AND status = ? AND is_deleted = ?", tenantID, "active", false).
+		Order("start_date DESC").First(&sub).Error == nil
+
+	plan := defaultPlan
+	if hasSub {
+		if _, ok := Plans[sub.PlanType]; ok {
+			plan = sub.PlanType
+		}
+	}
+	policy := clonePolicy(plan)
+
+	if hasSub {
+		applyOverrides(&policy, sub.Limits)
+	}
+	// A license can grant more than the plan, e.g. for an on-premise deal
+	var license models.License
+	if p.db.Where("tenant_id = ? AND status = ? AND is_deleted = ? AND end_date > ?", tenantID, "active", false, time.Now()).
+		Order("end_date DESC").First(&license).Error == nil {
+		applyOverrides(&policy, license.Limits)
+	}
+	return policy
+}
+
+func clonePolicy(plan string) Policy {
+	base := Plans[plan]
+	policy := base
+	policy.Plan = plan
+	policy.Budgets = make(map[string]Limit, len(base.Budgets))
+	for name, limit := range base.Budgets {
+		policy.Budgets[name] = limit
+	}
+	return policy
+}
+
+func applyOverrides(policy *Policy, limitsJSON string) {
+	if limitsJSON == "" {
+		return
+	}
+	var limits struct {
+		RateLimits map[string]limitOverride `json:"rate_limits"`
+	}
+	if err := json.Unmarshal([]byte(limitsJSON), &limits); err != nil {
+		log.Printf("Ignoring invalid limits JSON: %v", err)
+		return
+	}
+	for name, o := range limits.RateLimits {
+		switch name {
+		case "tenant":
+			policy.Tenant = o.apply(policy.Tenant)
+		case "user":
+			policy.User = o.apply(policy.User)
+		default:
+			policy.Budgets[name] = o.apply(policy.Budgets[name])
+		}
+	}
+}
+
+func (o limitOverride) apply(limit Limit) Limit {
+	if o.Requests != nil {
+		limit.Requests = *o.Requests
+	}
+	if o.Burst != nil {
+		limit.Burst = *o.Burst
+	}
+	if o.Period != "" {
+		if d, err := time.ParseDuration(o.Period); err == nil {
+			limit.Period = d
+		}
+	}
+	return limit
+}
@@ -0,0 +1,161 @@
+// Package ratelimit implements token bucket rate limits. Buckets live in
+// Redis when it is configured, so every API replica shares them; otherwise
+// each process keeps its own.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cyber/backend/internal/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Limit is a token bucket: Requests tokens are added every Period, up to
+// Burst. A request takes one token.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int // defaults to Requests
+}
+
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate returns the tokens added per millisecond
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Period.Milliseconds())
+}
+
+// Enabled reports whether the limit is set. A zero limit means unlimited.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Result is the state of a bucket after taking a token
+type Result struct {
+	Limit      Limit
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until a token is available; zero when allowed
+}
+
+// Limiter takes tokens from buckets
+type Limiter struct {
+	redis *cache.RedisClient
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter returns a limiter. redis may be nil to keep buckets in memory.
+func NewLimiter(redis *cache.RedisClient) *Limiter {
+	return &Limiter{redis: redis, buckets: map[string]*bucket{}}
+}
+
+// Allow takes a token from the bucket key, which refills at limit
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	var tokens float64
+	var allowed bool
+	var err error
+	if l.redis != nil {
+		tokens, allowed, err = l.takeRedis(ctx, key, limit)
+	} else {
+		tokens, allowed = l.takeLocal(key, limit)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	rate := limit.rate()
+	res := Result{
+		Limit:     limit,
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.burst())-tokens)/rate) * time.Millisecond,
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1-tokens)/rate) * time.Millisecond
+	}
+	return res, nil
+}
+
+// takeBucket refills a bucket stored as (tokens, updated at in ms) and takes
+// a token if there is one. It returns the tokens left.
+var takeBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+func (l *Limiter) takeRedis(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	res, err := l.redis.RunScript(ctx, takeBucket, []string{"ratelimit:" + key},
+		limit.rate(), limit.burst(), time.Now().UnixMilli()).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	allowed, _ := res[0].(int64)
+	s, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(s, 64)
+	return tokens, allowed == 1, err
+}
+
+// Buckets kept in memory before full ones are dropped
+const maxLocalBuckets = 10000
+
+func (l *Limiter) takeLocal(key string, limit Limit) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxLocalBuckets {
+			l.dropFull(now, limit)
+		}
+		b = &bucket{tokens: float64(limit.burst()), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.burst()), b.tokens+float64(now.Sub(b.updated).Milliseconds())*limit.rate())
+	b.updated = now
+	if b.tokens < 1 {
+		return b.tokens, false
+	}
+	b.tokens--
+	return b.tokens, true
+}
+
+// dropFull forgets buckets that have been idle long enough to refill, which
+// behave like new ones
+func (l *Limiter) dropFull(now time.Time, limit Limit) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > limit.Period {
+			delete(l.buckets, key)
+		}
+	}
+}
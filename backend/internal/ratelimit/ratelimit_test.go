@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		limit       Limit
+		wantEnabled bool
+		wantBurst   int
+	}{
+		{Limit{Requests: 10, Period: time.Minute}, true, 10},
+		{Limit{Requests: 10, Period: time.Minute, Burst: 25}, true, 25},
+		{Limit{}, false, 0},
+		{Limit{Requests: 10}, false, 10},
+		{Limit{Period: time.Minute}, false, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.Enabled(); got != tt.wantEnabled {
+			t.Errorf("%+v.Enabled() = %v, want %v", tt.limit, got, tt.wantEnabled)
+		}
+		if got := tt.limit.burst(); got != tt.wantBurst {
+			t.Errorf("%+v.burst() = %d, want %d", tt.limit, got, tt.wantBurst)
+		}
+	}
+}
+
+func TestAllow(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+	l := NewLimiter(nil)
+
+	tests := []struct {
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{true, 2},
+		{true, 1},
+		{true, 0},
+		{false, 0},
+	}
+	for i, tt := range tests {
+		res, err := l.Allow(ctx, "tenant-1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining {
+			t.Errorf("request %d: allowed %v, remaining %d, want %v, %d", i+1, res.Allowed, res.Remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+		if res.Allowed && res.RetryAfter != 0 {
+			t.Errorf("request %d: retry after %s when allowed", i+1, res.RetryAfter)
+		}
+		if !res.Allowed && (res.RetryAfter <= 0 || res.RetryAfter > time.Second) {
+			t.Errorf("request %d: retry after %s, want up to a second", i+1, res.RetryAfter)
+		}
+		if res.Reset <= 0 || res.Reset > 3*time.Second {
+			t.Errorf("request %d: reset %s, want up to 3s", i+1, res.Reset)
+		}
+	}
+
+	if res, _ := l.Allow(ctx, "tenant-2", limit); !res.Allowed || res.Remaining != 2 {
+		t.Errorf("other key: allowed %v, remaining %d, want a full bucket", res.Allowed, res.Remaining)
+	}
+}
+
+func TestAllowRefills(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+	tests := []struct {
+		idle          time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{0, false, 0},
+		{500 * time.Millisecond, false, 0},
+		{time.Second, true, 0},
+		{2 * time.Second, true, 1},
+		{time.Hour, true, 2}, // never above the burst
+	}
+	for _, tt := range tests {
+		l := NewLimiter(nil)
+		for i := 0; i < limit.Burst; i++ {
+			l.Allow(ctx, "key", limit)
+		}
+		l.buckets["key"].updated = l.buckets["key"].updated.Add(-tt.idle)
+		res, err := l.Allow(ctx, "key", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining {
+			t.Errorf("after %s: allowed %v, remaining %d, want %v, %d", tt.idle, res.Allowed, res.Remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+	}
+}
+
+func TestDropFull(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	l := NewLimiter(nil)
+	now := time.Now()
+	l.buckets["idle"] = &bucket{updated: now.Add(-2 * time.Minute)}
+	l.buckets["recent"] = &bucket{updated: now.Add(-time.Second)}
+	l.dropFull(now, limit)
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("idle bucket kept")
+	}
+	if _, ok := l.buckets["recent"]; !ok {
+		t.Error("recent bucket dropped")
+	}
+}
@@ -0,0 +1,188 @@
+package realtime
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// RegisterCallbacks publishes entity.created, entity.updated and
+// entity.deleted for writes to the registered tenant resources, and
+// task.assigned when a record is created with or given an assignee.
+// Receivers need the view permission of the resource. Writes through
+// Model(&T{}).Where(...) carry no tenant and are not announced; load the
+// record first.
+//
+// Writes inside an explicit transaction are announced when the statement
+// succeeds, before the commit. A rolled back write may therefore be
+// announced; clients only refetch, so they see the unchanged record.
+func RegisterCallbacks(db *gorm.DB, hub *Hub) error {
+	n := &notifier{hub: hub, resources: map[reflect.Type]models.Resource{}}
+	for _, r := range models.TenantResources {
+		n.resources[reflect.TypeOf(r.New()).Elem()] = r
+	}
+
+	if err := db.Callback().Create().After("gorm:commit_or_rollback_transaction").
+		Register("realtime:after_create", n.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:commit_or_rollback_transaction").
+		Register("realtime:after_update", n.afterUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:commit_or_rollback_transaction").
+		Register("realtime:after_delete", n.afterDelete)
+}
+
+type notifier struct {
+	hub       *Hub
+	resources map[reflect.Type]models.Resource
+}
+
+// resource returns the resource written by the statement, if it is one
+func (n *notifier) resource(db *gorm.DB) (models.Resource, bool) {
+	if db.Error != nil || db.RowsAffected == 0 || db.Statement.Schema == nil {
+		return models.Resource{}, false
+	}
+	r, ok := n.resources[db.Statement.Schema.ModelType]
+	return r, ok
+}
+
+func (n *notifier) afterCreate(db *gorm.DB) {
+	resource, ok := n.resource(db)
+	if !ok {
+		return
+	}
+	records := statementRecords(db)
+	if len(records) == 0 {
+		return
+	}
+	tenantID := stringField(db, records[0], "TenantID")
+	if tenantID == "" {
+		return
+	}
+
+	msg := Message{
+		Type:       EntityCreated,
+		TenantID:   tenantID,
+		Resource:   resource.Name,
+		Permission: resource.Permission("view"),
+	}
+	if len(records) == 1 {
+		msg.ResourceID = stringField(db, records[0], "ID")
+	} else {
+		// Batch inserts such as imports are announced once
+		msg.Data = mustJSON(map[string]int{"count": len(records)})
+	}
+	n.hub.Publish(msg)
+
+	for _, record := range records {
+		if assignee := stringField(db, record, "AssignedTo"); assignee != "" {
+			n.publishAssigned(resource, tenantID, stringField(db, record, "ID"), assignee)
+		}
+	}
+}
+
+func (n *notifier) afterUpdate(db *gorm.DB) {
+	resource, ok := n.resource(db)
+	if !ok {
+		return
+	}
+	records := statementRecords(db)
+	if len(records) != 1 {
+		return
+	}
+	tenantID := stringField(db, records[0], "TenantID")
+	id := stringField(db, records[0], "ID")
+	if tenantID == "" {
+		return
+	}
+
+	updates, _ := db.Statement.Dest.(map[string]interface{})
+	msgType := EntityUpdated
+	if deleted, _ := updates["is_deleted"].(bool); deleted {
+		msgType = EntityDeleted
+	}
+	n.hub.Publish(Message{
+		Type:       msgType,
+		TenantID:   tenantID,
+		Resource:   resource.Name,
+		ResourceID: id,
+		Permission: resource.Permission("view"),
+	})
+
+	if assignee, _ := updates["assigned_to"].(string); assignee != "" {
+		n.publishAssigned(resource, tenantID, id, assignee)
+	}
+}
+
+func (n *notifier) afterDelete(db *gorm.DB) {
+	resource, ok := n.resource(db)
+	if !ok {
+		return
+	}
+	records := statementRecords(db)
+	if len(records) != 1 {
+		return
+	}
+	tenantID := stringField(db, records[0], "TenantID")
+	if tenantID == "" {
+		return
+	}
+	n.hub.Publish(Message{
+		Type:       EntityDeleted,
+		TenantID:   tenantID,
+		Resource:   resource.Name,
+		ResourceID: stringField(db, records[0], "ID"),
+		Permission: resource.Permission("view"),
+	})
+}
+
+func (n *notifier) publishAssigned(resource models.Resource, tenantID, id, assignee string) {
+	n.hub.Publish(Message{
+		Type:       TaskAssigned,
+		TenantID:   tenantID,
+		Resource:   resource.Name,
+		ResourceID: id,
+		UserID:     assignee,
+		Permission: resource.Permission("view"),
+	})
+}
+
+// statementRecords returns the structs the statement wrote
+func statementRecords(db *gorm.DB) []reflect.Value {
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice, reflect.Array:
+		records := make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			records = append(records, reflect.Indirect(rv.Index(i)))
+		}
+		return records
+	}
+	return nil
+}
+
+// stringField reads a string field of record, or "" when the model has no
+// such field or it is empty
+func stringField(db *gorm.DB, record reflect.Value, name string) string {
+	field := db.Statement.Schema.LookUpField(name)
+	if field == nil {
+		return ""
+	}
+	value, zero := field.ValueOf(db.Statement.Context, record)
+	if zero {
+		return ""
+	}
+	s, _ := value.(string)
+	return s
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	raw, _ := json.Marshal(v)
+	return raw
+}
@@ -0,0 +1,169 @@
+// Package realtime pushes change notifications to connected users over
+// Server-Sent Events. When Redis is configured, messages go through Redis
+// pub/sub so every API replica delivers them to its own connections;
+// otherwise they only reach connections on this process.
+//
+// Notifications say what changed, not the new state: clients refetch the
+// records or stats they display.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cyber/backend/internal/cache"
+	"github.com/cyber/backend/internal/models"
+)
+
+// Message types
+const (
+	EntityCreated = "entity.created"
+	EntityUpdated = "entity.updated"
+	EntityDeleted = "entity.deleted"
+	TaskAssigned  = "task.assigned"
+	JobProgress   = "job.progress"
+	ReminderSent  = "reminder.sent"
+	Notification  = "notification"
+)
+
+// Redis pub/sub channel shared by all replicas
+const channel = "komplai:realtime"
+
+// Messages buffered per connection. A connection that falls this far behind
+// loses messages rather than holding up the others.
+const clientBuffer = 64
+
+// Message is a notification for the users of a tenant
+type Message struct {
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id"`
+	Resource   string          `json:"resource,omitempty"` // see models.TenantResources
+	ResourceID string          `json:"resource_id,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	At         time.Time       `json:"at"`
+
+	// UserID limits the message to one user. Optional.
+	UserID string `json:"user_id,omitempty"`
+	// Permission is required to receive the message. Optional.
+	Permission string `json:"permission,omitempty"`
+}
+
+// Client is one open stream
+type Client struct {
+	tenantID string
+	userID   string
+	role     string
+	messages chan Message
+}
+
+// Messages receives the messages the client may see
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// allowed applies the tenant, user and RBAC filters of m
+func (c *Client) allowed(m Message) bool {
+	if m.TenantID != c.tenantID {
+		return false
+	}
+	if m.UserID != "" && m.UserID != c.userID {
+		return false
+	}
+	return m.Permission == "" || models.HasPermission(c.role, m.Permission)
+}
+
+// Hub tracks the streams open on this replica
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	redis   *cache.RedisClient
+}
+
+// NewHub returns a hub. redis may be nil to deliver within this process only.
+func NewHub(redis *cache.RedisClient) *Hub {
+	return &Hub{clients: map[*Client]struct{}{}, redis: redis}
+}
+
+// Connect opens a stream for a user
+func (h *Hub) Connect(tenantID, userID, role string) *Client {
+	client := &Client{
+		tenantID: tenantID,
+		userID:   userID,
+		role:     role,
+		messages: make(chan Message, clientBuffer),
+	}
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+	return client
+}
+
+// Disconnect closes a stream opened by Connect
+func (h *Hub) Disconnect(client *Client) {
+	h.mu.Lock()
+	delete(h.clients, client)
+	h.mu.Unlock()
+}
+
+// Publish sends m to the allowed streams on every replica
+func (h *Hub) Publish(m Message) {
+	if m.At.IsZero() {
+		m.At = time.Now()
+	}
+	if h.redis != nil {
+		payload, err := json.Marshal(m)
+		if err == nil {
+			err = h.redis.Publish(context.Background(), channel, payload)
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to publish %s over Redis, delivering locally: %v", m.Type, err)
+	}
+	h.broadcast(m)
+}
+
+// Run relays messages published by any replica to the streams on this one,
+// until ctx is cancelled. It returns at once without Redis.
+func (h *Hub) Run(ctx context.Context) {
+	if h.redis == nil {
+		return
+	}
+	sub := h.redis.Subscribe(ctx, channel)
+	defer sub.Close()
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			var m Message
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				log.Printf("Dropping malformed realtime message: %v", err)
+				continue
+			}
+			h.broadcast(m)
+		}
+	}
+}
+
+func (h *Hub) broadcast(m Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if !client.allowed(m) {
+			continue
+		}
+		select {
+		case client.messages <- m:
+		default:
+			// Slow reader; it will catch up on its next refetch
+		}
+	}
+}
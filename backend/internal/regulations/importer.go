@@ -0,0 +1,129 @@
+package regulations
+
+import (
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Result counts the clauses an import created, changed, left as they were
+// and marked removed
+type Result struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Total     int `json:"total"` // clauses of the imported tree
+}
+
+// Import stores the tree as the clauses of a regulation. Clauses are matched
+// to those of earlier imports by ref, so their IDs, and the obligations and
+// controls linked to them, carry over. Clauses the tree no longer has are
+// marked removed. Run it in a transaction.
+func Import(tx *gorm.DB, tenantID, regulationID string, roots []*Node) (Result, error) {
+	var result Result
+	var existing []models.RegulationClause
+	if err := tx.Where("tenant_id = ? AND regulation_id = ?", tenantID, regulationID).Find(&existing).Error; err != nil {
+		return result, err
+	}
+	byRef := make(map[string]*models.RegulationClause, len(existing))
+	for i := range existing {
+		byRef[existing[i].Ref] = &existing[i]
+	}
+
+	position := 0
+	var store func(parentID *string, nodes []*Node) error
+	store = func(parentID *string, nodes []*Node) error {
+		for _, n := range nodes {
+			position++
+			result.Total++
+			clause, ok := byRef[n.Ref]
+			if !ok {
+				clause = &models.RegulationClause{TenantID: tenantID, RegulationID: regulationID, Ref: n.Ref}
+			}
+			changed := !ok || clause.Removed || clause.Kind != n.Kind || clause.Number != n.Number ||
+				clause.Title != n.Title || clause.Text != n.Text || clause.Position != position ||
+				!sameID(clause.ParentID, parentID)
+			clause.ParentID = parentID
+			clause.Kind = n.Kind
+			clause.Number = n.Number
+			clause.Title = n.Title
+			clause.Text = n.Text
+			clause.Position = position
+			clause.Removed = false
+
+			switch {
+			case !ok:
+				if err := tx.Create(clause).Error; err != nil {
+					return err
+				}
+				result.Created++
+			case changed:
+				if err := tx.Save(clause).Error; err != nil {
+					return err
+				}
+				result.Updated++
+			default:
+				result.Unchanged++
+			}
+			delete(byRef, n.Ref)
+
+			id := clause.ID
+			if err := store(&id, n.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := store(nil, roots); err != nil {
+		return result, err
+	}
+
+	var gone []string
+	for _, clause := range byRef {
+		if !clause.Removed {
+			gone = append(gone, clause.ID)
+		}
+	}
+	if len(gone) > 0 {
+		if err := tx.Model(&models.RegulationClause{}).Where("id IN ?", gone).
+			Update("removed", true).Error; err != nil {
+			return result, err
+		}
+		result.Removed = len(gone)
+	}
+	return result, nil
+}
+
+func sameID(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ClauseNode is a clause with its children, as the tree is listed
+type ClauseNode struct {
+	models.RegulationClause
+	Children []*ClauseNode `json:"children"`
+}
+
+// Tree nests clauses, ordered by position, under their parents. Clauses
+// whose parent is not among them are roots.
+func Tree(clauses []models.RegulationClause) []*ClauseNode {
+	nodes := make(map[string]*ClauseNode, len(clauses))
+	for i := range clauses {
+		nodes[clauses[i].ID] = &ClauseNode{RegulationClause: clauses[i], Children: []*ClauseNode{}}
+	}
+	roots := []*ClauseNode{}
+	for i := range clauses {
+		node := nodes[clauses[i].ID]
+		if parentID := clauses[i].ParentID; parentID != nil {
+			if parent, ok := nodes[*parentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
@@ -0,0 +1,32 @@
+package regulations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseJSON reads a tree of nodes, given as {"nodes": [...]} or as a bare
+// array. Each node has a kind, a number, a title, a text, children and
+// optionally a ref of its own.
+func parseJSON(content string) ([]*Node, error) {
+	content = strings.TrimSpace(content)
+	var nodes []*Node
+	if strings.HasPrefix(content, "[") {
+		if err := json.Unmarshal([]byte(content), &nodes); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return nodes, nil
+	}
+	var doc struct {
+		Nodes []*Node `json:"nodes"`
+	}
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if doc.Nodes == nil {
+		return nil, errors.New(`JSON must be an array of nodes or an object with "nodes"`)
+	}
+	return doc.Nodes, nil
+}
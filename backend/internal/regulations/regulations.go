@@ -0,0 +1,206 @@
+// Package regulations models regulations as a tree of clauses and imports
+// the tree from the regulation's text. The structure follows Indonesian
+// statute drafting, used by Undang-Undang and POJK alike: chapters (BAB)
+// hold sections (Bagian, Paragraf) and articles (Pasal), articles hold
+// numbered clauses (ayat), and clauses hold points (huruf, angka). English
+// translations using Chapter, Section, Part and Article are read the same
+// way. Requirements drawn from the text are leaves under any of them.
+package regulations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cyber/backend/internal/models"
+)
+
+// Formats of the text an import reads
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Formats lists the accepted formats
+var Formats = []string{FormatText, FormatMarkdown, FormatJSON}
+
+// Limits of an import
+const (
+	MaxContent = 8 << 20
+	MaxNodes   = 20000
+	maxRef     = 200
+)
+
+// Node is a clause of an imported regulation
+type Node struct {
+	Kind     string  `json:"kind"`
+	Number   string  `json:"number"`
+	Title    string  `json:"title"`
+	Text     string  `json:"text"`
+	Ref      string  `json:"ref"` // computed from the numbers when empty
+	Children []*Node `json:"children"`
+
+	line int // where the node starts in text and Markdown, for errors
+}
+
+// kindRank orders the kinds from the outermost. A node's children must rank
+// below it, except for the kinds in nests.
+var kindRank = map[string]int{
+	models.ClauseChapter:     1,
+	models.ClauseSection:     2,
+	models.ClauseArticle:     3,
+	models.ClauseClause:      4,
+	models.ClausePoint:       5,
+	models.ClauseRequirement: 6,
+}
+
+// nests lists the kinds that nest in their own kind: Paragraf within
+// Bagian, and numbered points within lettered ones
+var nests = map[string]bool{models.ClauseSection: true, models.ClausePoint: true}
+
+var refPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Parse reads the clauses of a regulation from content in format and gives
+// every clause its ref. Refs are derived from the numbers, so they stay the
+// same when an amended text is imported again: chapters are ch-I, sections
+// ch-I.sec-1 and a Paragraf within it ch-I.sec-1.par-1, articles art-5
+// (articles are numbered through the whole regulation, so their refs do not
+// depend on the chapter), clauses art-5.2, points art-5.2.a and
+// requirements art-5.2.r1.
+func Parse(format, content string) ([]*Node, error) {
+	if len(content) > MaxContent {
+		return nil, fmt.Errorf("content is larger than %d bytes", MaxContent)
+	}
+	var nodes []*Node
+	var err error
+	switch format {
+	case FormatText, FormatMarkdown:
+		nodes, err = parseText(content, format == FormatMarkdown)
+	case FormatJSON:
+		nodes, err = parseJSON(content)
+	default:
+		return nil, fmt.Errorf("format must be one of %v", Formats)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no chapters, sections or articles found")
+	}
+	if err := assignRefs(nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// assignRefs checks the tree and computes the refs of nodes without one
+func assignRefs(roots []*Node) error {
+	seen := map[string]*Node{}
+	count := 0
+	var walk func(parent *Node, nodes []*Node) error
+	walk = func(parent *Node, nodes []*Node) error {
+		requirements := 0
+		for _, n := range nodes {
+			count++
+			if count > MaxNodes {
+				return fmt.Errorf("more than %d clauses", MaxNodes)
+			}
+			n.Kind = strings.ToLower(strings.TrimSpace(n.Kind))
+			n.Number = strings.TrimSpace(n.Number)
+			n.Title = strings.TrimSpace(n.Title)
+			n.Text = strings.TrimSpace(n.Text)
+			n.Ref = strings.TrimSpace(n.Ref)
+			rank, ok := kindRank[n.Kind]
+			if !ok {
+				return nodeError(n, fmt.Errorf("unknown kind %q", n.Kind))
+			}
+			if parent != nil && rank <= kindRank[parent.Kind] && !(n.Kind == parent.Kind && nests[n.Kind]) {
+				return nodeError(n, fmt.Errorf("a %s cannot be nested in the %s", n.Kind, parent.Kind))
+			}
+			if n.Kind == models.ClauseRequirement {
+				requirements++
+				if len(n.Children) > 0 {
+					return nodeError(n, errors.New("a requirement cannot have children"))
+				}
+			}
+			if n.Ref == "" {
+				ref, err := deriveRef(parent, n, requirements)
+				if err != nil {
+					return nodeError(n, err)
+				}
+				n.Ref = ref
+			}
+			if len(n.Ref) > maxRef || !refPattern.MatchString(n.Ref) {
+				return nodeError(n, fmt.Errorf("invalid ref %q; refs are letters, digits, dots, dashes and underscores", n.Ref))
+			}
+			if first, ok := seen[n.Ref]; ok {
+				err := fmt.Errorf("duplicate ref %q", n.Ref)
+				if first.line > 0 {
+					err = fmt.Errorf("%w, first used on line %d", err, first.line)
+				}
+				return nodeError(n, err)
+			}
+			seen[n.Ref] = n
+			if err := walk(n, n.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(nil, roots)
+}
+
+// deriveRef computes the ref of n, the requirement-th requirement of parent
+// when n is one
+func deriveRef(parent, n *Node, requirement int) (string, error) {
+	if n.Kind == models.ClauseRequirement {
+		return join(parent, fmt.Sprintf("r%d", requirement)), nil
+	}
+	number := refSegment(n.Number)
+	if number == "" {
+		return "", fmt.Errorf("a %s needs a number or a ref", n.Kind)
+	}
+	switch n.Kind {
+	case models.ClauseChapter:
+		return "ch-" + number, nil
+	case models.ClauseSection:
+		if parent != nil && parent.Kind == models.ClauseSection {
+			return join(parent, "par-"+number), nil // Paragraf within a Bagian
+		}
+		return join(parent, "sec-"+number), nil
+	case models.ClauseArticle:
+		return "art-" + number, nil
+	}
+	return join(parent, number), nil
+}
+
+func join(parent *Node, segment string) string {
+	if parent == nil {
+		return segment
+	}
+	return parent.Ref + "." + segment
+}
+
+var nonRef = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// refSegment turns a number such as "5A", "(2)" or "Kesatu" into a ref
+// segment
+func refSegment(number string) string {
+	return strings.Trim(nonRef.ReplaceAllString(number, "-"), "-")
+}
+
+func nodeError(n *Node, err error) error {
+	label := n.Kind
+	if n.Number != "" {
+		label += " " + n.Number
+	}
+	if n.line > 0 {
+		return fmt.Errorf("line %d (%s): %w", n.line, label, err)
+	}
+	if n.Ref != "" {
+		label += " " + n.Ref
+	}
+	return fmt.Errorf("%s: %w", label, err)
+}
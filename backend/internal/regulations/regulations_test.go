@@ -0,0 +1,176 @@
+package regulations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// refs lists the refs of a tree in document order
+func refs(nodes []*Node) []string {
+	var out []string
+	for _, n := range nodes {
+		out = append(out, n.Ref)
+		out = append(out, refs(n.Children)...)
+	}
+	return out
+}
+
+// An excerpt of UU 27/2022 on personal data protection, shortened
+const uuPDP = `UNDANG-UNDANG REPUBLIK INDONESIA
+NOMOR 27 TAHUN 2022
+TENTANG
+PELINDUNGAN DATA PRIBADI
+
+Menimbang:
+a. bahwa pelindungan data pribadi merupakan salah satu hak asasi manusia;
+
+BAB I
+KETENTUAN UMUM
+Pasal 1
+Dalam Undang-Undang ini yang dimaksud dengan:
+1. Data Pribadi adalah data tentang orang perseorangan
+yang teridentifikasi.
+2. Informasi Pribadi adalah informasi tentang orang perseorangan.
+
+- 2 -
+
+BAB III
+JENIS DATA PRIBADI
+Pasal 5
+a. Data Pribadi yang bersifat spesifik; dan
+b. Data Pribadi yang bersifat umum.
+Bagian Kesatu
+Umum
+Paragraf 1
+Dasar Pemrosesan
+Pasal 20
+(1) Pengendali Data Pribadi wajib memiliki dasar pemrosesan.
+(2) Dasar pemrosesan meliputi:
+a. persetujuan yang sah secara eksplisit;
+b. pemenuhan kewajiban perjanjian:
+1. dalam hal Subjek Data Pribadi merupakan salah satu pihak; atau
+c. pemenuhan kewajiban hukum.
+Requirement: Record the legal basis of each processing activity.
+Pasal 20A
+Pengendali Data Pribadi wajib menjaga kerahasiaan.
+
+Disahkan di Jakarta
+Pasal 99
+`
+
+func TestParseText(t *testing.T) {
+	nodes, err := Parse(FormatText, uuPDP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ch-I", "art-1", "art-1.1", "art-1.2",
+		"ch-III", "art-5", "art-5.a", "art-5.b",
+		"ch-III.sec-Kesatu", "ch-III.sec-Kesatu.par-1",
+		"art-20", "art-20.1", "art-20.2", "art-20.2.a", "art-20.2.b", "art-20.2.b.1", "art-20.2.c", "art-20.2.c.r1",
+		"art-20A",
+	}
+	if got := refs(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("refs = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		path      []int
+		wantTitle string
+		wantText  string
+	}{
+		{[]int{0}, "KETENTUAN UMUM", ""},
+		{[]int{0, 0, 0}, "", "Data Pribadi adalah data tentang orang perseorangan yang teridentifikasi."},
+		{[]int{1, 1}, "Umum", ""},
+		{[]int{1, 1, 0}, "Dasar Pemrosesan", ""},
+		{[]int{1, 1, 0, 0, 1, 2, 0}, "", "Record the legal basis of each processing activity."},
+	}
+	for _, tt := range tests {
+		n := nodes[tt.path[0]]
+		for _, i := range tt.path[1:] {
+			n = n.Children[i]
+		}
+		if n.Title != tt.wantTitle || n.Text != tt.wantText {
+			t.Errorf("%s: title %q, text %q, want %q, %q", n.Ref, n.Title, n.Text, tt.wantTitle, tt.wantText)
+		}
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	content := `# Chapter 1 - General Provisions
+
+## Article 3: Scope
+
+(1) This regulation applies to banks.
+- [ ] Appoint a data protection officer
+- [x] Keep a record of processing
+`
+	nodes, err := Parse(FormatMarkdown, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ch-1", "art-3", "art-3.1", "art-3.1.r1", "art-3.1.r2"}
+	if got := refs(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("refs = %v, want %v", got, want)
+	}
+	if article := nodes[0].Children[0]; article.Title != "Scope" {
+		t.Errorf("article title = %q, want %q", article.Title, "Scope")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"array", `[{"kind":"Chapter","number":"I","children":[
+			{"kind":"article","number":"2","children":[{"kind":"requirement","text":"x"}]}]}]`,
+			[]string{"ch-I", "art-2", "art-2.r1"}},
+		{"object", `{"nodes":[{"kind":"article","number":"(3)"}]}`, []string{"art-3"}},
+		{"own refs", `[{"kind":"article","number":"4","ref":"a4","children":[{"kind":"clause","number":"1"}]}]`,
+			[]string{"a4", "a4.1"}},
+		{"nested sections", `[{"kind":"section","number":"Kedua","children":[{"kind":"section","number":"2"}]}]`,
+			[]string{"sec-Kedua", "sec-Kedua.par-2"}},
+	}
+	for _, tt := range tests {
+		nodes, err := Parse(FormatJSON, tt.content)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := refs(nodes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: refs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, format, content, wantErr string
+	}{
+		{"format", "pdf", "Pasal 1", "format must be one of [text markdown json]"},
+		{"empty", FormatText, "Menimbang: bahwa", "no chapters, sections or articles found"},
+		{"duplicate ref", FormatText, "BAB I\nKETENTUAN UMUM\nPasal 1\nIsi.\nBAB II\nASAS\nPasal 1\n",
+			`line 7 (article 1): duplicate ref "art-1", first used on line 3`},
+		{"rank", FormatJSON, `[{"kind":"article","number":"1","children":[{"kind":"chapter","number":"I"}]}]`,
+			"chapter I: a chapter cannot be nested in the article"},
+		{"clause in clause", FormatJSON, `[{"kind":"clause","number":"1","children":[{"kind":"clause","number":"2"}]}]`,
+			"clause 2: a clause cannot be nested in the clause"},
+		{"unknown kind", FormatJSON, `[{"kind":"annex","number":"1"}]`, `annex 1: unknown kind "annex"`},
+		{"no number", FormatJSON, `[{"kind":"article"}]`, "article: a article needs a number or a ref"},
+		{"requirement children", FormatJSON, `[{"kind":"requirement","ref":"r","children":[{"kind":"requirement"}]}]`,
+			"requirement r: a requirement cannot have children"},
+		{"invalid ref", FormatJSON, `[{"kind":"article","ref":"art 1"}]`,
+			`article art 1: invalid ref "art 1"; refs are letters, digits, dots, dashes and underscores`},
+		{"JSON shape", FormatJSON, `{"articles":[]}`, `JSON must be an array of nodes or an object with "nodes"`},
+		{"too large", FormatText, strings.Repeat("x", MaxContent+1), "content is larger than 8388608 bytes"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.format, tt.content)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
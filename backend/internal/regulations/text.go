@@ -0,0 +1,280 @@
+package regulations
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cyber/backend/internal/models"
+)
+
+// Headings of the structure. Keywords are matched in upper case or
+// capitalised only, and chapter numbers are upper-case Roman or Arabic
+// numerals, so that sentences wrapped onto a new line are not taken for
+// headings.
+var (
+	chapterLine   = regexp.MustCompile(`^(?:BAB|Bab|CHAPTER|Chapter)\s+([IVXLCDM]+|\d+)(?:\s*[.:\-–]\s*|\s+|$)(.*)$`)
+	sectionLine   = regexp.MustCompile(`^(?:BAGIAN|Bagian)\s+((?:KE|Ke)\S+)(?:\s*[.:\-–]\s*|\s+|$)(.*)$`)
+	partLine      = regexp.MustCompile(`^(?:SECTION|Section|PART|Part)\s+(\d+|[IVXLCDM]+)(?:\s*[.:\-–]\s*|\s+|$)(.*)$`)
+	paragrafLine  = regexp.MustCompile(`^(?:PARAGRAF|Paragraf)\s+(\d+)(?:\s*[.:\-–]\s*|\s+|$)(.*)$`)
+	pasalLine     = regexp.MustCompile(`^(?:PASAL|Pasal)\s+(\d+[A-Z]?)$`)
+	articleLine   = regexp.MustCompile(`^(?:ARTICLE|Article)\s+(\d+[A-Z]?)(?:\s*[.:\-–]\s*(.*))?$`)
+	articleHeader = regexp.MustCompile(`^(?:PASAL|Pasal|ARTICLE|Article)\s+(\d+[A-Z]?)(?:\s*[.:\-–]?\s*(.*))?$`)
+	clauseLine    = regexp.MustCompile(`^\((\d+[a-z]?)\)\s*(.*)$`)
+	letterPoint   = regexp.MustCompile(`^([a-z])[.)](?:\s+(.*))?$`)
+	digitPoint    = regexp.MustCompile(`^(\d+)[.)](?:\s+(.*))?$`)
+	checkboxLine  = regexp.MustCompile(`^(?:[-*+]\s+)?\[[ xX]?\]\s+(.+)$`)
+	requiredLine  = regexp.MustCompile(`^(?i:requirement|persyaratan)\s*:\s*(.+)$`)
+	pageNumber    = regexp.MustCompile(`^-\s*\d+\s*-$`)
+	bullet        = regexp.MustCompile(`^[-*+]\s+`)
+	rule          = regexp.MustCompile(`^(?:-{3,}|\*{3,}|_{3,})$`)
+)
+
+// endMarkers start the parts after the body of a regulation: the
+// elucidation (Penjelasan) and the enactment formula. Reading stops there.
+var endMarkers = []string{"PENJELASAN", "Agar setiap orang mengetahuinya", "Disahkan di", "Ditetapkan di"}
+
+// Styles of points: letters (huruf) and numbers (angka). A point of a style
+// other than its predecessor's starts a list nested in it.
+const (
+	letterStyle = "letter"
+	digitStyle  = "digit"
+)
+
+type point struct {
+	node  *Node
+	style string
+}
+
+// textParser reads the structure of a regulation line by line. Text before
+// the first heading, such as the considerations (Menimbang, Mengingat), is
+// skipped. Lines that are not headings, clauses, points or requirements
+// continue the text of the clause before them, or the title of a chapter or
+// section that has none yet.
+type textParser struct {
+	roots    []*Node
+	chapter  *Node
+	sections []*Node // a Bagian and the Paragraf within it
+	article  *Node
+	clause   *Node
+	points   []point
+	last     *Node
+	titled   bool // last is a heading whose title has been read
+}
+
+func parseText(content string, markdown bool) ([]*Node, error) {
+	p := &textParser{}
+	for i, raw := range strings.Split(content, "\n") {
+		line, heading := normalizeLine(raw, markdown)
+		if line == "" || pageNumber.MatchString(line) || rule.MatchString(line) {
+			continue
+		}
+		if p.started() && isEnd(line) {
+			break
+		}
+		p.read(line, heading, i+1)
+	}
+	return p.roots, nil
+}
+
+// normalizeLine trims a line and, for Markdown, strips heading marks,
+// quotes, emphasis and bullets. It reports whether the line was a Markdown
+// heading.
+func normalizeLine(raw string, markdown bool) (string, bool) {
+	line := strings.TrimSpace(strings.TrimPrefix(raw, "\ufeff"))
+	if !markdown {
+		return line, false
+	}
+	heading := strings.HasPrefix(line, "#")
+	line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+	line = strings.TrimSpace(strings.TrimLeft(line, ">"))
+	line = strings.ReplaceAll(line, "**", "")
+	line = strings.ReplaceAll(line, "__", "")
+	if !checkboxLine.MatchString(line) {
+		line = bullet.ReplaceAllString(line, "")
+	}
+	return strings.TrimSpace(line), heading
+}
+
+func isEnd(line string) bool {
+	for _, marker := range endMarkers {
+		if strings.HasPrefix(line, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *textParser) started() bool {
+	return p.last != nil
+}
+
+func (p *textParser) read(line string, heading bool, lineNo int) {
+	if m := chapterLine.FindStringSubmatch(line); m != nil {
+		p.chapter = p.add(nil, models.ClauseChapter, m[1], m[2], lineNo)
+		p.sections, p.article, p.clause, p.points = nil, nil, nil, nil
+		return
+	}
+	if m := sectionLine.FindStringSubmatch(line); m != nil {
+		p.openSection(m[1], m[2], false, lineNo)
+		return
+	}
+	if m := partLine.FindStringSubmatch(line); m != nil {
+		p.openSection(m[1], m[2], false, lineNo)
+		return
+	}
+	if m := paragrafLine.FindStringSubmatch(line); m != nil {
+		p.openSection(m[1], m[2], true, lineNo)
+		return
+	}
+	if m := matchArticle(line, heading); m != nil {
+		p.article = p.add(p.container(), models.ClauseArticle, m[1], m[2], lineNo)
+		p.clause, p.points = nil, nil
+		return
+	}
+	if !p.started() {
+		return
+	}
+	if m := clauseLine.FindStringSubmatch(line); m != nil {
+		parent := p.article
+		if parent == nil {
+			parent = p.container()
+		}
+		p.clause = p.add(parent, models.ClauseClause, m[1], "", lineNo)
+		p.clause.Text = m[2]
+		p.points = nil
+		return
+	}
+	if m := checkboxLine.FindStringSubmatch(line); m != nil {
+		p.addRequirement(m[1], lineNo)
+		return
+	}
+	if m := requiredLine.FindStringSubmatch(line); m != nil {
+		p.addRequirement(m[1], lineNo)
+		return
+	}
+	if m := letterPoint.FindStringSubmatch(line); m != nil {
+		p.addPoint(letterStyle, m[1], m[2], lineNo)
+		return
+	}
+	if m := digitPoint.FindStringSubmatch(line); m != nil {
+		p.addPoint(digitStyle, m[1], m[2], lineNo)
+		return
+	}
+	p.continueLast(line)
+}
+
+// matchArticle matches an article heading. Indonesian articles stand alone
+// on their line; English ones and Markdown headings may carry a title.
+func matchArticle(line string, heading bool) []string {
+	if heading {
+		return articleHeader.FindStringSubmatch(line)
+	}
+	if m := pasalLine.FindStringSubmatch(line); m != nil {
+		return []string{m[0], m[1], ""}
+	}
+	return articleLine.FindStringSubmatch(line)
+}
+
+// openSection opens a Bagian or Section, or a Paragraf within the current
+// Bagian
+func (p *textParser) openSection(number, title string, paragraf bool, lineNo int) {
+	if !paragraf || len(p.sections) == 0 {
+		p.sections = nil
+	} else {
+		p.sections = p.sections[:1]
+	}
+	parent := p.chapter
+	if len(p.sections) > 0 {
+		parent = p.sections[0]
+	}
+	p.sections = append(p.sections, p.add(parent, models.ClauseSection, number, title, lineNo))
+	p.article, p.clause, p.points = nil, nil, nil
+}
+
+// container is the innermost chapter or section articles go in
+func (p *textParser) container() *Node {
+	if len(p.sections) > 0 {
+		return p.sections[len(p.sections)-1]
+	}
+	return p.chapter
+}
+
+// innermost is the innermost open node, which requirements go in
+func (p *textParser) innermost() *Node {
+	switch {
+	case len(p.points) > 0:
+		return p.points[len(p.points)-1].node
+	case p.clause != nil:
+		return p.clause
+	case p.article != nil:
+		return p.article
+	}
+	return p.container()
+}
+
+func (p *textParser) addPoint(style, number, text string, lineNo int) {
+	for i := len(p.points) - 1; i >= 0; i-- {
+		if p.points[i].style == style {
+			p.points = p.points[:i]
+			break
+		}
+	}
+	var parent *Node
+	switch {
+	case len(p.points) > 0:
+		parent = p.points[len(p.points)-1].node
+	case p.clause != nil:
+		parent = p.clause
+	case p.article != nil:
+		parent = p.article
+	default:
+		parent = p.container()
+	}
+	n := p.add(parent, models.ClausePoint, number, "", lineNo)
+	n.Text = text
+	p.points = append(p.points, point{node: n, style: style})
+}
+
+func (p *textParser) addRequirement(text string, lineNo int) {
+	n := p.add(p.innermost(), models.ClauseRequirement, "", "", lineNo)
+	n.Text = text
+}
+
+// add appends a node to parent, or to the roots when parent is nil
+func (p *textParser) add(parent *Node, kind, number, title string, lineNo int) *Node {
+	n := &Node{Kind: kind, Number: number, Title: strings.TrimSpace(title), line: lineNo}
+	if parent == nil {
+		p.roots = append(p.roots, n)
+	} else {
+		parent.Children = append(parent.Children, n)
+	}
+	p.last = n
+	p.titled = n.Title != ""
+	return n
+}
+
+// continueLast appends a line to the node before it: to the title of a
+// chapter or section, as long as no clause follows, and to the text of
+// anything else
+func (p *textParser) continueLast(line string) {
+	n := p.last
+	if (n.Kind == models.ClauseChapter || n.Kind == models.ClauseSection) && (!p.titled || isUpper(line)) {
+		n.Title = joinText(n.Title, line)
+		p.titled = true
+		return
+	}
+	n.Text = joinText(n.Text, line)
+}
+
+func joinText(text, line string) string {
+	if text == "" {
+		return line
+	}
+	return text + " " + line
+}
+
+// isUpper reports whether a line is in capitals, as the continued titles of
+// chapters are
+func isUpper(line string) bool {
+	return strings.ToUpper(line) == line && strings.ToLower(line) != line
+}
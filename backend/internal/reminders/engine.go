@@ -0,0 +1,302 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/realtime"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SendJob sends the due reminders of every tenant
+var SendJob = jobs.Kind[struct{}]{Name: "reminders.send", MaxAttempts: 3}
+
+// Deadlines missed longer ago than this, past the escalation, are left
+// alone, so turning reminders on does not escalate years of missed dates
+const lookbackDays = 30
+
+// Stage of a deadline that has passed and been escalated
+const stageEscalation = "escalation"
+
+// Engine sends reminders as outbox events, reminder.due and
+// reminder.escalated, and pushes them to the recipients' streams
+type Engine struct {
+	db  *gorm.DB
+	hub *realtime.Hub
+}
+
+// NewEngine returns an engine. hub may be nil when no stream should be told.
+func NewEngine(db *gorm.DB, hub *realtime.Hub) *Engine {
+	return &Engine{db: db, hub: hub}
+}
+
+// Schedule registers SendJob with q and runs it on the cron spec. Run it
+// often: quiet hours and reminder days are checked when it runs.
+func (e *Engine) Schedule(q *jobs.Queue, spec string) error {
+	SendJob.Handle(q, func(ctx context.Context, _ *models.Job, _ struct{}) error {
+		return e.Send(ctx, time.Now())
+	})
+	return SendJob.Schedule(q, spec, struct{}{})
+}
+
+// Send sends the reminders due at now for every active tenant. A tenant
+// that fails is logged and skipped; the error of the last one is returned.
+func (e *Engine) Send(ctx context.Context, now time.Time) error {
+	var tenants []string
+	if err := e.db.WithContext(ctx).Model(&models.Tenant{}).Where("status = ?", "active").
+		Order("id").Pluck("id", &tenants).Error; err != nil {
+		return err
+	}
+	var lastErr error
+	for _, tenantID := range tenants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := e.sendTenant(ctx, tenantID, now); err != nil {
+			log.Printf("Failed to send reminders of tenant %s: %v", tenantID, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (e *Engine) sendTenant(ctx context.Context, tenantID string, now time.Time) error {
+	tx := e.db.WithContext(ctx)
+	settings := DefaultSettings(tenantID)
+	var saved []models.ReminderSettings
+	if err := tx.Where("tenant_id = ?", tenantID).Find(&saved).Error; err != nil {
+		return err
+	}
+	if len(saved) > 0 {
+		settings = saved[0]
+	}
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	if quiet(settings, local) {
+		return nil
+	}
+
+	var rules []models.ReminderRule
+	if err := tx.Where("tenant_id = ?", tenantID).Find(&rules).Error; err != nil {
+		return err
+	}
+	bySource := map[string]models.ReminderRule{}
+	for _, rule := range rules {
+		bySource[rule.Source] = rule
+	}
+	users, err := loadDirectory(tx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	today := civilDate(local)
+	for _, source := range Sources {
+		rule, ok := bySource[source.Name]
+		if !ok {
+			rule = DefaultRule(tenantID, source.Name)
+		}
+		if !rule.Enabled {
+			continue
+		}
+		if err := e.sendSource(tx, tenantID, source, rule, users, today); err != nil {
+			return fmt.Errorf("%s: %w", source.Name, err)
+		}
+	}
+	return nil
+}
+
+// deadline is a record held to a date of a source
+type deadline struct {
+	ID    string
+	Due   time.Time
+	Owner string
+	Title string
+}
+
+// sendSource sends the reminders due today about the records of a source
+func (e *Engine) sendSource(tx *gorm.DB, tenantID string, source Source, rule models.ReminderRule, users *directory, today time.Time) error {
+	resource, ok := models.LookupResource(source.Resource)
+	if !ok {
+		return fmt.Errorf("unknown resource %q", source.Resource)
+	}
+	ahead := 0
+	for _, days := range rule.DaysBefore {
+		if int(days) > ahead {
+			ahead = int(days)
+		}
+	}
+	owner := "''"
+	if source.ownerColumn != "" {
+		owner = source.ownerColumn
+	}
+	query := tx.Model(resource.New()).
+		Select(fmt.Sprintf("id, %s AS due, %s AS owner, %s AS title", source.dateColumn, owner, source.titleColumn)).
+		Where("tenant_id = ? AND is_deleted = ?", tenantID, false).
+		Where(fmt.Sprintf("%[1]s >= ? AND %[1]s < ?", source.dateColumn),
+			today.AddDate(0, 0, -rule.EscalateAfterDays-lookbackDays), today.AddDate(0, 0, ahead+1))
+	if source.open != "" {
+		query = query.Where(source.open, source.openArgs...)
+	}
+	var due []deadline
+	if err := query.Scan(&due).Error; err != nil {
+		return err
+	}
+
+	keys := make([]string, len(due))
+	stages := make([]string, len(due))
+	for i, d := range due {
+		daysLeft := int(civilDate(d.Due.UTC()).Sub(today).Hours() / 24)
+		stages[i] = stage(rule, daysLeft)
+		keys[i] = fmt.Sprintf("%s:%s:%s:%s", source.Name, d.ID, d.Due.UTC().Format("2006-01-02"), stages[i])
+	}
+	var sent []string
+	if len(keys) > 0 {
+		if err := tx.Model(&models.Reminder{}).Where("key IN ?", keys).Pluck("key", &sent).Error; err != nil {
+			return err
+		}
+	}
+	done := map[string]bool{}
+	for _, key := range sent {
+		done[key] = true
+	}
+
+	for i, d := range due {
+		if stages[i] == "" || done[keys[i]] {
+			continue
+		}
+		escalated := stages[i] == stageEscalation
+		reminder := &models.Reminder{
+			Key:          keys[i],
+			TenantID:     tenantID,
+			Source:       source.Name,
+			ResourceType: source.Resource,
+			ResourceID:   d.ID,
+			Title:        d.Title,
+			DueDate:      d.Due,
+			Stage:        stages[i],
+			Escalated:    escalated,
+			Recipients:   users.recipients(d.Owner, rule.EscalateTo, escalated),
+		}
+		if err := e.deliver(tx, reminder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver records the reminder with its event and pushes it to the
+// recipients. A reminder already recorded is not sent again.
+func (e *Engine) deliver(tx *gorm.DB, reminder *models.Reminder) error {
+	eventType := events.ReminderDue
+	if reminder.Escalated {
+		eventType = events.ReminderEscalated
+	}
+	created := false
+	if err := tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		event, err := events.New(eventType, reminder.TenantID, reminder.ResourceType, reminder.ResourceID, "", reminder)
+		if err != nil {
+			return err
+		}
+		event.Key = eventType + ":" + reminder.Key
+		return events.Record(tx, event)
+	}); err != nil || !created || e.hub == nil {
+		return err
+	}
+
+	data, _ := json.Marshal(reminder)
+	for _, userID := range reminder.Recipients {
+		e.hub.Publish(realtime.Message{
+			Type:       realtime.ReminderSent,
+			TenantID:   reminder.TenantID,
+			Resource:   reminder.ResourceType,
+			ResourceID: reminder.ResourceID,
+			Data:       data,
+			UserID:     userID,
+		})
+	}
+	return nil
+}
+
+// directory resolves the owners named in records to the tenant's active
+// users
+type directory struct {
+	byID   map[string]models.User
+	byName map[string]models.User // by lowercased email and full name
+	admins []string
+}
+
+func loadDirectory(tx *gorm.DB, tenantID string) (*directory, error) {
+	var users []models.User
+	if err := tx.Where("tenant_id = ? AND status = ?", tenantID, "active").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	d := &directory{byID: map[string]models.User{}, byName: map[string]models.User{}}
+	for _, u := range users {
+		d.byID[u.ID] = u
+		d.byName[strings.ToLower(u.Email)] = u
+		if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+			d.byName[strings.ToLower(name)] = u
+		}
+		if u.Role == models.RoleTenantAdmin {
+			d.admins = append(d.admins, u.ID)
+		}
+	}
+	return d, nil
+}
+
+func (d *directory) owner(name string) (models.User, bool) {
+	name = strings.TrimSpace(name)
+	if u, ok := d.byID[name]; ok {
+		return u, true
+	}
+	u, ok := d.byName[strings.ToLower(name)]
+	return u, ok && name != ""
+}
+
+// recipients returns who is told about a deadline of owner: the owner, or
+// the tenant admins when the owner is not a user, plus on escalation the
+// owner's manager or the tenant admins
+func (d *directory) recipients(ownerName, escalateTo string, escalated bool) []string {
+	var ids []string
+	owner, known := d.owner(ownerName)
+	if known {
+		ids = append(ids, owner.ID)
+	}
+	switch {
+	case escalated && escalateTo == EscalateToManager && known && d.byID[owner.ManagerID].ID != "":
+		ids = append(ids, owner.ManagerID)
+	case escalated || !known:
+		ids = append(ids, d.admins...)
+	}
+
+	seen := map[string]bool{}
+	unique := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// civilDate returns the calendar date of t, as midnight UTC
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
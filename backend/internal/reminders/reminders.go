@@ -0,0 +1,166 @@
+// Package reminders reminds owners of the deadlines in their records and
+// escalates the ones that pass. Each kind of deadline is a Source; tenants
+// set per source how many days ahead owners are reminded and when and to
+// whom missed deadlines are escalated, and quiet hours during which
+// reminders wait. Every reminder goes out once per record, due date and
+// stage, however often the engine runs.
+package reminders
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // tenant time zones without relying on the host's zoneinfo
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// Escalation targets
+const (
+	EscalateToManager      = "manager"       // the owner's manager, or the tenant admins when unknown
+	EscalateToTenantAdmins = "tenant_admins" // every tenant admin
+)
+
+// Source is a kind of deadline: a date column of a tenant resource
+type Source struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Resource string `json:"resource"` // see models.TenantResources
+
+	dateColumn string
+	// Column naming the owner by user ID, email or full name. Records
+	// without an owner, or whose owner is not a user, remind the tenant
+	// admins.
+	ownerColumn string
+	titleColumn string
+	// Condition selecting the records still held to the date
+	open     string
+	openArgs []interface{}
+}
+
+// Sources lists every kind of deadline reminded of
+var Sources = []Source{
+	{Name: "risk_review", Label: "Risk review", Resource: "risk",
+		dateColumn: "review_date", ownerColumn: "owner", titleColumn: "name", open: "status <> ?", openArgs: []interface{}{"closed"}},
+	{Name: "control_test", Label: "Control test", Resource: "control",
+		dateColumn: "next_test", ownerColumn: "owner", titleColumn: "name"},
+	{Name: "obligation_review", Label: "Obligation review", Resource: "obligation",
+		dateColumn: "next_review", titleColumn: "name"},
+	{Name: "dsr_due", Label: "Data subject request due", Resource: "dsr",
+		dateColumn: "due_date", ownerColumn: "handler", titleColumn: "data_subject_name",
+		open: "status NOT IN ?", openArgs: []interface{}{models.ClosedDSRStatuses}},
+	{Name: "vendor_assessment", Label: "Vendor assessment", Resource: "vendor",
+		dateColumn: "next_assessment_date", ownerColumn: "owner", titleColumn: "vendor_name"},
+	{Name: "governance_meeting", Label: "Governance meeting", Resource: "governance",
+		dateColumn: "next_meeting_date", titleColumn: "name", open: "status = ?", openArgs: []interface{}{"active"}},
+	{Name: "control_test_follow_up", Label: "Control test follow-up", Resource: "control_test",
+		dateColumn: "follow_up_date", ownerColumn: "tester", titleColumn: "control_name", open: "follow_up_required = ?", openArgs: []interface{}{true}},
+}
+
+// LookupSource finds a source by name
+func LookupSource(name string) (Source, bool) {
+	for _, s := range Sources {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
+
+// DefaultRule is the rule of a source the tenant has not set one for:
+// owners are reminded a week, a day and on the day before the date, and
+// their manager once it has passed
+func DefaultRule(tenantID, source string) models.ReminderRule {
+	return models.ReminderRule{
+		TenantID:   tenantID,
+		Source:     source,
+		Enabled:    true,
+		DaysBefore: pq.Int64Array{7, 1, 0},
+		EscalateTo: EscalateToManager,
+	}
+}
+
+// ValidateRule checks the settings of a rule
+func ValidateRule(rule *models.ReminderRule) error {
+	for _, days := range rule.DaysBefore {
+		if days < 0 || days > 365 {
+			return fmt.Errorf("days_before must be between 0 and 365")
+		}
+	}
+	if rule.EscalateAfterDays < 0 || rule.EscalateAfterDays > 365 {
+		return fmt.Errorf("escalate_after_days must be between 0 and 365")
+	}
+	if rule.EscalateTo != EscalateToManager && rule.EscalateTo != EscalateToTenantAdmins {
+		return fmt.Errorf("escalate_to must be %s or %s", EscalateToManager, EscalateToTenantAdmins)
+	}
+	return nil
+}
+
+// ValidateSettings checks a tenant's time zone and quiet hours
+func ValidateSettings(settings *models.ReminderSettings) error {
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return fmt.Errorf("unknown time zone %q", settings.Timezone)
+	}
+	if (settings.QuietStart == "") != (settings.QuietEnd == "") {
+		return fmt.Errorf("quiet_start and quiet_end must be set together")
+	}
+	for _, clock := range []string{settings.QuietStart, settings.QuietEnd} {
+		if _, err := parseClock(clock); clock != "" && err != nil {
+			return fmt.Errorf("quiet hours must be HH:MM, got %q", clock)
+		}
+	}
+	return nil
+}
+
+// DefaultSettings are the settings of a tenant that has set none: Jakarta
+// time, without quiet hours
+func DefaultSettings(tenantID string) models.ReminderSettings {
+	return models.ReminderSettings{TenantID: tenantID, Timezone: "Asia/Jakarta"}
+}
+
+// parseClock returns the minutes since midnight of an HH:MM time
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quiet reports whether now, in the tenant's time zone, falls in its quiet
+// hours. Quiet hours may span midnight.
+func quiet(settings models.ReminderSettings, now time.Time) bool {
+	start, err1 := parseClock(settings.QuietStart)
+	end, err2 := parseClock(settings.QuietEnd)
+	if err1 != nil || err2 != nil || start == end {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// stage returns the stage a deadline daysLeft days away has reached under
+// rule, or "" when nothing is due. Before the date it is the nearest
+// reminder day not yet past, so a deadline set at short notice still gets
+// one reminder; after the date it is the escalation.
+func stage(rule models.ReminderRule, daysLeft int) string {
+	if daysLeft < -rule.EscalateAfterDays {
+		return "escalation"
+	}
+	if daysLeft < 0 {
+		return ""
+	}
+	nearest := -1
+	for _, days := range rule.DaysBefore {
+		if int(days) >= daysLeft && (nearest < 0 || int(days) < nearest) {
+			nearest = int(days)
+		}
+	}
+	if nearest < 0 {
+		return ""
+	}
+	return fmt.Sprintf("before:%d", nearest)
+}
@@ -0,0 +1,176 @@
+// Package reqlog records API requests to the APIUsage and SystemLog tables.
+// Entries are queued and written in batches by a background goroutine, so
+// logging never waits on the database.
+package reqlog
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// SystemLog category of request entries. The retention purger deletes them
+// under the system_logs class, and API usage under api_usage.
+const CategoryAPI = "api"
+
+const (
+	// Entries waiting to be written. When the queue is full, entries are
+	// dropped rather than slowing requests down.
+	queueSize = 10000
+	// Entries written per batch
+	batchSize = 500
+	// How often a partial batch is written
+	flushInterval = 2 * time.Second
+)
+
+// Entry is one finished request
+type Entry struct {
+	RequestID string
+	Method    string
+	Route     string // route template, e.g. /api/riskops/risks/:id
+	Path      string
+	Status    int
+	Latency   time.Duration
+	TenantID  string
+	UserID    string
+	IPAddress string
+	UserAgent string
+	Bytes     int
+	Errors    string // errors attached to the gin context
+	At        time.Time
+}
+
+// Level is the SystemLog level of the entry
+func (e Entry) Level() string {
+	switch {
+	case e.Status >= http.StatusInternalServerError:
+		return "error"
+	case e.Status >= http.StatusBadRequest:
+		return "warning"
+	}
+	return "info"
+}
+
+// Recorder queues entries and writes them in the background
+type Recorder struct {
+	db      *gorm.DB
+	entries chan Entry
+	dropped atomic.Int64
+
+	// SampleRate is the share of successful requests recorded, from 0 to 1.
+	// Requests that failed with 4xx or 5xx are always recorded.
+	SampleRate float64
+}
+
+func NewRecorder(db *gorm.DB) *Recorder {
+	return &Recorder{db: db, entries: make(chan Entry, queueSize), SampleRate: 1}
+}
+
+// Sampled reports whether a request that ended with status is recorded
+func (r *Recorder) Sampled(status int) bool {
+	if status >= http.StatusBadRequest || r.SampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < r.SampleRate
+}
+
+// Record queues an entry without blocking
+func (r *Recorder) Record(e Entry) {
+	select {
+	case r.entries <- e:
+	default:
+		r.dropped.Add(1)
+	}
+}
+
+// Run writes queued entries until ctx is cancelled, then writes what is left
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]Entry, 0, batchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain(batch)
+			return
+		case e := <-r.entries:
+			batch = append(batch, e)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		r.write(batch)
+		batch = batch[:0]
+	}
+}
+
+func (r *Recorder) drain(batch []Entry) {
+	for {
+		select {
+		case e := <-r.entries:
+			batch = append(batch, e)
+		default:
+			r.write(batch)
+			return
+		}
+	}
+}
+
+func (r *Recorder) write(batch []Entry) {
+	if dropped := r.dropped.Swap(0); dropped > 0 {
+		log.Printf("Request log queue full, dropped %d entries", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	usage := make([]models.APIUsage, 0, len(batch))
+	logs := make([]models.SystemLog, 0, len(batch))
+	for _, e := range batch {
+		base := models.BaseModel{CreatedAt: e.At, UpdatedAt: e.At}
+		usage = append(usage, models.APIUsage{
+			BaseModel:    base,
+			TenantID:     e.TenantID,
+			Endpoint:     e.Route,
+			Method:       e.Method,
+			StatusCode:   e.Status,
+			ResponseTime: int(e.Latency.Milliseconds()),
+		})
+		details, _ := json.Marshal(map[string]interface{}{
+			"route":  e.Route,
+			"status": e.Status,
+			"bytes":  e.Bytes,
+			"errors": e.Errors,
+		})
+		logs = append(logs, models.SystemLog{
+			BaseModel: base,
+			TenantID:  e.TenantID,
+			UserID:    e.UserID,
+			Level:     e.Level(),
+			Category:  CategoryAPI,
+			Action:    e.Method + " " + e.Route,
+			Message:   e.Method + " " + e.Path + " " + http.StatusText(e.Status),
+			Details:   string(details),
+			IPAddress: e.IPAddress,
+			UserAgent: e.UserAgent,
+			RequestID: e.RequestID,
+			Duration:  int(e.Latency.Milliseconds()),
+		})
+	}
+
+	if err := r.db.CreateInBatches(&usage, batchSize).Error; err != nil {
+		log.Printf("Failed to write API usage: %v", err)
+	}
+	if err := r.db.CreateInBatches(&logs, batchSize).Error; err != nil {
+		log.Printf("Failed to write request logs: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/db"
+	"github.com/cyber/backend/internal/jobs"
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/storage"
+	"gorm.io/gorm"
+)
+
+// Action and resource type of purge reports in AuditLog
+const (
+	ActionPurge  = "retention_purge"
+	ResourceType = "retention"
+)
+
+// Report is what a purge did with a tenant's data. It is written to
+// AuditLog, in the tenant's chain.
+type Report struct {
+	TenantID  string        `json:"tenant_id"`
+	StartedAt time.Time     `json:"started_at"`
+	Classes   []ClassReport `json:"classes"`
+}
+
+// ClassReport is what a purge did with a class of a tenant's data
+type ClassReport struct {
+	Class         string    `json:"class"`
+	RetentionDays int       `json:"retention_days"`
+	Cutoff        time.Time `json:"cutoff"`
+	Held          bool      `json:"held"`            // purging was suspended by a legal hold
+	Purged        int64     `json:"purged"`          // records removed or, for files, records cleared
+	Files         int       `json:"files,omitempty"` // stored files deleted
+}
+
+// PurgeJob runs Purger.Purge in the background
+var PurgeJob = jobs.Kind[struct{}]{Name: "retention.purge", MaxAttempts: 3}
+
+// Purger removes data past its retention period
+type Purger struct {
+	db    *db.Database
+	files *storage.StorageService
+}
+
+func NewPurger(database *db.Database, files *storage.StorageService) *Purger {
+	return &Purger{db: database, files: files}
+}
+
+// Schedule registers PurgeJob with q and runs it on the cron spec
+func (p *Purger) Schedule(q *jobs.Queue, spec string) error {
+	PurgeJob.Handle(q, func(ctx context.Context, _ *models.Job, _ struct{}) error {
+		_, err := p.Purge(ctx)
+		return err
+	})
+	return PurgeJob.Schedule(q, spec, struct{}{})
+}
+
+type tenantRef struct {
+	ID      string
+	Deleted bool
+}
+
+// Purge applies the retention policies once, tenant by tenant, and returns
+// the reports of the tenants with a policy. Platform data without a tenant
+// is purged under the platform defaults. A tenant that fails is logged and
+// skipped; the error of the last one is returned.
+func (p *Purger) Purge(ctx context.Context) ([]*Report, error) {
+	gdb := p.db.DB.WithContext(ctx)
+	pol, err := loadPolicies(gdb)
+	if err != nil {
+		return nil, err
+	}
+	tenants := []tenantRef{{ID: ""}}
+	var list []tenantRef
+	if err := gdb.Unscoped().Model(&models.Tenant{}).
+		Select("id, is_deleted OR deleted_at IS NOT NULL AS deleted").Order("id").Scan(&list).Error; err != nil {
+		return nil, err
+	}
+	tenants = append(tenants, list...)
+
+	var reports []*Report
+	var lastErr error
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			return reports, ctx.Err()
+		}
+		report, err := p.purgeTenant(ctx, tenant, pol)
+		if err != nil {
+			log.Printf("Retention purge of tenant %q failed: %v", tenant.ID, err)
+			lastErr = err
+			continue
+		}
+		if report != nil {
+			reports = append(reports, report)
+		}
+	}
+	return reports, lastErr
+}
+
+// purgeTenant purges a tenant's data in one transaction, with the report.
+// Stored files are deleted after it commits. A tenant another replica is
+// purging is skipped.
+func (p *Purger) purgeTenant(ctx context.Context, tenant tenantRef, pol policies) (*Report, error) {
+	// Classes in the tenant's schema apply while the tenant exists
+	schemaTenant := ""
+	if tenant.ID != "" && !tenant.Deleted {
+		schemaTenant = tenant.ID
+	}
+	now := time.Now()
+	report := &Report{TenantID: tenant.ID, StartedAt: now}
+	var files []string
+
+	err := (&db.Database{DB: p.db.DB.WithContext(ctx)}).TenantTx(schemaTenant, func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", "retention:"+tenant.ID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		held, err := activeHolds(tx, tenant.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, class := range Classes {
+			if class.tenantSchema && schemaTenant == "" {
+				continue
+			}
+			days, ok := pol.days(tenant.ID, class.Name)
+			if !ok {
+				continue
+			}
+			result := ClassReport{Class: class.Name, RetentionDays: days, Cutoff: now.AddDate(0, 0, -days)}
+			if held.cover(class.Name) {
+				result.Held = true
+			} else {
+				purged, classFiles, err := class.purge(tx, tenant.ID, result.Cutoff)
+				switch {
+				case errors.Is(err, models.ErrLegalHold):
+					// A hold on a class its children cascade to
+					result.Held = true
+				case err != nil:
+					return fmt.Errorf("%s: %w", class.Name, err)
+				default:
+					result.Purged = purged
+					result.Files = len(classFiles)
+					files = append(files, classFiles...)
+				}
+			}
+			report.Classes = append(report.Classes, result)
+		}
+		if len(report.Classes) == 0 {
+			return nil
+		}
+		return writeReport(tx, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range files {
+		if err := p.files.DeleteFile(ctx, path); err != nil {
+			log.Printf("Retention purge failed to delete stored file %s: %v", path, err)
+		}
+	}
+	if len(report.Classes) == 0 {
+		return nil, nil
+	}
+	return report, nil
+}
+
+func writeReport(tx *gorm.DB, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	actor := audit.ActorFrom(tx.Statement.Context)
+	entry := models.AuditLog{
+		TenantID:     report.TenantID,
+		UserID:       actor.UserID,
+		Action:       ActionPurge,
+		ResourceType: ResourceType,
+		OldValues:    "{}",
+		NewValues:    string(data),
+		IPAddress:    actor.IPAddress,
+		UserAgent:    actor.UserAgent,
+		RequestID:    actor.RequestID,
+	}
+	return tx.Create(&entry).Error
+}
@@ -0,0 +1,204 @@
+// Package retention purges data older than the retention period set for its
+// class. Periods are set per tenant, with platform defaults for tenants that
+// set none; data of a class without any period is kept. Legal holds suspend
+// purging of a tenant's data until they are released.
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Prefix of the classes of records in the trash, followed by the resource
+// name, e.g. "trash.risk"
+const TrashPrefix = models.TrashClassPrefix
+
+// Class is a kind of data with its own retention period
+type Class struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// Data of the class lives in the tenant's schema, so the class only
+	// applies to existing tenants
+	tenantSchema bool
+	// purge removes the tenant's data of the class older than cutoff. It
+	// returns how many records were removed and the stored files to delete
+	// once the transaction has committed.
+	purge func(tx *gorm.DB, tenantID string, cutoff time.Time) (int64, []string, error)
+}
+
+// Classes lists every class of data with a retention period
+var Classes = classes()
+
+func classes() []Class {
+	list := []Class{
+		{Name: "system_logs", Label: "System logs", purge: purgeLog(&models.SystemLog{})},
+		{Name: "api_usage", Label: "API usage", purge: purgeLog(&models.APIUsage{})},
+		{Name: "ai_usage", Label: "AI usage", purge: purgeLog(&models.AIUsageLog{})},
+		{Name: "document_files", Label: "Stored files of documents in the trash", tenantSchema: true, purge: purgeDocumentFiles},
+	}
+	for _, r := range models.TenantResources {
+		list = append(list, Class{
+			Name:         TrashPrefix + r.Name,
+			Label:        r.Label + " in the trash",
+			tenantSchema: true,
+			purge:        purgeTrash(r),
+		})
+	}
+	return list
+}
+
+// LookupClass finds a class by name
+func LookupClass(name string) (Class, bool) {
+	for _, c := range Classes {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Class{}, false
+}
+
+// purgeLog deletes log records by creation time. It bypasses the model
+// callbacks: the purge report accounts for the records.
+func purgeLog(model interface{}) func(*gorm.DB, string, time.Time) (int64, []string, error) {
+	return func(tx *gorm.DB, tenantID string, cutoff time.Time) (int64, []string, error) {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return 0, nil, err
+		}
+		result := tx.Exec(fmt.Sprintf(`DELETE FROM %q WHERE COALESCE(tenant_id, '') = ? AND created_at < ?`, stmt.Schema.Table),
+			tenantID, cutoff)
+		return result.RowsAffected, nil, result.Error
+	}
+}
+
+// purgeTrash permanently deletes records that have been in the trash since
+// before cutoff, with their children, and the stored files of documents
+func purgeTrash(resource models.Resource) func(*gorm.DB, string, time.Time) (int64, []string, error) {
+	return func(tx *gorm.DB, tenantID string, cutoff time.Time) (int64, []string, error) {
+		expired := tx.Unscoped().Model(resource.New()).
+			Where("tenant_id = ? AND is_deleted = ? AND deleted_at < ?", tenantID, true, cutoff)
+		var ids []string
+		if err := expired.Session(&gorm.Session{}).Pluck("id", &ids).Error; err != nil {
+			return 0, nil, err
+		}
+		if len(ids) == 0 {
+			return 0, nil, nil
+		}
+		var files []string
+		if resource.Name == "document" {
+			if err := expired.Session(&gorm.Session{}).Where("storage_path <> ''").Pluck("storage_path", &files).Error; err != nil {
+				return 0, nil, err
+			}
+		}
+		if err := models.PurgeTrashed(tx, tenantID, resource.Name, ids); err != nil {
+			return 0, nil, err
+		}
+		return int64(len(ids)), files, nil
+	}
+}
+
+// purgeDocumentFiles removes the stored files of documents that have been in
+// the trash since before cutoff, keeping the records
+func purgeDocumentFiles(tx *gorm.DB, tenantID string, cutoff time.Time) (int64, []string, error) {
+	expired := tx.Unscoped().Model(&models.Document{}).
+		Where("tenant_id = ? AND is_deleted = ? AND deleted_at < ? AND storage_path <> ''", tenantID, true, cutoff)
+	var files []string
+	if err := expired.Session(&gorm.Session{}).Pluck("storage_path", &files).Error; err != nil {
+		return 0, nil, err
+	}
+	if len(files) == 0 {
+		return 0, nil, nil
+	}
+	result := expired.Session(&gorm.Session{}).Updates(map[string]interface{}{"storage_path": "", "storage_url": ""})
+	return result.RowsAffected, files, result.Error
+}
+
+// policies holds retention days by tenant and class. The empty tenant holds
+// the platform defaults.
+type policies map[string]map[string]int
+
+func loadPolicies(tx *gorm.DB) (policies, error) {
+	var list []models.RetentionPolicy
+	if err := tx.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	p := policies{}
+	for _, policy := range list {
+		if p[policy.TenantID] == nil {
+			p[policy.TenantID] = map[string]int{}
+		}
+		p[policy.TenantID][policy.DataClass] = policy.RetentionDays
+	}
+	return p, nil
+}
+
+// days returns the retention period of the tenant's data of class: its own,
+// or else the platform default
+func (p policies) days(tenantID, class string) (int, bool) {
+	if days, ok := p[tenantID][class]; ok {
+		return days, true
+	}
+	days, ok := p[""][class]
+	return days, ok
+}
+
+// holds are the classes under an active legal hold; the empty class holds
+// every class
+type holds map[string]bool
+
+func activeHolds(tx *gorm.DB, tenantID string) (holds, error) {
+	var classes []string
+	if err := tx.Model(&models.LegalHold{}).Where("tenant_id = ? AND released_at IS NULL", tenantID).
+		Distinct().Pluck("data_class", &classes).Error; err != nil {
+		return nil, err
+	}
+	h := holds{}
+	for _, class := range classes {
+		h[class] = true
+	}
+	return h, nil
+}
+
+func (h holds) cover(class string) bool {
+	return h[""] || h[class]
+}
+
+// Setting is the retention of a class of a tenant's data
+type Setting struct {
+	Class
+	RetentionDays *int `json:"retention_days"` // the tenant's own period
+	DefaultDays   *int `json:"default_days"`   // the platform default
+	EffectiveDays *int `json:"effective_days"` // period applied; kept forever when null
+	Held          bool `json:"held"`
+}
+
+// Settings returns the retention of every class of a tenant's data. With an
+// empty tenant it returns the platform defaults.
+func Settings(db *gorm.DB, tenantID string) ([]Setting, error) {
+	p, err := loadPolicies(db)
+	if err != nil {
+		return nil, err
+	}
+	h, err := activeHolds(db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	settings := make([]Setting, 0, len(Classes))
+	for _, class := range Classes {
+		s := Setting{Class: class, Held: h.cover(class.Name)}
+		if days, ok := p[tenantID][class.Name]; ok {
+			s.RetentionDays = &days
+		}
+		if days, ok := p[""][class.Name]; ok {
+			s.DefaultDays = &days
+		}
+		if days, ok := p.days(tenantID, class.Name); ok {
+			s.EffectiveDays = &days
+		}
+		settings = append(settings, s)
+	}
+	return settings, nil
+}
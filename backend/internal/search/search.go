@@ -0,0 +1,237 @@
+// Package search implements tenant-wide full-text search across GRC records
+// using PostgreSQL tsvector expression indexes.
+package search
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/models"
+	"github.com/cyber/backend/internal/query"
+	"gorm.io/gorm"
+)
+
+// Text search configuration. 'simple' does no stemming, which behaves
+// predictably for mixed Indonesian and English content.
+const textConfig = "simple"
+
+// ts_headline marks matches with these control characters rather than HTML,
+// so the record text around them can be escaped before they become <mark>
+const (
+	matchStart = "\x02"
+	matchStop  = "\x03"
+)
+
+// snippetMarks turns the match delimiters of an escaped snippet into <mark>
+var snippetMarks = strings.NewReplacer(matchStart, "<mark>", matchStop, "</mark>")
+
+// Source is a searchable resource and the columns that make up its document
+type Source struct {
+	Resource string   // name in models.TenantResources
+	Title    string   // column used as the result title
+	Columns  []string // text columns indexed for search
+}
+
+// Sources lists every searchable resource
+var Sources = []Source{
+	{Resource: "regulation", Title: "name", Columns: []string{"name", "description", "jurisdiction", "type"}},
+	{Resource: "policy", Title: "name", Columns: []string{"name", "description", "policy_type", "owner", "content"}},
+	{Resource: "control", Title: "name", Columns: []string{"name", "description", "control_family", "framework", "owner"}},
+	{Resource: "privacy_control", Title: "name", Columns: []string{"name", "description", "control_domain", "framework", "owner"}},
+	{Resource: "risk", Title: "name", Columns: []string{"name", "description", "risk_category", "owner", "mitigation_strategy", "mitigation_actions"}},
+	{Resource: "vulnerability", Title: "name", Columns: []string{"name", "description", "cve_id", "affected_systems", "affected_assets", "mitigation", "remediation_plan"}},
+	{Resource: "vendor", Title: "vendor_name", Columns: []string{"vendor_name", "vendor_type", "description", "contact_person", "data_shared", "findings", "recommendations"}},
+	{Resource: "incident", Title: "name", Columns: []string{"name", "description", "affected_data", "root_cause", "impact_assessment", "response_actions", "lessons_learned"}},
+	{Resource: "dsr", Title: "data_subject_name", Columns: []string{"data_subject_name", "data_subject_email", "request_type", "description", "response"}},
+	{Resource: "dpia", Title: "name", Columns: []string{"name", "description", "processing_activity", "risk_assessment", "mitigation_measures"}},
+	{Resource: "audit_report", Title: "report_name", Columns: []string{"report_name", "description", "executive_summary", "framework"}},
+	{Resource: "document", Title: "title", Columns: []string{"title", "description", "content"}},
+}
+
+// document returns the concatenated text expression for a source. The same
+// expression is used by the index and the query so the planner can use it.
+// Columns are qualified with alias when it is not empty.
+func (s Source) document(alias string) string {
+	parts := make([]string, len(s.Columns))
+	for i, col := range s.Columns {
+		if alias != "" {
+			parts[i] = fmt.Sprintf("coalesce(%s.%q, '')", alias, col)
+		} else {
+			parts[i] = fmt.Sprintf("coalesce(%q, '')", col)
+		}
+	}
+	return strings.Join(parts, " || ' ' || ")
+}
+
+func (s Source) vector(alias string) string {
+	return fmt.Sprintf("to_tsvector('%s'::regconfig, %s)", textConfig, s.document(alias))
+}
+
+func (s Source) resource() models.Resource {
+	r, _ := models.LookupResource(s.Resource)
+	return r
+}
+
+func (s Source) table(db *gorm.DB) (string, error) {
+	sch, err := query.Schema(db, s.resource().New())
+	if err != nil {
+		return "", err
+	}
+	return sch.Table, nil
+}
+
+// EnsureIndexes creates the GIN expression index for every source whose
+// table exists in the current search_path.
+func EnsureIndexes(db *gorm.DB) error {
+	for _, s := range Sources {
+		model := s.resource().New()
+		if !db.Migrator().HasTable(model) {
+			continue
+		}
+		table, err := s.table(db)
+		if err != nil {
+			return err
+		}
+		stmt := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %q ON %q USING GIN (%s)`,
+			"idx_"+table+"_fts", table, s.vector(""))
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to create search index on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// Request is a parsed search request
+type Request struct {
+	TenantID string
+	Query    string
+	Types    []string // restrict to these resources; empty means all allowed
+	Allowed  func(resource models.Resource) bool
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+	// TenantSchema reports whether a resource's records are kept in the
+	// tenant schema on the search_path. The tables of other resources are
+	// read from public. Nil reads every table from the search_path.
+	TenantSchema func(resource string) bool
+}
+
+// Hit is a single search result
+type Hit struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"` // HTML: escaped text with matches in <mark>
+	Rank      float64   `json:"rank"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Facet counts hits per resource type
+type Facet struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// Result is a page of hits with facets over the whole result set
+type Result struct {
+	Hits   []Hit   `json:"hits"`
+	Facets []Facet `json:"facets"`
+	Total  int64   `json:"total"`
+}
+
+// Search runs the query over every source the caller may see. Highlighted
+// fragments are wrapped in <mark></mark>.
+func Search(db *gorm.DB, req Request) (*Result, error) {
+	var selects []string
+	var args []interface{}
+
+	for _, s := range Sources {
+		r := s.resource()
+		if req.Allowed != nil && !req.Allowed(r) {
+			continue
+		}
+		if len(req.Types) > 0 && !contains(req.Types, s.Resource) {
+			continue
+		}
+		table, err := s.table(db)
+		if err != nil {
+			return nil, err
+		}
+		from := fmt.Sprintf("%q", table)
+		if req.TenantSchema != nil && !req.TenantSchema(s.Resource) {
+			from = "public." + from
+		}
+
+		sql := fmt.Sprintf(`SELECT '%s' AS type, t.id::text AS id, coalesce(t.%q, '') AS title, %s AS body,
+	ts_rank(%s, q.query) AS rank, t.updated_at
+FROM %s t, q
+WHERE t.tenant_id = ? AND t.is_deleted = false AND t.deleted_at IS NULL AND %s @@ q.query`,
+			s.Resource, s.Title, s.document("t"), s.vector("t"), from, s.vector("t"))
+		args = append(args, req.TenantID)
+		if req.From != nil {
+			sql += " AND t.updated_at >= ?"
+			args = append(args, *req.From)
+		}
+		if req.To != nil {
+			sql += " AND t.updated_at < ?"
+			args = append(args, *req.To)
+		}
+		selects = append(selects, sql)
+	}
+
+	result := &Result{Hits: []Hit{}, Facets: []Facet{}}
+	if len(selects) == 0 {
+		return result, nil
+	}
+
+	cte := fmt.Sprintf(`WITH q AS (SELECT websearch_to_tsquery('%s'::regconfig, ?) AS query),
+hits AS (%s)`, textConfig, strings.Join(selects, "\nUNION ALL\n"))
+	cteArgs := append([]interface{}{req.Query}, args...)
+
+	var facets []struct {
+		Type  string
+		Count int64
+	}
+	if err := db.Raw(cte+"\nSELECT type, COUNT(*) AS count FROM hits GROUP BY type ORDER BY count DESC", cteArgs...).
+		Scan(&facets).Error; err != nil {
+		return nil, err
+	}
+	for _, f := range facets {
+		r, _ := models.LookupResource(f.Type)
+		result.Facets = append(result.Facets, Facet{Type: f.Type, Label: r.Label, Count: f.Count})
+		result.Total += f.Count
+	}
+	if result.Total == 0 {
+		return result, nil
+	}
+
+	page := cte + fmt.Sprintf(`
+SELECT type, id, title,
+	ts_headline('%s'::regconfig, translate(body, ?, ''), (SELECT query FROM q), ?) AS snippet,
+	rank, updated_at
+FROM hits
+ORDER BY rank DESC, updated_at DESC
+LIMIT ? OFFSET ?`, textConfig)
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=25, MinWords=8", matchStart, matchStop)
+	pageArgs := append(cteArgs, matchStart+matchStop, options, req.Limit, req.Offset)
+	if err := db.Raw(page, pageArgs...).Scan(&result.Hits).Error; err != nil {
+		return nil, err
+	}
+	for i := range result.Hits {
+		result.Hits[i].Snippet = snippetMarks.Replace(html.EscapeString(result.Hits[i].Snippet))
+	}
+	return result, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
@@ -11,6 +12,10 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/cyber/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // StorageService handles file storage operations
@@ -39,11 +44,14 @@ func NewStorageService(storageType, basePath string, s3Config *S3Config) *Storag
 }
 
 // StoreFile stores a file and returns the storage URL and path
-func (s *StorageService) StoreFile(file *multipart.FileHeader, tenantID, folder string) (string, string, error) {
+func (s *StorageService) StoreFile(ctx context.Context, file *multipart.FileHeader, tenantID, folder string) (url string, path string, err error) {
 	// Generate unique filename
 	ext := filepath.Ext(file.Filename)
 	filename := s.generateUniqueFilename(tenantID, folder, ext)
 
+	span := s.startSpan(ctx, "storage.store_file", filename, attribute.Int64("storage.size", file.Size))
+	defer func() { tracing.End(span, err) }()
+
 	switch s.storageType {
 	case "local":
 		return s.storeLocal(file, filename)
@@ -55,13 +63,16 @@ func (s *StorageService) StoreFile(file *multipart.FileHeader, tenantID, folder
 }
 
 // StoreContent stores content directly and returns the storage URL and path
-func (s *StorageService) StoreContent(content []byte, tenantID, folder, filename string) (string, string, error) {
+func (s *StorageService) StoreContent(ctx context.Context, content []byte, tenantID, folder, filename string) (url string, path string, err error) {
 	// Generate unique filename if not provided
 	if filename == "" {
 		ext := ".html"
 		filename = s.generateUniqueFilename(tenantID, folder, ext)
 	}
 
+	span := s.startSpan(ctx, "storage.store_content", filename, attribute.Int("storage.size", len(content)))
+	defer func() { tracing.End(span, err) }()
+
 	switch s.storageType {
 	case "local":
 		return s.storeContentLocal(content, filename)
@@ -73,7 +84,10 @@ func (s *StorageService) StoreContent(content []byte, tenantID, folder, filename
 }
 
 // GetFile retrieves a file from storage
-func (s *StorageService) GetFile(path string) (io.ReadCloser, error) {
+func (s *StorageService) GetFile(ctx context.Context, path string) (file io.ReadCloser, err error) {
+	span := s.startSpan(ctx, "storage.get_file", path)
+	defer func() { tracing.End(span, err) }()
+
 	switch s.storageType {
 	case "local":
 		return s.getLocalFile(path)
@@ -85,7 +99,10 @@ func (s *StorageService) GetFile(path string) (io.ReadCloser, error) {
 }
 
 // DeleteFile deletes a file from storage
-func (s *StorageService) DeleteFile(path string) error {
+func (s *StorageService) DeleteFile(ctx context.Context, path string) (err error) {
+	span := s.startSpan(ctx, "storage.delete_file", path)
+	defer func() { tracing.End(span, err) }()
+
 	switch s.storageType {
 	case "local":
 		return s.deleteLocalFile(path)
@@ -96,6 +113,25 @@ func (s *StorageService) DeleteFile(path string) error {
 	}
 }
 
+// CheckWritable writes and removes a probe file, to report whether files
+// can be stored
+func (s *StorageService) CheckWritable() error {
+	switch s.storageType {
+	case "local", "s3": // S3 falls back to local storage for now
+		if err := os.MkdirAll(s.basePath, 0755); err != nil {
+			return err
+		}
+		probe, err := os.CreateTemp(s.basePath, ".probe-*")
+		if err != nil {
+			return err
+		}
+		probe.Close()
+		return os.Remove(probe.Name())
+	default:
+		return fmt.Errorf("unsupported storage type: %s", s.storageType)
+	}
+}
+
 // GetStorageURL returns the public URL for a stored file
 func (s *StorageService) GetStorageURL(path string) string {
 	switch s.storageType {
@@ -111,6 +147,13 @@ func (s *StorageService) GetStorageURL(path string) string {
 	}
 }
 
+// startSpan starts the span of a storage operation on path
+func (s *StorageService) startSpan(ctx context.Context, name, path string, attrs ...attribute.KeyValue) trace.Span {
+	attrs = append(attrs, attribute.String("storage.type", s.storageType), attribute.String("storage.path", path))
+	_, span := tracing.Start(ctx, name, attrs...)
+	return span
+}
+
 // generateUniqueFilename generates a unique filename
 func (s *StorageService) generateUniqueFilename(tenantID, folder, ext string) string {
 	timestamp := time.Now().Unix()
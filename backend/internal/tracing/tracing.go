@@ -0,0 +1,119 @@
+// Package tracing sets up OpenTelemetry tracing. Incoming requests, GORM
+// statements, Redis commands, storage operations and AI calls are spans of
+// one trace, and outbound HTTP calls carry the W3C trace context.
+//
+// Database statements are only traced when they run with a traced context,
+// e.g. db.WithContext(c.Request.Context()); statements of background workers
+// would otherwise each start a trace of their own.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"   // OTLP over HTTP
+	ExporterStdout = "stdout" // pretty-printed JSON, for tests and local runs
+)
+
+const (
+	serviceName = "komplai-api"
+	tracerName  = "github.com/cyber/backend"
+)
+
+// Config selects where spans are exported
+type Config struct {
+	Exporter string
+	// Endpoint is the OTLP collector URL, e.g. http://otel-collector:4318.
+	// Empty uses the OTEL_EXPORTER_OTLP_* variables or localhost.
+	Endpoint string
+	// SampleRate is the share of new traces recorded, from 0 to 1. Requests
+	// that arrive with a sampled trace context are always recorded.
+	SampleRate float64
+}
+
+// Init installs the global tracer provider and W3C propagators. The returned
+// function flushes pending spans. With no exporter, spans are not recorded
+// but trace context is still propagated.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var export sdktrace.SpanProcessor
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		export = sdktrace.NewBatchSpanProcessor(exporter)
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		export = sdktrace.NewSimpleSpanProcessor(exporter)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate))),
+		sdktrace.WithSpanProcessor(export),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer is the tracer of the API's own spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start starts a span, child of the span in ctx if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID is the ID of the trace in ctx, or empty when it is not traced
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
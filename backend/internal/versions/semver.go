@@ -0,0 +1,66 @@
+package versions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parts of a semantic version a publish may bump
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// Semver is a MAJOR.MINOR.PATCH version. Published policies carry one.
+type Semver struct {
+	Major, Minor, Patch int
+}
+
+// ParseSemver parses a version such as 1.2.3. A leading v and missing
+// minor or patch numbers are accepted, so 1.0 is 1.0.0. Pre-release and
+// build suffixes are not.
+func ParseSemver(s string) (Semver, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	if len(parts) > 3 {
+		return Semver{}, fmt.Errorf("%q is not a semantic version", s)
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || strings.TrimLeft(part, "0123456789") != "" || (len(part) > 1 && part[0] == '0') {
+			return Semver{}, fmt.Errorf("%q is not a semantic version", s)
+		}
+		nums[i] = n
+	}
+	return Semver{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// Bump returns the next version at level: major, minor or patch
+func (v Semver) Bump(level string) (Semver, error) {
+	switch level {
+	case BumpMajor:
+		return Semver{Major: v.Major + 1}, nil
+	case BumpMinor:
+		return Semver{Major: v.Major, Minor: v.Minor + 1}, nil
+	case BumpPatch:
+		return Semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}, nil
+	}
+	return v, fmt.Errorf("bump must be major, minor or patch, not %q", level)
+}
+
+// Less reports whether v precedes o
+func (v Semver) Less(o Semver) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+func (v Semver) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
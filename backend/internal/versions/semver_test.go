@@ -0,0 +1,83 @@
+package versions
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Semver
+		wantErr bool
+	}{
+		{"1.2.3", Semver{1, 2, 3}, false},
+		{"v1.2.3", Semver{1, 2, 3}, false},
+		{" 0.10.0 ", Semver{0, 10, 0}, false},
+		{"1.0", Semver{1, 0, 0}, false},
+		{"2", Semver{2, 0, 0}, false},
+		{"", Semver{}, true},
+		{"1.2.3.4", Semver{}, true},
+		{"1..3", Semver{}, true},
+		{"01.2.3", Semver{}, true},
+		{"1.-2.3", Semver{}, true},
+		{"+1.2.3", Semver{}, true},
+		{"1.2.3-beta", Semver{}, true},
+		{"1.2.3+build", Semver{}, true},
+		{"x.y.z", Semver{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSemver(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSemver(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSemver(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		from    Semver
+		level   string
+		want    Semver
+		wantErr bool
+	}{
+		{Semver{1, 2, 3}, BumpMajor, Semver{2, 0, 0}, false},
+		{Semver{1, 2, 3}, BumpMinor, Semver{1, 3, 0}, false},
+		{Semver{1, 2, 3}, BumpPatch, Semver{1, 2, 4}, false},
+		{Semver{0, 0, 0}, BumpPatch, Semver{0, 0, 1}, false},
+		{Semver{1, 2, 3}, "build", Semver{1, 2, 3}, true},
+		{Semver{1, 2, 3}, "", Semver{1, 2, 3}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.from.Bump(tt.level)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v.Bump(%q) error = %v, wantErr %v", tt.from, tt.level, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v.Bump(%q) = %v, want %v", tt.from, tt.level, got, tt.want)
+		}
+		if !tt.wantErr && !tt.from.Less(got) {
+			t.Errorf("%v.Bump(%q) = %v, which does not follow it", tt.from, tt.level, got)
+		}
+	}
+}
+
+func TestSemverLess(t *testing.T) {
+	tests := []struct {
+		a, b Semver
+		want bool
+	}{
+		{Semver{1, 2, 3}, Semver{1, 2, 4}, true},
+		{Semver{1, 2, 9}, Semver{1, 3, 0}, true},
+		{Semver{1, 9, 9}, Semver{2, 0, 0}, true},
+		{Semver{1, 2, 3}, Semver{1, 2, 3}, false},
+		{Semver{2, 0, 0}, Semver{1, 9, 9}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Less(tt.b); got != tt.want {
+			t.Errorf("%v.Less(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
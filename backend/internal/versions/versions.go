@@ -0,0 +1,196 @@
+// Package versions keeps the full history of versioned records. Every
+// create, update and restore of one, seen through the audit callbacks, stores
+// the record as it is afterwards in RecordVersion, numbered from 1 per record.
+package versions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/cyber/backend/internal/audit"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Versioned lists the resources whose history is kept
+var Versioned = map[string]bool{
+	"policy":          true,
+	"dpia":            true,
+	"control":         true,
+	"continuity_plan": true,
+	"document":        true,
+}
+
+// Action of a version written by a revert
+const ActionRevert = "revert"
+
+// Fields a revert leaves alone: they are managed by the server, not content
+var serverFields = map[string]bool{
+	"id":         true,
+	"tenant_id":  true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"deleted_by": true,
+	"is_deleted": true,
+	"revision":   true,
+	"created_by": true,
+}
+
+// Fields that change with every update, left out of diffs
+var bookkeepingFields = map[string]bool{
+	"updated_at": true,
+	"revision":   true,
+}
+
+// ErrNotFound is returned for a version that does not exist
+var ErrNotFound = errors.New("version not found")
+
+// Register records versions of the Versioned resources. It runs at startup,
+// with the audit callbacks.
+func Register() {
+	audit.Observe(record)
+}
+
+type revertKey struct{}
+
+// WithRevert marks the changes made with ctx as a revert to version
+func WithRevert(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, revertKey{}, version)
+}
+
+func record(tx *gorm.DB, change audit.Change) error {
+	if !Versioned[change.ResourceType] {
+		return nil
+	}
+	action := change.Action
+	switch action {
+	case audit.ActionCreate, audit.ActionUpdate, audit.ActionRestore:
+	default:
+		return nil
+	}
+	resource, _ := models.LookupResource(change.ResourceType)
+
+	current := resource.New()
+	if err := tx.Unscoped().Where("id = ?", change.ResourceID).Take(current).Error; err != nil {
+		return fmt.Errorf("load %s: %w", change.ResourceType, err)
+	}
+	snapshot, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var latest int
+	if err := tx.Model(&models.RecordVersion{}).Where("resource_type = ? AND resource_id = ?", change.ResourceType, change.ResourceID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+	actor := audit.ActorFrom(tx.Statement.Context)
+	version := models.RecordVersion{
+		TenantID:     change.TenantID,
+		ResourceType: change.ResourceType,
+		ResourceID:   change.ResourceID,
+		Version:      latest + 1,
+		Action:       action,
+		Snapshot:     string(snapshot),
+		ChangedBy:    actor.UserID,
+		RequestID:    actor.RequestID,
+	}
+	if to, ok := tx.Statement.Context.Value(revertKey{}).(int); ok && action == audit.ActionUpdate {
+		version.Action = ActionRevert
+		version.RevertedFrom = &to
+	}
+	return tx.Create(&version).Error
+}
+
+// Get returns a version of a tenant's record
+func Get(db *gorm.DB, tenantID, resourceType, resourceID string, version int) (*models.RecordVersion, error) {
+	var v models.RecordVersion
+	err := db.Where("tenant_id = ? AND resource_type = ? AND resource_id = ? AND version = ?", tenantID, resourceType, resourceID, version).
+		Take(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return &v, err
+}
+
+// At returns the version of a tenant's record that was current at a time
+func At(db *gorm.DB, tenantID, resourceType, resourceID string, at time.Time) (*models.RecordVersion, error) {
+	var v models.RecordVersion
+	err := db.Where("tenant_id = ? AND resource_type = ? AND resource_id = ? AND created_at <= ?", tenantID, resourceType, resourceID, at).
+		Order("version DESC").Take(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return &v, err
+}
+
+// FieldChange is a field that differs between two versions. Fields are
+// named as in the API.
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Diff returns the fields that differ between two versions, by name.
+// Fields changing with every update are left out.
+func Diff(from, to *models.RecordVersion) ([]FieldChange, error) {
+	var before, after map[string]interface{}
+	if err := json.Unmarshal([]byte(from.Snapshot), &before); err != nil {
+		return nil, fmt.Errorf("version %d: %w", from.Version, err)
+	}
+	if err := json.Unmarshal([]byte(to.Snapshot), &after); err != nil {
+		return nil, fmt.Errorf("version %d: %w", to.Version, err)
+	}
+
+	fields := map[string]bool{}
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	changes := []FieldChange{}
+	for field := range fields {
+		if bookkeepingFields[field] {
+			continue
+		}
+		a, _ := json.Marshal(before[field])
+		b, _ := json.Marshal(after[field])
+		if string(a) != string(b) {
+			changes = append(changes, FieldChange{Field: field, From: before[field], To: after[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// RevertUpdates returns the column updates that bring record back to the
+// content of version. record is a pointer to the model of the version's
+// resource; fields managed by the server keep their current values.
+func RevertUpdates(db *gorm.DB, record interface{}, version *models.RecordVersion) (map[string]interface{}, error) {
+	target := reflect.New(reflect.TypeOf(record).Elem()).Interface()
+	if err := json.Unmarshal([]byte(version.Snapshot), target); err != nil {
+		return nil, fmt.Errorf("version %d: %w", version.Version, err)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(target); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	value := reflect.ValueOf(target).Elem()
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName == "" || serverFields[field.DBName] {
+			continue
+		}
+		v, _ := field.ValueOf(db.Statement.Context, value)
+		updates[field.DBName] = v
+	}
+	return updates, nil
+}
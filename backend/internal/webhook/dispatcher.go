@@ -0,0 +1,295 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cyber/backend/internal/crypto"
+	"github.com/cyber/backend/internal/egress"
+	"github.com/cyber/backend/internal/events"
+	"github.com/cyber/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusRetrying  = "retrying"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead" // gave up; listed as dead letters until redelivered
+)
+
+const (
+	// How often the worker looks for due deliveries
+	pollInterval = 5 * time.Second
+	// Deliveries claimed per poll
+	batchSize = 20
+	// A claimed delivery is not picked up again for this long, so a worker
+	// that dies mid-delivery does not lose it
+	claimLease = 2 * time.Minute
+	// Longest wait between retries
+	maxBackoff = 12 * time.Hour
+	// Stored prefix of the receiver's response body
+	maxResponseBody = 2048
+)
+
+// Dispatcher records events as deliveries and sends them in the background
+type Dispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+
+	// MaxAttempts is the number of tries before a delivery is dead
+	MaxAttempts int
+	// RetryBase is the wait after the first failure; it doubles per attempt
+	RetryBase time.Duration
+}
+
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return &Dispatcher{
+		db:          db,
+		client:      egress.Client(10 * time.Second),
+		MaxAttempts: 8,
+		RetryBase:   30 * time.Second,
+	}
+}
+
+// Publish queues event for every active subscription of its tenant that
+// listens to its type. An event ID is generated when empty. Publishing the
+// same event ID twice queues it only once per subscription, so callers can
+// derive the ID from what happened to make publishing idempotent.
+func (d *Dispatcher) Publish(event Event) error {
+	var subs []models.WebhookSubscription
+	if err := d.db.Where("tenant_id = ? AND active = ? AND is_deleted = ? AND (? = ANY(events) OR ? = ANY(events))",
+		event.TenantID, true, false, event.Type, AllEvents).Find(&subs).Error; err != nil {
+		return err
+	}
+	for i := range subs {
+		if _, err := d.enqueue(&subs[i], event, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleEvent is the event bus subscriber that turns domain events into
+// deliveries. Deliveries are keyed by the event ID, so an event the bus
+// delivers twice is still sent once per subscription.
+func (d *Dispatcher) HandleEvent(ctx context.Context, e events.Event) error {
+	return d.Publish(Event{
+		ID:           e.ID,
+		Type:         e.Type,
+		TenantID:     e.TenantID,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		CreatedAt:    e.OccurredAt,
+		Data:         e.Data,
+	})
+}
+
+// Ping sends a ping event to sub right away and returns the delivery with
+// the outcome of the first attempt. Failed pings are retried like any other
+// delivery.
+func (d *Dispatcher) Ping(ctx context.Context, sub *models.WebhookSubscription) (*models.WebhookDelivery, error) {
+	// Hold the delivery back from the worker while it is sent here
+	delivery, err := d.enqueue(sub, Event{
+		Type:     EventPing,
+		TenantID: sub.TenantID,
+		Data:     map[string]interface{}{"subscription_id": sub.ID, "name": sub.Name},
+	}, time.Now().Add(claimLease))
+	if err != nil {
+		return nil, err
+	}
+	d.deliver(ctx, delivery)
+	return delivery, nil
+}
+
+// Redeliver resets a delivery so the worker sends it again with a fresh
+// set of attempts
+func (d *Dispatcher) Redeliver(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	return d.db.Model(delivery).Updates(map[string]interface{}{
+		"status":          StatusPending,
+		"attempts":        0,
+		"next_attempt_at": &now,
+		"error":           "",
+	}).Error
+}
+
+func (d *Dispatcher) enqueue(sub *models.WebhookSubscription, event Event, due time.Time) (*models.WebhookDelivery, error) {
+	if event.ID == "" {
+		id, err := newEventID()
+		if err != nil {
+			return nil, err
+		}
+		event.ID = id
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		TenantID:       sub.TenantID,
+		SubscriptionID: sub.ID,
+		EventID:        event.ID,
+		EventType:      event.Type,
+		Payload:        string(payload),
+		Status:         StatusPending,
+		NextAttemptAt:  &due,
+	}
+	err = d.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+	return delivery, err
+}
+
+// Run sends due deliveries until ctx is cancelled. Several replicas can run
+// it at once; each delivery is claimed by one of them.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			due, err := d.claim()
+			if err != nil {
+				log.Printf("Failed to claim webhook deliveries: %v", err)
+				break
+			}
+			for i := range due {
+				d.deliver(ctx, &due[i])
+			}
+			if len(due) < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks a batch of due deliveries and pushes their next attempt past
+// the lease, so other workers skip them
+func (d *Dispatcher) claim() ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?", []string{StatusPending, StatusRetrying}, now).
+			Order("next_attempt_at").
+			Limit(batchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]string, len(due))
+		for i := range due {
+			ids[i] = due[i].ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+	})
+	return due, err
+}
+
+// deliver makes one attempt and records its outcome on delivery
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":        delivery.Attempts + 1,
+		"last_attempt_at": &now,
+	}
+
+	status, body, err := d.send(ctx, delivery, now)
+	updates["response_status"] = status
+	updates["response_body"] = body
+	switch {
+	case err == nil:
+		updates["status"] = StatusDelivered
+		updates["delivered_at"] = &now
+		updates["next_attempt_at"] = nil
+		updates["error"] = ""
+	case errors.Is(err, errSubscriptionGone) || errors.Is(err, egress.ErrBlocked) || delivery.Attempts+1 >= d.MaxAttempts:
+		updates["status"] = StatusDead
+		updates["next_attempt_at"] = nil
+		updates["error"] = err.Error()
+	default:
+		next := now.Add(d.backoff(delivery.Attempts + 1))
+		updates["status"] = StatusRetrying
+		updates["next_attempt_at"] = &next
+		updates["error"] = err.Error()
+	}
+
+	if err := d.db.Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+var errSubscriptionGone = errors.New("subscription was deleted or disabled")
+
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	var sub models.WebhookSubscription
+	if err := d.db.Where("id = ? AND is_deleted = ?", delivery.SubscriptionID, false).First(&sub).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", errSubscriptionGone
+		}
+		return 0, "", err
+	}
+	if !sub.Active && delivery.EventType != EventPing {
+		return 0, "", errSubscriptionGone
+	}
+	secret, err := crypto.Decrypt(sub.Secret)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Komplai-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(secret, now, body))
+
+	// Only receivers that pass the egress check are connected to, so the
+	// response stored and shown in the delivery log is theirs
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// backoff returns the wait before the next try after attempt failures
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.RetryBase
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,124 @@
+// Package webhook delivers tenant events to subscriber URLs as signed JSON
+// POSTs, retrying failed deliveries with exponential backoff.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyber/backend/internal/events"
+)
+
+// EventPing is sent by the ping endpoint to test a subscription
+const EventPing = "ping"
+
+// EventTypes lists the event types a subscription can filter on: every
+// domain event
+var EventTypes = events.Types
+
+// AllEvents subscribes to every event type
+const AllEvents = "*"
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Komplai-Event"
+	HeaderDelivery  = "X-Komplai-Delivery"
+	HeaderSignature = "X-Komplai-Signature"
+)
+
+// Event is the JSON body POSTed to subscribers
+type Event struct {
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	TenantID     string      `json:"tenant_id"`
+	ResourceType string      `json:"resource_type,omitempty"`
+	ResourceID   string      `json:"resource_id,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	Data         interface{} `json:"data"`
+}
+
+// ValidEventType reports whether t can be used in a subscription filter
+func ValidEventType(t string) bool {
+	if t == AllEvents {
+		return true
+	}
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Komplai-Signature header for body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Including the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks a signature header produced by Sign. Signatures older than
+// tolerance are rejected; a zero tolerance accepts any age.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	ts, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.New("malformed signature header")
+	}
+	if tolerance > 0 {
+		if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+			return fmt.Errorf("signature timestamp is %s old", age.Round(time.Second))
+		}
+	}
+
+	expected := mac(secret, t, body)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match")
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
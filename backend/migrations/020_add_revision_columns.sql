@@ -0,0 +1,19 @@
+-- Migration 020: Add revision column for optimistic concurrency control
+-- Every table of a model embedding BaseModel (identified by deleted_by) gets
+-- a revision counter, in the public schema and every tenant schema.
+-- The server runs the same check on startup (db.EnsureRevisionColumns).
+
+DO $$
+DECLARE
+    t RECORD;
+BEGIN
+    FOR t IN
+        SELECT c.table_schema, c.table_name
+        FROM information_schema.columns c
+        WHERE c.column_name = 'deleted_by'
+          AND (c.table_schema = 'public' OR c.table_schema LIKE 'tenant_%')
+    LOOP
+        EXECUTE format('ALTER TABLE %I.%I ADD COLUMN IF NOT EXISTS revision BIGINT NOT NULL DEFAULT 1',
+                       t.table_schema, t.table_name);
+    END LOOP;
+END $$;
@@ -0,0 +1,45 @@
+-- Migration 021: Hash-chained, append-only audit trail
+-- Audit entries of a tenant are chained by sequence, each hash covering the
+-- entry and the previous hash. Signed checkpoints record chain heads.
+-- UPDATE, DELETE and TRUNCATE on both tables are rejected by triggers.
+-- The server runs the same changes on startup.
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS tenant_id TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS request_id TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS sequence BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_tenant_id ON audit_logs (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_request_id ON audit_logs (request_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_chain ON audit_logs (tenant_id, sequence);
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    sequence BIGINT NOT NULL,
+    hash TEXT NOT NULL,
+    key_id TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_audit_checkpoints_tenant_id ON audit_checkpoints (tenant_id);
+
+CREATE OR REPLACE FUNCTION reject_append_only_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% on %.% is not allowed: the table is append-only', TG_OP, TG_TABLE_SCHEMA, TG_TABLE_NAME;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
+DROP TRIGGER IF EXISTS audit_logs_append_only_truncate ON audit_logs;
+CREATE TRIGGER audit_logs_append_only_truncate BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_append_only_change();
+
+DROP TRIGGER IF EXISTS audit_checkpoints_append_only ON audit_checkpoints;
+CREATE TRIGGER audit_checkpoints_append_only BEFORE UPDATE OR DELETE ON audit_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION reject_append_only_change();
+DROP TRIGGER IF EXISTS audit_checkpoints_append_only_truncate ON audit_checkpoints;
+CREATE TRIGGER audit_checkpoints_append_only_truncate BEFORE TRUNCATE ON audit_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_append_only_change();
@@ -0,0 +1,28 @@
+-- Migration 022: One set of soft delete markers for the trash
+-- A record is in the trash when is_deleted is set; deleted_at and deleted_by
+-- record when and by whom. Rows of tenant resources deleted before the trash
+-- carry only one of is_deleted and deleted_at: both are filled in, in the
+-- public schema and every tenant schema.
+-- The server runs the same check on startup (db.EnsureTrashMarkers).
+
+DO $$
+DECLARE
+    t RECORD;
+BEGIN
+    FOR t IN
+        SELECT table_schema, table_name
+        FROM information_schema.tables
+        WHERE (table_schema = 'public' OR table_schema LIKE 'tenant_%')
+          AND table_name IN (
+              'regulations', 'compliance_assessments', 'gap_analyses', 'obligation_mappings',
+              'policies', 'reg_ops_controls', 'data_inventories', 'dsr_requests', 'dpia',
+              'privacy_controls', 'incidents', 'risk_registers', 'vulnerabilities',
+              'vendor_assessments', 'business_continuities', 'audit_plans', 'governances',
+              'audit_evidences', 'control_tests', 'audit_reports', 'documents', 'document_analyses')
+    LOOP
+        EXECUTE format('UPDATE %I.%I SET is_deleted = true WHERE deleted_at IS NOT NULL AND is_deleted IS NOT TRUE',
+                       t.table_schema, t.table_name);
+        EXECUTE format('UPDATE %I.%I SET deleted_at = updated_at WHERE is_deleted AND deleted_at IS NULL',
+                       t.table_schema, t.table_name);
+    END LOOP;
+END $$;
@@ -0,0 +1,43 @@
+-- Migration 023: Structure regulations as a tree of clauses
+-- Chapters, sections, articles, clauses, points and requirements of a
+-- regulation, identified within it by a ref stable across imports.
+-- Obligations and controls link to a clause through clause_id.
+-- The server creates the same on startup (regulation_clauses by AutoMigrate,
+-- clause_id by db.EnsureClauseColumns).
+
+CREATE TABLE IF NOT EXISTS public.regulation_clauses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id TEXT NOT NULL,
+    regulation_id TEXT NOT NULL,
+    ref TEXT NOT NULL,
+    parent_id UUID,
+    kind TEXT NOT NULL,
+    number TEXT,
+    title TEXT,
+    text TEXT,
+    position BIGINT NOT NULL,
+    removed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regulation_clauses_ref ON public.regulation_clauses (regulation_id, ref);
+CREATE INDEX IF NOT EXISTS idx_regulation_clauses_tenant_id ON public.regulation_clauses (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_regulation_clauses_parent_id ON public.regulation_clauses (parent_id);
+
+DO $$
+DECLARE
+    t RECORD;
+BEGIN
+    FOR t IN
+        SELECT table_schema, table_name
+        FROM information_schema.tables
+        WHERE table_name IN ('obligation_mappings', 'reg_ops_controls')
+          AND (table_schema = 'public' OR table_schema LIKE 'tenant_%')
+    LOOP
+        EXECUTE format('ALTER TABLE %I.%I ADD COLUMN IF NOT EXISTS clause_id TEXT',
+                       t.table_schema, t.table_name);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I.%I (clause_id)',
+                       'idx_' || t.table_name || '_clause_id', t.table_schema, t.table_name);
+    END LOOP;
+END $$;